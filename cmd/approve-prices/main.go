@@ -0,0 +1,103 @@
+// Lists the price changes the price guard held back, and approves the ones that are
+// real so the next price run pushes them.
+//
+//	approve-prices             list the quarantine
+//	approve-prices SKU [SKU]   approve those SKUs' held prices
+//	approve-prices -all        approve everything in the quarantine
+//
+// An approval covers the exact prices held. If the ERP changes the price again first,
+// the guard judges the new price afresh.
+//
+// On the VM run it through deploy/run-exporter-tool.sh, which waits for the cron lock:
+// the price step saves the quarantine it loaded at its start. It also carries over
+// approvals saved in between, but the lock keeps the two from writing at once.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"shopify-exporter/internal/config"
+	"shopify-exporter/internal/infra/pricequarantine"
+	"sort"
+	"strings"
+	"time"
+)
+
+func main() {
+	all := flag.Bool("all", false, "approve every held change")
+	flag.Parse()
+
+	cfg, err := config.LoadForDailySync()
+	if err != nil {
+		fmt.Printf("error %v\n", err)
+		os.Exit(1)
+	}
+	path := cfg.Prices.Guard.QuarantinePath
+
+	quarantine, err := pricequarantine.Load(path)
+	if err != nil {
+		fmt.Printf("error %v\n", err)
+		os.Exit(1)
+	}
+
+	skus := flag.Args()
+	if len(skus) == 0 && !*all {
+		list(quarantine, path)
+		return
+	}
+
+	missing := quarantine.Approve(skus, time.Now())
+	for _, sku := range missing {
+		fmt.Printf("[WARNING]: %s is not in the quarantine\n", sku)
+	}
+	if err := pricequarantine.Save(path, quarantine); err != nil {
+		fmt.Printf("error %v\n", err)
+		os.Exit(1)
+	}
+	approved := len(quarantine.Entries)
+	if !*all {
+		approved = len(skus) - len(missing)
+	}
+	fmt.Printf("approved=%d; the next price run pushes them\n", approved)
+}
+
+func list(quarantine pricequarantine.Quarantine, path string) {
+	entries := quarantine.Sorted()
+	if len(entries) == 0 {
+		fmt.Printf("no held price changes in %s\n", path)
+		return
+	}
+	fmt.Printf("held price changes in %s:\n", path)
+	for _, entry := range entries {
+		state := "held"
+		if entry.Approved() {
+			state = "approved " + entry.ApprovedAt.Format("2006-01-02 15:04")
+		}
+		fmt.Printf(
+			"%s  %s  before=%s after=%s  since %s  [%s]\n",
+			entry.SKU,
+			strings.Join(entry.Reasons, "; "),
+			formatAmounts(entry.Before),
+			formatAmounts(entry.Prices),
+			entry.HeldAt.Format("2006-01-02 15:04"),
+			state,
+		)
+	}
+}
+
+func formatAmounts(amounts map[string]float64) string {
+	if len(amounts) == 0 {
+		return "-"
+	}
+	currencies := make([]string, 0, len(amounts))
+	for currency := range amounts {
+		currencies = append(currencies, currency)
+	}
+	sort.Strings(currencies)
+	parts := make([]string, 0, len(currencies))
+	for _, currency := range currencies {
+		parts = append(parts, fmt.Sprintf("%s %.2f", currency, amounts[currency]))
+	}
+	return strings.Join(parts, ",")
+}
@@ -0,0 +1,161 @@
+// Lists, compares and rolls back the stock snapshots the stock sync keeps in
+// stock-history next to SYNC_STOCK_STATE_FILE.
+//
+//	stock-history                       list the snapshots, oldest first
+//	stock-history diff RUN [RUN]        what changed from the first to the second;
+//	                                    the second defaults to the current snapshot
+//	stock-history [-dry-run] rollback RUN
+//	                                    re-push RUN's quantities to Shopify
+//
+// A rollback honours SYNC_STOCK_DRY_RUN, and -dry-run forces it. The rolled-back
+// quantities become the current snapshot, and RUN is marked as restored. The ERP
+// still holds whatever went wrong: fix it, or pause the stock cron, before the next
+// stock run pushes it again.
+//
+// On the VM run it through deploy/run-exporter-tool.sh, which waits for the cron lock:
+// a rollback and a stock run must not write the snapshot at once.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"shopify-exporter/internal/adapters/shopify"
+	"shopify-exporter/internal/app/reporting"
+	"shopify-exporter/internal/app/usecases"
+	"shopify-exporter/internal/config"
+	infrahttp "shopify-exporter/internal/infra/http"
+	"shopify-exporter/internal/infra/stockstate"
+	"shopify-exporter/internal/logging"
+	"strconv"
+	"time"
+)
+
+// current names the live snapshot in a diff.
+const current = "current"
+
+func main() {
+	dryRun := flag.Bool("dry-run", false, "rollback: resolve and report, write nothing")
+	flag.Parse()
+
+	cfg, err := config.LoadForDailySync()
+	if err != nil {
+		fmt.Printf("error %v\n", err)
+		os.Exit(1)
+	}
+
+	args := flag.Args()
+	switch {
+	case len(args) == 0:
+		err = list(cfg.Stock)
+	case args[0] == "diff" && (len(args) == 2 || len(args) == 3):
+		after := current
+		if len(args) == 3 {
+			after = args[2]
+		}
+		err = diff(cfg.Stock, args[1], after)
+	case args[0] == "rollback" && len(args) == 2:
+		if *dryRun {
+			cfg.Stock.DryRun = true
+			cfg.Shopify.StockDryRun = true
+		}
+		err = rollback(cfg, args[1])
+	default:
+		fmt.Println("usage: stock-history | stock-history diff RUN [RUN] | stock-history [-dry-run] rollback RUN")
+		os.Exit(2)
+	}
+	if err != nil {
+		fmt.Printf("error %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func list(cfg config.StockConfig) error {
+	history, err := stockstate.History(cfg.HistoryDir)
+	if err != nil {
+		return err
+	}
+	if len(history) == 0 {
+		fmt.Printf("no stock snapshots in %s\n", cfg.HistoryDir)
+		return nil
+	}
+	live, _ := stockstate.Load(cfg.StatePath)
+	for _, snapshot := range history {
+		note := ""
+		if snapshot.RunID == live.RunID {
+			note += " current"
+		}
+		if snapshot.RollbackOf != "" {
+			note += " rollback-of=" + snapshot.RollbackOf
+		}
+		if snapshot.RestoredAt != nil {
+			note += " restored=" + snapshot.RestoredAt.Local().Format("2006-01-02 15:04")
+		}
+		fmt.Printf("%s  %s  skus=%d%s\n", snapshot.RunID, snapshot.UpdatedAt.Local().Format("2006-01-02 15:04:05"), len(snapshot.Quantities), note)
+	}
+	return nil
+}
+
+func diff(cfg config.StockConfig, beforeID, afterID string) error {
+	before, err := load(cfg, beforeID)
+	if err != nil {
+		return err
+	}
+	after, err := load(cfg, afterID)
+	if err != nil {
+		return err
+	}
+
+	changes := stockstate.Diff(before, after)
+	for _, change := range changes {
+		fmt.Printf("%s  %s -> %s  %s\n", change.Key, quantity(change.Before, change.BeforeKnown), quantity(change.After, change.AfterKnown), delta(change))
+	}
+	fmt.Printf("changed=%d from=%s to=%s\n", len(changes), beforeID, afterID)
+	return nil
+}
+
+func load(cfg config.StockConfig, runID string) (stockstate.Snapshot, error) {
+	if runID == current {
+		return stockstate.Load(cfg.StatePath)
+	}
+	return stockstate.LoadArchived(cfg.HistoryDir, runID)
+}
+
+func quantity(value int, known bool) string {
+	if !known {
+		return "—"
+	}
+	return strconv.Itoa(value)
+}
+
+func delta(change stockstate.KeyChange) string {
+	if !change.BeforeKnown || !change.AfterKnown {
+		return ""
+	}
+	return fmt.Sprintf("%+d", change.After-change.Before)
+}
+
+func rollback(cfg *config.DailyConfig, runID string) error {
+	startedAt := time.Now()
+	logger := logging.NewNamedLogger(cfg.TelegramBot, "stock-rollback")
+	httpClient := infrahttp.NewClient(cfg.Shopify.Timeout)
+
+	// A rollback writes to Shopify, so it reports like any run that does.
+	reporter := reporting.Start("stock-rollback", cfg, logger, startedAt)
+	defer reporter.Send()
+
+	shopifyClient := shopify.NewClient(cfg.Shopify, httpClient, logger)
+	if aware, ok := shopifyClient.(shopify.ReporterAware); ok {
+		aware.SetReporter(reporter.Recorder())
+	}
+	stockClient, ok := shopifyClient.(shopify.StockService)
+	if !ok {
+		return fmt.Errorf("shopify stock service unavailable")
+	}
+
+	finish := reporter.Step("rollbackStock")
+	err := usecases.NewRollbackStock(stockClient, logger, reporter.Recorder(), cfg.Stock).Run(context.Background(), runID)
+	finish(err)
+	return err
+}
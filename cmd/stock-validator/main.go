@@ -0,0 +1,207 @@
+// Long-running HTTP service a Shopify checkout asks, before the order is placed,
+// whether the ERP still has the cart's quantities. A cart/checkout validation
+// Function or an app proxy posts the cart lines:
+//
+//	POST /validate   {"lines":[{"sku":"HVM-1","quantity":2}]}
+//	GET  /healthz
+//
+// The answer has a verdict per line, in the order sent, and allowed=false when any
+// line must be blocked. Each SKU is read live from ApiHasav's single-SKU /stocks with
+// a short cache; see config.StockValidatorConfig for the budget and fail-open setting.
+// Every /validate request must carry STOCK_VALIDATOR_TOKEN as X-Validator-Token.
+//
+// It runs from the sync image as the systemd unit in deploy/shopify-stock-validator.service.
+package main
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"shopify-exporter/internal/adapters/apix"
+	"shopify-exporter/internal/app/usecases"
+	"shopify-exporter/internal/config"
+	infrahttp "shopify-exporter/internal/infra/http"
+	"shopify-exporter/internal/logging"
+	"strings"
+	"syscall"
+	"time"
+	// tzdata is embedded so REPORT_TIMEZONE resolves for the pre-order dates inside
+	// the scratch alpine image, which ships no system zoneinfo.
+	_ "time/tzdata"
+)
+
+const (
+	// maxCartLines bounds the ERP lookups one request can start.
+	maxCartLines = 250
+	maxBodyBytes = 64 << 10
+	tokenHeader  = "X-Validator-Token"
+)
+
+type validateRequest struct {
+	Lines []validateRequestLine `json:"lines"`
+}
+
+type validateRequestLine struct {
+	SKU      string `json:"sku"`
+	Quantity int    `json:"quantity"`
+}
+
+type validateResponse struct {
+	Allowed  bool                   `json:"allowed"`
+	FailOpen bool                   `json:"fail_open"`
+	Lines    []validateResponseLine `json:"lines"`
+}
+
+type validateResponseLine struct {
+	SKU      string `json:"sku"`
+	Quantity int    `json:"quantity"`
+	// Available is omitted when the ERP did not answer for the SKU.
+	Available *int   `json:"available,omitempty"`
+	Status    string `json:"status"`
+	Allowed   bool   `json:"allowed"`
+	// Message is shown to the customer on a blocked line.
+	Message string `json:"message,omitempty"`
+}
+
+func main() {
+	cfg, err := config.LoadForStockValidator()
+	if err != nil {
+		fmt.Printf("error %v\n", err)
+		os.Exit(1)
+	}
+	validatorCfg := cfg.StockValidator
+
+	// Telegram gets one synchronous message per log line; per checkout that would
+	// flood the chat and slow every answer, so the service logs locally only.
+	logCfg := cfg.TelegramBot
+	if output := strings.ToLower(strings.TrimSpace(logCfg.LogOutput)); output == "telegram" || output == "both" {
+		logCfg.LogOutput = "stdout"
+	}
+	logger := logging.NewNamedLogger(logCfg, "stock-validator")
+	httpClient := infrahttp.NewClient(validatorCfg.Timeout)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	validator := usecases.NewValidateCartStock(
+		ctx,
+		apix.NewStockLookupService(cfg.ApiHasav, httpClient, nil),
+		apix.NewCategoryClientService(cfg.ApiHasav, infrahttp.NewClient(cfg.ApiHasav.Timeout), logger),
+		validatorCfg.UntrackedSkuPrefixes,
+		logger,
+		cfg.Stock,
+		validatorCfg,
+	)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	mux.HandleFunc("/validate", func(w http.ResponseWriter, r *http.Request) {
+		handleValidate(w, r, validator, validatorCfg)
+	})
+
+	server := &http.Server{
+		Addr:              validatorCfg.Addr,
+		Handler:           mux,
+		ReadHeaderTimeout: 5 * time.Second,
+		WriteTimeout:      validatorCfg.Timeout + 5*time.Second,
+	}
+	go func() {
+		<-ctx.Done()
+		shutdown, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		_ = server.Shutdown(shutdown)
+	}()
+
+	if logger != nil {
+		logger.Log(fmt.Sprintf(
+			"stock validator listening addr=%s timeout=%s cache=%s cache_entries=%d max_lookups=%d fail_open=%t",
+			validatorCfg.Addr,
+			validatorCfg.Timeout,
+			validatorCfg.CacheTTL,
+			validatorCfg.MaxCacheEntries,
+			validatorCfg.MaxLookups,
+			validatorCfg.FailOpen,
+		))
+	}
+	if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		if logger != nil {
+			logger.LogError("stock validator stopped", err)
+		}
+		os.Exit(1)
+	}
+}
+
+func handleValidate(w http.ResponseWriter, r *http.Request, validator usecases.ValidateCartStockService, cfg config.StockValidatorConfig) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if subtle.ConstantTimeCompare([]byte(r.Header.Get(tokenHeader)), []byte(cfg.Token)) != 1 {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var request validateRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxBodyBytes)).Decode(&request); err != nil {
+		http.Error(w, "invalid body", http.StatusBadRequest)
+		return
+	}
+	if len(request.Lines) > maxCartLines {
+		http.Error(w, fmt.Sprintf("at most %d lines", maxCartLines), http.StatusRequestEntityTooLarge)
+		return
+	}
+
+	lines := make([]usecases.CartLine, 0, len(request.Lines))
+	for _, line := range request.Lines {
+		lines = append(lines, usecases.CartLine{SKU: line.SKU, Quantity: line.Quantity})
+	}
+	result := validator.Validate(r.Context(), lines)
+
+	response := validateResponse{
+		Allowed:  result.Allowed,
+		FailOpen: cfg.FailOpen,
+		Lines:    make([]validateResponseLine, 0, len(result.Lines)),
+	}
+	for _, line := range result.Lines {
+		out := validateResponseLine{
+			SKU:      line.SKU,
+			Quantity: line.Quantity,
+			Status:   line.Status,
+			Allowed:  line.Allowed,
+		}
+		if line.Verified {
+			available := line.Available
+			out.Available = &available
+		}
+		if !line.Allowed {
+			out.Message = blockedMessage(line)
+		}
+		response.Lines = append(response.Lines, out)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(response)
+}
+
+// blockedMessage is what the customer reads next to a blocked line.
+func blockedMessage(line usecases.CartLineStock) string {
+	switch line.Status {
+	case usecases.CartLineInsufficient:
+		if line.Available <= 0 {
+			return "המוצר אזל מהמלאי"
+		}
+		return fmt.Sprintf("נותרו במלאי %d יחידות בלבד", line.Available)
+	case usecases.CartLineUnknownSKU:
+		return "המוצר אינו זמין להזמנה"
+	default:
+		return "לא ניתן לאמת את המלאי כרגע, נסו שוב בעוד מספר דקות"
+	}
+}
@@ -11,6 +11,8 @@ import (
 	"shopify-exporter/internal/config"
 	"shopify-exporter/internal/debugsync"
 	infrahttp "shopify-exporter/internal/infra/http"
+	"shopify-exporter/internal/infra/pricerates"
+	"shopify-exporter/internal/infra/salescalendar"
 	"shopify-exporter/internal/logging"
 	"time"
 )
@@ -52,16 +54,42 @@ func main() {
 		}
 		apixPriceClient := apix.NewPriceSerivce(cfg.ApiHasav, httpClient, logger)
 		apixProductsClient := apix.NewClient(cfg.ApiHasav, httpClient)
-		return usecases.NewSyncPrices(apixPriceClient, apixProductsClient, priceClient, logger).Run(ctx)
+		calendar, err := salescalendar.Open(cfg.Prices.Sales, func(location *time.Location) salescalendar.Source {
+			return apix.NewSalesCalendarService(cfg.ApiHasav, httpClient, logger, location)
+		})
+		if err != nil {
+			return err
+		}
+		rates, err := pricerates.Open(cfg.Prices.Conversion, func() pricerates.Source {
+			return apix.NewRatesService(cfg.ApiHasav, httpClient, logger)
+		})
+		if err != nil {
+			return err
+		}
+		return usecases.NewSyncPrices(apixPriceClient, apixProductsClient, priceClient, logger, reporter.Recorder(), cfg.Prices, calendar, rates).Run(ctx)
 	})
 
+	// Before syncStocks, so the audit sees the drift a full run is about to correct.
+	if cfg.Stock.DriftAudit {
+		runStepIfEnabled(logger, reporter, "auditStockDrift", func() error {
+			auditClient, ok := shopifyClient.(shopify.StockAuditService)
+			if !ok {
+				return fmt.Errorf("shopify stock audit service unavailable")
+			}
+			apixStockClient := apix.NewStockService(cfg.ApiHasav, httpClient, logger)
+			apixCategoryClient := apix.NewCategoryClientService(cfg.ApiHasav, httpClient, logger)
+			return usecases.NewAuditStockDrift(apixStockClient, apixCategoryClient, auditClient, logger, reporter.Recorder(), cfg.Stock).Run(ctx)
+		})
+	}
+
 	runStepIfEnabled(logger, reporter, "syncStocks", func() error {
 		stockClient, ok := shopifyClient.(shopify.StockService)
 		if !ok {
 			return fmt.Errorf("shopify stock service unavailable")
 		}
 		apixStockClient := apix.NewStockService(cfg.ApiHasav, httpClient, logger)
-		return usecases.NewSyncStocks(apixStockClient, stockClient, logger, cfg.Stock).Run(ctx)
+		apixCategoryClient := apix.NewCategoryClientService(cfg.ApiHasav, httpClient, logger)
+		return usecases.NewSyncStocks(apixStockClient, apixCategoryClient, stockClient, logger, reporter.Recorder(), cfg.Stock).Run(ctx)
 	})
 
 	logger.LogSuccess("stock and price sync completed")
@@ -12,6 +12,8 @@ import (
 	"shopify-exporter/internal/config"
 	"shopify-exporter/internal/debugsync"
 	infrahttp "shopify-exporter/internal/infra/http"
+	"shopify-exporter/internal/infra/pricerates"
+	"shopify-exporter/internal/infra/salescalendar"
 	"shopify-exporter/internal/logging"
 	"strings"
 	"time"
@@ -48,6 +50,14 @@ func main() {
 	}
 	apixClient := apix.NewClient(cfg.ApiHasav, httpClient)
 
+	// Read-only: the findings go to the report's data-quality section for the ERP
+	// team. A failure here is reported like any step and never blocks the sync.
+	runStepIfEnabled(logger, reporter, "validateCatalog", func() error {
+		apixPriceClient := apix.NewPriceSerivce(cfg.ApiHasav, httpClient, logger)
+		apixCategoryClient := apix.NewCategoryClientService(cfg.ApiHasav, httpClient, logger)
+		return usecases.NewValidateCatalog(apixClient, apixPriceClient, apixCategoryClient, logger, reporter.Recorder(), cfg.Prices).Run(ctx)
+	})
+
 	runStepIfEnabled(logger, reporter, "syncProducts", func() error {
 		return usecases.NewSyncProducts(apixClient, shopifyClient, logger, reporter.Recorder()).Run(ctx)
 	})
@@ -73,16 +83,57 @@ func main() {
 			return fmt.Errorf("shopify price service unavailable")
 		}
 		apixPriceClient := apix.NewPriceSerivce(cfg.ApiHasav, httpClient, logger)
-		return usecases.NewSyncPrices(apixPriceClient, apixClient, priceClient, logger).Run(ctx)
+		calendar, err := salescalendar.Open(cfg.Prices.Sales, func(location *time.Location) salescalendar.Source {
+			return apix.NewSalesCalendarService(cfg.ApiHasav, httpClient, logger, location)
+		})
+		if err != nil {
+			return err
+		}
+		rates, err := pricerates.Open(cfg.Prices.Conversion, func() pricerates.Source {
+			return apix.NewRatesService(cfg.ApiHasav, httpClient, logger)
+		})
+		if err != nil {
+			return err
+		}
+		return usecases.NewSyncPrices(apixPriceClient, apixClient, priceClient, logger, reporter.Recorder(), cfg.Prices, calendar, rates).Run(ctx)
 	})
 
+	// Opt-in: B2B needs a Shopify Plus store.
+	if cfg.B2B.Enabled {
+		runStepIfEnabled(logger, reporter, "syncB2B", func() error {
+			b2bClient, ok := shopifyClient.(shopify.B2BService)
+			if !ok {
+				return fmt.Errorf("shopify b2b service unavailable")
+			}
+			apixCustomerClient := apix.NewCustomerService(cfg.ApiHasav, httpClient, logger)
+			apixPriceClient := apix.NewPriceSerivce(cfg.ApiHasav, httpClient, logger)
+			return usecases.NewSyncB2B(apixCustomerClient, apixPriceClient, b2bClient, logger, reporter.Recorder(), cfg.B2B).Run(ctx)
+		})
+	} else {
+		reporter.Skip("syncB2B", "SYNC_B2B is off")
+	}
+
+	// Before syncStocks, so the audit sees the drift a full run is about to correct.
+	if cfg.Stock.DriftAudit {
+		runStepIfEnabled(logger, reporter, "auditStockDrift", func() error {
+			auditClient, ok := shopifyClient.(shopify.StockAuditService)
+			if !ok {
+				return fmt.Errorf("shopify stock audit service unavailable")
+			}
+			apixStockClient := apix.NewStockService(cfg.ApiHasav, httpClient, logger)
+			apixCategoryClient := apix.NewCategoryClientService(cfg.ApiHasav, httpClient, logger)
+			return usecases.NewAuditStockDrift(apixStockClient, apixCategoryClient, auditClient, logger, reporter.Recorder(), cfg.Stock).Run(ctx)
+		})
+	}
+
 	runStepIfEnabled(logger, reporter, "syncStocks", func() error {
 		stockClient, ok := shopifyClient.(shopify.StockService)
 		if !ok {
 			return fmt.Errorf("shopify stock service unavailable")
 		}
 		apixStockClient := apix.NewStockService(cfg.ApiHasav, httpClient, logger)
-		return usecases.NewSyncStocks(apixStockClient, stockClient, logger, cfg.Stock).Run(ctx)
+		apixCategoryClient := apix.NewCategoryClientService(cfg.ApiHasav, httpClient, logger)
+		return usecases.NewSyncStocks(apixStockClient, apixCategoryClient, stockClient, logger, reporter.Recorder(), cfg.Stock).Run(ctx)
 	})
 
 	runStepIfEnabled(logger, reporter, "syncRelatedProducts", func() error {
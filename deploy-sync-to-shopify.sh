@@ -14,6 +14,8 @@ CONTAINER_LOG_DIR="/var/log/shopify-exporter"
 # `docker image prune -a` wipes it. See FIXES.md 2026-08-04.
 REMOTE_IMAGE_TARBALL="/home/spetsar/shopify-exporter-sync.tar.gz"
 REMOTE_RUNNER="/home/spetsar/run-shopify-exporter.sh"
+REMOTE_TOOL_RUNNER="/home/spetsar/run-exporter-tool.sh"
+REMOTE_VALIDATOR_UNIT="/etc/systemd/system/shopify-stock-validator.service"
 
 # --no-run ships the image without starting an immediate sync. Use it when a sync is
 # already in flight, or when you only want the next cron tick to pick up the change.
@@ -72,6 +74,18 @@ gcloud compute scp "${SCRIPT_DIR}/deploy/run-shopify-exporter.sh" "${INSTANCE}:/
   --project="${PROJECT_ID}" \
   --tunnel-through-iap
 
+echo "📤 Copying tool runner to ${INSTANCE}:/tmp/run-exporter-tool.sh"
+gcloud compute scp "${SCRIPT_DIR}/deploy/run-exporter-tool.sh" "${INSTANCE}:/tmp/run-exporter-tool.sh" \
+  --zone="${ZONE}" \
+  --project="${PROJECT_ID}" \
+  --tunnel-through-iap
+
+echo "📤 Copying stock validator unit to ${INSTANCE}:/tmp/shopify-stock-validator.service"
+gcloud compute scp "${SCRIPT_DIR}/deploy/shopify-stock-validator.service" "${INSTANCE}:/tmp/shopify-stock-validator.service" \
+  --zone="${ZONE}" \
+  --project="${PROJECT_ID}" \
+  --tunnel-through-iap
+
 echo "🔑 Deploying ${LOCAL_TAG} to ${INSTANCE}…"
 gcloud compute ssh "${INSTANCE}" \
   --zone="${ZONE}" \
@@ -95,6 +109,18 @@ gcloud compute ssh "${INSTANCE}" \
     sudo install -o root -g root -m 0755 /tmp/run-shopify-exporter.sh ${REMOTE_RUNNER}
     rm -f /tmp/run-shopify-exporter.sh
 
+    echo '— Installing tool runner (${REMOTE_TOOL_RUNNER})'
+    sudo install -o root -g root -m 0755 /tmp/run-exporter-tool.sh ${REMOTE_TOOL_RUNNER}
+    rm -f /tmp/run-exporter-tool.sh
+
+    # Restarted only when already running: enabling it is a one-off decision, see the
+    # unit file.
+    echo '— Installing stock validator unit (${REMOTE_VALIDATOR_UNIT})'
+    sudo install -o root -g root -m 0644 /tmp/shopify-stock-validator.service ${REMOTE_VALIDATOR_UNIT}
+    rm -f /tmp/shopify-stock-validator.service
+    sudo systemctl daemon-reload
+    sudo systemctl try-restart shopify-stock-validator
+
     echo '— Ensuring log directory exists'
     sudo mkdir -p ${REMOTE_LOG_DIR}
 
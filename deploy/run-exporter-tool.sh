@@ -0,0 +1,42 @@
+#!/usr/bin/env bash
+# Runs one of the exporter's maintenance tools inside the sync image, under the same
+# lock as the cron jobs. Installed next to run-shopify-exporter.sh by
+# deploy-sync-to-shopify.sh — edit it HERE, not on the VM.
+#
+# Usage: run-exporter-tool.sh TOOL [ARGS...]
+#   TOOL : a binary in the image's /app, e.g. approve-prices
+#   ARGS : passed to the tool unchanged
+#
+#   sudo /home/spetsar/run-exporter-tool.sh approve-prices          # list the holds
+#   sudo /home/spetsar/run-exporter-tool.sh approve-prices HVM-1    # approve one
+#   sudo /home/spetsar/run-exporter-tool.sh stock-history rollback 20260804-060000
+#
+# The tools rewrite state files the sync also rewrites (the price quarantine, the
+# stock snapshot). A sync in flight saves the copy it loaded at its start, so a tool
+# run during it would be lost. The lock is the one in the crontab (see
+# run-shopify-exporter.sh); unlike the cron jobs the tool WAITS for it, up to ten
+# minutes, instead of skipping.
+set -euo pipefail
+
+if [ $# -lt 1 ]; then
+  echo "usage: $0 TOOL [ARGS...]" >&2
+  exit 2
+fi
+TOOL="$1"
+shift
+
+IMAGE="shopify-exporter-sync:latest"
+ENV_FILE="/home/spetsar/shopify-exporter.env"
+LOG_DIR="/home/spetsar/shopify-exporter-logs"
+CONTAINER_LOG_DIR="/var/log/shopify-exporter"
+LOCK_FILE="/var/lock/shopify-exporter-stock.lock"
+
+# Same volume as the sync: the state files default to LOG_FILE_DIR.
+exec /usr/bin/flock -w 600 "${LOCK_FILE}" \
+  docker run --rm \
+    --name "shopify-exporter-${TOOL}" \
+    --env-file "${ENV_FILE}" \
+    --env LOG_FILE_DIR="${CONTAINER_LOG_DIR}" \
+    --volume "${LOG_DIR}:${CONTAINER_LOG_DIR}" \
+    --entrypoint "/app/${TOOL}" \
+    "${IMAGE}" "$@"
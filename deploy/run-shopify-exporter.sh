@@ -7,7 +7,8 @@
 #   STEPS : value for SYNC_ONLY_STEPS (e.g. "syncStocks"); empty = full sync (all steps)
 #   MODE  : short label used for the container name + log line (default "full").
 #           MODE=delta additionally runs the stock step in delta mode and silences the
-#           "nothing changed" report — see below.
+#           "nothing changed" report — see below. MODE=price-delta does the same for
+#           the price step.
 #
 # Scheduling (root crontab). Every stock-touching job shares ONE lock file, so a delta
 # tick can never overlap the daily full sync and race it on the snapshot:
@@ -16,12 +17,18 @@
 #     /home/spetsar/run-shopify-exporter.sh "syncStocks" delta \
 #     >> /home/spetsar/shopify-exporter-logs/cron-stock.log 2>&1
 #
+#   2-59/15 * * * * /usr/bin/flock -n /var/lock/shopify-exporter-stock.lock \
+#     /home/spetsar/run-shopify-exporter.sh "syncPrices" price-delta \
+#     >> /home/spetsar/shopify-exporter-logs/cron-price.log 2>&1
+#
 #   0 3 * * *   /usr/bin/flock -n /var/lock/shopify-exporter-stock.lock \
 #     /home/spetsar/run-shopify-exporter.sh "" full \
 #     >> /home/spetsar/shopify-exporter-logs/cron-full.log 2>&1
 #
 # `flock -n` skips the tick rather than queueing it: a stacked queue of stock runs all
-# pushing the same numbers helps nobody.
+# pushing the same numbers helps nobody. The price tick takes the same lock because the
+# full run writes the price snapshot and quarantine too; it fires at minute 2, 17, 32
+# and 47 so it does not collide with a stock tick, which would skip one of them.
 #
 #   delta (*/5)  -> only SKUs whose ERP quantity moved since the last successful run.
 #                   Usually a handful of SKUs and a few API calls, so the storefront is
@@ -30,6 +37,10 @@
 #                   Shopify's on_hand also moves on its own when an order is fulfilled,
 #                   and only a full pass notices that the ERP number needs re-pushing.
 #                   DO NOT drop this job — delta alone will slowly drift.
+#   price-delta (*/15) -> only SKUs whose resolved price moved, which includes a
+#                   scheduled sale starting or ending (SYNC_SALES_SOURCE). This tick is
+#                   what starts and ends sales within a quarter of an hour of their
+#                   window; without it they move at the daily full run.
 #
 # Self-heal: the image is loaded locally because the VM service account cannot pull
 # from Artifact Registry. Any backend/frontend deploy that runs
@@ -80,6 +91,10 @@ if [ "${MODE}" = "delta" ]; then
   ENV_ARGS+=(--env "SYNC_STOCK_MODE=delta")
   ENV_ARGS+=(--env "REPORT_EMAIL_ONLY_ON_CHANGE=true")
 fi
+if [ "${MODE}" = "price-delta" ]; then
+  ENV_ARGS+=(--env "SYNC_PRICE_MODE=delta")
+  ENV_ARGS+=(--env "REPORT_EMAIL_ONLY_ON_CHANGE=true")
+fi
 
 # Foreground run: flock holds the lock for the full run so the next tick of the
 # same mode cannot start a second overlapping run.
@@ -0,0 +1,87 @@
+package apix
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"shopify-exporter/internal/adapters/apix/dto"
+	"shopify-exporter/internal/config"
+	"shopify-exporter/internal/domain/model"
+	"shopify-exporter/internal/logging"
+	"strings"
+)
+
+// CustomerService reads the ERP customer cards.
+type CustomerService interface {
+	Customers(ctx context.Context) ([]model.Customer, error)
+}
+
+type NewCustomerS struct {
+	Config     config.ApiHasvConfig
+	httpClient *http.Client
+	logger     logging.LoggerService
+}
+
+const CustomersEndpoint = "/customers"
+
+func NewCustomerService(Config config.ApiHasvConfig, httpClient *http.Client, logger logging.LoggerService) CustomerService {
+	return &NewCustomerS{
+		Config:     Config,
+		httpClient: httpClient,
+		logger:     logger,
+	}
+}
+
+func (c *NewCustomerS) Customers(ctx context.Context) ([]model.Customer, error) {
+	jsonBody, err := json.Marshal(map[string]any{"dbName": "EMANUEL"})
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.Config.BaseUrl+CustomersEndpoint, bytes.NewReader(jsonBody))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", c.Config.Token)
+
+	client := c.httpClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("apix customers request failed: %s", resp.Status)
+	}
+
+	var apiResp dto.CustomersResponse
+	if err := json.Unmarshal(respBody, &apiResp); err != nil {
+		return nil, err
+	}
+
+	customers := make([]model.Customer, 0, len(apiResp.Customers))
+	for _, v := range apiResp.Customers {
+		key := strings.TrimSpace(v.AccountKey)
+		if key == "" {
+			continue
+		}
+		customers = append(customers, model.Customer{
+			Key:             key,
+			Name:            strings.TrimSpace(v.FullName),
+			Email:           strings.TrimSpace(v.EMail),
+			Phone:           strings.TrimSpace(v.Phone),
+			PriceListNumber: v.PriceListNumber,
+		})
+	}
+	return customers, nil
+}
@@ -0,0 +1,15 @@
+package dto
+
+type CustomerDto struct {
+	AccountKey      string `json:"AccountKey"`
+	FullName        string `json:"FullName"`
+	EMail           string `json:"EMail"`
+	Phone           string `json:"Phone"`
+	PriceListNumber int    `json:"PriceListNumber"`
+}
+
+type CustomersResponse struct {
+	Api       string        `json:"api"`
+	Status    string        `json:"status"`
+	Customers []CustomerDto `json:"customers"`
+}
@@ -10,6 +10,10 @@ type PriceDto struct {
 	DFlag           int     `json:"DFlag"`
 	UseFID          int     `json:"UseFID"`
 	CngDate         string  `json:"CngDate"`
+	FromQuantity    int     `json:"FromQuantity"`
+	MinOrderQty     int     `json:"MinOrderQty"`
+	MaxOrderQty     int     `json:"MaxOrderQty"`
+	OrderStep       int     `json:"OrderStep"`
 }
 
 type PriceRespone struct {
@@ -0,0 +1,12 @@
+package dto
+
+type RateDto struct {
+	CurrencyCode string  `json:"CurrencyCode"`
+	Rate         float64 `json:"Rate"`
+}
+
+type RatesResponse struct {
+	Api    string    `json:"api"`
+	Status string    `json:"status"`
+	Rates  []RateDto `json:"rates"`
+}
@@ -0,0 +1,17 @@
+package dto
+
+type SaleDto struct {
+	Name         string  `json:"Name"`
+	ItemKeys     string  `json:"ItemKeys"`
+	ItemPrefixes string  `json:"ItemPrefixes"`
+	DiscountPrc  float64 `json:"DiscountPrc"`
+	Markets      string  `json:"Markets"`
+	StartDate    string  `json:"StartDate"`
+	EndDate      string  `json:"EndDate"`
+}
+
+type SalesResponse struct {
+	Api    string    `json:"api"`
+	Status string    `json:"status"`
+	Sales  []SaleDto `json:"sales"`
+}
@@ -1,8 +1,24 @@
 package dto
 
+import "time"
+
 type Stock struct {
 	ItemKey     string  `json:"ITEMKEY"`
 	ItemWarHBal float64 `json:"ITEMWARHBAL"`
+	// ItemReserve is the optional per-item reserve kept in the ERP; null when unset.
+	ItemReserve *float64 `json:"ITEMRESERVE"`
+	// Warehouses breaks ItemWarHBal down per ERP warehouse; absent from older feeds.
+	Warehouses []StockWarehouse `json:"WAREHOUSES"`
+	// ExpectedReturnDate and Orden are the products feed's fields of the same name,
+	// for the stock validator's pre-order check; null or absent when the endpoint
+	// does not send them.
+	ExpectedReturnDate *time.Time `json:"expectedReturnDate"`
+	Orden              *float64   `json:"orden"`
+}
+
+type StockWarehouse struct {
+	Warehouse int     `json:"WARHSNUM"`
+	Balance   float64 `json:"WARHBAL"`
 }
 
 type StockResponse struct {
@@ -10,3 +26,12 @@ type StockResponse struct {
 	Status string  `json:"status"`
 	Items  []Stock `json:"items"`
 }
+
+// StockChangesResponse is the incremental feed: the rows changed since the requested
+// cursor, and the cursor to ask from next time.
+type StockChangesResponse struct {
+	Api    string  `json:"api"`
+	Status string  `json:"status"`
+	Items  []Stock `json:"items"`
+	Cursor string  `json:"cursor"`
+}
@@ -80,12 +80,13 @@ func (c *NewPriceService) PriceList(ctx context.Context) ([]model.Price, error)
 	for _, v := range apiResp.Prices {
 		if c.logger != nil && debugsync.MatchSKU(v.ItemKey) {
 			c.logger.Log(fmt.Sprintf(
-				"trace price api sku=%s raw_currency=%q currency=%s price=%.2f price_list=%d dflag=%d use_fid=%d changed=%s",
+				"trace price api sku=%s raw_currency=%q currency=%s price=%.2f price_list=%d from_qty=%d dflag=%d use_fid=%d changed=%s",
 				strings.TrimSpace(v.ItemKey),
 				v.CurrencyCode,
 				normalizeCurrencyCode(v.CurrencyCode),
 				float64(v.Price),
 				v.PriceListNumber,
+				v.FromQuantity,
 				v.DFlag,
 				v.UseFID,
 				strings.TrimSpace(v.CngDate),
@@ -104,6 +105,10 @@ func mapPrice(dto dto.PriceDto) model.Price {
 		Currency:        normalizeCurrencyCode(dto.CurrencyCode),
 		Price:           dto.Price,
 		PriceListNumber: dto.PriceListNumber,
+		FromQuantity:    dto.FromQuantity,
+		OrderMinimum:    dto.MinOrderQty,
+		OrderMaximum:    dto.MaxOrderQty,
+		OrderIncrement:  dto.OrderStep,
 	}
 }
 
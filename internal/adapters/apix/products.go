@@ -89,11 +89,17 @@ func (c *Client) ListProducts(ctx context.Context, page, limit int) ([]model.Pro
 
 func mapProduct(dto dto.ProductDto) model.Product {
 	return model.Product{
-		Sku:          dto.ItemKey,
-		HebrewTitle:  dto.ItemName,
-		EnglishTitle: dto.ForignName,
-		IsPublished:  dto.Status,
-		Barcode:      dto.BarCode,
-		DiscountCode: dto.DiscountCode,
+		Sku:                dto.ItemKey,
+		HebrewTitle:        dto.ItemName,
+		EnglishTitle:       dto.ForignName,
+		IsPublished:        dto.Status,
+		WebItem:            dto.WebItem,
+		Barcode:            dto.BarCode,
+		DiscountCode:       dto.DiscountCode,
+		DiscountPercent:    dto.DiscountPrc,
+		VatExempt:          dto.VatExampt != 0,
+		PurchasePrice:      dto.PurchPrice,
+		ExpectedReturnDate: dto.ExpectedReturnDate,
+		OnOrder:            dto.Orden,
 	}
 }
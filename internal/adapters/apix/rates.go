@@ -0,0 +1,84 @@
+package apix
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"shopify-exporter/internal/adapters/apix/dto"
+	"shopify-exporter/internal/config"
+	"shopify-exporter/internal/domain/model"
+	"shopify-exporter/internal/logging"
+)
+
+// RatesService reads the exchange rates the ERP converts with.
+type RatesService interface {
+	Rates(ctx context.Context) (model.ExchangeRates, error)
+}
+
+type NewRatesS struct {
+	Config     config.ApiHasvConfig
+	httpClient *http.Client
+	logger     logging.LoggerService
+}
+
+const RatesEndpoint = "/currency-rates"
+
+// ERPBookCurrency is the currency the ERP keeps its books in. Its rates are the
+// price of one unit of a foreign currency in it, and it is not listed itself.
+const ERPBookCurrency = "ILS"
+
+func NewRatesService(Config config.ApiHasvConfig, httpClient *http.Client, logger logging.LoggerService) RatesService {
+	return &NewRatesS{
+		Config:     Config,
+		httpClient: httpClient,
+		logger:     logger,
+	}
+}
+
+func (c *NewRatesS) Rates(ctx context.Context) (model.ExchangeRates, error) {
+	jsonBody, err := json.Marshal(map[string]any{"dbName": "EMANUEL"})
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.Config.BaseUrl+RatesEndpoint, bytes.NewReader(jsonBody))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", c.Config.Token)
+
+	client := c.httpClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("apix currency rates request failed: %s", resp.Status)
+	}
+
+	var apiResp dto.RatesResponse
+	if err := json.Unmarshal(respBody, &apiResp); err != nil {
+		return nil, err
+	}
+
+	rates := model.ExchangeRates{ERPBookCurrency: 1}
+	for _, v := range apiResp.Rates {
+		currency := normalizeCurrencyCode(v.CurrencyCode)
+		if currency == "" || v.Rate <= 0 {
+			continue
+		}
+		rates[currency] = v.Rate
+	}
+	return rates, nil
+}
@@ -0,0 +1,123 @@
+package apix
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"shopify-exporter/internal/adapters/apix/dto"
+	"shopify-exporter/internal/config"
+	"shopify-exporter/internal/domain/model"
+	"shopify-exporter/internal/logging"
+	"strings"
+	"time"
+)
+
+// SalesCalendarService reads the scheduled sales maintained in the ERP.
+type SalesCalendarService interface {
+	Sales(ctx context.Context) ([]model.Sale, error)
+}
+
+type NewSalesCalendarS struct {
+	Config     config.ApiHasvConfig
+	httpClient *http.Client
+	logger     logging.LoggerService
+	location   *time.Location
+}
+
+const SalesEndpoint = "/sales-calendar"
+
+// NewSalesCalendarService reads windows as wall-clock time in location.
+func NewSalesCalendarService(Config config.ApiHasvConfig, httpClient *http.Client, logger logging.LoggerService, location *time.Location) SalesCalendarService {
+	return &NewSalesCalendarS{
+		Config:     Config,
+		httpClient: httpClient,
+		logger:     logger,
+		location:   location,
+	}
+}
+
+func (c *NewSalesCalendarS) Sales(ctx context.Context) ([]model.Sale, error) {
+	jsonBody, err := json.Marshal(map[string]any{"dbName": "EMANUEL"})
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.Config.BaseUrl+SalesEndpoint, bytes.NewReader(jsonBody))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", c.Config.Token)
+
+	client := c.httpClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("apix sales calendar request failed: %s", resp.Status)
+	}
+
+	var apiResp dto.SalesResponse
+	if err := json.Unmarshal(respBody, &apiResp); err != nil {
+		return nil, err
+	}
+
+	location := c.location
+	if location == nil {
+		location = time.UTC
+	}
+	sales := make([]model.Sale, 0, len(apiResp.Sales))
+	for _, v := range apiResp.Sales {
+		sale, err := mapSale(v, location)
+		if err != nil {
+			return nil, err
+		}
+		sales = append(sales, sale)
+	}
+	return sales, nil
+}
+
+func mapSale(dto dto.SaleDto, location *time.Location) (model.Sale, error) {
+	start, err := time.ParseInLocation(model.SaleTimeLayout, strings.TrimSpace(dto.StartDate), location)
+	if err != nil {
+		return model.Sale{}, fmt.Errorf("apix sale %q start: %w", dto.Name, err)
+	}
+	end, err := time.ParseInLocation(model.SaleTimeLayout, strings.TrimSpace(dto.EndDate), location)
+	if err != nil {
+		return model.Sale{}, fmt.Errorf("apix sale %q end: %w", dto.Name, err)
+	}
+	sale := model.Sale{
+		Name:        strings.TrimSpace(dto.Name),
+		Skus:        splitList(dto.ItemKeys),
+		SkuPrefixes: splitList(dto.ItemPrefixes),
+		Percent:     dto.DiscountPrc,
+		Markets:     splitList(dto.Markets),
+		Start:       start,
+		End:         end,
+	}
+	if err := sale.Validate(); err != nil {
+		return model.Sale{}, fmt.Errorf("apix %w", err)
+	}
+	return sale, nil
+}
+
+func splitList(raw string) []string {
+	values := make([]string, 0)
+	for _, part := range strings.Split(raw, ",") {
+		if trimmed := strings.TrimSpace(part); trimmed != "" {
+			values = append(values, trimmed)
+		}
+	}
+	return values
+}
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"math"
@@ -14,10 +15,32 @@ import (
 	"shopify-exporter/internal/domain/model"
 	"shopify-exporter/internal/logging"
 	"strings"
+	"time"
 )
 
 type StockService interface {
 	FetchStocks(ctx context.Context) ([]model.Stock, error)
+	// FetchStockChanges returns only the rows the ERP changed since the cursor.
+	FetchStockChanges(ctx context.Context, since time.Time) (StockChanges, error)
+}
+
+// StockChanges is one read of the incremental stock feed.
+type StockChanges struct {
+	Items []model.Stock
+	// Cursor is the ERP's high-water mark for this read: the since of the next one.
+	Cursor time.Time
+}
+
+// ErrStockCursorExpired means the ERP no longer keeps changes back to the requested
+// cursor. The caller must read the full feed; anything else would miss changes.
+var ErrStockCursorExpired = errors.New("apix stock cursor expired")
+
+// StockLookupService reads one SKU live. It is for checkout-time validation, where the
+// bulk feed is far too slow to read per cart.
+type StockLookupService interface {
+	// FetchStock returns the SKU's current balance; found is false when the ERP does
+	// not know the SKU.
+	FetchStock(ctx context.Context, sku string) (stock model.Stock, found bool, err error)
 }
 
 type NewStockS struct {
@@ -26,7 +49,11 @@ type NewStockS struct {
 	logger     logging.LoggerService
 }
 
-const ENDPOINT = "/stocksProducts"
+const (
+	ENDPOINT         = "/stocksProducts"
+	CHANGES_ENDPOINT = "/stocksProductsChanges"
+	LOOKUP_ENDPOINT  = "/stocks"
+)
 
 func NewStockService(Config config.ApiHasvConfig, httpClient *http.Client, logger logging.LoggerService) StockService {
 	return &NewStockS{
@@ -36,6 +63,16 @@ func NewStockService(Config config.ApiHasvConfig, httpClient *http.Client, logge
 	}
 }
 
+// NewStockLookupService is the single-SKU reader; it shares the bulk client's request
+// handling.
+func NewStockLookupService(Config config.ApiHasvConfig, httpClient *http.Client, logger logging.LoggerService) StockLookupService {
+	return &NewStockS{
+		Config:     Config,
+		httpClient: httpClient,
+		logger:     logger,
+	}
+}
+
 func (c *NewStockS) logError(message string, err error) {
 	if c.logger == nil || err == nil {
 		return
@@ -51,10 +88,81 @@ func (c *NewStockS) logError(message string, err error) {
 // nothing had been synced. At four runs a day that was rare; on a five-minute cadence
 // it is routine, and a silent success is worse than a loud failure.
 func (c *NewStockS) FetchStocks(ctx context.Context) ([]model.Stock, error) {
-	body := map[string]any{
+	parsed, err := c.post(ctx, ENDPOINT, map[string]any{
+		"dbName": "EMANUEL",
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var result dto.StockResponse
+	if err := json.Unmarshal(parsed, &result); err != nil {
+		c.logError("apix stocks response unmarshal failed", err)
+		return nil, err
+	}
+	return c.mapStocks(result.Items), nil
+}
+
+// FetchStockChanges reads the rows changed since the cursor. The rows have the same
+// shape as the full feed. A response without a usable cursor is an error: saving
+// nothing would leave the caller re-reading the same window forever, and guessing
+// one could skip a change.
+func (c *NewStockS) FetchStockChanges(ctx context.Context, since time.Time) (StockChanges, error) {
+	parsed, err := c.post(ctx, CHANGES_ENDPOINT, map[string]any{
 		"dbName": "EMANUEL",
+		"since":  since.UTC().Format(time.RFC3339),
+	})
+	if err != nil {
+		return StockChanges{}, err
+	}
+
+	var result dto.StockChangesResponse
+	if err := json.Unmarshal(parsed, &result); err != nil {
+		c.logError("apix stock changes response unmarshal failed", err)
+		return StockChanges{}, err
+	}
+	cursor, err := time.Parse(time.RFC3339, strings.TrimSpace(result.Cursor))
+	if err != nil {
+		cursorErr := fmt.Errorf("apix stock changes cursor %q is not a timestamp: %w", result.Cursor, err)
+		c.logError("apix stock changes response cursor", cursorErr)
+		return StockChanges{}, cursorErr
 	}
-	url := strings.TrimRight(strings.TrimSpace(c.Config.BaseUrl), "/") + ENDPOINT
+	return StockChanges{Items: c.mapStocks(result.Items), Cursor: cursor}, nil
+}
+
+// FetchStock reads one SKU from /stocks. The response has the bulk feed's shape; only
+// the row for the requested SKU counts, so an endpoint that ignores the filter cannot
+// answer for the wrong item.
+func (c *NewStockS) FetchStock(ctx context.Context, sku string) (model.Stock, bool, error) {
+	sku = strings.TrimSpace(sku)
+	if sku == "" {
+		return model.Stock{}, false, errors.New("apix stock lookup: empty sku")
+	}
+	parsed, err := c.post(ctx, LOOKUP_ENDPOINT, map[string]any{
+		"dbName":  "EMANUEL",
+		"itemKey": sku,
+	})
+	if err != nil {
+		return model.Stock{}, false, err
+	}
+
+	var result dto.StockResponse
+	if err := json.Unmarshal(parsed, &result); err != nil {
+		c.logError("apix stock lookup response unmarshal failed", err)
+		return model.Stock{}, false, err
+	}
+	for _, item := range result.Items {
+		if strings.EqualFold(strings.TrimSpace(item.ItemKey), sku) {
+			return c.mapStocks([]dto.Stock{item})[0], true, nil
+		}
+	}
+	return model.Stock{}, false, nil
+}
+
+// post sends one stock request and returns the body of a 2xx response. 410 Gone from
+// the changes endpoint is ErrStockCursorExpired.
+func (c *NewStockS) post(ctx context.Context, endpoint string, body map[string]any) ([]byte, error) {
+	url := strings.TrimRight(strings.TrimSpace(c.Config.BaseUrl), "/") + endpoint
 	bodyBytes, err := json.Marshal(body)
 	if err != nil {
 		c.logError("apix stocks marshal failed", err)
@@ -81,49 +189,77 @@ func (c *NewStockS) FetchStocks(ctx context.Context) ([]model.Stock, error) {
 		c.logError("apix stocks response read failed", err)
 		return nil, err
 	}
+	if resp.StatusCode == http.StatusGone && endpoint == CHANGES_ENDPOINT {
+		return nil, ErrStockCursorExpired
+	}
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		statusErr := fmt.Errorf("apix stocks request failed: %s", resp.Status)
+		statusErr := fmt.Errorf("apix stocks request failed: %s %s", endpoint, resp.Status)
 		c.logError("apix stocks response status", statusErr)
 		return nil, statusErr
 	}
+	return parsed, nil
+}
 
-	var result dto.StockResponse
-	if err := json.Unmarshal(parsed, &result); err != nil {
-		c.logError("apix stocks response unmarshal failed", err)
-		return nil, err
-	}
-
-	resData := make([]model.Stock, 0, len(result.Items))
-	for _, v := range result.Items {
+func (c *NewStockS) mapStocks(items []dto.Stock) []model.Stock {
+	resData := make([]model.Stock, 0, len(items))
+	for _, v := range items {
 		mapped := dtoMap(v)
 		if c.logger != nil && debugsync.MatchSKU(v.ItemKey) {
 			c.logger.Log(fmt.Sprintf(
-				"trace stock api sku=%s raw_itemwarhbal=%.2f exported_quantity=%d buffer=3",
+				"trace stock api sku=%s raw_itemwarhbal=%.2f balance=%d erp_reserve=%s warehouses=%v",
 				strings.TrimSpace(v.ItemKey),
 				v.ItemWarHBal,
 				mapped.Stock,
+				erpReserveTrace(mapped),
+				mapped.Warehouses,
 			))
 		}
 		resData = append(resData, mapped)
 	}
-	return resData, nil
+	return resData
 }
 
-// CLIENT WISH TO TAKE STOCK AND - 3 (3-unit reserve buffer).
-// Clamp at 0: out-of-stock items (balance <= 3, or already negative in the ERP) are
-// pushed to Shopify as 0 instead of going negative and being SKIPPED by sync_stocks,
-// which left them showing as available on the storefront. See FIXES.md 2026-06-30.
+// dtoMap rounds the ERP balance and nothing else. The reserve (the client's wish to
+// keep units off the storefront, once a flat 3 here) and the clamp at 0 are applied
+// by the stock step, which knows the per-SKU reserve policy. A negative balance is
+// passed on as negative: the stock step pushes it as 0, which is what stopped
+// out-of-stock items showing as available. See FIXES.md 2026-06-30.
 func dtoMap(dto dto.Stock) model.Stock {
-	quantity := int32(0)
-	if !math.IsNaN(dto.ItemWarHBal) && !math.IsInf(dto.ItemWarHBal, 0) {
-		quantity = int32(math.Round(dto.ItemWarHBal))
+	stock := model.Stock{
+		Sku:   dto.ItemKey,
+		Stock: roundQuantity(dto.ItemWarHBal),
 	}
-	stock := quantity - 3
-	if stock < 0 {
-		stock = 0
+	if dto.ItemReserve != nil && !math.IsNaN(*dto.ItemReserve) && !math.IsInf(*dto.ItemReserve, 0) && *dto.ItemReserve >= 0 {
+		stock.Reserve = roundQuantity(*dto.ItemReserve)
+		stock.HasReserve = true
 	}
-	return model.Stock{
-		Sku:   dto.ItemKey,
-		Stock: stock,
+	if dto.ExpectedReturnDate != nil {
+		stock.ExpectedReturnDate = *dto.ExpectedReturnDate
+	}
+	if dto.Orden != nil && !math.IsNaN(*dto.Orden) && !math.IsInf(*dto.Orden, 0) {
+		stock.OnOrder = *dto.Orden
+	}
+	if len(dto.Warehouses) > 0 {
+		stock.Warehouses = make(map[int]int32, len(dto.Warehouses))
+		for _, warehouse := range dto.Warehouses {
+			// A warehouse listed twice is summed: the total must still match ITEMWARHBAL.
+			stock.Warehouses[warehouse.Warehouse] += roundQuantity(warehouse.Balance)
+		}
+	}
+	return stock
+}
+
+// roundQuantity rounds an ERP quantity; a non-numeric one is 0, never a huge number.
+func roundQuantity(value float64) int32 {
+	if math.IsNaN(value) || math.IsInf(value, 0) {
+		return 0
+	}
+	return int32(math.Round(value))
+}
+
+func erpReserveTrace(stock model.Stock) string {
+	if !stock.HasReserve {
+		return "none"
 	}
+	return fmt.Sprintf("%d", stock.Reserve)
 }
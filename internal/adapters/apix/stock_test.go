@@ -1,29 +1,31 @@
 package apix
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
 	"math"
+	"net/http"
+	"net/http/httptest"
 	"shopify-exporter/internal/adapters/apix/dto"
+	"shopify-exporter/internal/config"
 	"testing"
+	"time"
 )
 
-// The 3-unit reserve is the client's rule, and the clamp at 0 is what stopped
-// out-of-stock items showing as available on the storefront: a negative result used to
-// be skipped by sync_stocks, leaving Shopify on its last positive number.
-// See FIXES.md 2026-06-30.
-func TestDtoMapAppliesReserveAndClampsAtZero(t *testing.T) {
+// dtoMap passes the rounded ERP balance on as is, negative included: the reserve and
+// the clamp at 0 belong to the stock step, which knows each SKU's reserve.
+func TestDtoMapRoundsTheBalance(t *testing.T) {
 	cases := []struct {
 		name    string
 		balance float64
 		want    int32
 	}{
-		{"comfortably in stock", 250, 247},
-		{"just above the reserve", 4, 1},
-		{"exactly the reserve is out of stock", 3, 0},
-		{"below the reserve", 1, 0},
+		{"in stock", 250, 250},
 		{"zero balance", 0, 0},
-		{"negative balance in the erp", -1, 0},
-		{"rounds to nearest", 7.6, 5},
-		{"rounds half away from zero", 6.5, 4},
+		{"negative balance in the erp", -1, -1},
+		{"rounds to nearest", 7.6, 8},
+		{"rounds half away from zero", 6.5, 7},
 	}
 
 	for _, tc := range cases {
@@ -35,11 +37,60 @@ func TestDtoMapAppliesReserveAndClampsAtZero(t *testing.T) {
 			if got.Sku != "HVM-1" {
 				t.Errorf("sku = %q, want HVM-1", got.Sku)
 			}
+			if got.HasReserve {
+				t.Errorf("no ERP reserve field, got reserve %d", got.Reserve)
+			}
 		})
 	}
 }
 
+// The ERP reserve field is optional; an empty or nonsense value means none.
+func TestDtoMapReadsTheERPReserve(t *testing.T) {
+	reserve := func(v float64) *float64 { return &v }
+	if got := dtoMap(dto.Stock{ItemKey: "HVM-1", ItemWarHBal: 10, ItemReserve: reserve(0)}); !got.HasReserve || got.Reserve != 0 {
+		t.Errorf("reserve 0 -> %+v, want an explicit 0", got)
+	}
+	if got := dtoMap(dto.Stock{ItemKey: "HVM-1", ItemWarHBal: 10, ItemReserve: reserve(5.2)}); !got.HasReserve || got.Reserve != 5 {
+		t.Errorf("reserve 5.2 -> %+v, want 5", got)
+	}
+	for _, bad := range []float64{-1, math.NaN()} {
+		if got := dtoMap(dto.Stock{ItemKey: "HVM-1", ItemWarHBal: 10, ItemReserve: reserve(bad)}); got.HasReserve {
+			t.Errorf("reserve %v -> %+v, want none", bad, got)
+		}
+	}
+}
+
+// The per-warehouse breakdown is optional and rounded like the total.
+func TestDtoMapReadsWarehouseBalances(t *testing.T) {
+	got := dtoMap(dto.Stock{ItemKey: "HVM-1", ItemWarHBal: 12, Warehouses: []dto.StockWarehouse{
+		{Warehouse: 1, Balance: 4.4},
+		{Warehouse: 3, Balance: 2},
+		{Warehouse: 3, Balance: 6},
+	}})
+	if len(got.Warehouses) != 2 || got.Warehouses[1] != 4 || got.Warehouses[3] != 8 {
+		t.Errorf("warehouses = %v, want 1:4 3:8", got.Warehouses)
+	}
+	if got := dtoMap(dto.Stock{ItemKey: "HVM-1", ItemWarHBal: 12}); got.Warehouses != nil {
+		t.Errorf("no breakdown in the feed -> %v, want nil", got.Warehouses)
+	}
+}
+
 // A non-numeric balance must not become a huge or negative quantity.
+// The single-SKU /stocks row may carry the pre-order fields; absent they stay zero.
+func TestDtoMapReadsThePreorderFields(t *testing.T) {
+	var item dto.Stock
+	if err := json.Unmarshal([]byte(`{"ITEMKEY":"PRE-1","ITEMWARHBAL":0,"expectedReturnDate":"2026-04-01T00:00:00Z","orden":6}`), &item); err != nil {
+		t.Fatal(err)
+	}
+	got := dtoMap(item)
+	if got.ExpectedReturnDate.Format("2006-01-02") != "2026-04-01" || got.OnOrder != 6 {
+		t.Errorf("pre-order fields = %s, %v; want 2026-04-01, 6", got.ExpectedReturnDate, got.OnOrder)
+	}
+	if plain := dtoMap(dto.Stock{ItemKey: "HVM-1"}); !plain.ExpectedReturnDate.IsZero() || plain.OnOrder != 0 {
+		t.Errorf("row without the fields = %+v, want them zero", plain)
+	}
+}
+
 func TestDtoMapHandlesNaNAndInf(t *testing.T) {
 	for name, balance := range map[string]float64{
 		"NaN":  math.NaN(),
@@ -53,3 +104,86 @@ func TestDtoMapHandlesNaNAndInf(t *testing.T) {
 		})
 	}
 }
+
+func TestFetchStockChangesSendsTheCursorAndReadsTheNextOne(t *testing.T) {
+	var gotSince string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != CHANGES_ENDPOINT {
+			t.Errorf("path = %s, want %s", r.URL.Path, CHANGES_ENDPOINT)
+		}
+		var body map[string]string
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		gotSince = body["since"]
+		_, _ = w.Write([]byte(`{"status":"ok","cursor":"2026-08-04T09:30:00Z","items":[{"ITEMKEY":"HVM-1","ITEMWARHBAL":4}]}`))
+	}))
+	defer server.Close()
+
+	client := NewStockService(config.ApiHasvConfig{BaseUrl: server.URL}, server.Client(), nil)
+	since := time.Date(2026, 8, 4, 12, 25, 0, 0, time.FixedZone("IDT", 3*3600))
+	changes, err := client.FetchStockChanges(context.Background(), since)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if gotSince != "2026-08-04T09:25:00Z" {
+		t.Errorf("since sent = %q, want it in UTC", gotSince)
+	}
+	if len(changes.Items) != 1 || changes.Items[0].Stock != 4 {
+		t.Errorf("items = %+v", changes.Items)
+	}
+	if want := time.Date(2026, 8, 4, 9, 30, 0, 0, time.UTC); !changes.Cursor.Equal(want) {
+		t.Errorf("cursor = %s, want %s", changes.Cursor, want)
+	}
+}
+
+// 410 means the ERP dropped the changes the cursor points into; a missing cursor in
+// the reply is an error too, so the caller reads the full feed either way.
+func TestFetchStockChangesReportsCursorGaps(t *testing.T) {
+	status, reply := http.StatusGone, ``
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+		_, _ = w.Write([]byte(reply))
+	}))
+	defer server.Close()
+	client := NewStockService(config.ApiHasvConfig{BaseUrl: server.URL}, server.Client(), nil)
+
+	if _, err := client.FetchStockChanges(context.Background(), time.Now()); !errors.Is(err, ErrStockCursorExpired) {
+		t.Errorf("410 -> %v, want ErrStockCursorExpired", err)
+	}
+	status, reply = http.StatusOK, `{"status":"ok","items":[]}`
+	if _, err := client.FetchStockChanges(context.Background(), time.Now()); err == nil {
+		t.Error("a reply without a cursor must be an error")
+	}
+}
+
+// The lookup answers only for the SKU it asked about, whatever else the ERP returns.
+func TestFetchStockReadsOnlyTheRequestedSKU(t *testing.T) {
+	var gotKey string
+	reply := `{"status":"ok","items":[{"ITEMKEY":"HVM-10","ITEMWARHBAL":40},{"ITEMKEY":"hvm-1","ITEMWARHBAL":4}]}`
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != LOOKUP_ENDPOINT {
+			t.Errorf("path = %s, want %s", r.URL.Path, LOOKUP_ENDPOINT)
+		}
+		var body map[string]string
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		gotKey = body["itemKey"]
+		_, _ = w.Write([]byte(reply))
+	}))
+	defer server.Close()
+	client := NewStockLookupService(config.ApiHasvConfig{BaseUrl: server.URL}, server.Client(), nil)
+
+	stock, found, err := client.FetchStock(context.Background(), " HVM-1 ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if gotKey != "HVM-1" {
+		t.Errorf("itemKey sent = %q, want HVM-1", gotKey)
+	}
+	if !found || stock.Stock != 4 {
+		t.Errorf("HVM-1 -> %+v found=%t, want balance 4", stock, found)
+	}
+
+	reply = `{"status":"ok","items":[{"ITEMKEY":"HVM-10","ITEMWARHBAL":40}]}`
+	if _, found, err := client.FetchStock(context.Background(), "HVM-1"); err != nil || found {
+		t.Errorf("unknown SKU -> found=%t err=%v, want not found", found, err)
+	}
+}
@@ -0,0 +1,559 @@
+package shopify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"shopify-exporter/internal/adapters/shopify/dto"
+)
+
+const maxCompaniesPageSize = 250
+
+// B2BService keeps wholesale customers and their price lists in step with the ERP:
+// companies with one location each, and one company-location catalog per ERP price
+// list with its own price list. Every call fails on a store without B2B.
+type B2BService interface {
+	EnsureCompanies(ctx context.Context, companies []B2BCompanyInput) (map[string]string, error)
+	EnsureB2BCatalog(ctx context.Context, input B2BCatalogInput) (B2BCatalog, error)
+	UpsertB2BPrices(ctx context.Context, catalog B2BCatalog, inputs []PriceUpsertInput, prune bool) error
+}
+
+// B2BCompanyInput is one ERP customer. ExternalID (the ERP account key) is what ties
+// the company and its location to the customer across runs; renaming the customer
+// in the ERP renames the company.
+type B2BCompanyInput struct {
+	ExternalID string
+	Name       string
+	Email      string
+	Phone      string
+}
+
+// B2BCatalogInput is one ERP price list's catalog: its title, the price list name
+// and currency, and every company location that buys at it.
+type B2BCatalogInput struct {
+	Title         string
+	PriceListName string
+	Currency      string
+	LocationIDs   []string
+}
+
+// B2BCatalog are the Shopify ids behind one B2B catalog.
+type B2BCatalog struct {
+	Title         string
+	Currency      string
+	CatalogID     string
+	PublicationID string
+	PriceListID   string
+}
+
+// EnsureCompanies creates the companies and locations that do not exist yet and
+// renames the ones whose ERP name changed. It returns the company location id per
+// external id. A company's contact is only created with the company: from then on
+// the contacts are managed in Shopify, where the customer's people log in.
+func (c *Client) EnsureCompanies(ctx context.Context, companies []B2BCompanyInput) (map[string]string, error) {
+	if c == nil {
+		return nil, errors.New("shopify client is nil")
+	}
+	existing, err := c.listCompanies(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	locations := make(map[string]string, len(companies))
+	created, renamed := 0, 0
+	for _, input := range companies {
+		externalID := strings.TrimSpace(input.ExternalID)
+		if externalID == "" {
+			continue
+		}
+		name := strings.TrimSpace(input.Name)
+		if name == "" {
+			name = externalID
+		}
+
+		company, ok := existing[externalID]
+		if !ok {
+			company, err = c.createCompany(ctx, input, name)
+			if err != nil {
+				return nil, err
+			}
+			created++
+			c.logSuccess(fmt.Sprintf("shopify company created id=%s external_id=%s", company.ID, externalID))
+		} else if company.Name != name {
+			if err := c.renameCompany(ctx, company.ID, name); err != nil {
+				return nil, err
+			}
+			renamed++
+		}
+
+		locationID := companyLocationFor(company, externalID)
+		if locationID == "" {
+			location, err := c.createCompanyLocation(ctx, company.ID, input, name)
+			if err != nil {
+				return nil, err
+			}
+			locationID = location.ID
+		}
+		locations[externalID] = locationID
+	}
+
+	c.reportIncr("b2b", "companies_created", int64(created))
+	c.reportIncr("b2b", "companies_renamed", int64(renamed))
+	c.logSuccess(fmt.Sprintf("shopify companies ready total=%d created=%d renamed=%d", len(locations), created, renamed))
+	return locations, nil
+}
+
+// companyLocationFor prefers the location carrying the customer's external id, then
+// the company's only location.
+func companyLocationFor(company dto.CompanyNode, externalID string) string {
+	for _, location := range company.Locations.Nodes {
+		if strings.TrimSpace(location.ExternalID) == externalID {
+			return strings.TrimSpace(location.ID)
+		}
+	}
+	if len(company.Locations.Nodes) == 1 {
+		return strings.TrimSpace(company.Locations.Nodes[0].ID)
+	}
+	return ""
+}
+
+// listCompanies returns every company with an external id, keyed by it. Companies
+// created by hand in Shopify have none and are left alone.
+func (c *Client) listCompanies(ctx context.Context) (map[string]dto.CompanyNode, error) {
+	query := `
+	query companies($first: Int!, $after: String) {
+		companies(first: $first, after: $after) {
+			nodes {
+				id
+				name
+				externalId
+				locations(first: 10) { nodes { id externalId } }
+			}
+			pageInfo { hasNextPage endCursor }
+		}
+	}`
+
+	companies := make(map[string]dto.CompanyNode)
+	after := ""
+	for {
+		variables := map[string]any{"first": maxCompaniesPageSize}
+		if after != "" {
+			variables["after"] = after
+		}
+		var data dto.CompaniesQueryData
+		if err := c.graphqlRequest(ctx, query, variables, &data); err != nil {
+			return nil, err
+		}
+		for _, node := range data.Companies.Nodes {
+			externalID := strings.TrimSpace(node.ExternalID)
+			if externalID == "" {
+				continue
+			}
+			node.Name = strings.TrimSpace(node.Name)
+			companies[externalID] = node
+		}
+		if !data.Companies.PageInfo.HasNextPage {
+			break
+		}
+		after = strings.TrimSpace(data.Companies.PageInfo.EndCursor)
+		if after == "" {
+			break
+		}
+	}
+	return companies, nil
+}
+
+func (c *Client) createCompany(ctx context.Context, input B2BCompanyInput, name string) (dto.CompanyNode, error) {
+	query := `
+	mutation companyCreate($input: CompanyCreateInput!) {
+		companyCreate(input: $input) {
+			company {
+				id
+				name
+				externalId
+				locations(first: 1) { nodes { id externalId } }
+			}
+			userErrors { field message }
+		}
+	}`
+
+	externalID := strings.TrimSpace(input.ExternalID)
+	createInput := map[string]any{
+		"company": map[string]any{
+			"name":       name,
+			"externalId": externalID,
+		},
+		"companyLocation": companyLocationInput(input, name),
+	}
+	// The contact is the customer account the wholesale buyer logs in with; without
+	// an email there is nobody to invite, and the company waits for one in Shopify.
+	if email := strings.TrimSpace(input.Email); email != "" {
+		createInput["companyContact"] = map[string]any{
+			"email":     email,
+			"firstName": name,
+		}
+	}
+
+	var data dto.CompanyCreateData
+	if err := c.graphqlRequest(ctx, query, map[string]any{"input": createInput}, &data); err != nil {
+		return dto.CompanyNode{}, err
+	}
+	if err := userErrorsToDetailedError("companyCreate", data.CompanyCreate.UserErrors); err != nil {
+		return dto.CompanyNode{}, err
+	}
+	if data.CompanyCreate.Company == nil || strings.TrimSpace(data.CompanyCreate.Company.ID) == "" {
+		return dto.CompanyNode{}, errors.New("shopify company create returned empty id")
+	}
+	return *data.CompanyCreate.Company, nil
+}
+
+func (c *Client) renameCompany(ctx context.Context, companyID, name string) error {
+	query := `
+	mutation companyUpdate($companyId: ID!, $input: CompanyInput!) {
+		companyUpdate(companyId: $companyId, input: $input) {
+			company { id }
+			userErrors { field message }
+		}
+	}`
+
+	var data dto.CompanyUpdateData
+	if err := c.graphqlRequest(ctx, query, map[string]any{
+		"companyId": companyID,
+		"input":     map[string]any{"name": name},
+	}, &data); err != nil {
+		return err
+	}
+	return userErrorsToDetailedError("companyUpdate", data.CompanyUpdate.UserErrors)
+}
+
+func (c *Client) createCompanyLocation(ctx context.Context, companyID string, input B2BCompanyInput, name string) (dto.CompanyLocationNode, error) {
+	query := `
+	mutation companyLocationCreate($companyId: ID!, $input: CompanyLocationInput!) {
+		companyLocationCreate(companyId: $companyId, input: $input) {
+			companyLocation { id externalId }
+			userErrors { field message }
+		}
+	}`
+
+	var data dto.CompanyLocationCreateData
+	if err := c.graphqlRequest(ctx, query, map[string]any{
+		"companyId": companyID,
+		"input":     companyLocationInput(input, name),
+	}, &data); err != nil {
+		return dto.CompanyLocationNode{}, err
+	}
+	if err := userErrorsToDetailedError("companyLocationCreate", data.CompanyLocationCreate.UserErrors); err != nil {
+		return dto.CompanyLocationNode{}, err
+	}
+	if data.CompanyLocationCreate.CompanyLocation == nil || strings.TrimSpace(data.CompanyLocationCreate.CompanyLocation.ID) == "" {
+		return dto.CompanyLocationNode{}, errors.New("shopify company location create returned empty id")
+	}
+	return *data.CompanyLocationCreate.CompanyLocation, nil
+}
+
+func companyLocationInput(input B2BCompanyInput, name string) map[string]any {
+	location := map[string]any{
+		"name":       name,
+		"externalId": strings.TrimSpace(input.ExternalID),
+	}
+	if phone := strings.TrimSpace(input.Phone); phone != "" {
+		location["phone"] = phone
+	}
+	return location
+}
+
+// EnsureB2BCatalog finds (or creates) the catalog, makes its company locations
+// exactly the given ones, and makes sure its publication and price list exist. A
+// customer moved to another ERP price list is taken out of the old list's catalog
+// here, so they never see two wholesale prices.
+func (c *Client) EnsureB2BCatalog(ctx context.Context, input B2BCatalogInput) (B2BCatalog, error) {
+	if c == nil {
+		return B2BCatalog{}, errors.New("shopify client is nil")
+	}
+	if len(input.LocationIDs) == 0 {
+		return B2BCatalog{}, fmt.Errorf("shopify b2b catalog %q has no company locations", input.Title)
+	}
+
+	catalog, err := c.findCatalogByTitle(ctx, input.Title)
+	if err != nil {
+		return B2BCatalog{}, err
+	}
+	if catalog.ID == "" {
+		catalog, err = c.createB2BCatalog(ctx, input.Title, input.LocationIDs)
+		if err != nil {
+			return B2BCatalog{}, err
+		}
+		c.logSuccess(fmt.Sprintf("shopify b2b catalog created id=%s title=%s", catalog.ID, catalog.Title))
+	} else {
+		if err := c.syncCatalogLocations(ctx, catalog.ID, input.LocationIDs); err != nil {
+			return B2BCatalog{}, err
+		}
+		c.logSuccess(fmt.Sprintf("shopify b2b catalog found id=%s title=%s", catalog.ID, catalog.Title))
+	}
+
+	publication, priceList, err := c.ensureCatalogPublicationAndPriceList(ctx, catalog.ID, input.PriceListName, input.Currency)
+	if err != nil {
+		return B2BCatalog{}, err
+	}
+	return B2BCatalog{
+		Title:         input.Title,
+		Currency:      input.Currency,
+		CatalogID:     catalog.ID,
+		PublicationID: publication.ID,
+		PriceListID:   priceList.ID,
+	}, nil
+}
+
+func (c *Client) createB2BCatalog(ctx context.Context, title string, locationIDs []string) (dto.CatalogNode, error) {
+	query := `
+	mutation catalogCreate($input: CatalogCreateInput!) {
+		catalogCreate(input: $input) {
+			catalog { id title status }
+			userErrors { field message }
+		}
+	}`
+
+	input := map[string]any{
+		"title":  strings.TrimSpace(title),
+		"status": "ACTIVE",
+		"context": map[string]any{
+			"companyLocationIds": locationIDs,
+		},
+	}
+
+	var data dto.CatalogCreateData
+	if err := c.graphqlRequest(ctx, query, map[string]any{"input": input}, &data); err != nil {
+		return dto.CatalogNode{}, err
+	}
+	if err := userErrorsToDetailedError("catalogCreate", data.CatalogCreate.UserErrors); err != nil {
+		return dto.CatalogNode{}, err
+	}
+	if data.CatalogCreate.Catalog == nil || strings.TrimSpace(data.CatalogCreate.Catalog.ID) == "" {
+		return dto.CatalogNode{}, errors.New("shopify catalog create returned empty id")
+	}
+	return *data.CatalogCreate.Catalog, nil
+}
+
+// syncCatalogLocations adds the missing company locations to the catalog and removes
+// the ones no longer on its price list.
+func (c *Client) syncCatalogLocations(ctx context.Context, catalogID string, locationIDs []string) error {
+	current, err := c.catalogLocations(ctx, catalogID)
+	if err != nil {
+		return err
+	}
+	want := make(map[string]bool, len(locationIDs))
+	add := make([]string, 0)
+	for _, id := range locationIDs {
+		want[id] = true
+		if !current[id] {
+			add = append(add, id)
+		}
+	}
+	remove := make([]string, 0)
+	for id := range current {
+		if !want[id] {
+			remove = append(remove, id)
+		}
+	}
+	if len(add) == 0 && len(remove) == 0 {
+		return nil
+	}
+	sort.Strings(remove)
+
+	query := `
+	mutation catalogContextUpdate($catalogId: ID!, $contextsToAdd: CatalogContextInput, $contextsToRemove: CatalogContextInput) {
+		catalogContextUpdate(catalogId: $catalogId, contextsToAdd: $contextsToAdd, contextsToRemove: $contextsToRemove) {
+			catalog { id title status }
+			userErrors { field message }
+		}
+	}`
+	variables := map[string]any{"catalogId": catalogID}
+	if len(add) > 0 {
+		variables["contextsToAdd"] = map[string]any{"companyLocationIds": add}
+	}
+	if len(remove) > 0 {
+		variables["contextsToRemove"] = map[string]any{"companyLocationIds": remove}
+	}
+
+	var data dto.CatalogContextUpdateData
+	if err := c.graphqlRequest(ctx, query, variables, &data); err != nil {
+		return err
+	}
+	if err := userErrorsToDetailedError("catalogContextUpdate", data.CatalogContextUpdate.UserErrors); err != nil {
+		return err
+	}
+	c.reportIncr("b2b", "catalog_locations_added", int64(len(add)))
+	c.reportIncr("b2b", "catalog_locations_removed", int64(len(remove)))
+	c.logSuccess(fmt.Sprintf("shopify b2b catalog locations updated id=%s added=%d removed=%d", catalogID, len(add), len(remove)))
+	return nil
+}
+
+func (c *Client) catalogLocations(ctx context.Context, catalogID string) (map[string]bool, error) {
+	query := `
+	query catalogLocations($id: ID!, $first: Int!, $after: String) {
+		catalog(id: $id) {
+			id
+			... on CompanyLocationCatalog {
+				companyLocations(first: $first, after: $after) {
+					nodes { id }
+					pageInfo { hasNextPage endCursor }
+				}
+			}
+		}
+	}`
+
+	locations := make(map[string]bool)
+	after := ""
+	for {
+		variables := map[string]any{"id": catalogID, "first": maxCompaniesPageSize}
+		if after != "" {
+			variables["after"] = after
+		}
+		var data dto.CatalogCompanyLocationsData
+		if err := c.graphqlRequest(ctx, query, variables, &data); err != nil {
+			return nil, err
+		}
+		if data.Catalog == nil {
+			return nil, fmt.Errorf("shopify catalog %s not found", catalogID)
+		}
+		if data.Catalog.CompanyLocations == nil {
+			return nil, fmt.Errorf("shopify catalog %s is not a company location catalog", catalogID)
+		}
+		for _, node := range data.Catalog.CompanyLocations.Nodes {
+			locations[strings.TrimSpace(node.ID)] = true
+		}
+		if !data.Catalog.CompanyLocations.PageInfo.HasNextPage {
+			break
+		}
+		after = strings.TrimSpace(data.Catalog.CompanyLocations.PageInfo.EndCursor)
+		if after == "" {
+			break
+		}
+	}
+	return locations, nil
+}
+
+// UpsertB2BPrices writes each SKU's price in the catalog currency as a fixed price
+// on the catalog's price list, then its volume pricing. With prune, fixed prices for variants the ERP list no
+// longer prices are deleted, so those variants fall back to the store price instead
+// of keeping a stale wholesale one.
+func (c *Client) UpsertB2BPrices(ctx context.Context, catalog B2BCatalog, inputs []PriceUpsertInput, prune bool) error {
+	if c == nil {
+		return errors.New("shopify client is nil")
+	}
+	currency := strings.ToUpper(strings.TrimSpace(catalog.Currency))
+	skuLookup, err := c.buildVariantLookup(ctx, inputs)
+	if err != nil {
+		return err
+	}
+
+	resolved := make([]resolvedPriceInput, 0, len(inputs))
+	skippedMissing := 0
+	for _, input := range inputs {
+		if amount, ok := input.Prices[currency]; !ok || amount < 0 {
+			return fmt.Errorf("shopify b2b price for sku %s is missing %s", strings.TrimSpace(input.SKU), currency)
+		}
+		if err := input.ValidateQuantityPricing(currency); err != nil {
+			return fmt.Errorf("shopify b2b volume pricing for sku %s: %w", strings.TrimSpace(input.SKU), err)
+		}
+		var hint *variantLookup
+		if lookup, ok := skuLookup[strings.TrimSpace(input.SKU)]; ok {
+			input.VariantID = lookup.VariantID
+			input.ProductID = lookup.ProductID
+			hint = &lookup
+		} else if len(skuLookup) > 0 {
+			skippedMissing++
+			continue
+		}
+		item, err := c.resolvePriceInput(ctx, input, hint)
+		if err != nil {
+			if _, ok := isVariantNotFoundError(err); ok {
+				skippedMissing++
+				continue
+			}
+			return err
+		}
+		resolved = append(resolved, item)
+	}
+
+	// Reported as counters, not price rows: the report's price table is the retail
+	// price per currency, and a wholesale price in the same currency would read as a
+	// retail move.
+	if err := c.addFixedPrices(ctx, catalog.PriceListID, resolved, currency, false); err != nil {
+		return err
+	}
+
+	stale := make([]string, 0)
+	if prune {
+		keep := make(map[string]bool, len(resolved))
+		for _, item := range resolved {
+			keep[item.VariantID] = true
+		}
+		current, err := c.fixedPriceAmounts(ctx, catalog.PriceListID)
+		if err != nil {
+			return err
+		}
+		for variantID := range current {
+			if !keep[variantID] {
+				stale = append(stale, variantID)
+			}
+		}
+		sort.Strings(stale)
+	}
+
+	// Volume pricing goes between the two: it needs the fixed prices written above,
+	// and a stale variant's breaks and rule go before its fixed price does.
+	quantityUpdated, err := c.syncQuantityPricing(ctx, catalog.PriceListID, currency, resolved, stale)
+	if err != nil {
+		return err
+	}
+	if err := c.deleteFixedPrices(ctx, catalog.PriceListID, stale); err != nil {
+		return err
+	}
+	deleted := len(stale)
+
+	c.reportIncr("b2b", "prices_pushed", int64(len(resolved)))
+	c.reportIncr("b2b", "prices_deleted", int64(deleted))
+	c.reportIncr("b2b", "quantity_pricing_updated", int64(quantityUpdated))
+	c.reportIncr("b2b", "skipped_missing_variant", int64(skippedMissing))
+	c.logSuccess(fmt.Sprintf(
+		"shopify b2b prices updated catalog=%q variants=%d quantity_pricing=%d deleted=%d skipped_missing=%d",
+		catalog.Title,
+		len(resolved),
+		quantityUpdated,
+		deleted,
+		skippedMissing,
+	))
+	return nil
+}
+
+func (c *Client) deleteFixedPrices(ctx context.Context, priceListID string, variantIDs []string) error {
+	query := `
+	mutation priceListFixedPricesDelete($priceListId: ID!, $variantIds: [ID!]!) {
+		priceListFixedPricesDelete(priceListId: $priceListId, variantIds: $variantIds) {
+			deletedFixedPriceVariantIds
+			userErrors { field message }
+		}
+	}`
+
+	for start := 0; start < len(variantIDs); start += maxFixedPriceBatchSize {
+		end := start + maxFixedPriceBatchSize
+		if end > len(variantIDs) {
+			end = len(variantIDs)
+		}
+		var data dto.PriceListFixedPricesDeleteData
+		if err := c.graphqlRequest(ctx, query, map[string]any{
+			"priceListId": priceListID,
+			"variantIds":  variantIDs[start:end],
+		}, &data); err != nil {
+			return err
+		}
+		if err := userErrorsToDetailedError("priceListFixedPricesDelete", data.PriceListFixedPricesDelete.UserErrors); err != nil {
+			return err
+		}
+	}
+	return nil
+}
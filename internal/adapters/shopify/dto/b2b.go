@@ -0,0 +1,99 @@
+package dto
+
+type CompanyLocationNode struct {
+	ID         string `json:"id,omitempty"`
+	ExternalID string `json:"externalId,omitempty"`
+}
+
+type CompanyNode struct {
+	ID         string `json:"id,omitempty"`
+	Name       string `json:"name,omitempty"`
+	ExternalID string `json:"externalId,omitempty"`
+	Locations  struct {
+		Nodes []CompanyLocationNode `json:"nodes,omitempty"`
+	} `json:"locations,omitempty"`
+}
+
+type CompaniesQueryData struct {
+	Companies struct {
+		Nodes    []CompanyNode   `json:"nodes,omitempty"`
+		PageInfo ShopifyPageInfo `json:"pageInfo,omitempty"`
+	} `json:"companies"`
+}
+
+type CompanyCreateData struct {
+	CompanyCreate struct {
+		Company    *CompanyNode       `json:"company,omitempty"`
+		UserErrors []ShopifyUserError `json:"userErrors,omitempty"`
+	} `json:"companyCreate"`
+}
+
+type CompanyUpdateData struct {
+	CompanyUpdate struct {
+		Company    *CompanyNode       `json:"company,omitempty"`
+		UserErrors []ShopifyUserError `json:"userErrors,omitempty"`
+	} `json:"companyUpdate"`
+}
+
+type CompanyLocationCreateData struct {
+	CompanyLocationCreate struct {
+		CompanyLocation *CompanyLocationNode `json:"companyLocation,omitempty"`
+		UserErrors      []ShopifyUserError   `json:"userErrors,omitempty"`
+	} `json:"companyLocationCreate"`
+}
+
+type CatalogCompanyLocationsData struct {
+	Catalog *struct {
+		ID               string `json:"id,omitempty"`
+		CompanyLocations *struct {
+			Nodes    []CompanyLocationNode `json:"nodes,omitempty"`
+			PageInfo ShopifyPageInfo       `json:"pageInfo,omitempty"`
+		} `json:"companyLocations,omitempty"`
+	} `json:"catalog,omitempty"`
+}
+
+type CatalogContextUpdateData struct {
+	CatalogContextUpdate struct {
+		Catalog    *CatalogNode       `json:"catalog,omitempty"`
+		UserErrors []ShopifyUserError `json:"userErrors,omitempty"`
+	} `json:"catalogContextUpdate"`
+}
+
+type PriceListFixedPricesDeleteData struct {
+	PriceListFixedPricesDelete struct {
+		DeletedFixedPriceVariantIds []string           `json:"deletedFixedPriceVariantIds,omitempty"`
+		UserErrors                  []ShopifyUserError `json:"userErrors,omitempty"`
+	} `json:"priceListFixedPricesDelete"`
+}
+
+type QuantityPricingByVariantUpdateData struct {
+	QuantityPricingByVariantUpdate struct {
+		UserErrors []ShopifyUserError `json:"userErrors,omitempty"`
+	} `json:"quantityPricingByVariantUpdate"`
+}
+
+type PriceListQuantityPricingData struct {
+	PriceList *struct {
+		QuantityRules struct {
+			Nodes []struct {
+				ProductVariant struct {
+					ID string `json:"id,omitempty"`
+				} `json:"productVariant"`
+			} `json:"nodes,omitempty"`
+			PageInfo ShopifyPageInfo `json:"pageInfo,omitempty"`
+		} `json:"quantityRules"`
+		Prices struct {
+			Nodes []struct {
+				Variant struct {
+					ID string `json:"id,omitempty"`
+				} `json:"variant"`
+				QuantityPriceBreaks struct {
+					Nodes []struct {
+						ID string `json:"id,omitempty"`
+					} `json:"nodes,omitempty"`
+				} `json:"quantityPriceBreaks"`
+			} `json:"nodes,omitempty"`
+			PageInfo ShopifyPageInfo `json:"pageInfo,omitempty"`
+		} `json:"prices"`
+	} `json:"priceList,omitempty"`
+}
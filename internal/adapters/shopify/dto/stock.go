@@ -27,11 +27,17 @@ type InventoryLevelNode struct {
 // OnHand returns the on_hand quantity and whether Shopify reported one. A brand new
 // item has no level at the location yet, which is not the same as a level of zero.
 func (n *InventoryLevelNode) OnHand() (int, bool) {
+	return n.Quantity("on_hand")
+}
+
+// Quantity returns the named quantity (on_hand, available, committed, …) and whether
+// the query asked for it and Shopify reported it.
+func (n *InventoryLevelNode) Quantity(name string) (int, bool) {
 	if n == nil {
 		return 0, false
 	}
 	for _, quantity := range n.Quantities {
-		if quantity.Name == "on_hand" {
+		if quantity.Name == name {
 			return quantity.Quantity, true
 		}
 	}
@@ -57,10 +63,18 @@ type VariantInventoryQueryData struct {
 	} `json:"productVariants"`
 }
 
-type InventorySetOnHandQuantitiesData struct {
-	InventorySetOnHandQuantities struct {
-		UserErrors []ShopifyUserError `json:"userErrors,omitempty"`
-	} `json:"inventorySetOnHandQuantities"`
+type InventorySetQuantitiesData struct {
+	InventorySetQuantities struct {
+		UserErrors []InventoryUserError `json:"userErrors,omitempty"`
+	} `json:"inventorySetQuantities"`
+}
+
+// InventoryUserError is a ShopifyUserError with the machine-readable code the
+// inventory mutations add, e.g. COMPARE_QUANTITY_STALE.
+type InventoryUserError struct {
+	Field   []string `json:"field,omitempty"`
+	Message string   `json:"message"`
+	Code    string   `json:"code,omitempty"`
 }
 
 type InventoryItemUpdateData struct {
@@ -0,0 +1,235 @@
+package shopify
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"shopify-exporter/internal/adapters/shopify/dto"
+	"shopify-exporter/internal/config"
+	"shopify-exporter/internal/domain/model"
+	"strconv"
+	"strings"
+)
+
+const (
+	// incomingQuantityName is Shopify's inventory quantity for units on their way to
+	// a location.
+	incomingQuantityName = "incoming"
+	// incomingLedgerURIPrefix names the ERP as the document behind an incoming
+	// adjustment. Shopify requires a ledger document for every quantity name except
+	// available; the ERP's purchase orders have no URL of their own, so the SKU
+	// stands in for them.
+	incomingLedgerURIPrefix = "erp://hashavshevet/incoming/"
+
+	// custom.incoming_quantity carries the quantity on order to the theme when
+	// SHOPIFY_INCOMING_QUANTITY=metafield. The expected date is already on the
+	// product as custom.expected_return_date.
+	incomingMetafieldNamespace = "custom"
+	incomingMetafieldKey       = "incoming_quantity"
+	incomingMetafieldName      = "Incoming quantity"
+	incomingMetafieldType      = "number_integer"
+)
+
+// incomingLocation is the location whose incoming quantity the product sync reads and
+// adjusts: the primary one, since the ERP does not say which warehouse a purchase
+// order goes to. "" when incoming is not delivered as an inventory quantity, or the
+// location cannot be read, which skips it for this product without failing it.
+func (c *Client) incomingLocation(ctx context.Context, product model.Product) string {
+	if c.config.IncomingMode != config.IncomingInventory || !c.shouldTrackInventory(product.Sku) {
+		return ""
+	}
+	locationID, err := c.primaryLocationID(ctx)
+	if err != nil {
+		c.reportWarning("products", fmt.Sprintf("incoming quantity skipped sku=%s: %v", product.Sku, err))
+		return ""
+	}
+	return locationID
+}
+
+// syncIncoming delivers the product's quantity on order from suppliers the way
+// SHOPIFY_INCOMING_QUANTITY says. A metafield left over from the metafield mode is
+// removed in the other modes, so the theme never shows a quantity nobody updates.
+func (c *Client) syncIncoming(ctx context.Context, productGid string, product model.Product, variant primaryVariant, locationID string) error {
+	if !c.shouldTrackInventory(product.Sku) {
+		return nil
+	}
+	if c.config.IncomingMode == config.IncomingMetafield {
+		return c.syncIncomingMetafield(ctx, productGid, product, variant.IncomingMetafield)
+	}
+	if variant.IncomingMetafield != "" {
+		if err := c.deleteProductMetafield(ctx, productGid, incomingMetafieldNamespace, incomingMetafieldKey); err != nil {
+			return err
+		}
+		c.reportIncr("products", "incoming_metafield_cleared", 1)
+	}
+	if c.config.IncomingMode == config.IncomingInventory {
+		return c.adjustIncoming(ctx, product, variant, locationID)
+	}
+	return nil
+}
+
+// incomingAdjustment is the delta that brings Shopify's incoming quantity to want.
+// Shopify only takes incoming as an adjustment, so without a current reading there is
+// nothing safe to send.
+func incomingAdjustment(want, current int, currentKnown bool) (int, bool) {
+	if !currentKnown || want == current {
+		return 0, false
+	}
+	return want - current, true
+}
+
+// adjustIncoming moves the variant's incoming quantity at the primary location to the
+// ERP's. An item with no inventory level there yet has no incoming to adjust; the
+// stock step activates it, and the next product sync adjusts it.
+func (c *Client) adjustIncoming(ctx context.Context, product model.Product, variant primaryVariant, locationID string) error {
+	if locationID == "" || variant.InventoryItemID == "" {
+		return nil
+	}
+	want := product.Incoming()
+	delta, send := incomingAdjustment(want, variant.Incoming, variant.IncomingKnown)
+	if !send {
+		if variant.IncomingKnown {
+			c.reportIncr("products", "incoming_unchanged", 1)
+		} else {
+			c.traceSKU(product.Sku, "product incoming skipped: no inventory level at location_id=%s", locationID)
+		}
+		return nil
+	}
+
+	query := `
+	mutation inventoryAdjustQuantities($input: InventoryAdjustQuantitiesInput!) {
+		inventoryAdjustQuantities(input: $input) {
+			userErrors { field message code }
+		}
+	}`
+	var data struct {
+		InventoryAdjustQuantities struct {
+			UserErrors []dto.ShopifyUserError `json:"userErrors,omitempty"`
+		} `json:"inventoryAdjustQuantities"`
+	}
+	if err := c.graphqlRequest(ctx, query, map[string]any{
+		"input": map[string]any{
+			"name":   incomingQuantityName,
+			"reason": "correction",
+			"changes": []map[string]any{{
+				"inventoryItemId":   variant.InventoryItemID,
+				"locationId":        locationID,
+				"delta":             delta,
+				"ledgerDocumentUri": incomingLedgerURIPrefix + url.PathEscape(strings.TrimSpace(product.Sku)),
+			}},
+		},
+	}, &data); err != nil {
+		return err
+	}
+	if err := userErrorsToDetailedError("inventoryAdjustQuantities", data.InventoryAdjustQuantities.UserErrors); err != nil {
+		return err
+	}
+	c.reportIncr("products", "incoming_set", 1)
+	c.traceSKU(product.Sku, "product incoming set location_id=%s before=%d after=%d", locationID, variant.Incoming, want)
+	return nil
+}
+
+// syncIncomingMetafield writes custom.incoming_quantity while something is on order
+// and deletes it once nothing is, like the expected return date. current is the value
+// already on the product; an equal value costs no write.
+func (c *Client) syncIncomingMetafield(ctx context.Context, productGid string, product model.Product, current string) error {
+	want := ""
+	if incoming := product.Incoming(); incoming > 0 {
+		want = strconv.Itoa(incoming)
+	}
+	if want == current {
+		return nil
+	}
+
+	if want == "" {
+		if err := c.deleteProductMetafield(ctx, productGid, incomingMetafieldNamespace, incomingMetafieldKey); err != nil {
+			return err
+		}
+		c.reportIncr("products", "incoming_metafield_cleared", 1)
+		c.traceSKU(product.Sku, "product incoming metafield cleared before=%s", current)
+		return nil
+	}
+
+	if err := c.ensureIncomingMetafieldDefinition(ctx); err != nil {
+		return err
+	}
+
+	query := `
+	mutation metafieldsSet($metafields: [MetafieldsSetInput!]!) {
+		metafieldsSet(metafields: $metafields) {
+			metafields { id }
+			userErrors { field message }
+		}
+	}`
+	var data dto.MetafieldsSetData
+	if err := c.graphqlRequest(ctx, query, map[string]any{
+		"metafields": []map[string]any{{
+			"ownerId":   productGid,
+			"namespace": incomingMetafieldNamespace,
+			"key":       incomingMetafieldKey,
+			"type":      incomingMetafieldType,
+			"value":     want,
+		}},
+	}, &data); err != nil {
+		return err
+	}
+	if err := userErrorsToError("metafieldsSet", data.MetafieldsSet.UserErrors); err != nil {
+		return err
+	}
+	c.reportIncr("products", "incoming_metafield_set", 1)
+	c.traceSKU(product.Sku, "product incoming metafield set before=%s after=%s", current, want)
+	return nil
+}
+
+// ensureIncomingMetafieldDefinition makes sure the custom.incoming_quantity definition
+// exists once per process, so the theme and admin see it typed as an integer.
+func (c *Client) ensureIncomingMetafieldDefinition(ctx context.Context) error {
+	c.incomingMetaMu.Lock()
+	ready := c.incomingMetaReady
+	c.incomingMetaMu.Unlock()
+	if ready {
+		return nil
+	}
+
+	existing, err := c.listProductMetafieldDefinitions(ctx, incomingMetafieldNamespace)
+	if err != nil {
+		return err
+	}
+	found := false
+	for _, node := range existing {
+		if strings.EqualFold(strings.TrimSpace(node.Key), incomingMetafieldKey) {
+			found = true
+			break
+		}
+	}
+
+	if !found {
+		query := `
+		mutation metafieldDefinitionCreate($definition: MetafieldDefinitionInput!) {
+			metafieldDefinitionCreate(definition: $definition) {
+				createdDefinition { id name namespace key }
+				userErrors { field message }
+			}
+		}`
+		var data dto.MetafieldDefinitionCreateData
+		if err := c.graphqlRequest(ctx, query, map[string]any{
+			"definition": map[string]any{
+				"name":      incomingMetafieldName,
+				"namespace": incomingMetafieldNamespace,
+				"key":       incomingMetafieldKey,
+				"type":      incomingMetafieldType,
+				"ownerType": metafieldOwnerProduct,
+			},
+		}, &data); err != nil {
+			return err
+		}
+		if err := userErrorsToError("metafieldDefinitionCreate", data.MetafieldDefinitionCreate.UserErrors); err != nil {
+			return err
+		}
+	}
+
+	c.incomingMetaMu.Lock()
+	c.incomingMetaReady = true
+	c.incomingMetaMu.Unlock()
+	return nil
+}
@@ -0,0 +1,36 @@
+package shopify
+
+import (
+	"shopify-exporter/internal/domain/model"
+	"testing"
+)
+
+// Shopify takes incoming only as an adjustment, so the sync sends the difference to
+// the ERP's quantity on order, and nothing without a reading to take it from.
+func TestIncomingAdjustment(t *testing.T) {
+	cases := []struct {
+		name         string
+		product      model.Product
+		current      int
+		currentKnown bool
+		want         int
+		send         bool
+	}{
+		{"nothing on order", model.Product{}, 0, true, 0, false},
+		{"order placed", model.Product{OnOrder: 12}, 0, true, 12, true},
+		{"partial units round down", model.Product{OnOrder: 5.5}, 2, true, 3, true},
+		{"received", model.Product{}, 8, true, -8, true},
+		{"unchanged", model.Product{OnOrder: 4}, 4, true, 0, false},
+		{"negative erp", model.Product{OnOrder: -2}, 0, true, 0, false},
+		{"no level yet", model.Product{OnOrder: 4}, 0, false, 0, false},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, send := incomingAdjustment(tc.product.Incoming(), tc.current, tc.currentKnown)
+			if send != tc.send || got != tc.want {
+				t.Errorf("incomingAdjustment(%d, %d, %t) = %d, %t; want %d, %t",
+					tc.product.Incoming(), tc.current, tc.currentKnown, got, send, tc.want, tc.send)
+			}
+		})
+	}
+}
@@ -0,0 +1,247 @@
+package shopify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"shopify-exporter/internal/adapters/shopify/dto"
+	"shopify-exporter/internal/domain/model"
+	"strings"
+	"time"
+)
+
+const (
+	inventoryPolicyDeny     = "DENY"
+	inventoryPolicyContinue = "CONTINUE"
+
+	// custom.expected_return_date carries the ERP's ExpectedReturnDate to the theme
+	// ("back in stock on ..."). Only written while the date is still ahead.
+	returnDateMetafieldNamespace = "custom"
+	returnDateMetafieldKey       = "expected_return_date"
+	returnDateMetafieldName      = "Expected return date"
+	returnDateMetafieldType      = "date"
+	returnDateLayout             = "2006-01-02"
+)
+
+// PreorderRules are the SHOPIFY_PREORDER_* settings. The stock validator applies them
+// too, so it lets through exactly the SKUs the product sync set to CONTINUE.
+type PreorderRules struct {
+	SKUs          []string
+	Prefixes      []string
+	NeedsIncoming bool
+}
+
+func (c *Client) preorderRules() PreorderRules {
+	return PreorderRules{
+		SKUs:          c.config.PreorderSkus,
+		Prefixes:      c.config.PreorderSkuPrefixes,
+		NeedsIncoming: c.config.PreorderNeedsIncoming,
+	}
+}
+
+// inventoryPolicyFor returns the inventoryPolicy to assert for a tracked SKU.
+func (c *Client) inventoryPolicyFor(product model.Product, now time.Time) string {
+	return inventoryPolicyFor(product, now, c.preorderRules())
+}
+
+// inventoryPolicyFor is CONTINUE only while PreorderActive; everything else is DENY.
+func inventoryPolicyFor(product model.Product, now time.Time, rules PreorderRules) string {
+	if !PreorderActive(product, now, rules) {
+		return inventoryPolicyDeny
+	}
+	return inventoryPolicyContinue
+}
+
+// PreorderActive reports whether the product is sold beyond its stock now: a SKU
+// opted into pre-order whose return date has not passed yet and, with NeedsIncoming,
+// that has units on order from suppliers. The return date is a calendar day, so it
+// stays valid through the whole of that day; now must be in REPORT_TIMEZONE.
+func PreorderActive(product model.Product, now time.Time, rules PreorderRules) bool {
+	if !IsPreorderSKU(product.Sku, rules.SKUs, rules.Prefixes) {
+		return false
+	}
+	if !returnDateAhead(product.ExpectedReturnDate, now) {
+		return false
+	}
+	return !rules.NeedsIncoming || product.Incoming() > 0
+}
+
+// IsPreorderSKU reports whether SHOPIFY_PREORDER_SKUS or SHOPIFY_PREORDER_SKU_PREFIXES
+// opt the SKU into pre-order, matched case-insensitively.
+func IsPreorderSKU(sku string, skus, prefixes []string) bool {
+	normalized := strings.ToUpper(strings.TrimSpace(sku))
+	if normalized == "" {
+		return false
+	}
+	for _, candidate := range skus {
+		if strings.ToUpper(strings.TrimSpace(candidate)) == normalized {
+			return true
+		}
+	}
+	for _, prefix := range prefixes {
+		normalizedPrefix := strings.ToUpper(strings.TrimSpace(prefix))
+		if normalizedPrefix != "" && strings.HasPrefix(normalized, normalizedPrefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// returnDateAhead reports whether the return date is today or later, where today is
+// now's calendar day: callers pass now in REPORT_TIMEZONE (Client.localNow), so a
+// date ends at midnight there and not at the server's. Compared as YYYY-MM-DD strings
+// so the ERP's date is never shifted by a timezone conversion.
+func returnDateAhead(returnDate, now time.Time) bool {
+	if returnDate.IsZero() {
+		return false
+	}
+	return formatReturnDate(returnDate) >= now.Format(returnDateLayout)
+}
+
+func formatReturnDate(value time.Time) string {
+	if value.IsZero() {
+		return ""
+	}
+	return value.Format(returnDateLayout)
+}
+
+// syncReturnDateMetafield writes custom.expected_return_date while the ERP date is
+// ahead and deletes it once the date passes or is cleared, so the theme never shows
+// a stale "back on" date. current is the value already on the product; an equal
+// value costs no write.
+func (c *Client) syncReturnDateMetafield(ctx context.Context, productGid string, product model.Product, current string) error {
+	want := ""
+	if returnDateAhead(product.ExpectedReturnDate, c.localNow()) {
+		want = formatReturnDate(product.ExpectedReturnDate)
+	}
+	if want == current {
+		return nil
+	}
+
+	if want == "" {
+		if err := c.deleteProductMetafield(ctx, productGid, returnDateMetafieldNamespace, returnDateMetafieldKey); err != nil {
+			return err
+		}
+		c.reportIncr("products", "return_date_cleared", 1)
+		c.traceSKU(product.Sku, "product expected return date cleared before=%s", current)
+		return nil
+	}
+
+	if err := c.ensureReturnDateMetafieldDefinition(ctx); err != nil {
+		return err
+	}
+
+	query := `
+	mutation metafieldsSet($metafields: [MetafieldsSetInput!]!) {
+		metafieldsSet(metafields: $metafields) {
+			metafields { id }
+			userErrors { field message }
+		}
+	}`
+	var data dto.MetafieldsSetData
+	if err := c.graphqlRequest(ctx, query, map[string]any{
+		"metafields": []map[string]any{{
+			"ownerId":   productGid,
+			"namespace": returnDateMetafieldNamespace,
+			"key":       returnDateMetafieldKey,
+			"type":      returnDateMetafieldType,
+			"value":     want,
+		}},
+	}, &data); err != nil {
+		return err
+	}
+	if err := userErrorsToError("metafieldsSet", data.MetafieldsSet.UserErrors); err != nil {
+		return err
+	}
+	c.reportIncr("products", "return_date_set", 1)
+	c.traceSKU(product.Sku, "product expected return date set before=%s after=%s", current, want)
+	return nil
+}
+
+// ensureReturnDateMetafieldDefinition makes sure the custom.expected_return_date
+// definition exists once per process, so the theme and admin see it typed as a date.
+func (c *Client) ensureReturnDateMetafieldDefinition(ctx context.Context) error {
+	c.returnDateMetaMu.Lock()
+	ready := c.returnDateMetaReady
+	c.returnDateMetaMu.Unlock()
+	if ready {
+		return nil
+	}
+
+	existing, err := c.listProductMetafieldDefinitions(ctx, returnDateMetafieldNamespace)
+	if err != nil {
+		return err
+	}
+	found := false
+	for _, node := range existing {
+		if strings.EqualFold(strings.TrimSpace(node.Key), returnDateMetafieldKey) {
+			found = true
+			break
+		}
+	}
+
+	if !found {
+		query := `
+		mutation metafieldDefinitionCreate($definition: MetafieldDefinitionInput!) {
+			metafieldDefinitionCreate(definition: $definition) {
+				createdDefinition { id name namespace key }
+				userErrors { field message }
+			}
+		}`
+		var data dto.MetafieldDefinitionCreateData
+		if err := c.graphqlRequest(ctx, query, map[string]any{
+			"definition": map[string]any{
+				"name":      returnDateMetafieldName,
+				"namespace": returnDateMetafieldNamespace,
+				"key":       returnDateMetafieldKey,
+				"type":      returnDateMetafieldType,
+				"ownerType": metafieldOwnerProduct,
+			},
+		}, &data); err != nil {
+			return err
+		}
+		if err := userErrorsToError("metafieldDefinitionCreate", data.MetafieldDefinitionCreate.UserErrors); err != nil {
+			return err
+		}
+	}
+
+	c.returnDateMetaMu.Lock()
+	c.returnDateMetaReady = true
+	c.returnDateMetaMu.Unlock()
+	return nil
+}
+
+// deleteProductMetafield removes one metafield from a product. Deleting a metafield
+// that does not exist is not an error.
+func (c *Client) deleteProductMetafield(ctx context.Context, productGid, namespace, key string) error {
+	productGid = strings.TrimSpace(productGid)
+	if productGid == "" {
+		return errors.New("shopify product id is required")
+	}
+
+	query := `
+	mutation metafieldsDelete($metafields: [MetafieldIdentifierInput!]!) {
+		metafieldsDelete(metafields: $metafields) {
+			deletedMetafields { key }
+			userErrors { field message }
+		}
+	}`
+	var data struct {
+		MetafieldsDelete struct {
+			UserErrors []dto.ShopifyUserError `json:"userErrors,omitempty"`
+		} `json:"metafieldsDelete"`
+	}
+	if err := c.graphqlRequest(ctx, query, map[string]any{
+		"metafields": []map[string]any{{
+			"ownerId":   productGid,
+			"namespace": namespace,
+			"key":       key,
+		}},
+	}, &data); err != nil {
+		return err
+	}
+	if err := userErrorsToError("metafieldsDelete", data.MetafieldsDelete.UserErrors); err != nil {
+		return fmt.Errorf("delete %s.%s: %w", namespace, key, err)
+	}
+	return nil
+}
@@ -0,0 +1,59 @@
+package shopify
+
+import (
+	"shopify-exporter/internal/domain/model"
+	"testing"
+	"time"
+)
+
+// TestInventoryPolicyFor covers the pre-order switch: CONTINUE only for an opted-in
+// SKU whose return date is today or later (and, when required, with units on
+// order), DENY in every other case.
+func TestInventoryPolicyFor(t *testing.T) {
+	now := time.Date(2026, 3, 10, 15, 0, 0, 0, time.UTC)
+	day := func(y int, m time.Month, d int) time.Time { return time.Date(y, m, d, 0, 0, 0, 0, time.UTC) }
+
+	tests := []struct {
+		name       string
+		sku        string
+		returnDate time.Time
+		onOrder    float64
+		rules      PreorderRules
+		want       string
+	}{
+		{"not opted in", "A100", day(2026, 4, 1), 0, PreorderRules{}, inventoryPolicyDeny},
+		{"exact sku future date", "a100", day(2026, 4, 1), 0, PreorderRules{SKUs: []string{"A100"}}, inventoryPolicyContinue},
+		{"prefix future date", "PRE-7", day(2026, 4, 1), 0, PreorderRules{Prefixes: []string{"pre-"}}, inventoryPolicyContinue},
+		{"return date today", "A100", day(2026, 3, 10), 0, PreorderRules{SKUs: []string{"A100"}}, inventoryPolicyContinue},
+		{"return date passed", "A100", day(2026, 3, 9), 0, PreorderRules{SKUs: []string{"A100"}}, inventoryPolicyDeny},
+		{"no return date", "A100", time.Time{}, 0, PreorderRules{SKUs: []string{"A100"}}, inventoryPolicyDeny},
+		{"nothing on order", "A100", day(2026, 4, 1), 0, PreorderRules{SKUs: []string{"A100"}, NeedsIncoming: true}, inventoryPolicyDeny},
+		{"units on order", "A100", day(2026, 4, 1), 6, PreorderRules{SKUs: []string{"A100"}, NeedsIncoming: true}, inventoryPolicyContinue},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			product := model.Product{Sku: tt.sku, ExpectedReturnDate: tt.returnDate, OnOrder: tt.onOrder}
+			got := inventoryPolicyFor(product, now, tt.rules)
+			if got != tt.want {
+				t.Fatalf("inventoryPolicyFor() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+// A return date ends at midnight in REPORT_TIMEZONE: at 23:30 UTC it is already the
+// next day in Jerusalem, so the previous day's date has passed there.
+func TestReturnDateAheadInReportTimezone(t *testing.T) {
+	client := &Client{}
+	client.location = client.resolveLocation("Asia/Jerusalem")
+	utc := time.Date(2026, 3, 9, 23, 30, 0, 0, time.UTC)
+	returnDate := time.Date(2026, 3, 9, 0, 0, 0, 0, time.UTC)
+
+	if !returnDateAhead(returnDate, utc) {
+		t.Fatal("in UTC the return date is still today")
+	}
+	if returnDateAhead(returnDate, utc.In(client.location)) {
+		t.Fatal("in Jerusalem the return date has passed")
+	}
+}
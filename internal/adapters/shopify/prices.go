@@ -4,34 +4,21 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"sort"
 	"strconv"
 	"strings"
 
 	"shopify-exporter/internal/adapters/shopify/dto"
+	"shopify-exporter/internal/config"
 )
 
 const (
 	currencyUSD = "USD"
-	currencyILS = "ILS"
 
-	israelMarketHandle = "il"
-	israelMarketName   = "Israel"
-	israelCatalogTitle = "Israel Catalog"
-	israelPriceList    = "Israel ILS"
-
-	defaultInternationalMarketHandle  = "international"
-	defaultInternationalMarketName    = "International"
-	defaultInternationalCatalogTitle  = "International Catalog"
-	defaultInternationalPriceListName = "International USD"
-
-	usdMetafieldNamespace = "custom"
-	usdMetafieldKey       = "usd_price"
-	usdMetafieldName      = "USD price"
 	// number_decimal (not money): a money metafield is validated against the shop
-	// currency (ILS) and rejects a USD value. The storefront prepends the $ itself.
-	usdMetafieldType = "number_decimal"
+	// currency (ILS) and rejects a USD value. The storefront prepends the symbol itself.
+	priceMetafieldType = "number_decimal"
 
-	marketRegionIL         = "IL"
 	maxFixedPriceBatchSize = 250
 	maxVariantsBatchSize   = 250
 	maxVariantsPageSize    = 250
@@ -39,22 +26,30 @@ const (
 )
 
 type PriceService interface {
-	EnsureIsraelMarketAndCatalog(ctx context.Context) (IsraelMarketResources, error)
+	EnsureMarketsAndCatalogs(ctx context.Context) ([]MarketResources, error)
 	UpsertPrices(ctx context.Context, input PriceUpsertInput) error
 	UpsertPricesBatch(ctx context.Context, inputs []PriceUpsertInput) error
 }
 
+// PriceUpsertInput is one SKU's prices. Prices and CompareAt are keyed by currency
+// code; every configured market's currency needs a price, CompareAt is optional.
+// QuantityBreaks and QuantityRule are volume pricing, which only B2B price lists
+// carry; see ValidateQuantityPricing.
 type PriceUpsertInput struct {
-	SKU          string
-	ProductID    string
-	VariantID    string
-	USDPrice     float64
-	ILSPrice     float64
-	USDCompareAt float64
-	ILSCompareAt float64
-}
-
-type IsraelMarketResources struct {
+	SKU            string
+	ProductID      string
+	VariantID      string
+	Prices         map[string]float64
+	CompareAt      map[string]float64
+	QuantityBreaks []QuantityBreak
+	QuantityRule   QuantityRule
+}
+
+// MarketResources are the Shopify ids behind one configured market. A market priced
+// through a metafield has only Handle and Currency: its Shopify market is not touched.
+type MarketResources struct {
+	Handle        string
+	Currency      string
 	MarketID      string
 	CatalogID     string
 	PublicationID string
@@ -117,44 +112,84 @@ func isVariantNotFoundError(err error) (*variantNotFoundError, bool) {
 // variantPriceNode is the variant shape used by both the paginated and the
 // single-SKU lookup: identity plus the values a price push is about to overwrite.
 type variantPriceNode struct {
-	ID      string `json:"id,omitempty"`
-	SKU     string `json:"sku,omitempty"`
-	Price   string `json:"price,omitempty"`
-	Product struct {
+	ID    string `json:"id,omitempty"`
+	SKU   string `json:"sku,omitempty"`
+	Price string `json:"price,omitempty"`
+	// CompareAtPrice is read so the price guard can tell a sale ending from a jump.
+	CompareAtPrice string `json:"compareAtPrice,omitempty"`
+	Product        struct {
 		ID string `json:"id,omitempty"`
-		// Metafield is the current custom.usd_price value. USD is served through a
-		// product metafield rather than a Shopify price list, because the Israeli
-		// single-currency gateway blocks a USD market — see
-		// .claude/PRICE_ISSUE_KNOWN_ROOT_CAUSE.md.
-		Metafield *struct {
-			Value string `json:"value,omitempty"`
-		} `json:"metafield,omitempty"`
+		// Metafields are the current values of the metafield-priced markets (by
+		// default custom.usd_price). USD is served through a product metafield rather
+		// than a Shopify price list, because the Israeli single-currency gateway blocks
+		// a USD market — see .claude/PRICE_ISSUE_KNOWN_ROOT_CAUSE.md.
+		Metafields struct {
+			Nodes []struct {
+				Namespace string `json:"namespace,omitempty"`
+				Key       string `json:"key,omitempty"`
+				Value     string `json:"value,omitempty"`
+			} `json:"nodes,omitempty"`
+		} `json:"metafields,omitempty"`
 	} `json:"product,omitempty"`
 }
 
 // beforePrices extracts the pre-push variant price (in the shop's base currency)
-// and the current USD metafield value.
-func (n variantPriceNode) beforePrices() (base float64, baseKnown bool, usd float64, usdKnown bool) {
+// and the current metafield price per currency; a currency with no value is absent.
+func (n variantPriceNode) beforePrices(markets []config.MarketDefinition) (base float64, baseKnown bool, byCurrency map[string]float64) {
 	if parsed, err := strconv.ParseFloat(strings.TrimSpace(n.Price), 64); err == nil {
 		base, baseKnown = parsed, true
 	}
-	if n.Product.Metafield != nil {
-		if parsed, err := strconv.ParseFloat(strings.TrimSpace(n.Product.Metafield.Value), 64); err == nil {
-			usd, usdKnown = parsed, true
+	byCurrency = make(map[string]float64)
+	for _, node := range n.Product.Metafields.Nodes {
+		for _, market := range markets {
+			if !market.UsesMetafield() {
+				continue
+			}
+			namespace, key := market.MetafieldKey()
+			if !strings.EqualFold(node.Namespace, namespace) || !strings.EqualFold(node.Key, key) {
+				continue
+			}
+			if parsed, err := strconv.ParseFloat(strings.TrimSpace(node.Value), 64); err == nil {
+				byCurrency[market.Currency] = parsed
+			}
 		}
 	}
-	return base, baseKnown, usd, usdKnown
+	return base, baseKnown, byCurrency
+}
+
+// beforeCompareAt is the variant's current compare-at price, 0 when it has none.
+func (n variantPriceNode) beforeCompareAt() float64 {
+	parsed, err := strconv.ParseFloat(strings.TrimSpace(n.CompareAtPrice), 64)
+	if err != nil {
+		return 0
+	}
+	return parsed
 }
 
 // variantPriceSelection is the shared GraphQL selection set for the lookups above.
-const variantPriceSelection = `
+// It reads back every metafield-priced market's metafield.
+func (c *Client) variantPriceSelection() string {
+	keys := make([]string, 0)
+	for _, market := range c.markets() {
+		if market.UsesMetafield() {
+			namespace, key := market.MetafieldKey()
+			keys = append(keys, strconv.Quote(namespace+"."+key))
+		}
+	}
+	metafields := ""
+	if len(keys) > 0 {
+		metafields = fmt.Sprintf(`
+					metafields(first: %d, keys: [%s]) { nodes { namespace key value } }`, len(keys), strings.Join(keys, ", "))
+	}
+	return `
 				id
 				sku
 				price
+				compareAtPrice
 				product {
-					id
-					metafield(namespace: "` + usdMetafieldNamespace + `", key: "` + usdMetafieldKey + `") { value }
+					id` + metafields + `
 				}`
+}
 
 type productVariantListData struct {
 	ProductVariants struct {
@@ -172,25 +207,64 @@ type variantPriceSearchData struct {
 type variantLookup struct {
 	VariantID string
 	ProductID string
-	// BeforeBase / BeforeUSD are the values Shopify held before this run, captured so
+	// BeforeBase / Before are the values Shopify held before this run, captured so
 	// the run report can show real before -> after moves instead of a bare count.
+	// Before holds the metafield-priced currencies that had a value.
 	BeforeBase      float64
 	BeforeBaseKnown bool
-	BeforeUSD       float64
-	BeforeUSDKnown  bool
+	Before          map[string]float64
+	// BeforeCompareAt is the variant's compare-at price in the base currency, 0 when
+	// it has none.
+	BeforeCompareAt float64
+}
+
+// PriceCheck is what a PriceGuard sees of one SKU about to be pushed: the resolved
+// input and what Shopify holds now. Before has the base currency (when the variant
+// had a price) and the metafield-priced currencies; price-list currencies are absent.
+type PriceCheck struct {
+	Input        PriceUpsertInput
+	BaseCurrency string
+	Before       map[string]float64
+	// BeforeCompareAt is the base currency compare-at price, 0 when there is none.
+	BeforeCompareAt float64
+}
+
+// PriceGuard vets one SKU before its prices are pushed and reports whether to hold
+// it back. Held SKUs are left exactly as they are in Shopify.
+type PriceGuard func(check PriceCheck) bool
+
+// GuardedPriceService pushes prices like UpsertPricesBatch, asking guard about each
+// SKU once its current Shopify prices are known, so the check costs no extra reads.
+type GuardedPriceService interface {
+	UpsertPricesGuarded(ctx context.Context, inputs []PriceUpsertInput, guard PriceGuard) error
 }
 
-func (c *Client) EnsureIsraelMarketAndCatalog(ctx context.Context) (IsraelMarketResources, error) {
+// EnsureMarketsAndCatalogs makes sure every price-list market has its market,
+// catalog, publication and price list, once per process.
+func (c *Client) EnsureMarketsAndCatalogs(ctx context.Context) ([]MarketResources, error) {
 	if c == nil {
-		return IsraelMarketResources{}, errors.New("shopify client is nil")
+		return nil, errors.New("shopify client is nil")
 	}
 	if cached, ok := c.getPriceCache(); ok {
 		return cached, nil
 	}
 
-	resources, err := c.ensureIsraelMarketAndCatalog(ctx)
-	if err != nil {
-		return IsraelMarketResources{}, err
+	markets := c.markets()
+	resources := make([]MarketResources, 0, len(markets))
+	for _, market := range markets {
+		if market.UsesMetafield() {
+			resources = append(resources, MarketResources{Handle: market.Handle, Currency: market.Currency})
+			continue
+		}
+		ensure := c.ensureMarketAndCatalog
+		if c.config.PriceDryRun {
+			ensure = c.resolveMarketAndCatalog
+		}
+		ready, err := ensure(ctx, market)
+		if err != nil {
+			return nil, err
+		}
+		resources = append(resources, ready)
 	}
 	c.setPriceCache(resources)
 	return resources, nil
@@ -201,26 +275,34 @@ func (c *Client) UpsertPrices(ctx context.Context, input PriceUpsertInput) error
 }
 
 func (c *Client) UpsertPricesBatch(ctx context.Context, inputs []PriceUpsertInput) error {
+	return c.UpsertPricesGuarded(ctx, inputs, nil)
+}
+
+// UpsertPricesGuarded is UpsertPricesBatch with a guard; a nil guard holds nothing.
+func (c *Client) UpsertPricesGuarded(ctx context.Context, inputs []PriceUpsertInput, guard PriceGuard) error {
 	if len(inputs) == 0 {
 		return nil
 	}
-	resources, err := c.EnsureIsraelMarketAndCatalog(ctx)
+	resources, err := c.EnsureMarketsAndCatalogs(ctx)
 	if err != nil {
 		return err
 	}
+	markets := c.markets()
+	currencies := config.MarketCurrencies(markets)
 	baseCurrency := c.baseCurrencyCode()
-	if err := validateSupportedCurrency(baseCurrency); err != nil {
+	if err := validateBaseCurrency(baseCurrency, currencies); err != nil {
 		return err
 	}
 
 	skippedMissing := 0
+	held := 0
 	skuLookup, err := c.buildVariantLookup(ctx, inputs)
 	if err != nil {
 		return err
 	}
 	resolved := make([]resolvedPriceInput, 0, len(inputs))
 	for _, input := range inputs {
-		if err := validatePriceInput(input); err != nil {
+		if err := validatePriceInput(input, currencies); err != nil {
 			return err
 		}
 		var hint *variantLookup
@@ -252,144 +334,188 @@ func (c *Client) UpsertPricesBatch(ctx context.Context, inputs []PriceUpsertInpu
 		}
 		c.traceSKU(
 			item.SKU,
-			"price resolved product_id=%s variant_id=%s usd=%.2f ils=%.2f base_currency=%s israel_price_list_id=%s",
+			"price resolved product_id=%s variant_id=%s prices=%s base_currency=%s",
 			item.ProductID,
 			item.VariantID,
-			item.USDPrice,
-			item.ILSPrice,
+			formatCurrencyAmounts(item.Prices),
 			baseCurrency,
-			resources.PriceListID,
 		)
+		if guard != nil && guard(item.check(baseCurrency)) {
+			held++
+			c.traceSKU(item.SKU, "price held by guard prices=%s", formatCurrencyAmounts(item.Prices))
+			continue
+		}
 		resolved = append(resolved, item)
 	}
+	c.reportIncr("price", "held", int64(held))
 
 	if len(resolved) == 0 {
-		if skippedMissing > 0 {
-			c.logWarning(fmt.Sprintf("price sync skipped: missing variants=%d", skippedMissing))
+		if skippedMissing > 0 || held > 0 {
+			c.logWarning(fmt.Sprintf("price sync skipped: missing variants=%d held=%d", skippedMissing, held))
 		}
 		return nil
 	}
 
-	if err := c.updateBasePrices(ctx, resolved, baseCurrency); err != nil {
-		return err
+	// Dry run stops here, after every read: the report gets each would-be move.
+	if c.config.PriceDryRun {
+		return c.reportPriceDryRun(ctx, resolved, resources, baseCurrency, skippedMissing, held)
 	}
-	if err := c.addFixedPrices(ctx, resources.PriceListID, resolved, currencyILS); err != nil {
+
+	if err := c.updateBasePrices(ctx, resolved, baseCurrency); err != nil {
 		return err
 	}
 
-	// USD is served via a product metafield (custom.usd_price) read by the storefront,
-	// not via a Shopify multi-currency price list — the single-currency Israeli payment
-	// gateway blocks setting the International market to USD. See
-	// .claude/PRICE_ISSUE_KNOWN_ROOT_CAUSE.md. A failure here is surfaced, not swallowed.
-	if err := c.addUSDPriceMetafields(ctx, resolved); err != nil {
-		return err
+	// The base currency is reported by updateBasePrices; every other currency is
+	// reported once, by the first market that carries it.
+	reported := map[string]bool{baseCurrency: true}
+	for i, market := range markets {
+		report := !reported[market.Currency]
+		reported[market.Currency] = true
+		if market.UsesMetafield() {
+			// A failure here is surfaced, not swallowed.
+			if err := c.addPriceMetafields(ctx, market, resolved, report); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := c.addFixedPrices(ctx, resources[i].PriceListID, resolved, market.Currency, report); err != nil {
+			return err
+		}
 	}
 
 	c.reportIncr("price", "pushed", int64(len(resolved)))
 	c.reportIncr("price", "skipped_missing_variant", int64(skippedMissing))
-	c.logSuccess(fmt.Sprintf("shopify prices updated variants=%d skipped_missing=%d", len(resolved), skippedMissing))
+	c.logSuccess(fmt.Sprintf("shopify prices updated variants=%d skipped_missing=%d held=%d", len(resolved), skippedMissing, held))
 	return nil
 }
 
-func validateSupportedCurrency(code string) error {
-	switch strings.ToUpper(strings.TrimSpace(code)) {
-	case currencyUSD, currencyILS:
-		return nil
-	default:
-		return fmt.Errorf("shopify unsupported base currency %q (expected %s or %s)", code, currencyUSD, currencyILS)
-	}
-}
+// reportPriceDryRun records every price the run would have written, per currency and
+// with the same before values a real push reports, and returns without a mutation.
+// The price lists' fixed prices are read for that; a price list a real run would
+// create has none, so every price in its currency reports as new.
+func (c *Client) reportPriceDryRun(ctx context.Context, resolved []resolvedPriceInput, resources []MarketResources, baseCurrency string, skippedMissing, held int) error {
+	reported := make(map[string]bool)
+	for i, market := range c.markets() {
+		currency := market.Currency
+		if reported[currency] {
+			continue
+		}
+		reported[currency] = true
 
-func (c *Client) baseCurrencyCode() string {
-	if c == nil {
-		return currencyUSD
+		var fixed map[string]float64
+		if currency != baseCurrency && !market.UsesMetafield() && resources[i].PriceListID != "" {
+			var err error
+			fixed, err = c.fixedPriceAmounts(ctx, resources[i].PriceListID)
+			if err != nil {
+				return err
+			}
+		}
+		for _, item := range resolved {
+			var before float64
+			var known bool
+			switch {
+			case currency == baseCurrency:
+				before, known = item.BeforeBase, item.BeforeBaseKnown
+			case market.UsesMetafield():
+				before, known = item.Before[currency]
+			default:
+				before, known = fixed[item.VariantID]
+			}
+			c.reportPriceSeen(item.SKU, currency, before, known, item.Prices[currency])
+		}
 	}
-	code := strings.TrimSpace(c.config.BaseCurrency)
-	if code == "" {
-		return currencyUSD
+	for _, item := range resolved {
+		c.logWarning(fmt.Sprintf("DRY RUN would price sku=%s prices=%s compare_at=%s", item.SKU, formatCurrencyAmounts(item.Prices), formatCurrencyAmounts(item.CompareAt)))
 	}
-	return strings.ToUpper(code)
-}
 
-func (c *Client) internationalMarketHandle() string {
-	if c == nil {
-		return defaultInternationalMarketHandle
-	}
-	handle := strings.TrimSpace(c.config.InternationalMarketHandle)
-	if handle == "" {
-		return defaultInternationalMarketHandle
-	}
-	return handle
+	c.reportIncr("price", "dry_run", 1)
+	c.reportIncr("price", "dry_run_would_push", int64(len(resolved)))
+	c.reportWarning("price", fmt.Sprintf("DRY RUN: nothing was written to Shopify. %d SKUs were priced.", len(resolved)))
+	c.logSuccess(fmt.Sprintf(
+		"DRY RUN complete, no price writes sent: would_push=%d skipped_missing=%d held=%d",
+		len(resolved),
+		skippedMissing,
+		held,
+	))
+	return nil
 }
 
-func (c *Client) internationalMarketName() string {
-	if c == nil {
-		return defaultInternationalMarketName
-	}
-	name := strings.TrimSpace(c.config.InternationalMarketName)
-	if name == "" {
-		return defaultInternationalMarketName
+// validateBaseCurrency requires a market in the shop's base currency: the variant
+// price is set from that market's price, so without one it would have no source.
+func validateBaseCurrency(code string, currencies []string) error {
+	code = strings.ToUpper(strings.TrimSpace(code))
+	for _, currency := range currencies {
+		if currency == code {
+			return nil
+		}
 	}
-	return name
+	return fmt.Errorf("shopify base currency %q has no market (markets price %s)", code, strings.Join(currencies, ", "))
 }
 
-func (c *Client) internationalCatalogTitle() string {
+func (c *Client) baseCurrencyCode() string {
 	if c == nil {
-		return defaultInternationalCatalogTitle
+		return currencyUSD
 	}
-	title := strings.TrimSpace(c.config.InternationalCatalogTitle)
-	if title == "" {
-		return defaultInternationalCatalogTitle
+	code := strings.TrimSpace(c.config.BaseCurrency)
+	if code == "" {
+		return currencyUSD
 	}
-	return title
+	return strings.ToUpper(code)
 }
 
-func (c *Client) internationalPriceListName() string {
-	if c == nil {
-		return defaultInternationalPriceListName
+// markets returns the configured market definitions, DefaultMarkets when unset.
+func (c *Client) markets() []config.MarketDefinition {
+	if c == nil || len(c.config.Markets) == 0 {
+		return config.DefaultMarkets
 	}
-	name := strings.TrimSpace(c.config.InternationalPriceListName)
-	if name == "" {
-		return defaultInternationalPriceListName
-	}
-	return name
+	return c.config.Markets
 }
 
-func (c *Client) ensureIsraelMarketAndCatalog(ctx context.Context) (IsraelMarketResources, error) {
-	market, err := c.findIsraelMarket(ctx)
+// ensureMarketAndCatalog finds (or creates) the market, pins its currency, and makes
+// sure its catalog, publication and price list exist and are attached.
+func (c *Client) ensureMarketAndCatalog(ctx context.Context, definition config.MarketDefinition) (MarketResources, error) {
+	market, err := c.findMarket(ctx, definition)
 	if err != nil {
-		return IsraelMarketResources{}, err
+		return MarketResources{}, err
 	}
 	if market.ID == "" {
-		market, err = c.createIsraelMarket(ctx)
+		if len(definition.Countries) == 0 {
+			return MarketResources{}, fmt.Errorf(
+				"shopify market not found (handle=%s name=%s) and no countries to create it with",
+				definition.Handle,
+				definition.Name,
+			)
+		}
+		market, err = c.createMarket(ctx, definition)
 		if err != nil {
-			return IsraelMarketResources{}, err
+			return MarketResources{}, err
 		}
 		c.logSuccess(fmt.Sprintf("shopify market created id=%s handle=%s", market.ID, market.Handle))
 	} else {
 		c.logSuccess(fmt.Sprintf("shopify market found id=%s handle=%s", market.ID, market.Handle))
 	}
 
-	if !strings.EqualFold(market.CurrencyCode, currencyILS) || market.LocalCurrencies {
-		if err := c.updateMarketCurrencySettings(ctx, market.ID, currencyILS, false); err != nil {
-			return IsraelMarketResources{}, err
+	currency := definition.Currency
+	if !strings.EqualFold(market.CurrencyCode, currency) || market.LocalCurrencies {
+		if err := c.updateMarketCurrencySettings(ctx, market.ID, currency, false); err != nil {
+			return MarketResources{}, err
 		}
-		market.CurrencyCode = currencyILS
+		market.CurrencyCode = currency
 		market.LocalCurrencies = false
-		c.logSuccess(fmt.Sprintf("shopify market currency updated id=%s currency=%s", market.ID, currencyILS))
+		c.logSuccess(fmt.Sprintf("shopify market currency updated id=%s currency=%s", market.ID, currency))
 	}
 	if !market.Enabled {
 		c.logWarning(fmt.Sprintf("shopify market disabled id=%s", market.ID))
 	}
 
-	catalog, err := c.findCatalogByTitle(ctx, israelCatalogTitle)
+	catalog, err := c.findCatalogByTitle(ctx, definition.CatalogTitle)
 	if err != nil {
-		return IsraelMarketResources{}, err
+		return MarketResources{}, err
 	}
 	if catalog.ID == "" {
-		catalog, err = c.createCatalog(ctx, israelCatalogTitle, market.ID)
+		catalog, err = c.createCatalog(ctx, definition.CatalogTitle, market.ID)
 		if err != nil {
-			return IsraelMarketResources{}, err
+			return MarketResources{}, err
 		}
 		c.logSuccess(fmt.Sprintf("shopify catalog created id=%s title=%s", catalog.ID, catalog.Title))
 	} else {
@@ -398,25 +524,25 @@ func (c *Client) ensureIsraelMarketAndCatalog(ctx context.Context) (IsraelMarket
 
 	attached, err := c.marketHasCatalog(ctx, market.ID, catalog.ID)
 	if err != nil {
-		return IsraelMarketResources{}, err
+		return MarketResources{}, err
 	}
 	if !attached {
 		if err := c.addCatalogToMarket(ctx, market.ID, catalog.ID); err != nil {
-			return IsraelMarketResources{}, err
+			return MarketResources{}, err
 		}
 		c.logSuccess(fmt.Sprintf("shopify market catalog attached market=%s catalog=%s", market.ID, catalog.ID))
 	}
 	attached, err = c.marketHasCatalog(ctx, market.ID, catalog.ID)
 	if err != nil {
-		return IsraelMarketResources{}, err
+		return MarketResources{}, err
 	}
 	if !attached {
-		return IsraelMarketResources{}, fmt.Errorf("shopify market %s missing catalog %s", market.ID, catalog.ID)
+		return MarketResources{}, fmt.Errorf("shopify market %s missing catalog %s", market.ID, catalog.ID)
 	}
 
-	publication, priceList, err := c.ensureCatalogPublicationAndPriceList(ctx, catalog.ID, israelPriceList, currencyILS)
+	publication, priceList, err := c.ensureCatalogPublicationAndPriceList(ctx, catalog.ID, definition.PriceList, currency)
 	if err != nil {
-		return IsraelMarketResources{}, err
+		return MarketResources{}, err
 	}
 	if publication.ID != "" {
 		c.logSuccess(fmt.Sprintf("shopify publication ready id=%s", publication.ID))
@@ -425,101 +551,101 @@ func (c *Client) ensureIsraelMarketAndCatalog(ctx context.Context) (IsraelMarket
 		c.logSuccess(fmt.Sprintf("shopify price list ready id=%s currency=%s", priceList.ID, priceList.Currency))
 	}
 
-	resources := IsraelMarketResources{
+	resources := MarketResources{
+		Handle:        definition.Handle,
+		Currency:      currency,
 		MarketID:      market.ID,
 		CatalogID:     catalog.ID,
 		PublicationID: publication.ID,
 		PriceListID:   priceList.ID,
 	}
 
-	if err := c.verifyMarketSetup(ctx, resources, currencyILS); err != nil {
-		return IsraelMarketResources{}, err
+	if err := c.verifyMarketSetup(ctx, resources, currency); err != nil {
+		return MarketResources{}, err
 	}
 
 	return resources, nil
 }
 
-func (c *Client) ensureInternationalMarketAndCatalog(ctx context.Context) (IsraelMarketResources, error) {
-	market, err := c.findInternationalMarket(ctx)
+// resolveMarketAndCatalog is ensureMarketAndCatalog for a dry run: it reads the
+// market, catalog, publication and price list as they are and writes none of them.
+// Each step a real run would take is logged and put in the report instead; whatever
+// does not exist yet is returned with an empty ID.
+func (c *Client) resolveMarketAndCatalog(ctx context.Context, definition config.MarketDefinition) (MarketResources, error) {
+	resources := MarketResources{Handle: definition.Handle, Currency: definition.Currency}
+	would := make([]string, 0)
+
+	market, err := c.findMarket(ctx, definition)
 	if err != nil {
-		return IsraelMarketResources{}, err
-	}
-	if market.ID == "" {
-		return IsraelMarketResources{}, fmt.Errorf(
-			"shopify international market not found (handle=%s name=%s)",
-			c.internationalMarketHandle(),
-			c.internationalMarketName(),
+		return MarketResources{}, err
+	}
+	switch {
+	case market.ID == "" && len(definition.Countries) == 0:
+		return MarketResources{}, fmt.Errorf(
+			"shopify market not found (handle=%s name=%s) and no countries to create it with",
+			definition.Handle,
+			definition.Name,
 		)
+	case market.ID == "":
+		would = append(would, "create the market")
+	case !strings.EqualFold(market.CurrencyCode, definition.Currency) || market.LocalCurrencies:
+		would = append(would, fmt.Sprintf("set the market currency to %s", definition.Currency))
 	}
-	c.logSuccess(fmt.Sprintf("shopify market found id=%s handle=%s", market.ID, market.Handle))
-
-	if !strings.EqualFold(market.CurrencyCode, currencyUSD) || market.LocalCurrencies {
-		if err := c.updateMarketCurrencySettings(ctx, market.ID, currencyUSD, false); err != nil {
-			return IsraelMarketResources{}, err
-		}
-		market.CurrencyCode = currencyUSD
-		market.LocalCurrencies = false
-		c.logSuccess(fmt.Sprintf("shopify market currency updated id=%s currency=%s", market.ID, currencyUSD))
-	}
-	if !market.Enabled {
-		c.logWarning(fmt.Sprintf("shopify market disabled id=%s", market.ID))
-	}
+	resources.MarketID = market.ID
 
-	catalogTitle := c.internationalCatalogTitle()
-	catalog, err := c.findCatalogByTitle(ctx, catalogTitle)
+	catalog, err := c.findCatalogByTitle(ctx, definition.CatalogTitle)
 	if err != nil {
-		return IsraelMarketResources{}, err
+		return MarketResources{}, err
 	}
+	resources.CatalogID = catalog.ID
 	if catalog.ID == "" {
-		catalog, err = c.createCatalog(ctx, catalogTitle, market.ID)
+		would = append(would, fmt.Sprintf("create catalog %q with its publication and price list %q", definition.CatalogTitle, definition.PriceList))
+	} else {
+		if market.ID != "" {
+			attached, err := c.marketHasCatalog(ctx, market.ID, catalog.ID)
+			if err != nil {
+				return MarketResources{}, err
+			}
+			if !attached {
+				would = append(would, fmt.Sprintf("attach catalog %q to the market", definition.CatalogTitle))
+			}
+		}
+		publication, priceList, err := c.getCatalogDetails(ctx, catalog.ID)
 		if err != nil {
-			return IsraelMarketResources{}, err
+			return MarketResources{}, err
 		}
-		c.logSuccess(fmt.Sprintf("shopify catalog created id=%s title=%s", catalog.ID, catalog.Title))
-	} else {
-		c.logSuccess(fmt.Sprintf("shopify catalog found id=%s title=%s", catalog.ID, catalog.Title))
-	}
-
-	attached, err := c.marketHasCatalog(ctx, market.ID, catalog.ID)
-	if err != nil {
-		return IsraelMarketResources{}, err
-	}
-	if !attached {
-		if err := c.addCatalogToMarket(ctx, market.ID, catalog.ID); err != nil {
-			return IsraelMarketResources{}, err
+		switch {
+		case publication.ID == "":
+			would = append(would, "create the catalog publication")
+		case !publication.AutoPublish:
+			would = append(would, "turn on auto-publish for the catalog publication")
+		}
+		resources.PublicationID = publication.ID
+		if priceList.ID == "" || !strings.EqualFold(strings.TrimSpace(priceList.Currency), definition.Currency) {
+			fallback, err := c.findPriceListByCatalog(ctx, catalog.ID, definition.PriceList, definition.Currency)
+			if err != nil {
+				return MarketResources{}, err
+			}
+			if fallback.ID != "" {
+				priceList = fallback
+			}
+		}
+		switch {
+		case priceList.ID == "":
+			would = append(would, fmt.Sprintf("create price list %q", definition.PriceList))
+		case !strings.EqualFold(strings.TrimSpace(priceList.Currency), definition.Currency):
+			would = append(would, fmt.Sprintf("replace price list %s in %s with one in %s", priceList.ID, priceList.Currency, definition.Currency))
+		default:
+			resources.PriceListID = priceList.ID
 		}
-		c.logSuccess(fmt.Sprintf("shopify market catalog attached market=%s catalog=%s", market.ID, catalog.ID))
-	}
-	attached, err = c.marketHasCatalog(ctx, market.ID, catalog.ID)
-	if err != nil {
-		return IsraelMarketResources{}, err
-	}
-	if !attached {
-		return IsraelMarketResources{}, fmt.Errorf("shopify market %s missing catalog %s", market.ID, catalog.ID)
-	}
-
-	publication, priceList, err := c.ensureCatalogPublicationAndPriceList(ctx, catalog.ID, c.internationalPriceListName(), currencyUSD)
-	if err != nil {
-		return IsraelMarketResources{}, err
-	}
-	if publication.ID != "" {
-		c.logSuccess(fmt.Sprintf("shopify publication ready id=%s", publication.ID))
-	}
-	if priceList.ID != "" {
-		c.logSuccess(fmt.Sprintf("shopify price list ready id=%s currency=%s", priceList.ID, priceList.Currency))
-	}
-
-	resources := IsraelMarketResources{
-		MarketID:      market.ID,
-		CatalogID:     catalog.ID,
-		PublicationID: publication.ID,
-		PriceListID:   priceList.ID,
 	}
 
-	if err := c.verifyMarketSetup(ctx, resources, currencyUSD); err != nil {
-		return IsraelMarketResources{}, err
+	for _, step := range would {
+		message := fmt.Sprintf("DRY RUN would %s for market %s", step, definition.Handle)
+		c.logWarning(message)
+		c.reportWarning("price", message)
 	}
-
+	c.reportIncr("price", "dry_run_market_changes", int64(len(would)))
 	return resources, nil
 }
 
@@ -572,12 +698,14 @@ func (c *Client) listMarkets(ctx context.Context) ([]dto.MarketNode, error) {
 	return markets, nil
 }
 
-func (c *Client) findIsraelMarket(ctx context.Context) (marketInfo, error) {
-	preferred, err := c.findMarketByHandleOrName(ctx, israelMarketHandle, israelMarketName)
+// findMarket matches the definition's handle or name first, then falls back to the
+// one market that covers one of its countries.
+func (c *Client) findMarket(ctx context.Context, definition config.MarketDefinition) (marketInfo, error) {
+	preferred, err := c.findMarketByHandleOrName(ctx, definition.Handle, definition.Name)
 	if err != nil {
 		return marketInfo{}, err
 	}
-	if preferred.ID != "" {
+	if preferred.ID != "" || len(definition.Countries) == 0 {
 		return preferred, nil
 	}
 
@@ -587,11 +715,12 @@ func (c *Client) findIsraelMarket(ctx context.Context) (marketInfo, error) {
 	}
 	var found marketInfo
 	for _, market := range markets {
-		if !marketHasCountry(market, marketRegionIL) {
+		country, ok := marketCoversAny(market, definition.Countries)
+		if !ok {
 			continue
 		}
 		if found.ID != "" {
-			c.logWarning(fmt.Sprintf("multiple markets include %s, keeping id=%s", marketRegionIL, found.ID))
+			c.logWarning(fmt.Sprintf("multiple markets include %s, keeping id=%s", country, found.ID))
 			break
 		}
 		found = marketInfo{
@@ -606,49 +735,6 @@ func (c *Client) findIsraelMarket(ctx context.Context) (marketInfo, error) {
 	return found, nil
 }
 
-func (c *Client) findInternationalMarket(ctx context.Context) (marketInfo, error) {
-	handle := c.internationalMarketHandle()
-	name := c.internationalMarketName()
-	if strings.TrimSpace(handle) == "" && strings.TrimSpace(name) == "" {
-		return marketInfo{}, errors.New("shopify international market handle or name is required")
-	}
-	market, err := c.findMarketByHandleOrName(ctx, handle, name)
-	if err != nil {
-		return marketInfo{}, err
-	}
-	if market.ID != "" {
-		return market, nil
-	}
-	// Fallback: any non-Israel market with USD base currency.
-	// Handles stores where the primary market has a different handle/name than configured.
-	return c.findUSDMarketFallback(ctx)
-}
-
-func (c *Client) findUSDMarketFallback(ctx context.Context) (marketInfo, error) {
-	markets, err := c.listMarkets(ctx)
-	if err != nil {
-		return marketInfo{}, err
-	}
-	for _, market := range markets {
-		if marketHasCountry(market, marketRegionIL) {
-			continue
-		}
-		if !strings.EqualFold(strings.TrimSpace(market.CurrencySettings.BaseCurrency.CurrencyCode), currencyUSD) {
-			continue
-		}
-		c.logWarning(fmt.Sprintf("shopify intl market fallback: using id=%s handle=%s name=%s", market.ID, market.Handle, market.Name))
-		return marketInfo{
-			ID:              strings.TrimSpace(market.ID),
-			Name:            strings.TrimSpace(market.Name),
-			Handle:          strings.TrimSpace(market.Handle),
-			Enabled:         market.Enabled,
-			CurrencyCode:    strings.TrimSpace(market.CurrencySettings.BaseCurrency.CurrencyCode),
-			LocalCurrencies: market.CurrencySettings.LocalCurrencies,
-		}, nil
-	}
-	return marketInfo{}, nil
-}
-
 func (c *Client) findMarketByHandleOrName(ctx context.Context, handle string, name string) (marketInfo, error) {
 	markets, err := c.listMarkets(ctx)
 	if err != nil {
@@ -696,7 +782,16 @@ func marketHasCountry(market dto.MarketNode, countryCode string) bool {
 	return false
 }
 
-func (c *Client) createIsraelMarket(ctx context.Context) (marketInfo, error) {
+func marketCoversAny(market dto.MarketNode, countryCodes []string) (string, bool) {
+	for _, countryCode := range countryCodes {
+		if marketHasCountry(market, countryCode) {
+			return countryCode, true
+		}
+	}
+	return "", false
+}
+
+func (c *Client) createMarket(ctx context.Context, definition config.MarketDefinition) (marketInfo, error) {
 	query := `
 	mutation marketCreate($input: MarketCreateInput!) {
 		marketCreate(input: $input) {
@@ -714,14 +809,18 @@ func (c *Client) createIsraelMarket(ctx context.Context) (marketInfo, error) {
 		}
 	}`
 
+	name := definition.Name
+	if name == "" {
+		name = definition.Handle
+	}
 	input := map[string]any{
-		"name":   israelMarketName,
-		"handle": israelMarketHandle,
+		"name":   name,
+		"handle": definition.Handle,
 		"regionsCondition": map[string]any{
-			"countryCodes": []string{marketRegionIL},
+			"countryCodes": definition.Countries,
 		},
 		"currencySettings": map[string]any{
-			"baseCurrency":    currencyILS,
+			"baseCurrency":    definition.Currency,
 			"localCurrencies": false,
 		},
 	}
@@ -1216,7 +1315,7 @@ func (c *Client) createPriceList(ctx context.Context, catalogID string, priceLis
 	return *data.PriceListCreate.PriceList, nil
 }
 
-func (c *Client) verifyMarketSetup(ctx context.Context, resources IsraelMarketResources, currencyCode string) error {
+func (c *Client) verifyMarketSetup(ctx context.Context, resources MarketResources, currencyCode string) error {
 	if resources.MarketID == "" || resources.CatalogID == "" {
 		return errors.New("shopify market resources are incomplete")
 	}
@@ -1252,27 +1351,60 @@ type marketInfo struct {
 }
 
 type resolvedPriceInput struct {
-	SKU          string
-	ProductID    string
-	VariantID    string
-	USDPrice     float64
-	ILSPrice     float64
-	USDCompareAt float64
-	ILSCompareAt float64
+	SKU       string
+	ProductID string
+	VariantID string
+	Prices    map[string]float64
+	CompareAt map[string]float64
 	// Before* are the values Shopify held prior to this run, carried through so the
-	// report can show what actually moved. Known=false means Shopify had no value.
+	// report can show what actually moved. Known=false (or a currency missing from
+	// Before) means Shopify had no value.
 	BeforeBase      float64
 	BeforeBaseKnown bool
-	BeforeUSD       float64
-	BeforeUSDKnown  bool
+	Before          map[string]float64
+	BeforeCompareAt float64
+	QuantityBreaks  []QuantityBreak
+	QuantityRule    QuantityRule
+}
+
+// check builds what the price guard sees of the item.
+func (r resolvedPriceInput) check(baseCurrency string) PriceCheck {
+	before := make(map[string]float64, len(r.Before)+1)
+	for currency, amount := range r.Before {
+		before[currency] = amount
+	}
+	if r.BeforeBaseKnown {
+		before[baseCurrency] = r.BeforeBase
+	}
+	return PriceCheck{
+		Input: PriceUpsertInput{
+			SKU:       r.SKU,
+			ProductID: r.ProductID,
+			VariantID: r.VariantID,
+			Prices:    r.Prices,
+			CompareAt: r.CompareAt,
+		},
+		BaseCurrency:    baseCurrency,
+		Before:          before,
+		BeforeCompareAt: r.BeforeCompareAt,
+	}
 }
 
-func validatePriceInput(input PriceUpsertInput) error {
-	if input.USDPrice < 0 || input.ILSPrice < 0 {
-		return errors.New("shopify price must be non-negative")
+func validatePriceInput(input PriceUpsertInput, currencies []string) error {
+	for _, currency := range currencies {
+		if _, ok := input.Prices[currency]; !ok {
+			return fmt.Errorf("shopify price for sku %s is missing %s", strings.TrimSpace(input.SKU), currency)
+		}
 	}
-	if input.USDCompareAt < 0 || input.ILSCompareAt < 0 {
-		return errors.New("shopify compareAt price must be non-negative")
+	for _, amount := range input.Prices {
+		if amount < 0 {
+			return errors.New("shopify price must be non-negative")
+		}
+	}
+	for _, amount := range input.CompareAt {
+		if amount < 0 {
+			return errors.New("shopify compareAt price must be non-negative")
+		}
 	}
 	if input.VariantID == "" && strings.TrimSpace(input.SKU) == "" {
 		return errors.New("shopify price requires sku or variant id")
@@ -1285,19 +1417,20 @@ func validatePriceInput(input PriceUpsertInput) error {
 // without it, the single-SKU lookup reads them itself.
 func (c *Client) resolvePriceInput(ctx context.Context, input PriceUpsertInput, hint *variantLookup) (resolvedPriceInput, error) {
 	resolved := resolvedPriceInput{
-		SKU:          strings.TrimSpace(input.SKU),
-		ProductID:    strings.TrimSpace(input.ProductID),
-		VariantID:    strings.TrimSpace(input.VariantID),
-		USDPrice:     input.USDPrice,
-		ILSPrice:     input.ILSPrice,
-		USDCompareAt: input.USDCompareAt,
-		ILSCompareAt: input.ILSCompareAt,
+		SKU:       strings.TrimSpace(input.SKU),
+		ProductID: strings.TrimSpace(input.ProductID),
+		VariantID: strings.TrimSpace(input.VariantID),
+		Prices:    input.Prices,
+		CompareAt: input.CompareAt,
+		// Volume pricing rides along untouched; only the B2B push reads it.
+		QuantityBreaks: input.QuantityBreaks,
+		QuantityRule:   input.QuantityRule,
 	}
 	if hint != nil {
 		resolved.BeforeBase = hint.BeforeBase
 		resolved.BeforeBaseKnown = hint.BeforeBaseKnown
-		resolved.BeforeUSD = hint.BeforeUSD
-		resolved.BeforeUSDKnown = hint.BeforeUSDKnown
+		resolved.Before = hint.Before
+		resolved.BeforeCompareAt = hint.BeforeCompareAt
 	}
 
 	if resolved.VariantID == "" {
@@ -1312,8 +1445,8 @@ func (c *Client) resolvePriceInput(ctx context.Context, input PriceUpsertInput,
 		if hint == nil {
 			resolved.BeforeBase = found.BeforeBase
 			resolved.BeforeBaseKnown = found.BeforeBaseKnown
-			resolved.BeforeUSD = found.BeforeUSD
-			resolved.BeforeUSDKnown = found.BeforeUSDKnown
+			resolved.Before = found.Before
+			resolved.BeforeCompareAt = found.BeforeCompareAt
 		}
 	}
 
@@ -1349,7 +1482,7 @@ func (c *Client) findVariantBySKU(ctx context.Context, sku string) (variantLooku
 	query := `
 	query productVariantBySku($first: Int!, $query: String!) {
 		productVariants(first: $first, query: $query) {
-			nodes {` + variantPriceSelection + `
+			nodes {` + c.variantPriceSelection() + `
 			}
 		}
 	}`
@@ -1365,14 +1498,14 @@ func (c *Client) findVariantBySKU(ctx context.Context, sku string) (variantLooku
 		return variantLookup{}, &variantNotFoundError{SKU: sku}
 	}
 	variant := data.ProductVariants.Nodes[0]
-	beforeBase, beforeBaseKnown, beforeUSD, beforeUSDKnown := variant.beforePrices()
+	beforeBase, beforeBaseKnown, before := variant.beforePrices(c.markets())
 	return variantLookup{
 		VariantID:       strings.TrimSpace(variant.ID),
 		ProductID:       strings.TrimSpace(variant.Product.ID),
 		BeforeBase:      beforeBase,
 		BeforeBaseKnown: beforeBaseKnown,
-		BeforeUSD:       beforeUSD,
-		BeforeUSDKnown:  beforeUSDKnown,
+		Before:          before,
+		BeforeCompareAt: variant.beforeCompareAt(),
 	}, nil
 }
 
@@ -1390,12 +1523,13 @@ func (c *Client) buildVariantLookup(ctx context.Context, inputs []PriceUpsertInp
 		return nil, nil
 	}
 
+	markets := c.markets()
 	lookup := make(map[string]variantLookup)
 	after := ""
 	query := `
 	query productVariants($first: Int!, $after: String) {
 		productVariants(first: $first, after: $after) {
-			nodes {` + variantPriceSelection + `
+			nodes {` + c.variantPriceSelection() + `
 			}
 			pageInfo { hasNextPage endCursor }
 		}
@@ -1420,14 +1554,14 @@ func (c *Client) buildVariantLookup(ctx context.Context, inputs []PriceUpsertInp
 			if _, exists := lookup[sku]; exists {
 				continue
 			}
-			beforeBase, beforeBaseKnown, beforeUSD, beforeUSDKnown := node.beforePrices()
+			beforeBase, beforeBaseKnown, before := node.beforePrices(markets)
 			lookup[sku] = variantLookup{
 				VariantID:       strings.TrimSpace(node.ID),
 				ProductID:       strings.TrimSpace(node.Product.ID),
 				BeforeBase:      beforeBase,
 				BeforeBaseKnown: beforeBaseKnown,
-				BeforeUSD:       beforeUSD,
-				BeforeUSDKnown:  beforeUSDKnown,
+				Before:          before,
+				BeforeCompareAt: node.beforeCompareAt(),
 			}
 		}
 		if !data.ProductVariants.PageInfo.HasNextPage {
@@ -1550,10 +1684,12 @@ func (c *Client) updateBasePrices(ctx context.Context, inputs []resolvedPriceInp
 	return nil
 }
 
-// addFixedPrices mirrors the base price into the market price list. It deliberately
-// does NOT report changes: the value is the same money as the variant price already
-// reported by updateBasePrices, and reporting both would double every row.
-func (c *Client) addFixedPrices(ctx context.Context, priceListID string, inputs []resolvedPriceInput, currencyCode string) error {
+// addFixedPrices writes the market's price into its price list. For the base-currency
+// market this mirrors the variant price, so report is false there: the value is the
+// same money updateBasePrices already reported, and reporting both would double every
+// row. For any other currency report is true, and the list's current fixed prices are
+// read first so the report shows real moves.
+func (c *Client) addFixedPrices(ctx context.Context, priceListID string, inputs []resolvedPriceInput, currencyCode string, report bool) error {
 	priceListID = strings.TrimSpace(priceListID)
 	if priceListID == "" {
 		return errors.New("shopify price list id is required")
@@ -1565,6 +1701,14 @@ func (c *Client) addFixedPrices(ctx context.Context, priceListID string, inputs
 	if len(inputs) == 0 {
 		return nil
 	}
+	var before map[string]float64
+	if report {
+		var err error
+		before, err = c.fixedPriceAmounts(ctx, priceListID)
+		if err != nil {
+			return err
+		}
+	}
 
 	query := `
 	mutation priceListFixedPricesAdd($priceListId: ID!, $prices: [PriceListPriceInput!]!) {
@@ -1629,6 +1773,10 @@ func (c *Client) addFixedPrices(ctx context.Context, priceListID string, inputs
 			if err != nil {
 				return err
 			}
+			if report {
+				beforeAmount, known := before[item.VariantID]
+				c.reportPriceSeen(item.SKU, currencyCode, beforeAmount, known, priceAmount)
+			}
 			c.logSuccess(fmt.Sprintf("shopify price updated sku=%s %s=%s", item.SKU, strings.ToLower(currencyCode), formatMoneyAmount(priceAmount)))
 		}
 	}
@@ -1636,30 +1784,97 @@ func (c *Client) addFixedPrices(ctx context.Context, priceListID string, inputs
 	return nil
 }
 
-// ensureUSDPriceMetafieldDefinition makes sure the custom.usd_price PRODUCT metafield
-// definition exists with the expected type (number_decimal), once per process. If a
-// definition with the same key exists but with a different type (e.g. a stale "money"
-// definition), it is deleted and recreated so the value write does not fail.
-func (c *Client) ensureUSDPriceMetafieldDefinition(ctx context.Context) error {
-	c.usdMetaMu.Lock()
-	ready := c.usdMetaReady
-	c.usdMetaMu.Unlock()
+// fixedPriceAmounts returns the price list's fixed prices by variant id.
+func (c *Client) fixedPriceAmounts(ctx context.Context, priceListID string) (map[string]float64, error) {
+	query := `
+	query priceListPrices($id: ID!, $first: Int!, $after: String) {
+		priceList(id: $id) {
+			prices(first: $first, after: $after, originType: FIXED) {
+				nodes {
+					variant { id }
+					price { amount }
+				}
+				pageInfo { hasNextPage endCursor }
+			}
+		}
+	}`
+
+	type queryData struct {
+		PriceList *struct {
+			Prices struct {
+				Nodes []struct {
+					Variant struct {
+						ID string `json:"id,omitempty"`
+					} `json:"variant"`
+					Price struct {
+						Amount string `json:"amount,omitempty"`
+					} `json:"price"`
+				} `json:"nodes,omitempty"`
+				PageInfo dto.ShopifyPageInfo `json:"pageInfo,omitempty"`
+			} `json:"prices"`
+		} `json:"priceList,omitempty"`
+	}
+
+	amounts := make(map[string]float64)
+	after := ""
+	for {
+		variables := map[string]any{
+			"id":    priceListID,
+			"first": maxFixedPriceBatchSize,
+		}
+		if after != "" {
+			variables["after"] = after
+		}
+		var data queryData
+		if err := c.graphqlRequest(ctx, query, variables, &data); err != nil {
+			return nil, err
+		}
+		if data.PriceList == nil {
+			return amounts, nil
+		}
+		for _, node := range data.PriceList.Prices.Nodes {
+			if parsed, err := strconv.ParseFloat(strings.TrimSpace(node.Price.Amount), 64); err == nil {
+				amounts[strings.TrimSpace(node.Variant.ID)] = parsed
+			}
+		}
+		if !data.PriceList.Prices.PageInfo.HasNextPage {
+			break
+		}
+		after = strings.TrimSpace(data.PriceList.Prices.PageInfo.EndCursor)
+		if after == "" {
+			break
+		}
+	}
+	return amounts, nil
+}
+
+// ensurePriceMetafieldDefinition makes sure the market's PRODUCT price metafield
+// definition (custom.usd_price by default) exists with the expected type
+// (number_decimal), once per process. If a definition with the same key exists but
+// with a different type (e.g. a stale "money" definition), it is deleted and
+// recreated so the value write does not fail.
+func (c *Client) ensurePriceMetafieldDefinition(ctx context.Context, market config.MarketDefinition) error {
+	namespace, key := market.MetafieldKey()
+	cacheKey := strings.ToLower(namespace + "." + key)
+	c.priceMetaMu.Lock()
+	ready := c.priceMetaReady[cacheKey]
+	c.priceMetaMu.Unlock()
 	if ready {
 		return nil
 	}
 
-	existingID, existingType, err := c.findUSDPriceMetafieldDefinition(ctx)
+	existingID, existingType, err := c.findPriceMetafieldDefinition(ctx, namespace, key)
 	if err != nil {
 		return err
 	}
 	if existingID != "" {
-		if strings.EqualFold(strings.TrimSpace(existingType), usdMetafieldType) {
-			c.markUSDMetaReady()
+		if strings.EqualFold(strings.TrimSpace(existingType), priceMetafieldType) {
+			c.markPriceMetaReady(cacheKey)
 			return nil
 		}
 		c.logWarning(fmt.Sprintf(
-			"shopify usd metafield definition type mismatch id=%s have=%q want=%s, recreating",
-			existingID, existingType, usdMetafieldType,
+			"shopify %s metafield definition type mismatch id=%s have=%q want=%s, recreating",
+			strings.ToLower(market.Currency), existingID, existingType, priceMetafieldType,
 		))
 		if err := c.deleteMetafieldDefinition(ctx, existingID); err != nil {
 			return err
@@ -1674,10 +1889,10 @@ func (c *Client) ensureUSDPriceMetafieldDefinition(ctx context.Context) error {
 	}`
 	payload := map[string]any{
 		"definition": map[string]any{
-			"name":      usdMetafieldName,
-			"namespace": usdMetafieldNamespace,
-			"key":       usdMetafieldKey,
-			"type":      usdMetafieldType,
+			"name":      market.Currency + " price",
+			"namespace": namespace,
+			"key":       key,
+			"type":      priceMetafieldType,
 			"ownerType": metafieldOwnerProduct,
 		},
 	}
@@ -1692,15 +1907,15 @@ func (c *Client) ensureUSDPriceMetafieldDefinition(ctx context.Context) error {
 	if err := userErrorsToError("metafieldDefinitionCreate", resp.MetafieldDefinitionCreate.UserErrors); err != nil {
 		return err
 	}
-	c.markUSDMetaReady()
+	c.markPriceMetaReady(cacheKey)
 	return nil
 }
 
-// findUSDPriceMetafieldDefinition returns the id and type name of the existing
-// custom.usd_price PRODUCT metafield definition, or empty strings if none exists.
-func (c *Client) findUSDPriceMetafieldDefinition(ctx context.Context) (string, string, error) {
+// findPriceMetafieldDefinition returns the id and type name of the existing PRODUCT
+// metafield definition namespace.key, or empty strings if none exists.
+func (c *Client) findPriceMetafieldDefinition(ctx context.Context, namespace, key string) (string, string, error) {
 	query := `
-	query usdPriceDefinition($namespace: String!, $ownerType: MetafieldOwnerType!) {
+	query priceMetafieldDefinition($namespace: String!, $ownerType: MetafieldOwnerType!) {
 		metafieldDefinitions(first: 25, namespace: $namespace, ownerType: $ownerType) {
 			nodes { id key type { name } }
 		}
@@ -1717,20 +1932,19 @@ func (c *Client) findUSDPriceMetafieldDefinition(ctx context.Context) (string, s
 		} `json:"metafieldDefinitions"`
 	}
 	if err := c.graphqlRequest(ctx, query, map[string]any{
-		"namespace": usdMetafieldNamespace,
+		"namespace": namespace,
 		"ownerType": metafieldOwnerProduct,
 	}, &data); err != nil {
 		return "", "", err
 	}
 	for _, node := range data.MetafieldDefinitions.Nodes {
-		if strings.EqualFold(strings.TrimSpace(node.Key), usdMetafieldKey) {
+		if strings.EqualFold(strings.TrimSpace(node.Key), key) {
 			return strings.TrimSpace(node.ID), strings.TrimSpace(node.Type.Name), nil
 		}
 	}
 	return "", "", nil
 }
 
-// deleteMetafieldDefinition removes a metafield definition and any associated metafields.
 func (c *Client) deleteMetafieldDefinition(ctx context.Context, definitionID string) error {
 	definitionID = strings.TrimSpace(definitionID)
 	if definitionID == "" {
@@ -1758,25 +1972,30 @@ func (c *Client) deleteMetafieldDefinition(ctx context.Context, definitionID str
 	return userErrorsToError("metafieldDefinitionDelete", resp.MetafieldDefinitionDelete.UserErrors)
 }
 
-func (c *Client) markUSDMetaReady() {
-	c.usdMetaMu.Lock()
-	c.usdMetaReady = true
-	c.usdMetaMu.Unlock()
+func (c *Client) markPriceMetaReady(cacheKey string) {
+	c.priceMetaMu.Lock()
+	if c.priceMetaReady == nil {
+		c.priceMetaReady = make(map[string]bool)
+	}
+	c.priceMetaReady[cacheKey] = true
+	c.priceMetaMu.Unlock()
 }
 
-// addUSDPriceMetafields writes the fixed USD price into the product metafield
-// custom.usd_price (money type) for each resolved product. The storefront theme/app
-// reads this metafield to display USD, since Shopify multi-currency is blocked by the
-// store's single-currency payment gateway.
-func (c *Client) addUSDPriceMetafields(ctx context.Context, inputs []resolvedPriceInput) error {
+// addPriceMetafields writes the market's fixed price into its product metafield for
+// each resolved product. The storefront theme/app reads this metafield to display the
+// currency, since Shopify multi-currency is blocked by the store's single-currency
+// payment gateway. report is false when another market already reported the currency.
+func (c *Client) addPriceMetafields(ctx context.Context, market config.MarketDefinition, inputs []resolvedPriceInput, report bool) error {
 	if len(inputs) == 0 {
 		return nil
 	}
-	if err := c.ensureUSDPriceMetafieldDefinition(ctx); err != nil {
+	if err := c.ensurePriceMetafieldDefinition(ctx, market); err != nil {
 		return err
 	}
+	namespace, key := market.MetafieldKey()
+	currency := market.Currency
 
-	// One USD price per product. Warn (don't fail) if variants of the same product
+	// One price per product. Warn (don't fail) if variants of the same product
 	// disagree — product-level metafields hold a single value.
 	byProduct := make(map[string]resolvedPriceInput)
 	order := make([]string, 0, len(inputs))
@@ -1786,10 +2005,11 @@ func (c *Client) addUSDPriceMetafields(ctx context.Context, inputs []resolvedPri
 			continue
 		}
 		if existing, ok := byProduct[productID]; ok {
-			if existing.USDPrice != item.USDPrice {
+			if existing.Prices[currency] != item.Prices[currency] {
 				c.logWarning(fmt.Sprintf(
-					"shopify usd metafield conflict product_id=%s sku=%s keeping=%s skipping=%s",
-					productID, item.SKU, formatMoneyAmount(existing.USDPrice), formatMoneyAmount(item.USDPrice),
+					"shopify %s metafield conflict product_id=%s sku=%s keeping=%s skipping=%s",
+					strings.ToLower(currency), productID, item.SKU,
+					formatMoneyAmount(existing.Prices[currency]), formatMoneyAmount(item.Prices[currency]),
 				))
 			}
 			continue
@@ -1818,17 +2038,17 @@ func (c *Client) addUSDPriceMetafields(ctx context.Context, inputs []resolvedPri
 		metafields := make([]map[string]any, 0, len(batch))
 		for _, productID := range batch {
 			item := byProduct[productID]
-			value := formatMoneyAmount(item.USDPrice)
+			value := formatMoneyAmount(item.Prices[currency])
 			c.traceSKU(
 				item.SKU,
-				"usd metafield product_id=%s namespace=%s key=%s value=%s",
-				productID, usdMetafieldNamespace, usdMetafieldKey, value,
+				"%s metafield product_id=%s namespace=%s key=%s value=%s",
+				strings.ToLower(currency), productID, namespace, key, value,
 			)
 			metafields = append(metafields, map[string]any{
 				"ownerId":   productID,
-				"namespace": usdMetafieldNamespace,
-				"key":       usdMetafieldKey,
-				"type":      usdMetafieldType,
+				"namespace": namespace,
+				"key":       key,
+				"type":      priceMetafieldType,
 				"value":     value,
 			})
 		}
@@ -1839,36 +2059,44 @@ func (c *Client) addUSDPriceMetafields(ctx context.Context, inputs []resolvedPri
 		if err := userErrorsToError("metafieldsSet", data.MetafieldsSet.UserErrors); err != nil {
 			return err
 		}
+		if !report {
+			continue
+		}
 		for _, productID := range batch {
 			item := byProduct[productID]
-			c.reportPriceSeen(item.SKU, currencyUSD, item.BeforeUSD, item.BeforeUSDKnown, item.USDPrice)
+			beforeAmount, known := item.Before[currency]
+			c.reportPriceSeen(item.SKU, currency, beforeAmount, known, item.Prices[currency])
 		}
 	}
 
-	c.logSuccess(fmt.Sprintf("shopify usd price metafields updated products=%d", len(order)))
+	c.logSuccess(fmt.Sprintf("shopify %s price metafields updated products=%d", strings.ToLower(currency), len(order)))
 	return nil
 }
 
 func priceForCurrency(item resolvedPriceInput, currencyCode string) (float64, error) {
-	switch strings.ToUpper(strings.TrimSpace(currencyCode)) {
-	case currencyUSD:
-		return item.USDPrice, nil
-	case currencyILS:
-		return item.ILSPrice, nil
-	default:
-		return 0, fmt.Errorf("shopify unsupported currency %q for pricing", currencyCode)
+	amount, ok := item.Prices[strings.ToUpper(strings.TrimSpace(currencyCode))]
+	if !ok {
+		return 0, fmt.Errorf("shopify no %s price for sku %s", currencyCode, item.SKU)
 	}
+	return amount, nil
 }
 
 func compareAtForCurrency(item resolvedPriceInput, currencyCode string) float64 {
-	switch strings.ToUpper(strings.TrimSpace(currencyCode)) {
-	case currencyUSD:
-		return item.USDCompareAt
-	case currencyILS:
-		return item.ILSCompareAt
-	default:
-		return 0
+	return item.CompareAt[strings.ToUpper(strings.TrimSpace(currencyCode))]
+}
+
+// formatCurrencyAmounts renders a currency map for trace lines, sorted by currency.
+func formatCurrencyAmounts(amounts map[string]float64) string {
+	currencies := make([]string, 0, len(amounts))
+	for currency := range amounts {
+		currencies = append(currencies, currency)
+	}
+	sort.Strings(currencies)
+	parts := make([]string, 0, len(currencies))
+	for _, currency := range currencies {
+		parts = append(parts, strings.ToLower(currency)+"="+formatMoneyAmount(amounts[currency]))
 	}
+	return strings.Join(parts, ",")
 }
 
 func formatMoneyAmount(amount float64) string {
@@ -1897,18 +2125,18 @@ func userErrorsToDetailedError(action string, errs []dto.ShopifyUserError) error
 	return &userErrorsError{Action: action, Errors: details}
 }
 
-func (c *Client) getPriceCache() (IsraelMarketResources, bool) {
+func (c *Client) getPriceCache() ([]MarketResources, bool) {
 	c.priceMu.Lock()
 	defer c.priceMu.Unlock()
 	if c.priceCache == nil {
-		return IsraelMarketResources{}, false
+		return nil, false
 	}
-	return *c.priceCache, true
+	return c.priceCache, true
 }
 
-func (c *Client) setPriceCache(resources IsraelMarketResources) {
+func (c *Client) setPriceCache(resources []MarketResources) {
 	c.priceMu.Lock()
-	c.priceCache = &resources
+	c.priceCache = resources
 	c.priceMu.Unlock()
 }
 
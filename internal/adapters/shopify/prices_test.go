@@ -0,0 +1,56 @@
+package shopify
+
+import (
+	"encoding/json"
+	"shopify-exporter/internal/config"
+	"testing"
+)
+
+// TestBeforePricesReadsEachMetafieldMarket checks the pre-push values come back per
+// currency from the metafields the markets name, so a second metafield market does
+// not read the USD value (and vice versa).
+func TestBeforePricesReadsEachMetafieldMarket(t *testing.T) {
+	markets := append([]config.MarketDefinition{}, config.DefaultMarkets...)
+	markets = append(markets, config.MarketDefinition{Handle: "eu", Currency: "EUR", Metafield: "custom.eur_price", ERPPriceList: 12})
+
+	var node variantPriceNode
+	raw := `{"id": "v1", "sku": "A1", "price": "120.00", "product": {"id": "p1", "metafields": {"nodes": [
+		{"namespace": "custom", "key": "usd_price", "value": "33.50"},
+		{"namespace": "custom", "key": "eur_price", "value": "not a number"}
+	]}}}`
+	if err := json.Unmarshal([]byte(raw), &node); err != nil {
+		t.Fatal(err)
+	}
+
+	base, baseKnown, before := node.beforePrices(markets)
+	if !baseKnown || base != 120 {
+		t.Fatalf("base = %v known=%t, want 120", base, baseKnown)
+	}
+	if before["USD"] != 33.5 {
+		t.Fatalf("USD before = %v, want 33.5", before["USD"])
+	}
+	if _, ok := before["EUR"]; ok {
+		t.Fatalf("EUR before = %v, want unknown", before["EUR"])
+	}
+	if _, ok := before["ILS"]; ok {
+		t.Fatal("ILS is priced through a price list, not a metafield")
+	}
+}
+
+// TestValidatePriceInputRequiresEveryMarketCurrency: a SKU is pushed only with a price
+// in every market's currency, otherwise one market would keep a stale price.
+func TestValidatePriceInputRequiresEveryMarketCurrency(t *testing.T) {
+	currencies := []string{"ILS", "USD", "EUR"}
+	complete := PriceUpsertInput{SKU: "A1", Prices: map[string]float64{"ILS": 100, "USD": 30, "EUR": 27}}
+	if err := validatePriceInput(complete, currencies); err != nil {
+		t.Fatalf("complete input: %v", err)
+	}
+	missing := PriceUpsertInput{SKU: "A1", Prices: map[string]float64{"ILS": 100, "USD": 30}}
+	if err := validatePriceInput(missing, currencies); err == nil {
+		t.Fatal("input without EUR accepted")
+	}
+	negative := PriceUpsertInput{SKU: "A1", Prices: map[string]float64{"ILS": 100, "USD": -1, "EUR": 27}}
+	if err := validatePriceInput(negative, currencies); err == nil {
+		t.Fatal("negative price accepted")
+	}
+}
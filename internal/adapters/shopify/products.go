@@ -7,12 +7,14 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"math"
 	"net/http"
 	"shopify-exporter/internal/adapters/shopify/dto"
 	"shopify-exporter/internal/config"
 	"shopify-exporter/internal/domain/model"
 	"shopify-exporter/internal/logging"
 	"shopify-exporter/internal/report"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -32,14 +34,47 @@ type productUpdateData struct {
 
 type productVariantLookupData struct {
 	Product *struct {
+		ReturnDate *struct {
+			Value string `json:"value,omitempty"`
+		} `json:"returnDate,omitempty"`
+		Incoming *struct {
+			Value string `json:"value,omitempty"`
+		} `json:"incoming,omitempty"`
 		Variants struct {
 			Nodes []struct {
-				ID string `json:"id"`
+				ID            string `json:"id"`
+				Taxable       *bool  `json:"taxable,omitempty"`
+				InventoryItem *struct {
+					ID       string `json:"id,omitempty"`
+					UnitCost *struct {
+						Amount string `json:"amount,omitempty"`
+					} `json:"unitCost,omitempty"`
+					// InventoryLevel is asked for only when incoming is delivered as
+					// an inventory quantity.
+					InventoryLevel *dto.InventoryLevelNode `json:"inventoryLevel,omitempty"`
+				} `json:"inventoryItem,omitempty"`
 			} `json:"nodes,omitempty"`
 		} `json:"variants,omitempty"`
 	} `json:"product,omitempty"`
 }
 
+// primaryVariant is the first variant of a product plus the values the product sync
+// compares before writing: the tax flag, the inventory item's unit cost, its incoming
+// quantity at the primary location, and the product's current expected return date
+// and incoming quantity metafields ("" when unset).
+type primaryVariant struct {
+	ID                string
+	InventoryItemID   string
+	Taxable           bool
+	TaxableKnown      bool
+	Cost              float64
+	CostKnown         bool
+	Incoming          int
+	IncomingKnown     bool
+	ReturnDate        string
+	IncomingMetafield string
+}
+
 type productVariantSearchData struct {
 	ProductVariants struct {
 		Nodes []struct {
@@ -49,6 +84,7 @@ type productVariantSearchData struct {
 				ID string `json:"id,omitempty"`
 			} `json:"product,omitempty"`
 		} `json:"nodes,omitempty"`
+		PageInfo dto.ShopifyPageInfo `json:"pageInfo,omitempty"`
 	} `json:"productVariants"`
 }
 
@@ -83,17 +119,6 @@ type productTranslationUpdateData struct {
 	} `json:"translationsRegister"`
 }
 
-type publicationsQueryData struct {
-	Publications struct {
-		Edges []struct {
-			Node struct {
-				ID string `json:"id,omitempty"`
-			} `json:"node,omitempty"`
-		} `json:"edges,omitempty"`
-		PageInfo dto.ShopifyPageInfo `json:"pageInfo,omitempty"`
-	} `json:"publications"`
-}
-
 type publishablePublishData struct {
 	PublishablePublish struct {
 		UserErrors []dto.ShopifyUserError `json:"userErrors,omitempty"`
@@ -111,17 +136,35 @@ type NewClientService interface {
 }
 
 type Client struct {
-	config       config.ShopifyConfig
-	httpClient   *http.Client
-	logger       logging.LoggerService
-	priceMu      sync.Mutex
-	priceCache   *IsraelMarketResources
-	usdMetaMu    sync.Mutex
-	usdMetaReady bool
-	locationMu   sync.Mutex
-	locationID   string
-	reportMu     sync.Mutex
-	reporter     report.Recorder
+	config     config.ShopifyConfig
+	httpClient *http.Client
+	logger     logging.LoggerService
+	priceMu    sync.Mutex
+	priceCache []MarketResources
+	// priceMetaReady caches, by "namespace.key", the price metafield definitions
+	// already checked this process.
+	priceMetaMu    sync.Mutex
+	priceMetaReady map[string]bool
+	// returnDateMetaReady caches that the custom.expected_return_date definition
+	// exists, so it is checked once per process like the price ones.
+	returnDateMetaMu    sync.Mutex
+	returnDateMetaReady bool
+	// incomingMetaReady does the same for custom.incoming_quantity.
+	incomingMetaMu    sync.Mutex
+	incomingMetaReady bool
+	// publications is the store's publication list, fetched once per process.
+	// publicationsMissing remembers configured names already warned about.
+	publicationMu       sync.Mutex
+	publications        []publication
+	publicationsMissing map[string]bool
+	locationMu          sync.Mutex
+	locationID          string
+	locationsByName     map[string]dto.LocationNode
+	reportMu            sync.Mutex
+	reporter            report.Recorder
+	// location is config.Timezone resolved, for the pre-order dates; nil uses the
+	// server's zone.
+	location *time.Location
 }
 
 const maxPublicationBatchSize = 50
@@ -134,11 +177,36 @@ func NewClient(config config.ShopifyConfig, httpClient *http.Client, logger logg
 		}
 		httpClient = &http.Client{Timeout: timeout}
 	}
-	return &Client{
+	client := &Client{
 		config:     config,
 		httpClient: httpClient,
 		logger:     logger,
 	}
+	client.location = client.resolveLocation(config.Timezone)
+	return client
+}
+
+// resolveLocation loads the REPORT_TIMEZONE zone. An unknown name falls back to UTC
+// with a warning, as the report does.
+func (c *Client) resolveLocation(name string) *time.Location {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return time.UTC
+	}
+	location, err := time.LoadLocation(name)
+	if err != nil {
+		c.logWarning(fmt.Sprintf("unknown REPORT_TIMEZONE=%q, judging pre-order dates in UTC", name))
+		return time.UTC
+	}
+	return location
+}
+
+// localNow is the current time in REPORT_TIMEZONE.
+func (c *Client) localNow() time.Time {
+	if c.location == nil {
+		return time.Now()
+	}
+	return time.Now().In(c.location)
 }
 
 func (c *Client) logError(message string, err error) {
@@ -206,11 +274,9 @@ func (c *Client) CreateProduct(ctx context.Context, product model.Product) (stri
 		return "", err
 	}
 
-	if product.IsPublished {
-		if err := c.publishProduct(ctx, data.ProductCreate.Product.ID); err != nil {
-			c.logError("shopify product publish failed", err)
-			return "", err
-		}
+	if err := c.syncProductPublications(ctx, data.ProductCreate.Product.ID, product, true); err != nil {
+		c.logError("shopify product publish failed", err)
+		return "", err
 	}
 
 	return data.ProductCreate.Product.ID, nil
@@ -261,11 +327,9 @@ func (c *Client) UpdateProduct(ctx context.Context, product model.Product, produ
 		return err
 	}
 
-	if product.IsPublished {
-		if err := c.publishProduct(ctx, productGid); err != nil {
-			c.logError("shopify product publish failed", err)
-			return err
-		}
+	if err := c.syncProductPublications(ctx, productGid, product, false); err != nil {
+		c.logError("shopify product publish failed", err)
+		return err
 	}
 
 	return nil
@@ -313,6 +377,102 @@ func (c *Client) CheckExistProductBySku(ctx context.Context, product model.Produ
 	return gid != "", gid, nil
 }
 
+// ProductLookupService resolves many SKUs to product ids in a handful of searches.
+// The product sync uses it per ERP page instead of one CheckExistProductBySku call
+// per SKU: every GraphQL call serialises on the process-wide limiter, so per-SKU
+// lookups made the page fan-out wait in line for nothing.
+type ProductLookupService interface {
+	LookupProductIDsBySKU(ctx context.Context, skus []string) (map[string]string, error)
+}
+
+// maxSKUSearchBatchSize caps the sku: terms OR-ed into one search, keeping the query
+// string well inside Shopify's search limits.
+const maxSKUSearchBatchSize = 50
+
+// LookupProductIDsBySKU returns the product id for every SKU that exists in Shopify,
+// keyed by the SKU as passed in (trimmed). SKUs missing from the map do not exist.
+// Matching is case-insensitive like Shopify's own sku: filter, but exact otherwise,
+// so a search hit on a different SKU is never mistaken for the one asked about.
+func (c *Client) LookupProductIDsBySKU(ctx context.Context, skus []string) (map[string]string, error) {
+	// Case variants of one SKU are searched once and all resolve to the same product.
+	wanted := make(map[string][]string, len(skus))
+	ordered := make([]string, 0, len(skus))
+	for _, sku := range skus {
+		sku = strings.TrimSpace(sku)
+		if sku == "" {
+			continue
+		}
+		key := strings.ToUpper(sku)
+		if _, seen := wanted[key]; !seen {
+			ordered = append(ordered, sku)
+		}
+		wanted[key] = append(wanted[key], sku)
+	}
+
+	query := `
+	query productVariantsBySkus($first: Int!, $after: String, $query: String!) {
+		productVariants(first: $first, after: $after, query: $query) {
+			nodes {
+				id
+				sku
+				product { id }
+			}
+			pageInfo { hasNextPage endCursor }
+		}
+	}`
+
+	found := make(map[string]string, len(ordered))
+	queries := 0
+	for start := 0; start < len(ordered); start += maxSKUSearchBatchSize {
+		end := start + maxSKUSearchBatchSize
+		if end > len(ordered) {
+			end = len(ordered)
+		}
+		terms := make([]string, 0, end-start)
+		for _, sku := range ordered[start:end] {
+			terms = append(terms, buildSearchQuery("sku", sku))
+		}
+
+		after := ""
+		for {
+			vars := map[string]any{
+				"first": 250,
+				"query": strings.Join(terms, " OR "),
+			}
+			if after != "" {
+				vars["after"] = after
+			}
+			var data productVariantSearchData
+			if err := c.graphqlRequest(ctx, query, vars, &data); err != nil {
+				c.logError("shopify product variant batch search failed", err)
+				return nil, err
+			}
+			queries++
+			for _, node := range data.ProductVariants.Nodes {
+				productID := strings.TrimSpace(node.Product.ID)
+				if productID == "" {
+					continue
+				}
+				for _, sku := range wanted[strings.ToUpper(strings.TrimSpace(node.SKU))] {
+					if _, dup := found[sku]; !dup {
+						found[sku] = productID
+					}
+				}
+			}
+			if !data.ProductVariants.PageInfo.HasNextPage || data.ProductVariants.PageInfo.EndCursor == "" {
+				break
+			}
+			after = data.ProductVariants.PageInfo.EndCursor
+		}
+	}
+
+	c.reportIncr("products", "lookup_queries", int64(queries))
+	if saved := len(skus) - queries; saved > 0 {
+		c.reportIncr("products", "lookups_saved", int64(saved))
+	}
+	return found, nil
+}
+
 func (c *Client) AttachCategoryToProduct(ctx context.Context, productCategory model.ProductCategories) {
 	if len(productCategory.Categproes) == 0 {
 		return
@@ -719,28 +879,69 @@ func suppressGraphQLErrors(ctx context.Context) bool {
 	return ok && value
 }
 
-func (c *Client) getPrimaryVariantID(ctx context.Context, productGid string) (string, error) {
+// getPrimaryVariant reads the first variant. With a locationID it also reads the
+// incoming quantity there.
+func (c *Client) getPrimaryVariant(ctx context.Context, productGid, locationID string) (primaryVariant, error) {
+	variables := map[string]any{"id": productGid}
+	levelVariable, levelSelection := "", ""
+	if locationID != "" {
+		variables["locationId"] = locationID
+		levelVariable = ", $locationId: ID!"
+		levelSelection = `
+						inventoryLevel(locationId: $locationId) {
+							quantities(names: ["` + incomingQuantityName + `"]) { name quantity }
+						}`
+	}
 	query := `
-	query productVariant($id: ID!) {
+	query productVariant($id: ID!` + levelVariable + `) {
 		product(id: $id) {
+			returnDate: metafield(namespace: "` + returnDateMetafieldNamespace + `", key: "` + returnDateMetafieldKey + `") { value }
+			incoming: metafield(namespace: "` + incomingMetafieldNamespace + `", key: "` + incomingMetafieldKey + `") { value }
 			variants(first: 1) {
-				nodes { id }
+				nodes {
+					id
+					taxable
+					inventoryItem {
+						id
+						unitCost { amount }` + levelSelection + `
+					}
+				}
 			}
 		}
 	}`
 
 	var data productVariantLookupData
-	err := c.graphqlRequest(ctx, query, map[string]any{
-		"id": productGid,
-	}, &data)
+	err := c.graphqlRequest(ctx, query, variables, &data)
 	if err != nil {
 		c.logError("shopify variant lookup failed", err)
-		return "", err
+		return primaryVariant{}, err
 	}
 	if data.Product == nil || len(data.Product.Variants.Nodes) == 0 {
-		return "", nil
+		return primaryVariant{}, nil
+	}
+	node := data.Product.Variants.Nodes[0]
+	variant := primaryVariant{ID: strings.TrimSpace(node.ID)}
+	if data.Product.ReturnDate != nil {
+		variant.ReturnDate = strings.TrimSpace(data.Product.ReturnDate.Value)
 	}
-	return strings.TrimSpace(data.Product.Variants.Nodes[0].ID), nil
+	if data.Product.Incoming != nil {
+		variant.IncomingMetafield = strings.TrimSpace(data.Product.Incoming.Value)
+	}
+	if node.Taxable != nil {
+		variant.Taxable = *node.Taxable
+		variant.TaxableKnown = true
+	}
+	if node.InventoryItem != nil {
+		variant.InventoryItemID = strings.TrimSpace(node.InventoryItem.ID)
+		variant.Incoming, variant.IncomingKnown = node.InventoryItem.InventoryLevel.Quantity(incomingQuantityName)
+	}
+	if node.InventoryItem != nil && node.InventoryItem.UnitCost != nil {
+		if parsed, err := strconv.ParseFloat(strings.TrimSpace(node.InventoryItem.UnitCost.Amount), 64); err == nil {
+			variant.Cost = parsed
+			variant.CostKnown = true
+		}
+	}
+	return variant, nil
 }
 
 // shouldTrackInventory reports whether Shopify should track inventory for a SKU.
@@ -769,16 +970,30 @@ func shouldTrackInventory(sku string, untrackedPrefixes []string) bool {
 }
 
 func (c *Client) updatePrimaryVariantIdentifiers(ctx context.Context, productGid string, product model.Product) error {
-	variantID, err := c.getPrimaryVariantID(ctx, productGid)
+	incomingLocation := c.incomingLocation(ctx, product)
+	variant, err := c.getPrimaryVariant(ctx, productGid, incomingLocation)
 	if err != nil {
 		c.logError("shopify primary variant lookup failed", err)
 		return err
 	}
-	if variantID == "" {
+	if variant.ID == "" {
 		return errors.New("shopify product has no variants to update")
 	}
 
-	variantInput := map[string]any{"id": variantID}
+	variantInput := map[string]any{"id": variant.ID}
+
+	// VAT-exempt ERP items are sent taxable=false, everything else taxable=true.
+	// Asserted on every run like `tracked`, so a flag flipped in the ERP (or by hand
+	// in the admin) is corrected on the next sync rather than charged at checkout.
+	taxable := !product.VatExempt
+	variantInput["taxable"] = taxable
+	if product.VatExempt {
+		c.reportIncr("products", "vat_exempt", 1)
+	}
+	if variant.TaxableKnown && variant.Taxable != taxable {
+		c.reportIncr("products", "taxable_changed", 1)
+		c.traceSKU(product.Sku, "product taxable changed before=%t after=%t", variant.Taxable, taxable)
+	}
 
 	if product.Sku != "" {
 		// Inventory tracking must be set here, on every create AND update. The stock
@@ -786,18 +1001,35 @@ func (c *Client) updatePrimaryVariantIdentifiers(ctx context.Context, productGid
 		// /stocksProducts feed, so anything the feed misses used to stay untracked in
 		// Shopify and could be oversold. Sent explicitly in both directions so a
 		// re-sync also corrects a variant that is in the wrong state.
-		variantInput["inventoryItem"] = map[string]any{
+		inventoryItem := map[string]any{
 			"sku":     product.Sku,
 			"tracked": c.shouldTrackInventory(product.Sku),
 		}
+		if cost, send := inventoryCostUpdate(product.PurchasePrice, variant.Cost, variant.CostKnown); send {
+			inventoryItem["cost"] = formatMoneyAmount(cost)
+			c.reportIncr("products", "cost_updated", 1)
+			c.traceSKU(product.Sku, "product cost update before=%s known=%t after=%s",
+				formatMoneyAmount(variant.Cost), variant.CostKnown, formatMoneyAmount(cost))
+		} else if product.PurchasePrice > 0 {
+			c.reportIncr("products", "cost_unchanged", 1)
+		}
+		variantInput["inventoryItem"] = inventoryItem
 
 		// DENY is Shopify's default for a new variant, but nothing here ever asserted
 		// it, so a variant switched to "continue selling when out of stock" — by hand
 		// in the admin, or by an app — stayed oversellable forever. No amount of stock
 		// accuracy helps then: pushing 0 still leaves the item buyable. Sent on every
-		// create and update for the same reason `tracked` is.
+		// create and update for the same reason `tracked` is. The only exception is a
+		// pre-order SKU with a return date still ahead (see inventoryPolicyFor). The
+		// policy is owned by this step alone; the stock step only ever writes
+		// quantities and `tracked`, so the two never overwrite each other.
 		if c.shouldTrackInventory(product.Sku) {
-			variantInput["inventoryPolicy"] = "DENY"
+			policy := c.inventoryPolicyFor(product, c.localNow())
+			variantInput["inventoryPolicy"] = policy
+			if policy == inventoryPolicyContinue {
+				c.reportIncr("products", "preorder_active", 1)
+				c.traceSKU(product.Sku, "product pre-order active until=%s incoming=%d", formatReturnDate(product.ExpectedReturnDate), product.Incoming())
+			}
 		}
 	}
 
@@ -827,96 +1059,34 @@ func (c *Client) updatePrimaryVariantIdentifiers(ctx context.Context, productGid
 		return err
 	}
 
-	return nil
-}
-
-func (c *Client) listPublicationIDs(ctx context.Context) ([]string, error) {
-	query := `
-	query publications($first: Int!, $after: String) {
-		publications(first: $first, after: $after) {
-			edges {
-				node { id }
-			}
-			pageInfo { hasNextPage endCursor }
-		}
-	}`
-
-	ids := make([]string, 0)
-	after := ""
-	for {
-		vars := map[string]any{
-			"first": 50,
-		}
-		if after != "" {
-			vars["after"] = after
-		}
-		var data publicationsQueryData
-		if err := c.graphqlRequest(ctx, query, vars, &data); err != nil {
-			return nil, err
-		}
-		for _, edge := range data.Publications.Edges {
-			if id := strings.TrimSpace(edge.Node.ID); id != "" {
-				ids = append(ids, id)
-			}
-		}
-		if !data.Publications.PageInfo.HasNextPage {
-			break
-		}
-		after = strings.TrimSpace(data.Publications.PageInfo.EndCursor)
-		if after == "" {
-			break
-		}
+	// The date is display-only for the theme; a failed write must not fail the
+	// product, whose variant is already correct at this point.
+	if err := c.syncReturnDateMetafield(ctx, productGid, product, variant.ReturnDate); err != nil {
+		c.logError("shopify expected return date metafield update failed", err)
+		c.reportWarning("products", fmt.Sprintf("expected return date not written sku=%s: %v", product.Sku, err))
+	}
+	// The quantity on order is display data too.
+	if err := c.syncIncoming(ctx, productGid, product, variant, incomingLocation); err != nil {
+		c.logError("shopify incoming quantity update failed", err)
+		c.reportWarning("products", fmt.Sprintf("incoming quantity not written sku=%s: %v", product.Sku, err))
 	}
 
-	return ids, nil
+	return nil
 }
 
-func (c *Client) publishProduct(ctx context.Context, productID string) error {
-	productID = strings.TrimSpace(productID)
-	if productID == "" {
-		return errors.New("shopify product id is required")
+// inventoryCostUpdate decides whether the ERP purchase price needs writing. A zero or
+// negative purchase price means the ERP has none, and is never sent: overwriting a
+// cost someone entered by hand with 0 would make every margin report lie. An equal
+// cost is skipped so an unchanged catalogue costs no extra writes.
+func inventoryCostUpdate(purchasePrice, current float64, currentKnown bool) (float64, bool) {
+	if purchasePrice <= 0 {
+		return 0, false
 	}
-
-	publicationIDs, err := c.listPublicationIDs(ctx)
-	if err != nil {
-		return err
+	cost := math.Round(purchasePrice*100) / 100
+	if currentKnown && math.Abs(current-cost) < 0.005 {
+		return 0, false
 	}
-	if len(publicationIDs) == 0 {
-		return errors.New("shopify publications not found")
-	}
-
-	query := `
-	mutation publishablePublish($id: ID!, $input: [PublicationInput!]!) {
-		publishablePublish(id: $id, input: $input) {
-			userErrors { field message }
-		}
-	}`
-
-	for start := 0; start < len(publicationIDs); start += maxPublicationBatchSize {
-		end := start + maxPublicationBatchSize
-		if end > len(publicationIDs) {
-			end = len(publicationIDs)
-		}
-		batch := publicationIDs[start:end]
-		input := make([]map[string]any, 0, len(batch))
-		for _, publicationID := range batch {
-			input = append(input, map[string]any{
-				"publicationId": publicationID,
-			})
-		}
-		var data publishablePublishData
-		if err := c.graphqlRequest(ctx, query, map[string]any{
-			"id":    productID,
-			"input": input,
-		}, &data); err != nil {
-			return err
-		}
-		if err := userErrorsToError("publishablePublish", data.PublishablePublish.UserErrors); err != nil {
-			return err
-		}
-	}
-
-	return nil
+	return cost, true
 }
 
 func productStatus(isPublished bool) string {
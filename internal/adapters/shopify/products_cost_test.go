@@ -0,0 +1,34 @@
+package shopify
+
+import "testing"
+
+// The ERP purchase price becomes the inventory item cost, but only when it is real
+// and actually different: a zero must never wipe a cost entered by hand, and an
+// unchanged cost must not cost a write.
+func TestInventoryCostUpdate(t *testing.T) {
+	cases := []struct {
+		name         string
+		purchase     float64
+		current      float64
+		currentKnown bool
+		want         float64
+		send         bool
+	}{
+		{"no erp cost", 0, 12.5, true, 0, false},
+		{"negative erp cost", -3, 0, false, 0, false},
+		{"shopify has no cost yet", 12.5, 0, false, 12.5, true},
+		{"unchanged", 12.5, 12.5, true, 0, false},
+		{"sub-cent noise", 12.501, 12.5, true, 0, false},
+		{"moved", 14, 12.5, true, 14, true},
+		{"rounded to cents", 9.999, 0, false, 10, true},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, send := inventoryCostUpdate(tc.purchase, tc.current, tc.currentKnown)
+			if send != tc.send || got != tc.want {
+				t.Errorf("inventoryCostUpdate(%v, %v, %t) = %v, %t; want %v, %t",
+					tc.purchase, tc.current, tc.currentKnown, got, send, tc.want, tc.send)
+			}
+		})
+	}
+}
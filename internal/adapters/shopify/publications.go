@@ -0,0 +1,410 @@
+package shopify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"shopify-exporter/internal/adapters/shopify/dto"
+	"shopify-exporter/internal/config"
+	"shopify-exporter/internal/domain/model"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// b2bCatalogType is the GraphQL type of a B2B (company location) catalog.
+const b2bCatalogType = "CompanyLocationCatalog"
+
+// publication is a Shopify sales channel or B2B catalog a product can be published
+// to. Name is the channel name ("Online Store", "Point of Sale", ...) and Title the
+// title of the catalog behind it; configured names match either. B2B marks a B2B
+// catalog, which only the B2B sync's customers see: it is never part of "every
+// publication" and is published to only when named.
+type publication struct {
+	ID    string
+	Name  string
+	Title string
+	B2B   bool
+}
+
+func (p publication) matches(name string) bool {
+	name = strings.TrimSpace(name)
+	return strings.EqualFold(p.Name, name) || (p.Title != "" && strings.EqualFold(p.Title, name))
+}
+
+type publicationsQueryData struct {
+	Publications struct {
+		Nodes []struct {
+			ID      string `json:"id,omitempty"`
+			Name    string `json:"name,omitempty"`
+			Catalog *struct {
+				Title    string `json:"title,omitempty"`
+				TypeName string `json:"__typename,omitempty"`
+			} `json:"catalog,omitempty"`
+		} `json:"nodes,omitempty"`
+		PageInfo dto.ShopifyPageInfo `json:"pageInfo,omitempty"`
+	} `json:"publications"`
+}
+
+type productPublicationsData struct {
+	Product *struct {
+		ResourcePublications struct {
+			Nodes []struct {
+				Publication struct {
+					ID string `json:"id,omitempty"`
+				} `json:"publication"`
+			} `json:"nodes,omitempty"`
+			PageInfo dto.ShopifyPageInfo `json:"pageInfo,omitempty"`
+		} `json:"resourcePublications"`
+	} `json:"product,omitempty"`
+}
+
+type publishableUnpublishData struct {
+	PublishableUnpublish struct {
+		UserErrors []dto.ShopifyUserError `json:"userErrors,omitempty"`
+	} `json:"publishableUnpublish"`
+}
+
+// syncProductPublications publishes the product to the publications its ERP kind
+// maps to and unpublishes it from every other one, so an item that stops being a web
+// item (or is deactivated in the ERP) leaves the channels it used to be on. isNew
+// skips the lookup of current publications for a product created moments ago.
+// Without SHOPIFY_CHANNEL_PUBLICATIONS it only publishes, see publishEverywhere.
+func (c *Client) syncProductPublications(ctx context.Context, productID string, product model.Product, isNew bool) error {
+	productID = strings.TrimSpace(productID)
+	if productID == "" {
+		return errors.New("shopify product id is required")
+	}
+
+	all, err := c.listPublications(ctx)
+	if err != nil {
+		return err
+	}
+	rules := c.config.ChannelPublications
+	if len(rules) == 0 {
+		return c.publishEverywhere(ctx, productID, product, all)
+	}
+	names := channelPublicationNames(product.WebItem, product.IsPublished, rules)
+	target := c.resolvePublications(all, names)
+	if len(names) == 0 {
+		c.reportIncr("products", "not_web_item", 1)
+	}
+
+	var current []string
+	if !isNew {
+		current, err = c.productPublicationIDs(ctx, productID)
+		if err != nil {
+			return err
+		}
+	}
+
+	add, remove := diffIDs(current, target)
+	if len(add) > 0 {
+		if err := c.publishToPublications(ctx, productID, add); err != nil {
+			return err
+		}
+		c.reportIncr("products", "publications_added", int64(len(add)))
+	}
+	if len(remove) > 0 {
+		if err := c.unpublishFromPublications(ctx, productID, remove); err != nil {
+			return err
+		}
+		c.reportIncr("products", "publications_removed", int64(len(remove)))
+	}
+	if len(add) > 0 || len(remove) > 0 {
+		c.traceSKU(product.Sku, "product publications web_item=%d active=%t added=%d removed=%d",
+			product.WebItem, product.IsPublished, len(add), len(remove))
+	}
+	return nil
+}
+
+// publishEverywhere publishes an active web item to every publication but the B2B
+// catalogs, and leaves anything else alone: an inactive product's DRAFT status
+// already hides it, and a product that is not a web item (WebItem=0) is not put on
+// any channel. Per-kind publishing unpublishes, so it has to be asked for with
+// SHOPIFY_CHANNEL_PUBLICATIONS.
+func (c *Client) publishEverywhere(ctx context.Context, productID string, product model.Product, all []publication) error {
+	if !product.IsPublished {
+		return nil
+	}
+	if product.WebItem == 0 {
+		c.reportIncr("products", "not_web_item", 1)
+		return nil
+	}
+	return c.publishToPublications(ctx, productID, everywherePublicationIDs(all))
+}
+
+// everywherePublicationIDs is "every publication": every sales channel and market
+// catalog, without the B2B catalogs.
+func everywherePublicationIDs(all []publication) []string {
+	ids := make([]string, 0, len(all))
+	for _, pub := range all {
+		if !pub.B2B {
+			ids = append(ids, pub.ID)
+		}
+	}
+	return ids
+}
+
+// channelPublicationNames returns the configured publication names for an item, or
+// nil when it belongs on no channel: inactive in the ERP, not a web item (WebItem=0,
+// unless "0" is mapped explicitly), or a kind with no rule and no "*" rule.
+func channelPublicationNames(webItem int, active bool, rules map[string][]string) []string {
+	if !active {
+		return nil
+	}
+	if names, ok := rules[strconv.Itoa(webItem)]; ok {
+		return names
+	}
+	if webItem == 0 {
+		return nil
+	}
+	return rules[config.ChannelPublicationsAll]
+}
+
+// resolvePublications maps configured names to publication IDs, case-insensitively.
+// A name that matches nothing is warned about once per process and otherwise
+// ignored, so a renamed channel shows up in the report instead of failing products.
+func (c *Client) resolvePublications(all []publication, names []string) []string {
+	ids := make([]string, 0, len(names))
+	seen := make(map[string]bool, len(names))
+	for _, name := range names {
+		if strings.TrimSpace(name) == config.ChannelPublicationsAll {
+			for _, id := range everywherePublicationIDs(all) {
+				if !seen[id] {
+					seen[id] = true
+					ids = append(ids, id)
+				}
+			}
+			continue
+		}
+		found := false
+		for _, pub := range all {
+			if pub.matches(name) {
+				found = true
+				if !seen[pub.ID] {
+					seen[pub.ID] = true
+					ids = append(ids, pub.ID)
+				}
+			}
+		}
+		if !found {
+			c.warnMissingPublication(name)
+		}
+	}
+	return ids
+}
+
+func (c *Client) warnMissingPublication(name string) {
+	key := strings.ToLower(strings.TrimSpace(name))
+	c.publicationMu.Lock()
+	if c.publicationsMissing == nil {
+		c.publicationsMissing = make(map[string]bool)
+	}
+	warned := c.publicationsMissing[key]
+	c.publicationsMissing[key] = true
+	c.publicationMu.Unlock()
+	if warned {
+		return
+	}
+	message := fmt.Sprintf("shopify publication not found name=%q (check SHOPIFY_CHANNEL_PUBLICATIONS)", name)
+	c.logWarning(message)
+	c.reportWarning("products", message)
+}
+
+// diffIDs returns the ids to add and to remove to get from current to target
+// (publications, collection members), both sorted so the trace and the mutations are
+// stable between runs.
+func diffIDs(current, target []string) ([]string, []string) {
+	currentSet := make(map[string]bool, len(current))
+	for _, id := range current {
+		currentSet[id] = true
+	}
+	targetSet := make(map[string]bool, len(target))
+	for _, id := range target {
+		targetSet[id] = true
+	}
+
+	add := make([]string, 0)
+	for id := range targetSet {
+		if !currentSet[id] {
+			add = append(add, id)
+		}
+	}
+	remove := make([]string, 0)
+	for id := range currentSet {
+		if !targetSet[id] {
+			remove = append(remove, id)
+		}
+	}
+	sort.Strings(add)
+	sort.Strings(remove)
+	return add, remove
+}
+
+// listPublications returns every publication in the store, fetched once per process.
+func (c *Client) listPublications(ctx context.Context) ([]publication, error) {
+	c.publicationMu.Lock()
+	cached := c.publications
+	c.publicationMu.Unlock()
+	if cached != nil {
+		return cached, nil
+	}
+
+	query := `
+	query publications($first: Int!, $after: String) {
+		publications(first: $first, after: $after) {
+			nodes { id name catalog { title __typename } }
+			pageInfo { hasNextPage endCursor }
+		}
+	}`
+
+	results := make([]publication, 0)
+	after := ""
+	for {
+		vars := map[string]any{
+			"first": maxPublicationBatchSize,
+		}
+		if after != "" {
+			vars["after"] = after
+		}
+		var data publicationsQueryData
+		if err := c.graphqlRequest(ctx, query, vars, &data); err != nil {
+			return nil, err
+		}
+		for _, node := range data.Publications.Nodes {
+			id := strings.TrimSpace(node.ID)
+			if id == "" {
+				continue
+			}
+			pub := publication{ID: id, Name: strings.TrimSpace(node.Name)}
+			if node.Catalog != nil {
+				pub.Title = strings.TrimSpace(node.Catalog.Title)
+				pub.B2B = node.Catalog.TypeName == b2bCatalogType
+			}
+			results = append(results, pub)
+		}
+		if !data.Publications.PageInfo.HasNextPage {
+			break
+		}
+		after = strings.TrimSpace(data.Publications.PageInfo.EndCursor)
+		if after == "" {
+			break
+		}
+	}
+	if len(results) == 0 {
+		return nil, errors.New("shopify publications not found")
+	}
+
+	c.publicationMu.Lock()
+	c.publications = results
+	c.publicationMu.Unlock()
+	return results, nil
+}
+
+// productPublicationIDs returns the publications the product is currently published to.
+func (c *Client) productPublicationIDs(ctx context.Context, productID string) ([]string, error) {
+	query := `
+	query productPublications($id: ID!, $first: Int!, $after: String) {
+		product(id: $id) {
+			resourcePublications(first: $first, after: $after) {
+				nodes { publication { id } }
+				pageInfo { hasNextPage endCursor }
+			}
+		}
+	}`
+
+	ids := make([]string, 0)
+	after := ""
+	for {
+		vars := map[string]any{
+			"id":    productID,
+			"first": maxPublicationBatchSize,
+		}
+		if after != "" {
+			vars["after"] = after
+		}
+		var data productPublicationsData
+		if err := c.graphqlRequest(ctx, query, vars, &data); err != nil {
+			return nil, err
+		}
+		if data.Product == nil {
+			return ids, nil
+		}
+		for _, node := range data.Product.ResourcePublications.Nodes {
+			if id := strings.TrimSpace(node.Publication.ID); id != "" {
+				ids = append(ids, id)
+			}
+		}
+		if !data.Product.ResourcePublications.PageInfo.HasNextPage {
+			break
+		}
+		after = strings.TrimSpace(data.Product.ResourcePublications.PageInfo.EndCursor)
+		if after == "" {
+			break
+		}
+	}
+	return ids, nil
+}
+
+func (c *Client) publishToPublications(ctx context.Context, productID string, publicationIDs []string) error {
+	query := `
+	mutation publishablePublish($id: ID!, $input: [PublicationInput!]!) {
+		publishablePublish(id: $id, input: $input) {
+			userErrors { field message }
+		}
+	}`
+
+	for start := 0; start < len(publicationIDs); start += maxPublicationBatchSize {
+		end := start + maxPublicationBatchSize
+		if end > len(publicationIDs) {
+			end = len(publicationIDs)
+		}
+		var data publishablePublishData
+		if err := c.graphqlRequest(ctx, query, map[string]any{
+			"id":    productID,
+			"input": publicationInputs(publicationIDs[start:end]),
+		}, &data); err != nil {
+			return err
+		}
+		if err := userErrorsToError("publishablePublish", data.PublishablePublish.UserErrors); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *Client) unpublishFromPublications(ctx context.Context, productID string, publicationIDs []string) error {
+	query := `
+	mutation publishableUnpublish($id: ID!, $input: [PublicationInput!]!) {
+		publishableUnpublish(id: $id, input: $input) {
+			userErrors { field message }
+		}
+	}`
+
+	for start := 0; start < len(publicationIDs); start += maxPublicationBatchSize {
+		end := start + maxPublicationBatchSize
+		if end > len(publicationIDs) {
+			end = len(publicationIDs)
+		}
+		var data publishableUnpublishData
+		if err := c.graphqlRequest(ctx, query, map[string]any{
+			"id":    productID,
+			"input": publicationInputs(publicationIDs[start:end]),
+		}, &data); err != nil {
+			return err
+		}
+		if err := userErrorsToError("publishableUnpublish", data.PublishableUnpublish.UserErrors); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func publicationInputs(publicationIDs []string) []map[string]any {
+	input := make([]map[string]any, 0, len(publicationIDs))
+	for _, publicationID := range publicationIDs {
+		input = append(input, map[string]any{"publicationId": publicationID})
+	}
+	return input
+}
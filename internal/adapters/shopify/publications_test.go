@@ -0,0 +1,79 @@
+package shopify
+
+import (
+	"context"
+	"reflect"
+	"shopify-exporter/internal/domain/model"
+	"testing"
+)
+
+// TestChannelPublicationNames covers which configured channels an ERP item goes to:
+// nothing when inactive or not a web item, its own kind first, then the "*" rule.
+func TestChannelPublicationNames(t *testing.T) {
+	rules := map[string][]string{
+		"2": {"Point of Sale"},
+		"*": {"Online Store", "Point of Sale"},
+	}
+
+	tests := []struct {
+		name    string
+		webItem int
+		active  bool
+		rules   map[string][]string
+		want    []string
+	}{
+		{"inactive web item", 1, false, rules, nil},
+		{"not a web item", 0, true, rules, nil},
+		{"own kind", 2, true, rules, []string{"Point of Sale"}},
+		{"falls back to wildcard", 1, true, rules, []string{"Online Store", "Point of Sale"}},
+		{"zero mapped explicitly", 0, true, map[string][]string{"0": {"Point of Sale"}}, []string{"Point of Sale"}},
+		{"no wildcard rule", 1, true, map[string][]string{"2": {"Point of Sale"}}, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := channelPublicationNames(tt.webItem, tt.active, tt.rules)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("channelPublicationNames() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestDiffPublications checks that only missing channels are published and only
+// channels the item no longer qualifies for are unpublished.
+func TestDiffPublications(t *testing.T) {
+	add, remove := diffIDs([]string{"pos", "online"}, []string{"online", "google"})
+	if !reflect.DeepEqual(add, []string{"google"}) {
+		t.Fatalf("add = %v, want [google]", add)
+	}
+	if !reflect.DeepEqual(remove, []string{"pos"}) {
+		t.Fatalf("remove = %v, want [pos]", remove)
+	}
+}
+
+// "Every publication", the default and the "*" name, leaves the B2B catalogs out;
+// naming one still publishes to it.
+func TestEverywhereLeavesOutB2BCatalogs(t *testing.T) {
+	all := []publication{
+		{ID: "online", Name: "Online Store"},
+		{ID: "israel", Name: "Israel Catalog", Title: "Israel Catalog"},
+		{ID: "b2b", Title: "Wholesale", B2B: true},
+	}
+	if got := everywherePublicationIDs(all); !reflect.DeepEqual(got, []string{"online", "israel"}) {
+		t.Fatalf("everywherePublicationIDs() = %v, want [online israel]", got)
+	}
+	client := &Client{}
+	if got := client.resolvePublications(all, []string{"*", "Wholesale"}); !reflect.DeepEqual(got, []string{"online", "israel", "b2b"}) {
+		t.Fatalf("resolvePublications(*, Wholesale) = %v, want the B2B catalog only by name", got)
+	}
+}
+
+// Without per-kind rules an item that is not a web item is put on no channel.
+func TestPublishEverywhereSkipsNonWebItems(t *testing.T) {
+	client := &Client{}
+	product := model.Product{Sku: "A-1", IsPublished: true, WebItem: 0}
+	if err := client.publishEverywhere(context.Background(), "gid://shopify/Product/1", product, []publication{{ID: "online"}}); err != nil {
+		t.Fatalf("publishEverywhere(WebItem=0) = %v, want nothing sent", err)
+	}
+}
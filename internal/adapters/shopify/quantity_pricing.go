@@ -0,0 +1,264 @@
+package shopify
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"shopify-exporter/internal/adapters/shopify/dto"
+)
+
+// QuantityBreak is a volume price: from MinimumQuantity units on, each costs Price
+// in the price list's currency.
+type QuantityBreak struct {
+	MinimumQuantity int
+	Price           float64
+}
+
+// QuantityRule limits the quantities a B2B customer can order: at least Minimum, at
+// most Maximum (0 for no limit), in steps of Increment. The zero value is no rule.
+type QuantityRule struct {
+	Minimum   int
+	Maximum   int
+	Increment int
+}
+
+// IsZero reports whether the rule is unset.
+func (r QuantityRule) IsZero() bool {
+	return r == QuantityRule{}
+}
+
+// ValidateQuantityPricing checks the volume pricing against the unit price in
+// currency, the way Shopify will: breaks strictly ascending in quantity and strictly
+// descending in price, all below the unit price, and every break quantity allowed by
+// the quantity rule. Shopify rejects a whole batch for one bad variant, so a bad SKU
+// is caught here instead.
+func (p PriceUpsertInput) ValidateQuantityPricing(currency string) error {
+	rule := p.QuantityRule
+	if !rule.IsZero() {
+		increment := rule.Increment
+		if increment == 0 {
+			increment = 1
+		}
+		switch {
+		case rule.Minimum < 0 || rule.Maximum < 0 || rule.Increment < 0:
+			return fmt.Errorf("quantity rule %+v has a negative value", rule)
+		case rule.Minimum > 0 && rule.Minimum%increment != 0:
+			return fmt.Errorf("minimum %d is not a multiple of the increment %d", rule.Minimum, increment)
+		case rule.Maximum > 0 && rule.Maximum < rule.Minimum:
+			return fmt.Errorf("maximum %d is below the minimum %d", rule.Maximum, rule.Minimum)
+		case rule.Maximum > 0 && rule.Maximum%increment != 0:
+			return fmt.Errorf("maximum %d is not a multiple of the increment %d", rule.Maximum, increment)
+		}
+	}
+
+	if len(p.QuantityBreaks) == 0 {
+		return nil
+	}
+	unit, ok := p.Prices[currency]
+	if !ok || unit <= 0 {
+		return fmt.Errorf("quantity breaks without a unit price in %s", currency)
+	}
+	previousQuantity, previousPrice := 1, unit
+	for _, brk := range p.QuantityBreaks {
+		switch {
+		case brk.MinimumQuantity <= previousQuantity:
+			return fmt.Errorf("break at %d units does not come after %d units", brk.MinimumQuantity, previousQuantity)
+		case brk.Price <= 0:
+			return fmt.Errorf("break at %d units has no price", brk.MinimumQuantity)
+		case brk.Price >= previousPrice:
+			return fmt.Errorf("break at %d units costs %.2f, not less than %.2f", brk.MinimumQuantity, brk.Price, previousPrice)
+		case rule.Increment > 1 && brk.MinimumQuantity%rule.Increment != 0:
+			return fmt.Errorf("break at %d units is not a multiple of the increment %d", brk.MinimumQuantity, rule.Increment)
+		case rule.Maximum > 0 && brk.MinimumQuantity > rule.Maximum:
+			return fmt.Errorf("break at %d units is above the maximum %d", brk.MinimumQuantity, rule.Maximum)
+		}
+		previousQuantity, previousPrice = brk.MinimumQuantity, brk.Price
+	}
+	return nil
+}
+
+// syncQuantityPricing replaces the volume pricing of the given variants on the price
+// list: the breaks and rule each item carries, and none for an item that carries
+// none but had some. Variants in clear lose theirs entirely. It runs after the fixed
+// prices are written, because Shopify only accepts breaks on a fixed price.
+func (c *Client) syncQuantityPricing(ctx context.Context, priceListID, currency string, items []resolvedPriceInput, clear []string) (int, error) {
+	withRules, withBreaks, err := c.quantityPricingVariants(ctx, priceListID)
+	if err != nil {
+		return 0, err
+	}
+
+	type change struct {
+		variantID string
+		item      *resolvedPriceInput
+	}
+	changes := make([]change, 0)
+	for i := range items {
+		item := &items[i]
+		if len(item.QuantityBreaks) > 0 || !item.QuantityRule.IsZero() || withRules[item.VariantID] || withBreaks[item.VariantID] {
+			changes = append(changes, change{variantID: item.VariantID, item: item})
+		}
+	}
+	for _, variantID := range clear {
+		if withRules[variantID] || withBreaks[variantID] {
+			changes = append(changes, change{variantID: variantID})
+		}
+	}
+	if len(changes) == 0 {
+		return 0, nil
+	}
+
+	query := `
+	mutation quantityPricingByVariantUpdate($priceListId: ID!, $input: QuantityPricingByVariantUpdateInput!) {
+		quantityPricingByVariantUpdate(priceListId: $priceListId, input: $input) {
+			productVariants { id }
+			userErrors { field message }
+		}
+	}`
+
+	for start := 0; start < len(changes); start += maxFixedPriceBatchSize {
+		end := start + maxFixedPriceBatchSize
+		if end > len(changes) {
+			end = len(changes)
+		}
+		rulesToAdd := make([]map[string]any, 0)
+		rulesToDelete := make([]string, 0)
+		breaksToAdd := make([]map[string]any, 0)
+		breaksToDelete := make([]string, 0)
+		for _, ch := range changes[start:end] {
+			// Breaks are replaced wholesale: deleting by variant and adding the
+			// current set keeps no stale break a changed ERP list dropped.
+			if withBreaks[ch.variantID] {
+				breaksToDelete = append(breaksToDelete, ch.variantID)
+			}
+			if ch.item == nil || ch.item.QuantityRule.IsZero() {
+				if withRules[ch.variantID] {
+					rulesToDelete = append(rulesToDelete, ch.variantID)
+				}
+			} else {
+				rulesToAdd = append(rulesToAdd, quantityRuleInput(ch.variantID, ch.item.QuantityRule))
+			}
+			if ch.item == nil {
+				continue
+			}
+			for _, brk := range ch.item.QuantityBreaks {
+				breaksToAdd = append(breaksToAdd, map[string]any{
+					"variantId":       ch.variantID,
+					"minimumQuantity": brk.MinimumQuantity,
+					"price": map[string]any{
+						"amount":       formatMoneyAmount(brk.Price),
+						"currencyCode": currency,
+					},
+				})
+			}
+			c.traceSKU(ch.item.SKU, "price quantity mutation price_list_id=%s variant_id=%s rule=%+v breaks=%d", priceListID, ch.variantID, ch.item.QuantityRule, len(ch.item.QuantityBreaks))
+		}
+
+		input := map[string]any{
+			"pricesToAdd":                            []any{},
+			"pricesToDeleteByVariantId":              []string{},
+			"quantityRulesToAdd":                     rulesToAdd,
+			"quantityRulesToDeleteByVariantId":       rulesToDelete,
+			"quantityPriceBreaksToAdd":               breaksToAdd,
+			"quantityPriceBreaksToDelete":            []string{},
+			"quantityPriceBreaksToDeleteByVariantId": breaksToDelete,
+		}
+		var data dto.QuantityPricingByVariantUpdateData
+		if err := c.graphqlRequest(ctx, query, map[string]any{
+			"priceListId": priceListID,
+			"input":       input,
+		}, &data); err != nil {
+			return 0, err
+		}
+		if err := userErrorsToDetailedError("quantityPricingByVariantUpdate", data.QuantityPricingByVariantUpdate.UserErrors); err != nil {
+			return 0, err
+		}
+	}
+	return len(changes), nil
+}
+
+func quantityRuleInput(variantID string, rule QuantityRule) map[string]any {
+	increment := rule.Increment
+	if increment == 0 {
+		increment = 1
+	}
+	minimum := rule.Minimum
+	if minimum == 0 {
+		minimum = increment
+	}
+	input := map[string]any{
+		"variantId": variantID,
+		"minimum":   minimum,
+		"increment": increment,
+	}
+	if rule.Maximum > 0 {
+		input["maximum"] = rule.Maximum
+	}
+	return input
+}
+
+// quantityPricingVariants returns the variants that have a quantity rule and the
+// ones that have quantity breaks on the price list, so only those are cleared.
+func (c *Client) quantityPricingVariants(ctx context.Context, priceListID string) (map[string]bool, map[string]bool, error) {
+	query := `
+	query priceListQuantityPricing($id: ID!, $first: Int!, $afterRules: String, $afterPrices: String, $rules: Boolean!, $prices: Boolean!) {
+		priceList(id: $id) {
+			quantityRules(first: $first, after: $afterRules, originType: FIXED) @include(if: $rules) {
+				nodes { productVariant { id } }
+				pageInfo { hasNextPage endCursor }
+			}
+			prices(first: $first, after: $afterPrices, originType: FIXED) @include(if: $prices) {
+				nodes {
+					variant { id }
+					quantityPriceBreaks(first: 1) { nodes { id } }
+				}
+				pageInfo { hasNextPage endCursor }
+			}
+		}
+	}`
+
+	withRules := make(map[string]bool)
+	withBreaks := make(map[string]bool)
+	afterRules, afterPrices := "", ""
+	moreRules, morePrices := true, true
+	for moreRules || morePrices {
+		variables := map[string]any{
+			"id":     priceListID,
+			"first":  maxFixedPriceBatchSize,
+			"rules":  moreRules,
+			"prices": morePrices,
+		}
+		if afterRules != "" {
+			variables["afterRules"] = afterRules
+		}
+		if afterPrices != "" {
+			variables["afterPrices"] = afterPrices
+		}
+		var data dto.PriceListQuantityPricingData
+		if err := c.graphqlRequest(ctx, query, variables, &data); err != nil {
+			return nil, nil, err
+		}
+		if data.PriceList == nil {
+			break
+		}
+		if moreRules {
+			rules := data.PriceList.QuantityRules
+			for _, node := range rules.Nodes {
+				withRules[strings.TrimSpace(node.ProductVariant.ID)] = true
+			}
+			afterRules = strings.TrimSpace(rules.PageInfo.EndCursor)
+			moreRules = rules.PageInfo.HasNextPage && afterRules != ""
+		}
+		if morePrices {
+			prices := data.PriceList.Prices
+			for _, node := range prices.Nodes {
+				if len(node.QuantityPriceBreaks.Nodes) > 0 {
+					withBreaks[strings.TrimSpace(node.Variant.ID)] = true
+				}
+			}
+			afterPrices = strings.TrimSpace(prices.PageInfo.EndCursor)
+			morePrices = prices.PageInfo.HasNextPage && afterPrices != ""
+		}
+	}
+	return withRules, withBreaks, nil
+}
@@ -0,0 +1,36 @@
+package shopify
+
+import "testing"
+
+// TestValidateQuantityPricing: breaks must climb in quantity and drop in price below
+// the unit price, and fit the quantity rule, or Shopify rejects the whole batch.
+func TestValidateQuantityPricing(t *testing.T) {
+	unit := map[string]float64{"ILS": 100}
+	cases := []struct {
+		name  string
+		input PriceUpsertInput
+		ok    bool
+	}{
+		{"no volume pricing", PriceUpsertInput{Prices: unit}, true},
+		{"monotonic breaks", PriceUpsertInput{Prices: unit, QuantityBreaks: []QuantityBreak{{10, 90}, {50, 80}}}, true},
+		{"break at one unit", PriceUpsertInput{Prices: unit, QuantityBreaks: []QuantityBreak{{1, 90}}}, false},
+		{"same quantity twice", PriceUpsertInput{Prices: unit, QuantityBreaks: []QuantityBreak{{10, 90}, {10, 85}}}, false},
+		{"break above the unit price", PriceUpsertInput{Prices: unit, QuantityBreaks: []QuantityBreak{{10, 100}}}, false},
+		{"price rises with quantity", PriceUpsertInput{Prices: unit, QuantityBreaks: []QuantityBreak{{10, 80}, {50, 85}}}, false},
+		{"breaks without a unit price", PriceUpsertInput{Prices: map[string]float64{"USD": 30}, QuantityBreaks: []QuantityBreak{{10, 25}}}, false},
+		{"rule fits breaks", PriceUpsertInput{Prices: unit, QuantityRule: QuantityRule{Minimum: 6, Maximum: 120, Increment: 6}, QuantityBreaks: []QuantityBreak{{24, 90}}}, true},
+		{"break off the increment", PriceUpsertInput{Prices: unit, QuantityRule: QuantityRule{Increment: 6}, QuantityBreaks: []QuantityBreak{{10, 90}}}, false},
+		{"break above the maximum", PriceUpsertInput{Prices: unit, QuantityRule: QuantityRule{Maximum: 20}, QuantityBreaks: []QuantityBreak{{50, 90}}}, false},
+		{"minimum off the increment", PriceUpsertInput{Prices: unit, QuantityRule: QuantityRule{Minimum: 5, Increment: 6}}, false},
+		{"maximum below the minimum", PriceUpsertInput{Prices: unit, QuantityRule: QuantityRule{Minimum: 10, Maximum: 5}}, false},
+	}
+	for _, tc := range cases {
+		err := tc.input.ValidateQuantityPricing("ILS")
+		if tc.ok && err != nil {
+			t.Errorf("%s: unexpected error %v", tc.name, err)
+		}
+		if !tc.ok && err == nil {
+			t.Errorf("%s: accepted", tc.name)
+		}
+	}
+}
@@ -32,13 +32,14 @@ func (c *Client) recorder() report.Recorder {
 	return c.reporter
 }
 
-// reportStockSeen records one SKU's quantity outcome after a successful mutation.
+// reportStockSeen records one SKU's quantity outcome at its location after a
+// successful mutation.
 func (c *Client) reportStockSeen(item resolvedStockInput) {
 	recorder := c.recorder()
 	if recorder == nil {
 		return
 	}
-	recorder.StockSeen(item.SKU, item.BeforeQuantity, item.BeforeKnown, item.Quantity)
+	recorder.StockSeenAt(item.SKU, item.Location, item.BeforeQuantity, item.BeforeKnown, item.Quantity)
 }
 
 // reportPriceSeen records one SKU's price outcome for a single currency.
@@ -0,0 +1,294 @@
+package shopify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"shopify-exporter/internal/adapters/shopify/dto"
+	"sort"
+	"strings"
+)
+
+// SaleCollectionService keeps the sale collection in step with the discounted SKUs.
+type SaleCollectionService interface {
+	SyncSaleCollection(ctx context.Context, title string, skus []string, prune bool) error
+}
+
+const maxCollectionProductsBatchSize = 250
+
+// maxSaleCollectionMatches is how many title matches are read to tell an exact title
+// from a collection that merely contains it.
+const maxSaleCollectionMatches = 25
+
+type collectionProductsData struct {
+	Collection *struct {
+		Products struct {
+			Nodes []struct {
+				ID       string `json:"id,omitempty"`
+				Variants struct {
+					Nodes []struct {
+						SKU string `json:"sku,omitempty"`
+					} `json:"nodes,omitempty"`
+				} `json:"variants"`
+			} `json:"nodes,omitempty"`
+			PageInfo dto.ShopifyPageInfo `json:"pageInfo,omitempty"`
+		} `json:"products"`
+	} `json:"collection,omitempty"`
+}
+
+type collectionRemoveProductsData struct {
+	CollectionRemoveProducts struct {
+		UserErrors []dto.ShopifyUserError `json:"userErrors,omitempty"`
+	} `json:"collectionRemoveProducts"`
+}
+
+// SyncSaleCollection makes the collection titled title hold exactly the products of
+// the given SKUs. With prune=false (a run filtered to a few SKUs) products are only
+// added: the rest of the catalogue was not priced, so its absence means nothing.
+// The collection must be a manual one; the category step creates it as such.
+// Products already in the collection are matched by their variants' SKUs, so only
+// SKUs newly on sale are looked up.
+func (c *Client) SyncSaleCollection(ctx context.Context, title string, skus []string, prune bool) error {
+	collectionID, err := c.findSaleCollection(ctx, title)
+	if err != nil {
+		return err
+	}
+	if collectionID == "" {
+		collectionID, err = c.createCollection(ctx, title)
+		if err != nil {
+			return err
+		}
+		c.logSuccess(fmt.Sprintf("shopify sale collection created title=%s id=%s", title, collectionID))
+	}
+
+	members, err := c.collectionProductSKUs(ctx, collectionID)
+	if err != nil {
+		return err
+	}
+	missing, remove := planSaleCollection(members, skus)
+	if !prune {
+		remove = nil
+	}
+
+	add := make([]string, 0)
+	if len(missing) > 0 {
+		productIDs, err := c.LookupProductIDsBySKU(ctx, missing)
+		if err != nil {
+			return err
+		}
+		seen := make(map[string]bool, len(productIDs))
+		for _, productID := range productIDs {
+			if _, member := members[productID]; !member && !seen[productID] {
+				seen[productID] = true
+				add = append(add, productID)
+			}
+		}
+		sort.Strings(add)
+	}
+
+	if len(add) > 0 {
+		if err := c.addProductsToCollection(ctx, collectionID, add); err != nil {
+			return err
+		}
+		c.reportIncr("price", "sale_added", int64(len(add)))
+	}
+	if len(remove) > 0 {
+		if err := c.removeProductsFromCollection(ctx, collectionID, remove); err != nil {
+			return err
+		}
+		c.reportIncr("price", "sale_removed", int64(len(remove)))
+	}
+	c.logSuccess(fmt.Sprintf("shopify sale collection synced title=%s products=%d added=%d removed=%d", title, len(members)+len(add)-len(remove), len(add), len(remove)))
+	return nil
+}
+
+// planSaleCollection splits the discounted SKUs against the collection's members
+// (product id -> variant SKUs): the SKUs no member carries, to be looked up and
+// added, and the members that carry none of them, to be removed. SKUs compare
+// trimmed and case-insensitively.
+func planSaleCollection(members map[string][]string, skus []string) ([]string, []string) {
+	wanted := make(map[string]string, len(skus))
+	for _, sku := range skus {
+		if key := strings.ToUpper(strings.TrimSpace(sku)); key != "" {
+			if _, seen := wanted[key]; !seen {
+				wanted[key] = strings.TrimSpace(sku)
+			}
+		}
+	}
+
+	covered := make(map[string]bool, len(wanted))
+	remove := make([]string, 0)
+	for productID, variantSKUs := range members {
+		keep := false
+		for _, sku := range variantSKUs {
+			key := strings.ToUpper(strings.TrimSpace(sku))
+			if _, ok := wanted[key]; ok {
+				covered[key] = true
+				keep = true
+			}
+		}
+		if !keep {
+			remove = append(remove, productID)
+		}
+	}
+
+	missing := make([]string, 0)
+	for key, sku := range wanted {
+		if !covered[key] {
+			missing = append(missing, sku)
+		}
+	}
+	sort.Strings(missing)
+	sort.Strings(remove)
+	return missing, remove
+}
+
+// findSaleCollection resolves the sale collection by its exact title, ignoring case.
+// Shopify's title search also matches titles that only contain the words, and the
+// sale sync removes products, so a near match is never taken: none is "", and two
+// collections with the title are an error rather than a guess.
+func (c *Client) findSaleCollection(ctx context.Context, title string) (string, error) {
+	title = strings.TrimSpace(title)
+	if title == "" {
+		return "", errors.New("shopify collection title is required")
+	}
+
+	query := `
+	query collections($first: Int!, $query: String!) {
+		collections(first: $first, query: $query) {
+			nodes { id title }
+		}
+	}`
+
+	var data dto.CollectionsQueryData
+	if err := c.graphqlRequest(ctx, query, map[string]any{
+		"first": maxSaleCollectionMatches,
+		"query": buildSearchQuery("title", title),
+	}, &data); err != nil {
+		return "", err
+	}
+
+	ids := make([]string, 0, 1)
+	for _, node := range data.Collections.Nodes {
+		if strings.EqualFold(strings.TrimSpace(node.Title), title) {
+			ids = append(ids, strings.TrimSpace(node.ID))
+		}
+	}
+	if len(ids) > 1 {
+		return "", fmt.Errorf("%d collections are titled %q (%s); rename all but the sale collection", len(ids), title, strings.Join(ids, ", "))
+	}
+	if len(ids) == 0 {
+		return "", nil
+	}
+	return ids[0], nil
+}
+
+// collectionProductSKUs returns the collection's products with their variants' SKUs.
+func (c *Client) collectionProductSKUs(ctx context.Context, collectionID string) (map[string][]string, error) {
+	query := `
+	query collectionProducts($id: ID!, $first: Int!, $after: String) {
+		collection(id: $id) {
+			products(first: $first, after: $after) {
+				nodes {
+					id
+					variants(first: 10) { nodes { sku } }
+				}
+				pageInfo { hasNextPage endCursor }
+			}
+		}
+	}`
+
+	members := make(map[string][]string)
+	after := ""
+	for {
+		vars := map[string]any{
+			"id":    collectionID,
+			"first": maxCollectionProductsBatchSize,
+		}
+		if after != "" {
+			vars["after"] = after
+		}
+		var data collectionProductsData
+		if err := c.graphqlRequest(ctx, query, vars, &data); err != nil {
+			return nil, err
+		}
+		if data.Collection == nil {
+			return nil, errors.New("shopify collection not found")
+		}
+		for _, node := range data.Collection.Products.Nodes {
+			id := strings.TrimSpace(node.ID)
+			if id == "" {
+				continue
+			}
+			skus := make([]string, 0, len(node.Variants.Nodes))
+			for _, variant := range node.Variants.Nodes {
+				skus = append(skus, variant.SKU)
+			}
+			members[id] = skus
+		}
+		if !data.Collection.Products.PageInfo.HasNextPage {
+			break
+		}
+		after = strings.TrimSpace(data.Collection.Products.PageInfo.EndCursor)
+		if after == "" {
+			break
+		}
+	}
+	return members, nil
+}
+
+func (c *Client) addProductsToCollection(ctx context.Context, collectionID string, productIDs []string) error {
+	query := `
+	mutation collectionAddProducts($id: ID!, $productIds: [ID!]!) {
+		collectionAddProducts(id: $id, productIds: $productIds) {
+			userErrors { field message }
+		}
+	}`
+
+	for start := 0; start < len(productIDs); start += maxCollectionProductsBatchSize {
+		end := start + maxCollectionProductsBatchSize
+		if end > len(productIDs) {
+			end = len(productIDs)
+		}
+		var data dto.CollectionAddProductsData
+		if err := c.graphqlRequest(ctx, query, map[string]any{
+			"id":         collectionID,
+			"productIds": productIDs[start:end],
+		}, &data); err != nil {
+			return err
+		}
+		if err := userErrorsToError("collectionAddProducts", data.CollectionAddProducts.UserErrors); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// removeProductsFromCollection starts Shopify's asynchronous removal job; the
+// products leave the collection shortly after the call returns.
+func (c *Client) removeProductsFromCollection(ctx context.Context, collectionID string, productIDs []string) error {
+	query := `
+	mutation collectionRemoveProducts($id: ID!, $productIds: [ID!]!) {
+		collectionRemoveProducts(id: $id, productIds: $productIds) {
+			userErrors { field message }
+		}
+	}`
+
+	for start := 0; start < len(productIDs); start += maxCollectionProductsBatchSize {
+		end := start + maxCollectionProductsBatchSize
+		if end > len(productIDs) {
+			end = len(productIDs)
+		}
+		var data collectionRemoveProductsData
+		if err := c.graphqlRequest(ctx, query, map[string]any{
+			"id":         collectionID,
+			"productIds": productIDs[start:end],
+		}, &data); err != nil {
+			return err
+		}
+		if err := userErrorsToError("collectionRemoveProducts", data.CollectionRemoveProducts.UserErrors); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,23 @@
+package shopify
+
+import (
+	"reflect"
+	"testing"
+)
+
+// TestPlanSaleCollection checks that only SKUs no member carries are looked up and
+// only members carrying no discounted SKU are removed.
+func TestPlanSaleCollection(t *testing.T) {
+	members := map[string][]string{
+		"gid://shopify/Product/1": {"HVM-1"},
+		"gid://shopify/Product/2": {"CMG-28"},
+		"gid://shopify/Product/3": {""},
+	}
+	missing, remove := planSaleCollection(members, []string{" hvm-1 ", "DRA-1", "dra-1"})
+	if !reflect.DeepEqual(missing, []string{"DRA-1"}) {
+		t.Errorf("missing = %v, want [DRA-1]", missing)
+	}
+	if !reflect.DeepEqual(remove, []string{"gid://shopify/Product/2", "gid://shopify/Product/3"}) {
+		t.Errorf("remove = %v, want products 2 and 3", remove)
+	}
+}
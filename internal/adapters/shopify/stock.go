@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"shopify-exporter/internal/adapters/shopify/dto"
+	"strconv"
 	"strings"
 )
 
@@ -16,10 +17,14 @@ type StockService interface {
 type StockInput struct {
 	SKU      string
 	Quantity int
+	// Location is the name of the Shopify location to write; empty means the
+	// primary location.
+	Location string
 }
 
 type resolvedStockInput struct {
 	SKU             string
+	Location        string
 	InventoryItemID string
 	Quantity        int
 	Tracked         bool
@@ -90,16 +95,14 @@ func (c *Client) SetOnHandQuantities(ctx context.Context, inputs []StockInput) e
 		return nil
 	}
 
-	locationID, err := c.primaryLocationID(ctx)
-	if err != nil {
-		return err
-	}
-
 	// Deduplicated but order-preserving: the caller sorts its input so two runs can be
 	// diffed line by line in a trace, and iterating the map directly would shuffle that
-	// back into Go's randomised order.
-	unique := make(map[string]StockInput, len(inputs))
-	order := make([]string, 0, len(inputs))
+	// back into Go's randomised order. A SKU appears once per location.
+	type stockKey struct{ sku, location string }
+	unique := make(map[stockKey]StockInput, len(inputs))
+	order := make([]stockKey, 0, len(inputs))
+	locations := make([]string, 0, 1)
+	seenLocations := make(map[string]bool, 1)
 	skippedUntracked := 0
 	for _, input := range inputs {
 		sku := strings.TrimSpace(input.SKU)
@@ -118,32 +121,82 @@ func (c *Client) SetOnHandQuantities(ctx context.Context, inputs []StockInput) e
 			skippedUntracked++
 			continue
 		}
-		if _, seen := unique[sku]; !seen {
-			order = append(order, sku)
+		key := stockKey{sku: sku, location: strings.TrimSpace(input.Location)}
+		if _, seen := unique[key]; !seen {
+			order = append(order, key)
+			if !seenLocations[key.location] {
+				seenLocations[key.location] = true
+				locations = append(locations, key.location)
+			}
 		}
-		unique[sku] = StockInput{
+		unique[key] = StockInput{
 			SKU:      sku,
 			Quantity: input.Quantity,
+			Location: key.location,
 		}
 	}
 
+	if skippedUntracked > 0 {
+		c.logWarning(fmt.Sprintf("stock sync skipped untracked service skus=%d", skippedUntracked))
+	}
+	c.reportIncr("stock", "skipped_untracked", int64(skippedUntracked))
 	if len(unique) == 0 {
-		if skippedUntracked > 0 {
-			c.logWarning(fmt.Sprintf("stock sync skipped: untracked service skus=%d", skippedUntracked))
-		}
 		return nil
 	}
 
-	lookup, err := c.inventoryLookup(ctx, len(unique), locationID)
+	byLocation := make(map[string][]StockInput, len(locations))
+	for _, key := range order {
+		byLocation[key.location] = append(byLocation[key.location], unique[key])
+	}
+	for _, name := range locations {
+		location, err := c.stockLocation(ctx, name)
+		if err != nil {
+			return err
+		}
+		if err := c.setOnHandAt(ctx, location, byLocation[name]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// stockLocation is where one group of inputs is written: the named Shopify location,
+// or the primary one when the input names none.
+type stockLocation struct {
+	ID   string
+	Name string
+}
+
+// label names the location in log lines.
+func (l stockLocation) label() string {
+	if l.Name == "" {
+		return "primary"
+	}
+	return strconv.Quote(l.Name)
+}
+
+func (c *Client) stockLocation(ctx context.Context, name string) (stockLocation, error) {
+	if name == "" {
+		locationID, err := c.primaryLocationID(ctx)
+		return stockLocation{ID: locationID}, err
+	}
+	locationID, err := c.locationIDByName(ctx, name)
+	return stockLocation{ID: locationID, Name: name}, err
+}
+
+// setOnHandAt pushes one location's quantities: one inventory lookup scoped to the
+// location, then batched mutations for the SKUs whose on-hand actually moved.
+func (c *Client) setOnHandAt(ctx context.Context, location stockLocation, inputs []StockInput) error {
+	locationID := location.ID
+	lookup, err := c.inventoryLookup(ctx, len(inputs), locationID)
 	if err != nil {
 		return err
 	}
 
-	resolved := make([]resolvedStockInput, 0, len(unique))
+	resolved := make([]resolvedStockInput, 0, len(inputs))
 	skippedMissing := 0
 	unchanged := 0
-	for _, sku := range order {
-		input := unique[sku]
+	for _, input := range inputs {
 		variant, found, err := c.resolveVariantInventory(ctx, input.SKU, locationID, lookup)
 		if err != nil {
 			return err
@@ -162,6 +215,7 @@ func (c *Client) SetOnHandQuantities(ctx context.Context, inputs []StockInput) e
 			// mutation was sent — Shopify genuinely holds this value.
 			c.reportStockSeen(resolvedStockInput{
 				SKU:            input.SKU,
+				Location:       location.Name,
 				Quantity:       input.Quantity,
 				BeforeQuantity: variant.OnHand,
 				BeforeKnown:    true,
@@ -178,6 +232,7 @@ func (c *Client) SetOnHandQuantities(ctx context.Context, inputs []StockInput) e
 
 		resolved = append(resolved, resolvedStockInput{
 			SKU:             input.SKU,
+			Location:        location.Name,
 			InventoryItemID: variant.InventoryItemID,
 			Quantity:        input.Quantity,
 			Tracked:         variant.Tracked,
@@ -203,22 +258,18 @@ func (c *Client) SetOnHandQuantities(ctx context.Context, inputs []StockInput) e
 	// Aggregated into one line rather than one warning per SKU: at a five-minute
 	// cadence the per-SKU form buries every other line in the log.
 	if skippedMissing > 0 {
-		c.logWarning(fmt.Sprintf("stock sync missing variants=%d", skippedMissing))
-	}
-	if skippedUntracked > 0 {
-		c.logWarning(fmt.Sprintf("stock sync skipped untracked service skus=%d", skippedUntracked))
+		c.logWarning(fmt.Sprintf("stock sync missing variants=%d location=%s", skippedMissing, location.label()))
 	}
 
 	c.reportIncr("stock", "unchanged", int64(unchanged))
 	c.reportIncr("stock", "skipped_missing_variant", int64(skippedMissing))
-	c.reportIncr("stock", "skipped_untracked", int64(skippedUntracked))
 
 	if len(resolved) == 0 {
 		c.logSuccess(fmt.Sprintf(
-			"shopify stock already current items=%d skipped_missing=%d skipped_untracked=%d",
+			"shopify stock already current location=%s items=%d skipped_missing=%d",
+			location.label(),
 			unchanged,
 			skippedMissing,
-			skippedUntracked,
 		))
 		return nil
 	}
@@ -228,16 +279,10 @@ func (c *Client) SetOnHandQuantities(ctx context.Context, inputs []StockInput) e
 	// before -> after" is the entire deliverable — it is what makes a change of this
 	// size checkable before it touches a live storefront.
 	if c.config.StockDryRun {
-		return c.reportDryRun(resolved, locationID, unchanged, skippedMissing, skippedUntracked)
+		return c.reportDryRun(resolved, location, unchanged, skippedMissing)
 	}
 
-	query := `
-	mutation inventorySetOnHandQuantities($input: InventorySetOnHandQuantitiesInput!) {
-		inventorySetOnHandQuantities(input: $input) {
-			userErrors { field message }
-		}
-	}`
-
+	pushed := 0
 	for start := 0; start < len(resolved); start += maxStockBatchSize {
 		end := start + maxStockBatchSize
 		if end > len(resolved) {
@@ -257,34 +302,11 @@ func (c *Client) SetOnHandQuantities(ctx context.Context, inputs []StockInput) e
 				}
 			}
 		}
-		payload := make([]map[string]any, 0, len(batch))
-		for _, item := range batch {
-			c.traceSKU(
-				item.SKU,
-				"stock mutation inventory_item_id=%s location_id=%s quantity=%d",
-				item.InventoryItemID,
-				locationID,
-				item.Quantity,
-			)
-			payload = append(payload, map[string]any{
-				"inventoryItemId": item.InventoryItemID,
-				"locationId":      locationID,
-				"quantity":        item.Quantity,
-			})
-		}
-		var data dto.InventorySetOnHandQuantitiesData
-		if err := c.graphqlRequest(ctx, query, map[string]any{
-			"input": map[string]any{
-				"reason":        "correction",
-				"setQuantities": payload,
-			},
-		}, &data); err != nil {
-			return err
-		}
-		if err := userErrorsToDetailedError("inventorySetOnHandQuantities", data.InventorySetOnHandQuantities.UserErrors); err != nil {
+		written, err := c.compareAndSetOnHand(ctx, location, batch)
+		if err != nil {
 			return err
 		}
-		for _, item := range batch {
+		for _, item := range written {
 			// Recorded only after the mutation succeeded, so the report describes
 			// what Shopify actually holds rather than what we intended to send.
 			c.reportStockSeen(item)
@@ -296,15 +318,16 @@ func (c *Client) SetOnHandQuantities(ctx context.Context, inputs []StockInput) e
 				item.Quantity,
 			)
 		}
+		pushed += len(written)
 	}
 
-	c.reportIncr("stock", "pushed", int64(len(resolved)))
+	c.reportIncr("stock", "pushed", int64(pushed))
 	c.logSuccess(fmt.Sprintf(
-		"shopify stock updated items=%d unchanged=%d skipped_missing=%d skipped_untracked=%d",
-		len(resolved),
+		"shopify stock updated location=%s items=%d unchanged=%d skipped_missing=%d",
+		location.label(),
+		pushed,
 		unchanged,
 		skippedMissing,
-		skippedUntracked,
 	))
 	return nil
 }
@@ -314,8 +337,8 @@ func (c *Client) SetOnHandQuantities(ctx context.Context, inputs []StockInput) e
 // so the log answers "what exactly would have changed" rather than just "nothing ran".
 func (c *Client) reportDryRun(
 	resolved []resolvedStockInput,
-	locationID string,
-	unchanged, skippedMissing, skippedUntracked int,
+	location stockLocation,
+	unchanged, skippedMissing int,
 ) error {
 	wouldTrack := 0
 	wouldActivate := 0
@@ -330,15 +353,16 @@ func (c *Client) reportDryRun(
 			wouldActivate++
 			writes = append(writes, "inventoryActivate")
 		}
-		writes = append(writes, "inventorySetOnHandQuantities")
+		writes = append(writes, "inventorySetQuantities")
 
 		before := "unknown"
 		if item.BeforeKnown {
 			before = fmt.Sprintf("%d", item.BeforeQuantity)
 		}
 		c.logWarning(fmt.Sprintf(
-			"DRY RUN would change sku=%s %s -> %d inventory_item_id=%s writes=%s",
+			"DRY RUN would change sku=%s location=%s %s -> %d inventory_item_id=%s writes=%s",
 			item.SKU,
+			location.label(),
 			before,
 			item.Quantity,
 			item.InventoryItemID,
@@ -352,7 +376,7 @@ func (c *Client) reportDryRun(
 			item.SKU,
 			"stock dry-run inventory_item_id=%s location_id=%s before=%s quantity=%d",
 			item.InventoryItemID,
-			locationID,
+			location.ID,
 			before,
 			item.Quantity,
 		)
@@ -363,18 +387,19 @@ func (c *Client) reportDryRun(
 	c.reportIncr("stock", "dry_run_would_track", int64(wouldTrack))
 	c.reportIncr("stock", "dry_run_would_activate", int64(wouldActivate))
 	c.reportWarning("stock", fmt.Sprintf(
-		"DRY RUN: nothing was written to Shopify. %d SKUs would change.",
+		"DRY RUN: nothing was written to Shopify. %d SKUs would change at %s.",
 		len(resolved),
+		location.label(),
 	))
 
 	c.logSuccess(fmt.Sprintf(
-		"DRY RUN complete, no writes sent: would_push=%d would_track=%d would_activate=%d unchanged=%d skipped_missing=%d skipped_untracked=%d",
+		"DRY RUN complete, no writes sent: location=%s would_push=%d would_track=%d would_activate=%d unchanged=%d skipped_missing=%d",
+		location.label(),
 		len(resolved),
 		wouldTrack,
 		wouldActivate,
 		unchanged,
 		skippedMissing,
-		skippedUntracked,
 	))
 	return nil
 }
@@ -521,6 +546,9 @@ func (c *Client) lookupInventoryItemIDBySKU(ctx context.Context, sku, locationID
 	}, nil
 }
 
+// ensureInventoryItemTracked only ever flips `tracked`. The variant's inventoryPolicy
+// (DENY, or CONTINUE for an active pre-order) belongs to the product sync and must
+// not be written from the stock step, or the two would undo each other every run.
 func (c *Client) ensureInventoryItemTracked(ctx context.Context, inventoryItemID string, tracked bool) error {
 	inventoryItemID = strings.TrimSpace(inventoryItemID)
 	if inventoryItemID == "" || tracked {
@@ -591,19 +619,12 @@ func (c *Client) primaryLocationID(ctx context.Context) (string, error) {
 	}
 	c.locationMu.Unlock()
 
-	query := `
-	query locations($first: Int!) {
-		locations(first: $first) {
-			nodes { id name isActive }
-		}
-	}`
-
-	var data dto.LocationsQueryData
-	if err := c.graphqlRequest(ctx, query, map[string]any{"first": 50}, &data); err != nil {
+	nodes, err := c.fetchLocations(ctx)
+	if err != nil {
 		return "", err
 	}
 	locationID := ""
-	for _, location := range data.Locations.Nodes {
+	for _, location := range nodes {
 		if location.ID == "" {
 			continue
 		}
@@ -612,8 +633,8 @@ func (c *Client) primaryLocationID(ctx context.Context) (string, error) {
 			break
 		}
 	}
-	if locationID == "" && len(data.Locations.Nodes) > 0 {
-		locationID = data.Locations.Nodes[0].ID
+	if locationID == "" && len(nodes) > 0 {
+		locationID = nodes[0].ID
 	}
 	if locationID == "" {
 		return "", errors.New("shopify location not found")
@@ -624,3 +645,59 @@ func (c *Client) primaryLocationID(ctx context.Context) (string, error) {
 	c.locationMu.Unlock()
 	return locationID, nil
 }
+
+// locationIDByName resolves a configured location name, case-insensitively. The
+// list is read once per process. An unknown or deactivated name is an error rather
+// than a fallback to the primary location: writing a store's stock into the
+// warehouse would be silently wrong on every run.
+func (c *Client) locationIDByName(ctx context.Context, name string) (string, error) {
+	key := strings.ToLower(strings.TrimSpace(name))
+	if key == "" {
+		return "", errors.New("shopify location name is required")
+	}
+
+	c.locationMu.Lock()
+	byName := c.locationsByName
+	c.locationMu.Unlock()
+
+	if byName == nil {
+		nodes, err := c.fetchLocations(ctx)
+		if err != nil {
+			return "", err
+		}
+		byName = make(map[string]dto.LocationNode, len(nodes))
+		for _, location := range nodes {
+			if location.ID == "" {
+				continue
+			}
+			byName[strings.ToLower(strings.TrimSpace(location.Name))] = location
+		}
+		c.locationMu.Lock()
+		c.locationsByName = byName
+		c.locationMu.Unlock()
+	}
+
+	location, ok := byName[key]
+	if !ok {
+		return "", fmt.Errorf("shopify location %q not found", name)
+	}
+	if !location.IsActive {
+		return "", fmt.Errorf("shopify location %q is not active", name)
+	}
+	return location.ID, nil
+}
+
+func (c *Client) fetchLocations(ctx context.Context) ([]dto.LocationNode, error) {
+	query := `
+	query locations($first: Int!) {
+		locations(first: $first) {
+			nodes { id name isActive }
+		}
+	}`
+
+	var data dto.LocationsQueryData
+	if err := c.graphqlRequest(ctx, query, map[string]any{"first": 50}, &data); err != nil {
+		return nil, err
+	}
+	return data.Locations.Nodes, nil
+}
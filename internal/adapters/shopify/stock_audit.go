@@ -0,0 +1,115 @@
+package shopify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// StockAuditService reads inventory without writing anything. The drift audit uses
+// it to compare Shopify with the ERP; it must stay safe to run at any time.
+type StockAuditService interface {
+	// InventoryLevels returns the catalogue's inventory at the named location (empty
+	// for the primary one), keyed by SKU. Service SKUs that are untracked by design
+	// are left out.
+	InventoryLevels(ctx context.Context, location string) (map[string]InventoryLevel, error)
+}
+
+// InventoryLevel is one SKU's inventory at a location as Shopify holds it.
+type InventoryLevel struct {
+	SKU     string
+	Tracked bool
+	// HasLevel is false when the item is not stocked at the location at all; the
+	// quantities are then zero.
+	HasLevel  bool
+	OnHand    int
+	Available int
+	// Committed is what open orders hold: counted in OnHand, not in Available.
+	Committed int
+}
+
+// inventoryAuditSelection is inventoryVariantSelection with the quantities the audit
+// classifies by. It is kept apart so the stock push does not pay for them.
+const inventoryAuditSelection = `
+				id
+				sku
+				inventoryItem {
+					id
+					tracked
+					inventoryLevel(locationId: $locationId) {
+						id
+						quantities(names: ["on_hand", "available", "committed"]) { name quantity }
+					}
+				}`
+
+func (c *Client) InventoryLevels(ctx context.Context, location string) (map[string]InventoryLevel, error) {
+	if c == nil {
+		return nil, errors.New("shopify client is nil")
+	}
+	target, err := c.stockLocation(ctx, strings.TrimSpace(location))
+	if err != nil {
+		return nil, err
+	}
+
+	query := `
+	query inventoryAudit($first: Int!, $after: String, $locationId: ID!) {
+		productVariants(first: $first, after: $after) {
+			nodes {` + inventoryAuditSelection + `
+			}
+			pageInfo { hasNextPage endCursor }
+		}
+	}`
+
+	levels := make(map[string]InventoryLevel)
+	after := ""
+	pages := 0
+	for {
+		vars := map[string]any{
+			"first":      maxInventoryPageSize,
+			"locationId": target.ID,
+		}
+		if after != "" {
+			vars["after"] = after
+		}
+
+		var data variantInventoryListData
+		if err := c.graphqlRequest(ctx, query, vars, &data); err != nil {
+			return nil, err
+		}
+		pages++
+
+		for _, node := range data.ProductVariants.Nodes {
+			sku := strings.TrimSpace(node.SKU)
+			if sku == "" || node.InventoryItem == nil || !c.shouldTrackInventory(sku) {
+				continue
+			}
+			if _, exists := levels[sku]; exists {
+				continue
+			}
+			level := node.InventoryItem.InventoryLevel
+			onHand, _ := level.Quantity("on_hand")
+			available, _ := level.Quantity("available")
+			committed, _ := level.Quantity("committed")
+			levels[sku] = InventoryLevel{
+				SKU:       sku,
+				Tracked:   node.InventoryItem.Tracked,
+				HasLevel:  level != nil,
+				OnHand:    onHand,
+				Available: available,
+				Committed: committed,
+			}
+		}
+
+		if !data.ProductVariants.PageInfo.HasNextPage {
+			break
+		}
+		after = strings.TrimSpace(data.ProductVariants.PageInfo.EndCursor)
+		if after == "" {
+			break
+		}
+	}
+
+	c.logSuccess(fmt.Sprintf("shopify inventory audit read location=%s skus=%d pages=%d", target.label(), len(levels), pages))
+	return levels, nil
+}
@@ -0,0 +1,214 @@
+package shopify
+
+import (
+	"context"
+	"fmt"
+	"shopify-exporter/internal/adapters/shopify/dto"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	// maxStockCompareAttempts bounds the re-read-and-retry loop for one batch. A SKU
+	// still contested after that is left for the next run rather than forced.
+	maxStockCompareAttempts = 3
+	// compareQuantityStaleCode is the userError code Shopify returns when a
+	// compareQuantity no longer matches what it holds; changeFromQuantityStaleCode is
+	// the same for changeFromQuantity.
+	compareQuantityStaleCode    = "COMPARE_QUANTITY_STALE"
+	changeFromQuantityStaleCode = "CHANGE_FROM_QUANTITY_STALE"
+	// changeFromQuantityVersion is the first API version that names the compare field
+	// changeFromQuantity.
+	changeFromQuantityVersion = "2026-04"
+)
+
+// compareQuantityField is the name inventorySetQuantities gives the expected current
+// quantity in apiVersion. Versions are YYYY-MM, so they compare as strings;
+// "unstable" is ahead of every release.
+func compareQuantityField(apiVersion string) string {
+	apiVersion = strings.TrimSpace(apiVersion)
+	if apiVersion == "unstable" || apiVersion >= changeFromQuantityVersion {
+		return "changeFromQuantity"
+	}
+	return "compareQuantity"
+}
+
+// compareAndSetOnHand writes one batch with inventorySetQuantities, passing the
+// on-hand read in the lookup as compareQuantity. The quantity written is absolute and
+// the lookup may be minutes old by now; without the compare, a fulfilment or an admin
+// edit landing in between would be overwritten without anyone knowing. Shopify
+// rejects the whole batch on a stale compare, so the contested SKUs are re-read and
+// the batch is sent again. It returns the items actually written.
+//
+// API versions from 2026-04 rename compareQuantity to changeFromQuantity; the
+// semantics are the same, and compareQuantityField picks the name SHOPIFY_API_VERSION
+// expects.
+func (c *Client) compareAndSetOnHand(ctx context.Context, location stockLocation, batch []resolvedStockInput) ([]resolvedStockInput, error) {
+	pending := append([]resolvedStockInput(nil), batch...)
+	for attempt := 1; len(pending) > 0; attempt++ {
+		stale, err := c.setOnHandCompared(ctx, location, pending)
+		if err != nil {
+			return nil, err
+		}
+		if len(stale) == 0 {
+			return pending, nil
+		}
+
+		c.reportIncr("stock", "compare_conflicts", int64(len(stale)))
+		if attempt >= maxStockCompareAttempts {
+			skus := make([]string, 0, len(stale))
+			for index := range stale {
+				skus = append(skus, pending[index].SKU)
+			}
+			sort.Strings(skus)
+			c.logWarning(fmt.Sprintf(
+				"stock compare still stale after %d attempts, left for the next run location=%s skus=%s",
+				attempt,
+				location.label(),
+				strings.Join(skus, ","),
+			))
+			c.reportIncr("stock", "compare_conflicts_skipped", int64(len(stale)))
+			pending = withoutIndexes(pending, stale)
+			continue
+		}
+		pending, err = c.refreshContested(ctx, location, pending, stale)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return nil, nil
+}
+
+// setOnHandCompared sends one inventorySetQuantities call. It returns the indexes of
+// the items whose compareQuantity was stale; any other userError is an error.
+func (c *Client) setOnHandCompared(ctx context.Context, location stockLocation, items []resolvedStockInput) (map[int]bool, error) {
+	query := `
+	mutation inventorySetQuantities($input: InventorySetQuantitiesInput!) {
+		inventorySetQuantities(input: $input) {
+			userErrors { field message code }
+		}
+	}`
+
+	compareField := compareQuantityField(c.config.APIVer)
+	payload := make([]map[string]any, 0, len(items))
+	for _, item := range items {
+		c.traceSKU(
+			item.SKU,
+			"stock mutation inventory_item_id=%s location_id=%s compare_quantity=%d quantity=%d",
+			item.InventoryItemID,
+			location.ID,
+			item.BeforeQuantity,
+			item.Quantity,
+		)
+		// An item activated a moment ago has no earlier reading; its new level is 0.
+		payload = append(payload, map[string]any{
+			"inventoryItemId": item.InventoryItemID,
+			"locationId":      location.ID,
+			"quantity":        item.Quantity,
+			compareField:      item.BeforeQuantity,
+		})
+	}
+
+	var data dto.InventorySetQuantitiesData
+	if err := c.graphqlRequest(ctx, query, map[string]any{
+		"input": map[string]any{
+			"name":       "on_hand",
+			"reason":     "correction",
+			"quantities": payload,
+		},
+	}, &data); err != nil {
+		return nil, err
+	}
+
+	stale := make(map[int]bool)
+	others := make([]dto.ShopifyUserError, 0)
+	for _, userErr := range data.InventorySetQuantities.UserErrors {
+		if userErr.Code != compareQuantityStaleCode && userErr.Code != changeFromQuantityStaleCode {
+			others = append(others, dto.ShopifyUserError{Field: userErr.Field, Message: userErr.Message})
+			continue
+		}
+		index, ok := quantityIndex(userErr.Field, len(items))
+		if !ok {
+			// Cannot tell which item: re-read them all, which is slower but safe.
+			for i := range items {
+				stale[i] = true
+			}
+			continue
+		}
+		stale[index] = true
+	}
+	if err := userErrorsToDetailedError("inventorySetQuantities", others); err != nil {
+		return nil, err
+	}
+	return stale, nil
+}
+
+// refreshContested re-reads the contested SKUs and takes their current on-hand as
+// the new compareQuantity. One that already holds its target drops out: someone else
+// set it, and there is nothing left to write.
+func (c *Client) refreshContested(
+	ctx context.Context,
+	location stockLocation,
+	items []resolvedStockInput,
+	stale map[int]bool,
+) ([]resolvedStockInput, error) {
+	refreshed := make([]resolvedStockInput, 0, len(items))
+	for index, item := range items {
+		if !stale[index] {
+			refreshed = append(refreshed, item)
+			continue
+		}
+		variant, err := c.lookupInventoryItemIDBySKU(ctx, item.SKU, location.ID)
+		if err != nil {
+			if missing, ok := isVariantNotFoundError(err); ok {
+				// Deleted since the lookup: nothing left to write.
+				c.logWarning(missing.Error())
+				continue
+			}
+			return nil, err
+		}
+		c.traceSKU(
+			item.SKU,
+			"stock compare stale location_id=%s expected=%d current=%d quantity=%d",
+			location.ID,
+			item.BeforeQuantity,
+			variant.OnHand,
+			item.Quantity,
+		)
+		item.BeforeQuantity = variant.OnHand
+		item.BeforeKnown = variant.OnHandKnown
+		if variant.OnHandKnown && variant.OnHand == item.Quantity {
+			c.reportStockSeen(item)
+			continue
+		}
+		refreshed = append(refreshed, item)
+	}
+	return refreshed, nil
+}
+
+// quantityIndex reads the item index out of a userError field path such as
+// ["input", "quantities", "3", "compareQuantity"].
+func quantityIndex(field []string, count int) (int, bool) {
+	for i := 0; i+1 < len(field); i++ {
+		if field[i] != "quantities" {
+			continue
+		}
+		index, err := strconv.Atoi(field[i+1])
+		if err != nil || index < 0 || index >= count {
+			return 0, false
+		}
+		return index, true
+	}
+	return 0, false
+}
+
+func withoutIndexes(items []resolvedStockInput, drop map[int]bool) []resolvedStockInput {
+	kept := make([]resolvedStockInput, 0, len(items))
+	for index, item := range items {
+		if !drop[index] {
+			kept = append(kept, item)
+		}
+	}
+	return kept
+}
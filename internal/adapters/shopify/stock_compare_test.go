@@ -0,0 +1,47 @@
+package shopify
+
+import "testing"
+
+func TestQuantityIndex(t *testing.T) {
+	cases := []struct {
+		field []string
+		want  int
+		ok    bool
+	}{
+		{[]string{"input", "quantities", "3", "compareQuantity"}, 3, true},
+		{[]string{"input", "quantities", "0"}, 0, true},
+		{[]string{"input", "quantities", "7", "compareQuantity"}, 0, false}, // out of range
+		{[]string{"input", "quantities", "x"}, 0, false},
+		{[]string{"input", "name"}, 0, false},
+		{nil, 0, false},
+	}
+	for _, tc := range cases {
+		got, ok := quantityIndex(tc.field, 5)
+		if got != tc.want || ok != tc.ok {
+			t.Errorf("quantityIndex(%v) = %d, %t; want %d, %t", tc.field, got, ok, tc.want, tc.ok)
+		}
+	}
+}
+
+func TestWithoutIndexesKeepsOrder(t *testing.T) {
+	items := []resolvedStockInput{{SKU: "A"}, {SKU: "B"}, {SKU: "C"}, {SKU: "D"}}
+	kept := withoutIndexes(items, map[int]bool{1: true, 3: true})
+	if len(kept) != 2 || kept[0].SKU != "A" || kept[1].SKU != "C" {
+		t.Errorf("kept = %+v, want A, C", kept)
+	}
+}
+
+func TestCompareQuantityField(t *testing.T) {
+	cases := map[string]string{
+		"2025-10":  "compareQuantity",
+		"2026-01":  "compareQuantity",
+		"2026-04":  "changeFromQuantity",
+		"2026-07":  "changeFromQuantity",
+		"unstable": "changeFromQuantity",
+	}
+	for version, want := range cases {
+		if got := compareQuantityField(version); got != want {
+			t.Errorf("compareQuantityField(%q) = %s, want %s", version, got, want)
+		}
+	}
+}
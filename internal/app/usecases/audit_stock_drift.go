@@ -0,0 +1,195 @@
+package usecases
+
+import (
+	"context"
+	"fmt"
+	"shopify-exporter/internal/adapters/apix"
+	"shopify-exporter/internal/adapters/shopify"
+	"shopify-exporter/internal/config"
+	"shopify-exporter/internal/debugsync"
+	"shopify-exporter/internal/infra/stockstate"
+	"shopify-exporter/internal/logging"
+	"shopify-exporter/internal/report"
+	"sort"
+	"time"
+)
+
+type AuditStockDriftService interface {
+	Run(ctx context.Context) error
+}
+
+// StockDriftAuditor compares what Shopify holds with what the stock sync would push.
+// Delta runs only notice ERP changes, so Shopify-side movement (fulfilments, edits in
+// the admin) waits for the next full run; this makes that drift visible. It is
+// read-only: nothing is written to Shopify and the stock snapshot is left alone.
+type StockDriftAuditor struct {
+	// stock reads the ERP and builds the targets exactly as the stock step does. It
+	// has no recorder, so the audit adds no reserve notes to the report.
+	stock    *ClientStock
+	shopify  shopify.StockAuditService
+	recorder report.Recorder
+	// driftPath keeps the totals between audits; see config.StockConfig.DriftPath.
+	driftPath string
+}
+
+func NewAuditStockDrift(
+	apixClient apix.StockService,
+	apixCategories apix.CategoryService,
+	shopifyClient shopify.StockAuditService,
+	logger logging.LoggerService,
+	recorder report.Recorder,
+	stockConfig config.StockConfig,
+) AuditStockDriftService {
+	return &StockDriftAuditor{
+		stock: &ClientStock{
+			apixClient:     apixClient,
+			apixCategories: apixCategories,
+			logger:         logger,
+			stockConfig:    stockConfig,
+		},
+		shopify:   shopifyClient,
+		recorder:  recorder,
+		driftPath: stockConfig.DriftPath,
+	}
+}
+
+func (a *StockDriftAuditor) Run(ctx context.Context) error {
+	a.stock.log("Stock drift audit started")
+
+	// Always the full feed: drift is measured against every SKU, not the ones the ERP
+	// happened to change.
+	stocks, err := a.stock.apixClient.FetchStocks(ctx)
+	if err != nil {
+		a.stock.logError("Error fetch api stocks for drift audit", err)
+		return err
+	}
+	targets, _ := a.stock.buildTargets(stocks, a.stock.reservePolicy(ctx))
+
+	levels := make(map[string]map[string]shopify.InventoryLevel)
+	for _, target := range targets {
+		if _, read := levels[target.Location]; read {
+			continue
+		}
+		atLocation, err := a.shopify.InventoryLevels(ctx, target.Location)
+		if err != nil {
+			a.stock.logError("Error read shopify inventory for drift audit", err)
+			return err
+		}
+		levels[target.Location] = atLocation
+	}
+
+	keys := make([]string, 0, len(targets))
+	for key := range targets {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	byKind := make(map[string]int)
+	total := 0
+	missingVariant := 0
+	for _, key := range keys {
+		target := targets[key]
+		level, ok := levels[target.Location][target.SKU]
+		if !ok {
+			// No Shopify variant: the stock step skips these too, so it is not drift.
+			missingVariant++
+			continue
+		}
+		kind, drifted := classifyStockDrift(target.Quantity, level)
+		if !drifted {
+			continue
+		}
+		total++
+		byKind[kind]++
+		if a.recorder != nil {
+			a.recorder.StockDrift(report.StockDrift{
+				SKU:       target.SKU,
+				Location:  target.Location,
+				Kind:      kind,
+				Target:    target.Quantity,
+				OnHand:    level.OnHand,
+				Available: level.Available,
+				Committed: level.Committed,
+			})
+		}
+		if debugsync.MatchSKU(target.SKU) {
+			a.stock.log(fmt.Sprintf(
+				"trace stock drift sku=%s location=%q kind=%s target=%d on_hand=%d available=%d committed=%d",
+				target.SKU,
+				target.Location,
+				kind,
+				target.Quantity,
+				level.OnHand,
+				level.Available,
+				level.Committed,
+			))
+		}
+	}
+
+	previous, previousKnown := a.recordTrend(total, byKind)
+	if a.recorder != nil {
+		a.recorder.StockDriftAudited(previous, previousKnown)
+		a.recorder.Incr("stock_drift", "total", int64(total))
+		for _, kind := range []string{report.DriftPendingOrder, report.DriftManualEdit, report.DriftUntracked, report.DriftMissingLevel} {
+			a.recorder.Incr("stock_drift", kind, int64(byKind[kind]))
+		}
+		if previousKnown {
+			// The trend counter: positive means drift grew since the last audit.
+			a.recorder.Incr("stock_drift", "change", int64(total-previous))
+		}
+	}
+
+	a.stock.logSuccess(fmt.Sprintf(
+		"Stock drift audit completed drift=%d of=%d pending_order=%d manual_edit=%d untracked=%d missing_level=%d missing_variant=%d",
+		total,
+		len(targets),
+		byKind[report.DriftPendingOrder],
+		byKind[report.DriftManualEdit],
+		byKind[report.DriftUntracked],
+		byKind[report.DriftMissingLevel],
+		missingVariant,
+	))
+	return nil
+}
+
+// classifyStockDrift names why Shopify disagrees with the ERP target, if it does.
+// Untracked and unstocked items come first: their on-hand means nothing. A Shopify
+// on-hand above the target by no more than what open orders commit is the ERP
+// having already deducted orders Shopify has not fulfilled yet, which clears by
+// itself. Anything else moved in Shopify with no order to explain it.
+func classifyStockDrift(target int, level shopify.InventoryLevel) (string, bool) {
+	switch {
+	case !level.Tracked:
+		return report.DriftUntracked, true
+	case !level.HasLevel && target == 0:
+		// Not stocked here and nothing to stock: nothing a push would change.
+		return "", false
+	case !level.HasLevel:
+		return report.DriftMissingLevel, true
+	case level.OnHand == target:
+		return "", false
+	case level.Committed > 0 && level.OnHand > target && level.OnHand-target <= level.Committed:
+		return report.DriftPendingOrder, true
+	default:
+		return report.DriftManualEdit, true
+	}
+}
+
+// recordTrend appends this audit to the history and returns the previous total. A
+// SKU-filtered run audits part of the catalogue, so it is compared but not recorded.
+func (a *StockDriftAuditor) recordTrend(total int, byKind map[string]int) (int, bool) {
+	history, err := stockstate.LoadDriftHistory(a.driftPath)
+	if err != nil {
+		a.stock.logWarning(fmt.Sprintf("stock drift history unusable, starting a new one: %v", err))
+	}
+	last, known := history.Last()
+
+	if debugsync.HasOnlySKUFilter() {
+		return last.Total, known
+	}
+	history = history.Append(stockstate.DriftAudit{At: time.Now(), Total: total, ByKind: byKind})
+	if err := stockstate.SaveDriftHistory(a.driftPath, history); err != nil {
+		a.stock.logWarning(fmt.Sprintf("stock drift history write failed at %s: %v", a.driftPath, err))
+	}
+	return last.Total, known
+}
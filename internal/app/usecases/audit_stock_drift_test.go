@@ -0,0 +1,84 @@
+package usecases
+
+import (
+	"context"
+	"path/filepath"
+	"shopify-exporter/internal/adapters/shopify"
+	"shopify-exporter/internal/report"
+	"testing"
+)
+
+type fakeStockAudit struct {
+	levels map[string]map[string]shopify.InventoryLevel
+}
+
+func (f *fakeStockAudit) InventoryLevels(_ context.Context, location string) (map[string]shopify.InventoryLevel, error) {
+	return f.levels[location], nil
+}
+
+func TestClassifyStockDrift(t *testing.T) {
+	cases := []struct {
+		name   string
+		target int
+		level  shopify.InventoryLevel
+		want   string
+	}{
+		{"in line", 5, shopify.InventoryLevel{Tracked: true, HasLevel: true, OnHand: 5, Available: 5}, ""},
+		{"erp deducted an unfulfilled order", 3, shopify.InventoryLevel{Tracked: true, HasLevel: true, OnHand: 5, Available: 3, Committed: 2}, report.DriftPendingOrder},
+		{"more gone than orders explain", 1, shopify.InventoryLevel{Tracked: true, HasLevel: true, OnHand: 5, Available: 3, Committed: 2}, report.DriftManualEdit},
+		{"edited down in the admin", 5, shopify.InventoryLevel{Tracked: true, HasLevel: true, OnHand: 2, Available: 2}, report.DriftManualEdit},
+		{"tracking off", 5, shopify.InventoryLevel{HasLevel: true, OnHand: 5}, report.DriftUntracked},
+		{"not stocked at the location", 4, shopify.InventoryLevel{Tracked: true}, report.DriftMissingLevel},
+		{"not stocked and nothing to stock", 0, shopify.InventoryLevel{Tracked: true}, ""},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, drifted := classifyStockDrift(tc.target, tc.level)
+			if drifted != (tc.want != "") || got != tc.want {
+				t.Errorf("classifyStockDrift = %q, %t; want %q", got, drifted, tc.want)
+			}
+		})
+	}
+}
+
+// The audit reports each mismatch, writes nothing to the stock snapshot, and compares
+// its total with the previous audit.
+func TestAuditStockDriftReportsAndTracksTheTrend(t *testing.T) {
+	cfg := deltaConfig(t)
+	cfg.DriftPath = filepath.Join(filepath.Dir(cfg.StatePath), "stock-drift.json")
+	api := &fakeStockAPI{stocks: stocks(map[string]int32{"OK-1": 5, "EDIT-1": 8, "GONE-1": 2})}
+	audit := &fakeStockAudit{levels: map[string]map[string]shopify.InventoryLevel{"": {
+		"OK-1":   {SKU: "OK-1", Tracked: true, HasLevel: true, OnHand: 5, Available: 5},
+		"EDIT-1": {SKU: "EDIT-1", Tracked: true, HasLevel: true, OnHand: 3, Available: 3},
+	}}}
+
+	first := report.NewRun("test", "full", "", "", testTime())
+	if err := NewAuditStockDrift(api, nil, audit, nil, first, cfg).Run(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	summary := first.Snapshot()
+	if len(summary.StockDrift) != 1 || summary.StockDrift[0].SKU != "EDIT-1" || summary.StockDrift[0].Kind != report.DriftManualEdit {
+		t.Fatalf("drift = %+v, want EDIT-1 as a manual edit", summary.StockDrift)
+	}
+	if !summary.StockDriftAudited || summary.StockDriftPrevious != nil {
+		t.Errorf("first audit: audited=%t previous=%v, want audited with no previous", summary.StockDriftAudited, summary.StockDriftPrevious)
+	}
+
+	audit.levels[""]["OK-1"] = shopify.InventoryLevel{SKU: "OK-1", Tracked: true, HasLevel: true, OnHand: 1, Available: 1}
+	second := report.NewRun("test", "full", "", "", testTime())
+	if err := NewAuditStockDrift(api, nil, audit, nil, second, cfg).Run(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	summary = second.Snapshot()
+	if summary.StockDriftPrevious == nil || *summary.StockDriftPrevious != 1 || len(summary.StockDrift) != 2 {
+		t.Errorf("second audit: previous=%v drift=%d, want previous 1 and drift 2", summary.StockDriftPrevious, len(summary.StockDrift))
+	}
+	for _, counter := range summary.Counters {
+		if counter.Name == "stock_drift.change" && counter.Value != 1 {
+			t.Errorf("stock_drift.change = %d, want +1", counter.Value)
+		}
+	}
+	if matches, _ := filepath.Glob(cfg.StatePath); len(matches) != 0 {
+		t.Error("the audit must not write the stock snapshot")
+	}
+}
@@ -0,0 +1,73 @@
+package usecases
+
+import (
+	"math"
+	"shopify-exporter/internal/config"
+	"shopify-exporter/internal/domain/model"
+)
+
+// priceConverter derives the prices the ERP has no list price for from the ones it
+// has, at the SYNC_PRICE_CONVERSION rates, rounded per target currency.
+type priceConverter struct {
+	rates    model.ExchangeRates
+	rounding map[string]config.ConversionRounding
+}
+
+// derivedPrice is one converted price and what it was converted from.
+type derivedPrice struct {
+	Price float64
+	From  string
+	Rate  float64
+}
+
+// derive converts every missing currency from the first currency in order the ERP
+// does have a price for. Derived prices are never converted again. ok is false when
+// any missing currency cannot be derived, and the SKU is then skipped as before.
+func (c priceConverter) derive(have map[string]float64, missing, order []string) (map[string]derivedPrice, bool) {
+	from := ""
+	for _, currency := range order {
+		if have[currency] > 0 {
+			from = currency
+			break
+		}
+	}
+	if from == "" {
+		return nil, false
+	}
+
+	derived := make(map[string]derivedPrice, len(missing))
+	for _, currency := range missing {
+		amount, ok := c.rates.Convert(have[from], from, currency)
+		if !ok {
+			return nil, false
+		}
+		rate, _ := c.rates.Rate(from, currency)
+		derived[currency] = derivedPrice{
+			Price: c.round(amount, currency),
+			From:  from,
+			Rate:  rate,
+		}
+	}
+	return derived, true
+}
+
+// round applies the currency's rounding. A whole or ending rounding that would reach
+// zero keeps cents, so a cheap item is never derived to a free one.
+func (c priceConverter) round(amount float64, currency string) float64 {
+	cents := math.Round(amount*100) / 100
+	rule := c.rounding[currency]
+	var rounded float64
+	switch {
+	case rule.Ending > 0:
+		// The nearest price with that ending: 48.90 for 49.31, 49.90 for 49.50.
+		rounded = math.Round(amount-rule.Ending) + rule.Ending
+	case rule.Whole:
+		rounded = math.Round(amount)
+	default:
+		return cents
+	}
+	if rounded <= 0 {
+		return cents
+	}
+	return math.Round(rounded*100) / 100
+}
@@ -0,0 +1,58 @@
+package usecases
+
+import (
+	"shopify-exporter/internal/config"
+	"shopify-exporter/internal/domain/model"
+	"testing"
+)
+
+func TestPriceConverterRounding(t *testing.T) {
+	converter := priceConverter{rounding: map[string]config.ConversionRounding{
+		"USD": {Ending: 0.90},
+		"EUR": {Whole: true},
+	}}
+	tests := []struct {
+		amount   float64
+		currency string
+		want     float64
+	}{
+		{49.31, "USD", 48.90},
+		{49.50, "USD", 49.90},
+		{50.41, "USD", 50.90},
+		// .90 endings would make it free or negative, so it keeps cents.
+		{0.31, "USD", 0.31},
+		{49.5, "EUR", 50},
+		{0.4, "EUR", 0.4},
+		{12.345, "ILS", 12.35},
+	}
+	for _, tt := range tests {
+		if got := converter.round(tt.amount, tt.currency); got != tt.want {
+			t.Errorf("round(%v, %s) = %v, want %v", tt.amount, tt.currency, got, tt.want)
+		}
+	}
+}
+
+// A SKU is derived from the first currency in market order the ERP has a price for,
+// never from another derived price, and skipped when a rate is missing.
+func TestPriceConverterDerive(t *testing.T) {
+	converter := priceConverter{rates: model.ExchangeRates{"ILS": 1, "USD": 3.6, "EUR": 4}}
+	order := []string{"ILS", "USD", "EUR"}
+
+	derived, ok := converter.derive(map[string]float64{"USD": 10}, []string{"ILS", "EUR"}, order)
+	if !ok {
+		t.Fatal("expected USD to derive ILS and EUR")
+	}
+	if got := derived["ILS"]; got.Price != 36 || got.From != "USD" || got.Rate != 3.6 {
+		t.Errorf("ILS = %+v, want 36 from USD at 3.6", got)
+	}
+	if got := derived["EUR"]; got.Price != 9 || got.From != "USD" {
+		t.Errorf("EUR = %+v, want 9 from USD", got)
+	}
+
+	if _, ok := converter.derive(map[string]float64{"ILS": 36}, []string{"GBP"}, []string{"ILS", "GBP"}); ok {
+		t.Error("a currency with no rate must not be derived")
+	}
+	if _, ok := converter.derive(map[string]float64{}, []string{"USD"}, order); ok {
+		t.Error("a SKU with no price at all must not be derived")
+	}
+}
@@ -0,0 +1,72 @@
+package usecases
+
+import (
+	"math"
+	"shopify-exporter/internal/config"
+	"strings"
+)
+
+// itemDiscount is what the ERP says about one SKU's discount.
+type itemDiscount struct {
+	Code    string
+	Percent float64
+}
+
+// discountTable applies the SYNC_DISCOUNTS table to ERP discount codes.
+type discountTable struct {
+	rules    map[string]config.DiscountRule
+	rounding string
+}
+
+func newDiscountTable(cfg config.DiscountConfig) discountTable {
+	return discountTable{rules: cfg.Rules, rounding: cfg.Rounding}
+}
+
+// lookup returns the rule for the item's code and the percentage it resolves to. ok
+// is false when the item sells at full price: no code, a code with no rule, or an
+// "erp" rule whose item carries no usable DiscountPrc.
+func (t discountTable) lookup(item itemDiscount) (config.DiscountRule, float64, bool) {
+	code := strings.TrimSpace(item.Code)
+	if code == "" {
+		return config.DiscountRule{}, 0, false
+	}
+	rule, ok := t.rules[code]
+	if !ok {
+		return config.DiscountRule{}, 0, false
+	}
+	percent := rule.Percent
+	if percent == config.DiscountERPPercent {
+		percent = item.Percent
+	}
+	if percent <= 0 || percent >= 100 {
+		return rule, 0, false
+	}
+	return rule, percent, true
+}
+
+// knows reports whether the code has a rule, so an unmapped code can be reported.
+func (t discountTable) knows(code string) bool {
+	_, ok := t.rules[strings.TrimSpace(code)]
+	return ok
+}
+
+// apply returns the sale price for full at percent off, rounded by the configured
+// mode. A rounding that would erase the discount (or push the price to zero) falls
+// back to cents, so a discounted item always sells below its compare-at price.
+func (t discountTable) apply(full, percent float64) float64 {
+	discounted := full * (1 - percent/100)
+	cents := math.Round(discounted*100) / 100
+	var rounded float64
+	switch t.rounding {
+	case config.DiscountRoundWhole:
+		rounded = math.Round(discounted)
+	case config.DiscountRoundCharm:
+		rounded = math.Round(discounted) - 0.10
+	default:
+		return cents
+	}
+	if rounded <= 0 || rounded >= full {
+		return cents
+	}
+	return math.Round(rounded*100) / 100
+}
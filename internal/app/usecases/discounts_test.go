@@ -0,0 +1,59 @@
+package usecases
+
+import (
+	"shopify-exporter/internal/config"
+	"testing"
+)
+
+// TestDiscountTableLookup covers the table: a fixed percentage, a code that defers to
+// the ERP DiscountPrc, and the cases that must leave the item at full price.
+func TestDiscountTableLookup(t *testing.T) {
+	table := newDiscountTable(config.DiscountConfig{Rules: map[string]config.DiscountRule{
+		"5": {Code: "5", Percent: 50},
+		"7": {Code: "7", Percent: config.DiscountERPPercent},
+	}})
+
+	tests := []struct {
+		name    string
+		item    itemDiscount
+		percent float64
+		ok      bool
+	}{
+		{"fixed percentage", itemDiscount{Code: "5", Percent: 10}, 50, true},
+		{"erp percentage", itemDiscount{Code: "7", Percent: 15}, 15, true},
+		{"erp percentage missing", itemDiscount{Code: "7"}, 0, false},
+		{"erp percentage out of range", itemDiscount{Code: "7", Percent: 100}, 0, false},
+		{"unmapped code", itemDiscount{Code: "9", Percent: 20}, 0, false},
+		{"no code", itemDiscount{Percent: 20}, 0, false},
+	}
+	for _, tt := range tests {
+		_, percent, ok := table.lookup(tt.item)
+		if ok != tt.ok || percent != tt.percent {
+			t.Errorf("%s: lookup = %v, %t, want %v, %t", tt.name, percent, ok, tt.percent, tt.ok)
+		}
+	}
+}
+
+// TestDiscountTableApplyRounding checks each rounding mode, and that a rounding which
+// would erase the discount falls back to cents.
+func TestDiscountTableApplyRounding(t *testing.T) {
+	tests := []struct {
+		name     string
+		rounding string
+		full     float64
+		percent  float64
+		want     float64
+	}{
+		{"cents", config.DiscountRoundCents, 99.9, 30, 69.93},
+		{"whole", config.DiscountRoundWhole, 99.9, 30, 70},
+		{"charm", config.DiscountRoundCharm, 99.9, 30, 69.9},
+		{"whole would erase discount", config.DiscountRoundWhole, 5, 5, 4.75},
+		{"charm would reach zero", config.DiscountRoundCharm, 0.2, 50, 0.1},
+	}
+	for _, tt := range tests {
+		table := newDiscountTable(config.DiscountConfig{Rounding: tt.rounding})
+		if got := table.apply(tt.full, tt.percent); got != tt.want {
+			t.Errorf("%s: apply(%v, %v) = %v, want %v", tt.name, tt.full, tt.percent, got, tt.want)
+		}
+	}
+}
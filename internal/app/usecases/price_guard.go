@@ -0,0 +1,92 @@
+package usecases
+
+import (
+	"fmt"
+	"math"
+	"shopify-exporter/internal/adapters/shopify"
+	"shopify-exporter/internal/config"
+)
+
+// priceGuard applies the SYNC_PRICE_GUARD limits to one SKU's prices.
+type priceGuard struct {
+	cfg config.PriceGuardConfig
+}
+
+// holdReason is why one currency of a SKU was held back.
+type holdReason struct {
+	Currency string
+	Reason   string
+}
+
+// check returns the reasons to hold the SKU, none when every currency is within the
+// limits. cost is the ERP purchase price in the cost currency, 0 when unknown, with
+// VAT added when that currency's price went out with VAT. It is compared with the list
+// price, not the sale price: a sale below cost is a decision made in the sales
+// calendar, not an ERP mistake.
+func (g priceGuard) check(check shopify.PriceCheck, currencies []string, cost float64) []holdReason {
+	reasons := make([]holdReason, 0)
+	for _, currency := range currencies {
+		after, ok := check.Input.Prices[currency]
+		if !ok {
+			continue
+		}
+		switch {
+		case after <= 0:
+			if !g.cfg.AllowZero {
+				reasons = append(reasons, holdReason{currency, "zero price"})
+			}
+			continue
+		case g.cfg.MinPrice > 0 && after < g.cfg.MinPrice:
+			reasons = append(reasons, holdReason{currency, fmt.Sprintf("below the floor of %.2f", g.cfg.MinPrice)})
+			continue
+		case g.cfg.BelowCost && cost > 0 && currency == g.cfg.CostCurrency && listPrice(check, currency) < cost:
+			reasons = append(reasons, holdReason{currency, fmt.Sprintf("below purchase cost %.2f", cost)})
+			continue
+		}
+		if move, before, ok := g.move(check, currency); ok && move > g.cfg.MaxChangePercent {
+			reasons = append(reasons, holdReason{currency, fmt.Sprintf("moves %.0f%% from %.2f (limit %.0f%%)", move, before, g.cfg.MaxChangePercent)})
+		}
+	}
+	return reasons
+}
+
+// move is the percentage the currency's price moves from what Shopify holds. A sale
+// starting or ending is not a move: the full price is compared as well as the sale
+// price on both sides, and the closest pair counts. Shopify only keeps the compare-at
+// price in the base currency, so another currency's old full price is estimated from
+// the base currency's discount. ok is false when there is nothing to compare with.
+func (g priceGuard) move(check shopify.PriceCheck, currency string) (float64, float64, bool) {
+	if g.cfg.MaxChangePercent <= 0 {
+		return 0, 0, false
+	}
+	before, known := check.Before[currency]
+	if !known || before <= 0 {
+		return 0, 0, false
+	}
+
+	befores := []float64{before}
+	if base := check.Before[check.BaseCurrency]; base > 0 && check.BeforeCompareAt > base {
+		befores = append(befores, before*check.BeforeCompareAt/base)
+	}
+	afters := []float64{check.Input.Prices[currency]}
+	if compareAt := check.Input.CompareAt[currency]; compareAt > 0 {
+		afters = append(afters, compareAt)
+	}
+
+	smallest := math.Inf(1)
+	for _, b := range befores {
+		for _, a := range afters {
+			smallest = math.Min(smallest, math.Abs(a-b)/b*100)
+		}
+	}
+	return smallest, before, true
+}
+
+// listPrice is the currency's undiscounted price: the compare-at price during a sale,
+// the price otherwise.
+func listPrice(check shopify.PriceCheck, currency string) float64 {
+	if compareAt := check.Input.CompareAt[currency]; compareAt > 0 {
+		return compareAt
+	}
+	return check.Input.Prices[currency]
+}
@@ -0,0 +1,60 @@
+package usecases
+
+import (
+	"shopify-exporter/internal/adapters/shopify"
+	"shopify-exporter/internal/config"
+	"testing"
+)
+
+// TestPriceGuardCheck covers each limit, and that a sale starting or ending is not
+// mistaken for a price jump.
+func TestPriceGuardCheck(t *testing.T) {
+	guard := priceGuard{cfg: config.PriceGuardConfig{
+		Enabled:          true,
+		MaxChangePercent: 60,
+		MinPrice:         1,
+		BelowCost:        true,
+		CostCurrency:     "ILS",
+	}}
+	currencies := []string{"ILS", "USD"}
+
+	tests := []struct {
+		name            string
+		before          map[string]float64
+		beforeCompareAt float64
+		prices          map[string]float64
+		compareAt       map[string]float64
+		cost            float64
+		held            []string
+	}{
+		{"normal rise", map[string]float64{"ILS": 100, "USD": 27}, 0, map[string]float64{"ILS": 110, "USD": 30}, nil, 0, nil},
+		{"decimal point typo", map[string]float64{"ILS": 23.36, "USD": 6.3}, 0, map[string]float64{"ILS": 2336, "USD": 6.3}, nil, 0, []string{"ILS"}},
+		{"zero price", map[string]float64{"ILS": 100, "USD": 27}, 0, map[string]float64{"ILS": 0, "USD": 27}, nil, 0, []string{"ILS"}},
+		{"below floor", nil, 0, map[string]float64{"ILS": 0.5, "USD": 27}, nil, 0, []string{"ILS"}},
+		{"below cost", nil, 0, map[string]float64{"ILS": 40, "USD": 27}, nil, 50, []string{"ILS"}},
+		{"sale below cost", nil, 0, map[string]float64{"ILS": 40, "USD": 11}, map[string]float64{"ILS": 80, "USD": 22}, 50, nil},
+		{"list price below cost", nil, 0, map[string]float64{"ILS": 20, "USD": 5.5}, map[string]float64{"ILS": 40, "USD": 11}, 50, []string{"ILS"}},
+		{"cost only in its currency", nil, 0, map[string]float64{"ILS": 60, "USD": 20}, nil, 50, nil},
+		{"no before price", nil, 0, map[string]float64{"ILS": 2336, "USD": 630}, nil, 0, nil},
+		{"sale starts", map[string]float64{"ILS": 100, "USD": 27}, 0, map[string]float64{"ILS": 30, "USD": 8.1}, map[string]float64{"ILS": 100, "USD": 27}, 0, nil},
+		{"sale ends", map[string]float64{"ILS": 30, "USD": 8.1}, 100, map[string]float64{"ILS": 100, "USD": 27}, nil, 0, nil},
+	}
+	for _, tt := range tests {
+		check := shopify.PriceCheck{
+			Input:           shopify.PriceUpsertInput{SKU: "HVM-1", Prices: tt.prices, CompareAt: tt.compareAt},
+			BaseCurrency:    "ILS",
+			Before:          tt.before,
+			BeforeCompareAt: tt.beforeCompareAt,
+		}
+		reasons := guard.check(check, currencies, tt.cost)
+		if len(reasons) != len(tt.held) {
+			t.Errorf("%s: held %v, want %v", tt.name, reasons, tt.held)
+			continue
+		}
+		for i, reason := range reasons {
+			if reason.Currency != tt.held[i] {
+				t.Errorf("%s: held %s, want %s", tt.name, reason.Currency, tt.held[i])
+			}
+		}
+	}
+}
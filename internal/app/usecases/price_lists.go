@@ -0,0 +1,58 @@
+package usecases
+
+import (
+	"shopify-exporter/internal/config"
+	"strings"
+)
+
+// priceListSelector applies the SYNC_PRICE_LISTS rules: for a SKU and currency it
+// ranks each ERP price list by its position in the matching rule's order.
+type priceListSelector struct {
+	rules []config.PriceListRule
+}
+
+func newPriceListSelector(rules []config.PriceListRule) priceListSelector {
+	return priceListSelector{rules: rules}
+}
+
+// rank returns the position of priceList in the order that applies to the SKU, or -1
+// when that order does not accept the list (or no rule covers the currency). Lower
+// ranks win; a "*" entry gives every unnamed list the same rank, so among those the
+// first one the ERP returns is kept, as it always was.
+func (s priceListSelector) rank(sku, currency string, priceList int) int {
+	rule, ok := s.ruleFor(sku, currency)
+	if !ok {
+		return -1
+	}
+	for i, candidate := range rule.Lists {
+		if candidate == priceList || candidate == config.PriceListAny {
+			return i
+		}
+	}
+	return -1
+}
+
+// ruleFor picks the rule with the longest SKU prefix matching the SKU, falling back
+// to the currency's default rule.
+func (s priceListSelector) ruleFor(sku, currency string) (config.PriceListRule, bool) {
+	sku = strings.ToUpper(strings.TrimSpace(sku))
+	currency = strings.ToUpper(strings.TrimSpace(currency))
+	var (
+		best  config.PriceListRule
+		found bool
+	)
+	for _, rule := range s.rules {
+		if rule.Currency != currency {
+			continue
+		}
+		prefix := strings.ToUpper(rule.SkuPrefix)
+		if !strings.HasPrefix(sku, prefix) {
+			continue
+		}
+		if !found || len(prefix) > len(best.SkuPrefix) {
+			best = rule
+			found = true
+		}
+	}
+	return best, found
+}
@@ -0,0 +1,40 @@
+package usecases
+
+import (
+	"shopify-exporter/internal/config"
+	"testing"
+)
+
+// TestPriceListSelectorRank covers the configured order, the "*" fallback and a
+// SKU-prefix override replacing the currency default.
+func TestPriceListSelectorRank(t *testing.T) {
+	selector := newPriceListSelector([]config.PriceListRule{
+		{Currency: "ILS", Lists: []int{10, config.PriceListAny}},
+		{Currency: "USD", Lists: []int{7}},
+		{Currency: "ILS", SkuPrefix: "gc-", Lists: []int{12, 10}},
+	})
+
+	tests := []struct {
+		name      string
+		sku       string
+		currency  string
+		priceList int
+		want      int
+	}{
+		{"preferred list", "A-1", "ILS", 10, 0},
+		{"any other list", "A-1", "ILS", 3, 1},
+		{"list not accepted", "A-1", "USD", 3, -1},
+		{"currency without rule", "A-1", "EUR", 7, -1},
+		{"prefix override first", "GC-5", "ILS", 12, 0},
+		{"prefix override second", "GC-5", "ILS", 10, 1},
+		{"prefix override has no wildcard", "GC-5", "ILS", 3, -1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := selector.rank(tt.sku, tt.currency, tt.priceList); got != tt.want {
+				t.Fatalf("rank() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,123 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"shopify-exporter/internal/adapters/shopify"
+	"shopify-exporter/internal/config"
+	"shopify-exporter/internal/debugsync"
+	"shopify-exporter/internal/infra/stockstate"
+	"shopify-exporter/internal/logging"
+	"shopify-exporter/internal/report"
+	"sort"
+	"time"
+)
+
+type RollbackStockService interface {
+	Run(ctx context.Context, runID string) error
+}
+
+// StockRollback re-pushes the quantities of a snapshot from the history, for when a
+// bad ERP feed reached Shopify. It writes through the same SetOnHandQuantities as the
+// stock step, so a dry run, compare-and-set and the report all behave the same.
+type StockRollback struct {
+	stock *ClientStock
+}
+
+func NewRollbackStock(
+	shopifyClient shopify.StockService,
+	logger logging.LoggerService,
+	recorder report.Recorder,
+	stockConfig config.StockConfig,
+) RollbackStockService {
+	return &StockRollback{stock: &ClientStock{
+		shopifyClient: shopifyClient,
+		logger:        logger,
+		recorder:      recorder,
+		stockConfig:   stockConfig,
+	}}
+}
+
+func (r *StockRollback) Run(ctx context.Context, runID string) error {
+	cfg := r.stock.stockConfig
+	snapshot, err := stockstate.LoadArchived(cfg.HistoryDir, runID)
+	if err != nil {
+		r.stock.logError("Error load stock snapshot for rollback", err)
+		return err
+	}
+	if len(snapshot.Quantities) == 0 {
+		err := errors.New("stock snapshot " + snapshot.RunID + " has no quantities")
+		r.stock.logError("Error load stock snapshot for rollback", err)
+		return err
+	}
+
+	keys := make([]string, 0, len(snapshot.Quantities))
+	for key := range snapshot.Quantities {
+		sku, _ := stockstate.SplitKey(key)
+		if !debugsync.ShouldProcessSKU(sku) {
+			continue
+		}
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	inputs := make([]shopify.StockInput, 0, len(keys))
+	for _, key := range keys {
+		sku, location := stockstate.SplitKey(key)
+		inputs = append(inputs, shopify.StockInput{SKU: sku, Location: location, Quantity: snapshot.Quantities[key]})
+	}
+
+	r.stock.log(fmt.Sprintf(
+		"Stock rollback started run_id=%s written_at=%s candidates=%d of=%d dry_run=%t",
+		snapshot.RunID,
+		snapshot.UpdatedAt.Format(time.RFC3339),
+		len(inputs),
+		len(snapshot.Quantities),
+		cfg.DryRun,
+	))
+	if len(inputs) == 0 {
+		r.stock.logWarning("Stock rollback skipped: no SKUs left after " + debugsync.OnlySKUsEnv)
+		return nil
+	}
+	if err := r.stock.shopifyClient.SetOnHandQuantities(ctx, inputs); err != nil {
+		r.stock.logError("Error stock rollback", err)
+		return err
+	}
+
+	if cfg.DryRun {
+		r.stock.log("stock rollback was a dry run: snapshot not written, history not marked")
+		return nil
+	}
+	// A partial rollback is not the state of the whole catalogue; see selectInputs.
+	if debugsync.HasOnlySKUFilter() {
+		r.stock.logWarning("stock snapshot not written: " + debugsync.OnlySKUsEnv + " limited the rollback to a subset of SKUs")
+		return nil
+	}
+
+	// The rolled-back quantities are now what Shopify holds, so they become the
+	// snapshot the next delta run diffs against.
+	now := time.Now()
+	current := stockstate.Snapshot{
+		UpdatedAt:  now,
+		Quantities: snapshot.Quantities,
+		RunID:      stockstate.NextRunID(cfg.HistoryDir, now),
+		RollbackOf: snapshot.RunID,
+	}
+	if err := stockstate.SaveSnapshot(cfg.StatePath, current); err != nil {
+		r.stock.logWarning(fmt.Sprintf("stock snapshot write failed at %s: %v", cfg.StatePath, err))
+	} else {
+		r.stock.archiveSnapshot(current)
+	}
+	if err := stockstate.MarkRestored(cfg.HistoryDir, snapshot.RunID, now); err != nil {
+		r.stock.logWarning(fmt.Sprintf("stock history snapshot %s not marked as restored: %v", snapshot.RunID, err))
+	}
+
+	// The ERP still holds whatever caused the rollback. The next run that reads it
+	// pushes it again, so that has to be fixed (or the cron paused) first.
+	r.stock.logSuccess(fmt.Sprintf(
+		"Stock rollback completed run_id=%s candidates=%d; the next stock run pushes the ERP again",
+		snapshot.RunID,
+		len(inputs),
+	))
+	return nil
+}
@@ -0,0 +1,77 @@
+package usecases
+
+import (
+	"context"
+	"path/filepath"
+	"shopify-exporter/internal/config"
+	"shopify-exporter/internal/infra/stockstate"
+	"testing"
+)
+
+func historyConfig(t *testing.T) config.StockConfig {
+	t.Helper()
+	cfg := config.StockConfig{Mode: config.StockModeFull, StatePath: filepath.Join(t.TempDir(), "stock-state.json")}
+	cfg.HistoryDir = filepath.Join(filepath.Dir(cfg.StatePath), "stock-history")
+	cfg.HistoryKeep = 10
+	return cfg
+}
+
+// A bad feed is rolled back to the snapshot before it, which becomes the current one
+// and is marked in the history.
+func TestRollbackStockRepushesAnArchivedSnapshot(t *testing.T) {
+	cfg := historyConfig(t)
+	for _, feed := range []map[string]int32{{"A-1": 5, "B-2": 3}, {"A-1": 0, "B-2": 0}} {
+		if err := NewSyncStocks(&fakeStockAPI{stocks: stocks(feed)}, nil, &fakeStockShopify{}, nil, nil, cfg).Run(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+	}
+	history, err := stockstate.History(cfg.HistoryDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(history) != 2 {
+		t.Fatalf("history = %d snapshots, want one per run", len(history))
+	}
+	good := history[0].RunID
+
+	dry := cfg
+	dry.DryRun = true
+	dryShopify := &fakeStockShopify{}
+	if err := NewRollbackStock(dryShopify, nil, nil, dry).Run(context.Background(), good); err != nil {
+		t.Fatal(err)
+	}
+	if current, _ := stockstate.Load(cfg.StatePath); current.Quantities["A-1"] != 0 || current.RollbackOf != "" {
+		t.Fatalf("a dry rollback changed the snapshot: %+v", current)
+	}
+
+	shopify := &fakeStockShopify{}
+	if err := NewRollbackStock(shopify, nil, nil, cfg).Run(context.Background(), good); err != nil {
+		t.Fatal(err)
+	}
+	equalSKUs(t, shopify.pushed(), []string{"A-1", "B-2"})
+	if got := shopify.batches[0][0]; got.Quantity != 5 {
+		t.Errorf("A-1 rolled back to %d, want 5", got.Quantity)
+	}
+
+	current, err := stockstate.Load(cfg.StatePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if current.RollbackOf != good || current.Quantities["A-1"] != 5 || current.Quantities["B-2"] != 3 {
+		t.Errorf("current snapshot = %+v, want the rolled-back quantities", current)
+	}
+	restored, err := stockstate.LoadArchived(cfg.HistoryDir, good)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if restored.RestoredAt == nil {
+		t.Error("the rolled-back snapshot must be marked as restored")
+	}
+	if history, _ := stockstate.History(cfg.HistoryDir); len(history) != 3 {
+		t.Errorf("history = %d snapshots, want the rollback archived too", len(history))
+	}
+
+	if err := NewRollbackStock(shopify, nil, nil, cfg).Run(context.Background(), "20000101-000000"); err == nil {
+		t.Error("an unknown run id must be an error")
+	}
+}
@@ -0,0 +1,102 @@
+package usecases
+
+import (
+	"fmt"
+	"shopify-exporter/internal/config"
+	"shopify-exporter/internal/domain/model"
+	"strings"
+	"time"
+)
+
+// saleSchedule is the part of the sales calendar running at one moment.
+type saleSchedule struct {
+	active []model.Sale
+	// currencies holds each active sale's currencies; nil means every currency.
+	currencies map[int][]string
+	// next is the next start or end after now, zero when nothing is scheduled. It is
+	// only logged: the price-delta cron tick is what acts on it.
+	next time.Time
+}
+
+// newSaleSchedule picks the sales running at now and resolves their markets to
+// currencies. Prices are per currency, so a sale for one market also discounts any
+// other market sharing its currency. An unknown market fails every sale that has not
+// ended yet: guessing would put the wrong catalogue on sale.
+func newSaleSchedule(sales []model.Sale, now time.Time, markets []config.MarketDefinition) (saleSchedule, error) {
+	currencyByHandle := make(map[string]string, len(markets))
+	for _, market := range markets {
+		currencyByHandle[strings.ToLower(market.Handle)] = market.Currency
+	}
+
+	schedule := saleSchedule{currencies: make(map[int][]string)}
+	for _, sale := range sales {
+		if !now.Before(sale.End) {
+			continue
+		}
+		var currencies []string
+		for _, handle := range sale.Markets {
+			currency, ok := currencyByHandle[strings.ToLower(strings.TrimSpace(handle))]
+			if !ok {
+				return saleSchedule{}, fmt.Errorf("sale %q: unknown market %q", sale.Name, handle)
+			}
+			if !containsString(currencies, currency) {
+				currencies = append(currencies, currency)
+			}
+		}
+
+		boundary := sale.End
+		if now.Before(sale.Start) {
+			boundary = sale.Start
+		}
+		if schedule.next.IsZero() || boundary.Before(schedule.next) {
+			schedule.next = boundary
+		}
+		if !sale.ActiveAt(now) {
+			continue
+		}
+		schedule.currencies[len(schedule.active)] = currencies
+		schedule.active = append(schedule.active, sale)
+	}
+	return schedule, nil
+}
+
+// best returns, per currency, the active sale with the largest percentage covering
+// the SKU. Overlapping sales do not stack; the first listed wins a tie.
+func (s saleSchedule) best(sku string, currencies []string) map[string]model.Sale {
+	var best map[string]model.Sale
+	for i, sale := range s.active {
+		if !sale.Covers(sku) {
+			continue
+		}
+		for _, currency := range currencies {
+			if scoped := s.currencies[i]; scoped != nil && !containsString(scoped, currency) {
+				continue
+			}
+			if current, ok := best[currency]; ok && current.Percent >= sale.Percent {
+				continue
+			}
+			if best == nil {
+				best = make(map[string]model.Sale)
+			}
+			best[currency] = sale
+		}
+	}
+	return best
+}
+
+func (s saleSchedule) names() []string {
+	names := make([]string, 0, len(s.active))
+	for _, sale := range s.active {
+		names = append(names, sale.Name)
+	}
+	return names
+}
+
+func containsString(values []string, value string) bool {
+	for _, candidate := range values {
+		if candidate == value {
+			return true
+		}
+	}
+	return false
+}
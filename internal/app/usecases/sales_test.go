@@ -0,0 +1,67 @@
+package usecases
+
+import (
+	"shopify-exporter/internal/config"
+	"shopify-exporter/internal/domain/model"
+	"testing"
+	"time"
+)
+
+// TestSaleScheduleActiveAndNext checks which sales run at a moment, that the window
+// end is exclusive, and that the next boundary is the nearest future start or end.
+func TestSaleScheduleActiveAndNext(t *testing.T) {
+	day := func(d, h int) time.Time { return time.Date(2026, 7, d, h, 0, 0, 0, time.UTC) }
+	sales := []model.Sale{
+		{Name: "ended", SkuPrefixes: []string{"A"}, Percent: 10, Start: day(1, 0), End: day(5, 0)},
+		{Name: "running", SkuPrefixes: []string{"A"}, Percent: 20, Start: day(4, 0), End: day(10, 0)},
+		{Name: "upcoming", SkuPrefixes: []string{"A"}, Percent: 30, Start: day(8, 0), End: day(12, 0)},
+	}
+
+	schedule, err := newSaleSchedule(sales, day(5, 0), config.DefaultMarkets)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if names := schedule.names(); len(names) != 1 || names[0] != "running" {
+		t.Errorf("active = %v, want [running]", names)
+	}
+	if !schedule.next.Equal(day(8, 0)) {
+		t.Errorf("next = %v, want %v", schedule.next, day(8, 0))
+	}
+}
+
+// TestSaleScheduleBest checks that overlapping sales do not stack and that a sale
+// scoped to one market only discounts that market's currency.
+func TestSaleScheduleBest(t *testing.T) {
+	now := time.Date(2026, 7, 5, 12, 0, 0, 0, time.UTC)
+	start, end := now.Add(-time.Hour), now.Add(time.Hour)
+	sales := []model.Sale{
+		{Name: "all", SkuPrefixes: []string{"SUM-"}, Percent: 10, Start: start, End: end},
+		{Name: "israel", Skus: []string{"sum-1"}, Percent: 25, Markets: []string{"il"}, Start: start, End: end},
+	}
+	schedule, err := newSaleSchedule(sales, now, config.DefaultMarkets)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	best := schedule.best("SUM-1", []string{"ILS", "USD"})
+	if best["ILS"].Name != "israel" || best["USD"].Name != "all" {
+		t.Errorf("best = ILS:%s USD:%s, want ILS:israel USD:all", best["ILS"].Name, best["USD"].Name)
+	}
+	if best := schedule.best("WIN-1", []string{"ILS", "USD"}); len(best) != 0 {
+		t.Errorf("uncovered sku got sales %v", best)
+	}
+}
+
+// TestSaleScheduleUnknownMarket refuses a running or future sale naming a market
+// that does not exist, but ignores one that has already ended.
+func TestSaleScheduleUnknownMarket(t *testing.T) {
+	now := time.Date(2026, 7, 5, 12, 0, 0, 0, time.UTC)
+	sale := model.Sale{Name: "typo", Skus: []string{"A"}, Percent: 10, Markets: []string{"isreal"}, Start: now, End: now.Add(time.Hour)}
+
+	if _, err := newSaleSchedule([]model.Sale{sale}, now, config.DefaultMarkets); err == nil {
+		t.Error("expected an error for an unknown market")
+	}
+	if _, err := newSaleSchedule([]model.Sale{sale}, now.Add(2*time.Hour), config.DefaultMarkets); err != nil {
+		t.Errorf("ended sale must be ignored, got %v", err)
+	}
+}
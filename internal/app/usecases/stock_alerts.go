@@ -0,0 +1,237 @@
+package usecases
+
+import (
+	"context"
+	"fmt"
+	"shopify-exporter/internal/config"
+	"shopify-exporter/internal/debugsync"
+	"shopify-exporter/internal/domain/model"
+	"shopify-exporter/internal/infra/stockstate"
+	"shopify-exporter/internal/report"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Alert levels as kept in stockstate.AlertState; a SKU above its threshold has none.
+const (
+	stockLevelLow = "low"
+	stockLevelOut = "out"
+)
+
+// maxAlertLines caps the Telegram message; the report lists every alert.
+const maxAlertLines = 20
+
+// checkAlerts compares each SKU's storefront quantity with its threshold and with the
+// level it was last alerted at, and raises an alert only when the level changed. An
+// alert of a kind the SKU raised within the cooldown is held: the SKU keeps its old
+// level, so the change alerts on the first run after the cooldown if it still holds. The
+// quantity is what the sync pushes (the ERP balance less the reserve, summed over the
+// locations), since that is what a customer can buy. Alerts go to the logger, which
+// is Telegram in production, and to the report; neither makes the run a warning.
+func (c *ClientStock) checkAlerts(ctx context.Context, targets map[string]stockTarget, feed stockFeed) {
+	cfg := c.stockConfig.Alerts
+	state, err := stockstate.LoadAlerts(cfg.StatePath)
+	if err != nil {
+		c.warnStock(fmt.Sprintf("stock alert state unusable, recording levels without alerting: %v", err))
+	}
+	policy := c.alertPolicy(ctx)
+
+	quantities := make(map[string]int)
+	for _, target := range targets {
+		quantities[target.SKU] += target.Quantity
+	}
+	skus := make([]string, 0, len(quantities))
+	for sku := range quantities {
+		skus = append(skus, sku)
+	}
+	sort.Strings(skus)
+
+	// SKUs this run did not read (a changes feed, a SKU filter) keep their level.
+	levels := make(map[string]string, len(state.Levels))
+	for sku, level := range state.Levels {
+		levels[sku] = level
+	}
+	now := time.Now()
+	alerted := keptAlertTimes(state.Alerted, now, cfg.Cooldown)
+
+	alerts := make([]report.StockAlert, 0)
+	for _, sku := range skus {
+		quantity := quantities[sku]
+		threshold := policy.reserve(model.Stock{Sku: sku})
+		level := stockAlertLevel(quantity, threshold.Units)
+		previous := state.Levels[sku]
+		if level == "" {
+			delete(levels, sku)
+		} else {
+			levels[sku] = level
+		}
+		if debugsync.MatchSKU(sku) {
+			c.log(fmt.Sprintf(
+				"trace stock alert sku=%s quantity=%d threshold=%d threshold_source=%q level=%q previous=%q",
+				sku,
+				quantity,
+				threshold.Units,
+				threshold.Source,
+				level,
+				previous,
+			))
+		}
+		// An unseeded state knows no previous levels: everything already low would
+		// alert at once.
+		if !state.Seeded() {
+			continue
+		}
+		kind := stockAlertKind(previous, level, cfg)
+		if kind == "" {
+			continue
+		}
+		if last := state.LastAlerted(sku, kind); coolingDown(last, now, cfg.Cooldown) {
+			restoreLevel(levels, sku, previous)
+			if debugsync.MatchSKU(sku) {
+				c.log(fmt.Sprintf("trace stock alert held sku=%s kind=%s last_alerted=%s cooldown=%s", sku, kind, last.Format(time.RFC3339), cfg.Cooldown))
+			}
+			if c.recorder != nil {
+				c.recorder.Incr("stock_alert", "held", 1)
+			}
+			continue
+		}
+		if alerted[sku] == nil {
+			alerted[sku] = make(map[string]time.Time)
+		}
+		alerted[sku][kind] = now
+		alert := report.StockAlert{SKU: sku, Kind: kind, Quantity: quantity}
+		if threshold.Units > 0 {
+			alert.Threshold, alert.Source = threshold.Units, threshold.Source
+		}
+		alerts = append(alerts, alert)
+	}
+
+	if !state.Seeded() {
+		c.log(fmt.Sprintf("stock alert levels recorded for the first time, not alerted: low=%d out=%d", countLevels(levels, stockLevelLow), countLevels(levels, stockLevelOut)))
+	}
+	c.deliverAlerts(alerts)
+
+	// Like the snapshot: a dry run or a SKU-filtered run leaves the state alone, so
+	// the next real run still alerts on what it sees.
+	if c.stockConfig.DryRun || debugsync.HasOnlySKUFilter() {
+		return
+	}
+	// Seeding from a changes feed would record only the SKUs that moved, and the
+	// next full read would alert on every other SKU already low.
+	if !state.Seeded() && feed.incremental {
+		return
+	}
+	if err := stockstate.SaveAlerts(cfg.StatePath, stockstate.AlertState{UpdatedAt: now, Levels: levels, Alerted: alerted}); err != nil {
+		c.warnStock(fmt.Sprintf("stock alert state write failed at %s: %v", cfg.StatePath, err))
+	}
+}
+
+// stockAlertLevel is "out" at 0, "low" at or below a positive threshold, else "".
+func stockAlertLevel(quantity, threshold int) string {
+	switch {
+	case quantity <= 0:
+		return stockLevelOut
+	case quantity <= threshold:
+		return stockLevelLow
+	default:
+		return ""
+	}
+}
+
+// stockAlertKind names the alert a move from previous to current raises, if any.
+// Recovering from low is quiet: the low alert was the news.
+func stockAlertKind(previous, current string, cfg config.StockAlertConfig) string {
+	switch {
+	case current == previous:
+		return ""
+	case current == stockLevelOut:
+		if cfg.OutOfStock {
+			return report.AlertOutOfStock
+		}
+	case previous == stockLevelOut:
+		if cfg.BackInStock {
+			return report.AlertBackInStock
+		}
+	case current == stockLevelLow:
+		return report.AlertLowStock
+	}
+	return ""
+}
+
+// coolingDown reports whether an alert last raised at last is still inside cooldown
+// at now.
+func coolingDown(last, now time.Time, cooldown time.Duration) bool {
+	return cooldown > 0 && !last.IsZero() && now.Sub(last) < cooldown
+}
+
+// restoreLevel puts back the level a SKU was last alerted at.
+func restoreLevel(levels map[string]string, sku, previous string) {
+	if previous == "" {
+		delete(levels, sku)
+		return
+	}
+	levels[sku] = previous
+}
+
+// keptAlertTimes copies the alert times still inside the cooldown; older ones can no
+// longer hold an alert back.
+func keptAlertTimes(alerted map[string]map[string]time.Time, now time.Time, cooldown time.Duration) map[string]map[string]time.Time {
+	kept := make(map[string]map[string]time.Time)
+	for sku, kinds := range alerted {
+		for kind, at := range kinds {
+			if !coolingDown(at, now, cooldown) {
+				continue
+			}
+			if kept[sku] == nil {
+				kept[sku] = make(map[string]time.Time)
+			}
+			kept[sku][kind] = at
+		}
+	}
+	return kept
+}
+
+// deliverAlerts sends one message for the run, not one per SKU, and puts every alert
+// in the report.
+func (c *ClientStock) deliverAlerts(alerts []report.StockAlert) {
+	if len(alerts) == 0 {
+		return
+	}
+	lines := make([]string, 0, maxAlertLines+2)
+	lines = append(lines, fmt.Sprintf("stock alerts (%d)", len(alerts)))
+	for i, alert := range alerts {
+		if c.recorder != nil {
+			c.recorder.StockAlert(alert)
+			c.recorder.Incr("stock_alert", alert.Kind, 1)
+		}
+		if i < maxAlertLines {
+			lines = append(lines, stockAlertLine(alert))
+		}
+	}
+	if len(alerts) > maxAlertLines {
+		lines = append(lines, fmt.Sprintf("... and %d more in the report", len(alerts)-maxAlertLines))
+	}
+	c.logWarning(strings.Join(lines, "\n"))
+}
+
+func stockAlertLine(alert report.StockAlert) string {
+	switch alert.Kind {
+	case report.AlertOutOfStock:
+		return fmt.Sprintf("out of stock: %s", alert.SKU)
+	case report.AlertLowStock:
+		return fmt.Sprintf("low stock: %s quantity=%d threshold=%d", alert.SKU, alert.Quantity, alert.Threshold)
+	default:
+		return fmt.Sprintf("back in stock: %s quantity=%d", alert.SKU, alert.Quantity)
+	}
+}
+
+func countLevels(levels map[string]string, level string) int {
+	count := 0
+	for _, value := range levels {
+		if value == level {
+			count++
+		}
+	}
+	return count
+}
@@ -0,0 +1,126 @@
+package usecases
+
+import (
+	"context"
+	"path/filepath"
+	"shopify-exporter/internal/config"
+	"shopify-exporter/internal/report"
+	"testing"
+	"time"
+)
+
+func alertConfig(t *testing.T) config.StockConfig {
+	t.Helper()
+	cfg := config.StockConfig{Mode: config.StockModeFull, StatePath: filepath.Join(t.TempDir(), "stock-state.json")}
+	cfg.Alerts = config.StockAlertConfig{
+		Enabled: true,
+		Thresholds: config.StockReserveConfig{
+			Default: 3,
+			SKUs:    map[string]int{"VIP-1": 10},
+		},
+		OutOfStock:  true,
+		BackInStock: true,
+		StatePath:   filepath.Join(filepath.Dir(cfg.StatePath), "stock-alerts.json"),
+	}
+	return cfg
+}
+
+func runAlerts(t *testing.T, cfg config.StockConfig, feed map[string]int32) []report.StockAlert {
+	t.Helper()
+	run := report.NewRun("test", "full", "", "", testTime())
+	if err := NewSyncStocks(&fakeStockAPI{stocks: stocks(feed)}, nil, &fakeStockShopify{}, nil, run, cfg).Run(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	return run.Snapshot().StockAlerts
+}
+
+func alertKinds(alerts []report.StockAlert) map[string]string {
+	kinds := make(map[string]string, len(alerts))
+	for _, alert := range alerts {
+		kinds[alert.SKU] = alert.Kind
+	}
+	return kinds
+}
+
+// Each SKU alerts once per change of level: the first run only records the levels,
+// a level that holds stays quiet, and coming back from zero is its own alert.
+func TestStockAlertsFireOncePerLevelChange(t *testing.T) {
+	cfg := alertConfig(t)
+
+	if got := runAlerts(t, cfg, map[string]int32{"A-1": 0, "B-2": 20, "VIP-1": 20}); len(got) != 0 {
+		t.Fatalf("first run alerts = %+v, want none while seeding", got)
+	}
+
+	got := alertKinds(runAlerts(t, cfg, map[string]int32{"A-1": 0, "B-2": 2, "VIP-1": 9}))
+	want := map[string]string{"B-2": report.AlertLowStock, "VIP-1": report.AlertLowStock}
+	if len(got) != len(want) || got["B-2"] != want["B-2"] || got["VIP-1"] != want["VIP-1"] {
+		t.Fatalf("second run alerts = %v, want %v (A-1 was already out)", got, want)
+	}
+
+	got = alertKinds(runAlerts(t, cfg, map[string]int32{"A-1": 4, "B-2": 0, "VIP-1": 8}))
+	want = map[string]string{"A-1": report.AlertBackInStock, "B-2": report.AlertOutOfStock}
+	if len(got) != len(want) || got["A-1"] != want["A-1"] || got["B-2"] != want["B-2"] {
+		t.Fatalf("third run alerts = %v, want %v", got, want)
+	}
+
+	if got := runAlerts(t, cfg, map[string]int32{"A-1": 4, "B-2": 0, "VIP-1": 8}); len(got) != 0 {
+		t.Fatalf("unchanged levels alerted again: %+v", got)
+	}
+}
+
+func TestStockAlertsDryRunKeepsTheState(t *testing.T) {
+	cfg := alertConfig(t)
+	runAlerts(t, cfg, map[string]int32{"A-1": 20})
+
+	dry := cfg
+	dry.DryRun = true
+	if got := runAlerts(t, dry, map[string]int32{"A-1": 0}); len(got) != 1 {
+		t.Fatalf("dry run alerts = %+v, want the out-of-stock alert reported", got)
+	}
+	if got := runAlerts(t, cfg, map[string]int32{"A-1": 0}); len(got) != 1 || got[0].Kind != report.AlertOutOfStock {
+		t.Fatalf("real run after a dry run = %+v, want it to alert too", got)
+	}
+}
+
+// A SKU flipping between 0 and 1 alerts out and back in once per cooldown; a flip
+// inside the cooldown is held and alerts once the cooldown is over.
+func TestStockAlertsCooldownHoldsFlapping(t *testing.T) {
+	cfg := alertConfig(t)
+	cfg.Alerts.Thresholds.Default = 0
+	cfg.Alerts.Cooldown = time.Hour
+	runAlerts(t, cfg, map[string]int32{"A-1": 20})
+
+	for i, step := range []struct {
+		quantity int32
+		want     string
+	}{
+		{0, report.AlertOutOfStock},
+		{1, report.AlertBackInStock},
+		{0, ""},
+		{1, ""},
+		{0, ""},
+	} {
+		got := alertKinds(runAlerts(t, cfg, map[string]int32{"A-1": step.quantity}))
+		if got["A-1"] != step.want || len(got) > 1 {
+			t.Fatalf("step %d quantity=%d alerts = %v, want %q", i, step.quantity, got, step.want)
+		}
+	}
+
+	cfg.Alerts.Cooldown = 0
+	if got := alertKinds(runAlerts(t, cfg, map[string]int32{"A-1": 0})); got["A-1"] != report.AlertOutOfStock {
+		t.Fatalf("held out of stock after the cooldown = %v, want it alerted", got)
+	}
+}
+
+func TestStockAlertKindRespectsTheSwitches(t *testing.T) {
+	cfg := config.StockAlertConfig{}
+	if kind := stockAlertKind("", stockLevelOut, cfg); kind != "" {
+		t.Errorf("out of stock with the alert off = %q", kind)
+	}
+	if kind := stockAlertKind(stockLevelOut, "", cfg); kind != "" {
+		t.Errorf("back in stock with the alert off = %q", kind)
+	}
+	if kind := stockAlertKind(stockLevelLow, "", cfg); kind != "" {
+		t.Errorf("recovering from low = %q, want quiet", kind)
+	}
+}
@@ -0,0 +1,110 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"shopify-exporter/internal/adapters/apix"
+	"shopify-exporter/internal/domain/model"
+	"shopify-exporter/internal/infra/stockstate"
+	"time"
+)
+
+const (
+	// stockCursorOverlap is taken off the local clock when a full read seeds the
+	// cursor, to cover skew against the ERP's clock. Re-reading a few minutes of
+	// changes costs nothing: they diff as unchanged against the snapshot.
+	stockCursorOverlap = 5 * time.Minute
+	// maxStockCursorAge is how stale a cursor may get before a delta reads the full
+	// feed anyway. The daily full run re-seeds it, so an older one means that run has
+	// not happened, and the full feed is the only read that reconciles everything.
+	maxStockCursorAge = 24 * time.Hour
+)
+
+// stockFeed is what one stock run read from the ERP.
+type stockFeed struct {
+	items []model.Stock
+	// incremental is set when items holds only the rows changed since the cursor;
+	// base is then the snapshot they are diffed against and merged into.
+	incremental bool
+	base        stockstate.Snapshot
+	since       time.Time
+	// cursor is what the next changes read starts from; zero when unknown.
+	cursor time.Time
+}
+
+// fetchFeed reads the changes since the cursor when the feed is enabled and every
+// precondition holds, and the full feed otherwise. Falling back is always safe, just
+// slower; trusting a changes read with a gap behind it oversells.
+func (c *ClientStock) fetchFeed(ctx context.Context) (stockFeed, error) {
+	startedAt := time.Now()
+	if c.stockConfig.IsDelta() && c.stockConfig.ChangesFeed {
+		if feed, ok := c.fetchChanges(ctx, startedAt); ok {
+			return feed, nil
+		}
+	}
+
+	stocks, err := c.apixClient.FetchStocks(ctx)
+	if err != nil {
+		return stockFeed{}, err
+	}
+	return stockFeed{items: stocks, cursor: startedAt.Add(-stockCursorOverlap)}, nil
+}
+
+func (c *ClientStock) fetchChanges(ctx context.Context, now time.Time) (stockFeed, bool) {
+	cursor, err := stockstate.LoadCursor(c.stockConfig.CursorPath)
+	if err != nil {
+		c.warnStock(fmt.Sprintf("stock changes cursor unusable, reading the full feed: %v", err))
+		return stockFeed{}, false
+	}
+	if cursor.Since.IsZero() {
+		c.log("stock changes feed has no cursor yet, reading the full feed once")
+		return stockFeed{}, false
+	}
+	if age := now.Sub(cursor.Since); age > maxStockCursorAge {
+		c.logWarning(fmt.Sprintf("stock changes cursor is %s old, reading the full feed", age.Round(time.Minute)))
+		return stockFeed{}, false
+	}
+	// The changes are only meaningful against the snapshot the cursor was saved with.
+	snapshot, err := stockstate.Load(c.stockConfig.StatePath)
+	if err != nil || len(snapshot.Quantities) == 0 {
+		c.log("stock changes feed has no usable snapshot, reading the full feed")
+		return stockFeed{}, false
+	}
+
+	changes, err := c.apixClient.FetchStockChanges(ctx, cursor.Since)
+	if err != nil {
+		if errors.Is(err, apix.ErrStockCursorExpired) {
+			c.logWarning("stock changes cursor is older than the ERP keeps, reading the full feed")
+		} else {
+			c.warnStock(fmt.Sprintf("stock changes feed failed, reading the full feed: %v", err))
+		}
+		return stockFeed{}, false
+	}
+	if c.recorder != nil {
+		c.recorder.Incr("stock", "changes_feed_rows", int64(len(changes.Items)))
+	}
+	if sku, changed := c.kitChanged(changes.Items); changed {
+		c.log(fmt.Sprintf("stock changes touch kit SKU %s, reading the full feed to recompute the kits", sku))
+		return stockFeed{}, false
+	}
+	return stockFeed{
+		items:       changes.Items,
+		incremental: true,
+		base:        snapshot,
+		since:       cursor.Since,
+		cursor:      changes.Cursor,
+	}, true
+}
+
+// saveCursor moves the cursor on once the snapshot matches what this run read. Under
+// the same rules as the snapshot: never on a dry run or a SKU-filtered run.
+func (c *ClientStock) saveCursor(feed stockFeed, usable bool) {
+	if !c.stockConfig.ChangesFeed || feed.cursor.IsZero() || c.stockConfig.DryRun || !usable {
+		return
+	}
+	if err := stockstate.SaveCursor(c.stockConfig.CursorPath, feed.cursor, time.Now()); err != nil {
+		// The old cursor stays, so the next tick re-reads a wider window: safe.
+		c.logWarning(fmt.Sprintf("stock changes cursor write failed at %s: %v", c.stockConfig.CursorPath, err))
+	}
+}
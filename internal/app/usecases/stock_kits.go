@@ -0,0 +1,179 @@
+package usecases
+
+import (
+	"fmt"
+	"shopify-exporter/internal/config"
+	"shopify-exporter/internal/debugsync"
+	"shopify-exporter/internal/domain/model"
+	"shopify-exporter/internal/infra/stockstate"
+	"strings"
+)
+
+// kitBalance is a kit's ERP balance at one location: its own balance plus as many
+// kits as the components there make, and the component that runs out first.
+type kitBalance struct {
+	locationBalance
+	Assemblable int
+	Limiting    string
+}
+
+// kitIndex maps each kit's stockSKUKey to its definition.
+func (c *ClientStock) kitIndex() map[string]config.StockKit {
+	kits := make(map[string]config.StockKit, len(c.stockConfig.Kits))
+	for _, kit := range c.stockConfig.Kits {
+		kits[stockSKUKey(kit.SKU)] = kit
+	}
+	return kits
+}
+
+// addKitTargets puts each kit the feed carries into targets, computed from the rows
+// buildTargets read and then reserved like any SKU, so the delta and the snapshot
+// treat a kit like any other SKU. A kit is computed only when the feed has its row
+// or one of its components'. The full feed always has, and a changes feed reaches
+// here only when it touched no kit (see kitChanged). A component missing from a feed
+// that has the rest counts as 0.
+func (c *ClientStock) addKitTargets(targets map[string]stockTarget, counts *stockTargetCounts, rows map[string]model.Stock, policy stockReservePolicy) {
+	for _, kit := range c.stockConfig.Kits {
+		if !kitInFeed(kit, rows) {
+			continue
+		}
+		if !debugsync.ShouldProcessSKU(kit.SKU) {
+			counts.filteredOut++
+			continue
+		}
+		balances, ok := c.kitBalances(kit, rows, policy)
+		if !ok {
+			counts.noBreakdown++
+			continue
+		}
+		own, found := rows[stockSKUKey(kit.SKU)]
+		if !found {
+			own = model.Stock{Sku: kit.SKU}
+		}
+		own.Sku = kit.SKU
+		reserve := policy.reserve(own)
+		values := make([]int32, len(balances))
+		for i, balance := range balances {
+			values[i] = balance.Balance
+		}
+		quantities, held := reserve.spread(values)
+		for i, balance := range balances {
+			quantity := quantities[i]
+			targets[stockstate.Key(kit.SKU, balance.Location)] = stockTarget{SKU: kit.SKU, Location: balance.Location, Quantity: quantity}
+			if c.recorder != nil {
+				c.recorder.StockReserve(kit.SKU, balance.Location, int(balance.Balance), held[i], reserve.Source)
+				c.recorder.StockKit(kit.SKU, balance.Location, balance.Assemblable, balance.Limiting)
+			}
+			if debugsync.MatchSKU(kit.SKU) {
+				c.log(fmt.Sprintf(
+					"trace stock kit sku=%s location=%q api_quantity=%d assemblable=%d limiting=%s reserve=%d reserve_source=%q shopify_quantity=%d",
+					kit.SKU,
+					balance.Location,
+					balance.Balance,
+					balance.Assemblable,
+					balance.Limiting,
+					reserve.Units,
+					reserve.Source,
+					quantity,
+				))
+			}
+		}
+	}
+}
+
+// kitBalances computes a kit per location as its own ERP balance (a few may be
+// pre-assembled) plus the minimum over the components of what each may sell there,
+// divided by the quantity a kit takes, rounded down. What a component may sell is
+// its storefront quantity: its balance less its own reserve (stockReserve.spread),
+// so a component the reserve holds back is not sold through a kit either. Negative
+// balances count as 0. ok is false when locations are mapped and a row present has
+// no breakdown: a kit cannot be split across locations from a total any more than a
+// SKU can.
+//
+// The units are not shared out: kits with a component in common, and the component
+// sold on its own, each count all of its sellable units. Until the next sync moves
+// them all, the storefront can sell the same unit more than once, and so can the
+// checkout validator, which checks each cart line on its own.
+func (c *ClientStock) kitBalances(kit config.StockKit, rows map[string]model.Stock, policy stockReservePolicy) ([]kitBalance, bool) {
+	own, ok := c.rowBalances(rows, kit.SKU)
+	if !ok {
+		return nil, false
+	}
+	components := make([][]int, 0, len(kit.Components))
+	for _, component := range kit.Components {
+		balances, ok := c.rowBalances(rows, component.SKU)
+		if !ok {
+			return nil, false
+		}
+		row, found := rows[stockSKUKey(component.SKU)]
+		if !found {
+			row = model.Stock{Sku: component.SKU}
+		}
+		row.Sku = component.SKU
+		sellable, _ := policy.reserve(row).spread(balanceValues(balances))
+		components = append(components, sellable)
+	}
+
+	result := make([]kitBalance, 0, len(own))
+	for i, balance := range own {
+		kitAt := kitBalance{locationBalance: locationBalance{Location: balance.Location}, Assemblable: -1}
+		for j, component := range kit.Components {
+			makes := components[j][i] / component.Quantity
+			if kitAt.Assemblable < 0 || makes < kitAt.Assemblable {
+				kitAt.Assemblable, kitAt.Limiting = makes, component.SKU
+			}
+		}
+		kitAt.Balance = max(balance.Balance, 0) + int32(kitAt.Assemblable)
+		result = append(result, kitAt)
+	}
+	return result, true
+}
+
+// rowBalances is locationBalances for the row of sku, or zero at every location when
+// the feed has no row for it.
+func (c *ClientStock) rowBalances(rows map[string]model.Stock, sku string) ([]locationBalance, bool) {
+	item, found := rows[stockSKUKey(sku)]
+	if !found {
+		item = model.Stock{Sku: sku, Warehouses: map[int]int32{}}
+	}
+	return c.locationBalances(item)
+}
+
+// kitChanged returns a kit SKU or component among the changed rows, if any. A kit is
+// computed from rows that did not all change, so a changes feed touching one cannot
+// recompute it, and the full feed is read instead.
+func (c *ClientStock) kitChanged(items []model.Stock) (string, bool) {
+	if len(c.stockConfig.Kits) == 0 {
+		return "", false
+	}
+	parts := make(map[string]bool)
+	for _, kit := range c.stockConfig.Kits {
+		parts[stockSKUKey(kit.SKU)] = true
+		for _, component := range kit.Components {
+			parts[stockSKUKey(component.SKU)] = true
+		}
+	}
+	for _, item := range items {
+		if parts[stockSKUKey(item.Sku)] {
+			return strings.TrimSpace(item.Sku), true
+		}
+	}
+	return "", false
+}
+
+func kitInFeed(kit config.StockKit, rows map[string]model.Stock) bool {
+	if _, ok := rows[stockSKUKey(kit.SKU)]; ok {
+		return true
+	}
+	for _, component := range kit.Components {
+		if _, ok := rows[stockSKUKey(component.SKU)]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// stockSKUKey matches SKUs the way the reserve rules do: trimmed, upper-cased.
+func stockSKUKey(sku string) string {
+	return strings.ToUpper(strings.TrimSpace(sku))
+}
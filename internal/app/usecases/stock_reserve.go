@@ -0,0 +1,95 @@
+package usecases
+
+import (
+	"fmt"
+	"shopify-exporter/internal/config"
+	"shopify-exporter/internal/domain/model"
+	"strings"
+)
+
+// stockReservePolicy resolves how many units of a SKU are kept off the storefront,
+// following the order StockReserveConfig documents.
+type stockReservePolicy struct {
+	cfg config.StockReserveConfig
+	// categories are each SKU's lower-cased category titles, Hebrew and English; nil
+	// when no category rule is configured or the categories could not be read.
+	categories map[string][]string
+}
+
+// stockReserve is one SKU's reserve and the rule it came from, for the trace and the
+// report: "ERP 5, Shopify 2" reads "reserve 3 (default)".
+type stockReserve struct {
+	Units  int
+	Source string
+}
+
+func newStockReservePolicy(cfg config.StockReserveConfig, categories []model.ProductCategories) stockReservePolicy {
+	policy := stockReservePolicy{cfg: cfg}
+	if !cfg.HasCategoryRules() || len(categories) == 0 {
+		return policy
+	}
+	policy.categories = make(map[string][]string, len(categories))
+	for _, item := range categories {
+		sku := strings.ToUpper(strings.TrimSpace(item.SKU))
+		for _, category := range item.Categproes {
+			for _, title := range []string{category.TitleHebrew, category.TitlteEnglish} {
+				if title = strings.ToLower(strings.TrimSpace(title)); title != "" {
+					policy.categories[sku] = append(policy.categories[sku], title)
+				}
+			}
+		}
+	}
+	return policy
+}
+
+func (p stockReservePolicy) reserve(stock model.Stock) stockReserve {
+	sku := strings.ToUpper(strings.TrimSpace(stock.Sku))
+	if units, ok := p.cfg.SKUs[sku]; ok {
+		return stockReserve{Units: units, Source: config.StockReserveBySKU}
+	}
+	if p.cfg.UseERPField && stock.HasReserve {
+		return stockReserve{Units: int(stock.Reserve), Source: "erp"}
+	}
+	for _, rule := range p.cfg.Prefixes {
+		if strings.HasPrefix(sku, rule.Prefix) {
+			return stockReserve{Units: rule.Reserve, Source: fmt.Sprintf("%s %s", config.StockReserveByPrefix, rule.Prefix)}
+		}
+	}
+	// An item in several reserved categories keeps the largest reserve: holding a
+	// unit back too many is a missed sale, one too few is an oversold order.
+	best, found := stockReserve{}, false
+	for _, title := range p.categories[sku] {
+		if units, ok := p.cfg.Categories[title]; ok && (!found || units > best.Units) {
+			best, found = stockReserve{Units: units, Source: fmt.Sprintf("%s %s", config.StockReserveByCategory, title)}, true
+		}
+	}
+	if found {
+		return best
+	}
+	return stockReserve{Units: p.cfg.Default, Source: "default"}
+}
+
+// spread is what Shopify gets at each of a SKU's locations, given its ERP balance at
+// each in the configured order, and the units held back at each. The reserve is taken
+// once per SKU, not once per location: the first location holds back what it has and
+// the rest carries on to the next, so the storefront total is the ERP total less the
+// reserve. Each quantity is clamped at 0 so an out-of-stock or negative item is
+// pushed as 0 rather than skipped and left showing as available. See FIXES.md
+// 2026-06-30.
+func (r stockReserve) spread(balances []int32) (quantities, held []int) {
+	quantities, held = make([]int, len(balances)), make([]int, len(balances))
+	left := r.Units
+	for i, balance := range balances {
+		quantity := int(balance)
+		if quantity < 0 {
+			quantity = 0
+		}
+		take := left
+		if take > quantity {
+			take = quantity
+		}
+		quantities[i], held[i] = quantity-take, take
+		left -= take
+	}
+	return quantities, held
+}
@@ -0,0 +1,62 @@
+package usecases
+
+import (
+	"fmt"
+	"shopify-exporter/internal/config"
+	"shopify-exporter/internal/domain/model"
+	"testing"
+)
+
+// Each rule kind wins over the ones after it, and the target never goes below 0.
+func TestStockReservePolicyOrder(t *testing.T) {
+	cfg := config.StockReserveConfig{
+		Default:     3,
+		SKUs:        map[string]int{"CMG-1": 0},
+		Prefixes:    []config.StockReservePrefix{{Prefix: "CMG-GOLD-", Reserve: 1}, {Prefix: "CMG-", Reserve: 5}},
+		Categories:  map[string]int{"silver": 2, "כסף": 4},
+		UseERPField: true,
+	}
+	policy := newStockReservePolicy(cfg, []model.ProductCategories{
+		{SKU: "KID-1", Categproes: []model.Category{{TitleHebrew: "כסף", TitlteEnglish: "Silver"}}},
+	})
+
+	cases := []struct {
+		stock  model.Stock
+		units  int
+		source string
+	}{
+		{model.Stock{Sku: "cmg-1", Stock: 5, Reserve: 9, HasReserve: true}, 0, "sku"},
+		{model.Stock{Sku: "CMG-2", Stock: 5, Reserve: 9, HasReserve: true}, 9, "erp"},
+		{model.Stock{Sku: "CMG-GOLD-7", Stock: 5}, 1, "prefix CMG-GOLD-"},
+		{model.Stock{Sku: "CMG-8", Stock: 5}, 5, "prefix CMG-"},
+		{model.Stock{Sku: "KID-1", Stock: 5}, 4, "category כסף"},
+		{model.Stock{Sku: "OTHER-1", Stock: 5}, 3, "default"},
+	}
+	for _, tc := range cases {
+		got := policy.reserve(tc.stock)
+		if got.Units != tc.units || got.Source != tc.source {
+			t.Errorf("%s: reserve = %+v, want %d from %s", tc.stock.Sku, got, tc.units, tc.source)
+		}
+	}
+
+	for _, tc := range []struct {
+		units    int
+		balances []int32
+		want     []int
+	}{
+		{3, []int32{2}, []int{0}},
+		{3, []int32{-4}, []int{0}},
+		// A slow item with reserve 0 must show its last unit.
+		{0, []int32{1}, []int{1}},
+		// The reserve is taken once per SKU: what the first location lacks comes off
+		// the next, and a negative balance holds nothing back.
+		{3, []int32{10, 5}, []int{7, 5}},
+		{3, []int32{1, -2, 5}, []int{0, 0, 3}},
+		{3, []int32{1, 1}, []int{0, 0}},
+	} {
+		got, _ := (stockReserve{Units: tc.units}).spread(tc.balances)
+		if fmt.Sprint(got) != fmt.Sprint(tc.want) {
+			t.Errorf("reserve %d over %v = %v, want %v", tc.units, tc.balances, got, tc.want)
+		}
+	}
+}
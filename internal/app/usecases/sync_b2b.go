@@ -0,0 +1,269 @@
+package usecases
+
+import (
+	"context"
+	"fmt"
+	"shopify-exporter/internal/adapters/apix"
+	"shopify-exporter/internal/adapters/shopify"
+	"shopify-exporter/internal/config"
+	"shopify-exporter/internal/debugsync"
+	"shopify-exporter/internal/domain/model"
+	"shopify-exporter/internal/logging"
+	"shopify-exporter/internal/report"
+	"sort"
+	"strings"
+)
+
+type SyncB2BService interface {
+	Run(ctx context.Context) error
+}
+
+// ClientB2B gives the wholesale customers who order by phone their ERP price lists
+// in Shopify. Every ERP customer on a price list becomes a company, and every such
+// price list becomes a B2B catalog with that list's prices as fixed prices.
+type ClientB2B struct {
+	apixCustomers apix.CustomerService
+	apixPrices    apix.PriceService
+	shopifyClient shopify.B2BService
+	logger        logging.LoggerService
+	recorder      report.Recorder
+	cfg           config.B2BConfig
+}
+
+func NewSyncB2B(apixCustomers apix.CustomerService, apixPrices apix.PriceService, shopifyClient shopify.B2BService, logger logging.LoggerService, recorder report.Recorder, cfg config.B2BConfig) SyncB2BService {
+	return &ClientB2B{
+		apixCustomers: apixCustomers,
+		apixPrices:    apixPrices,
+		shopifyClient: shopifyClient,
+		logger:        logger,
+		recorder:      recorder,
+		cfg:           cfg,
+	}
+}
+
+// b2bCatalogTitle names the catalog of one ERP price list. The title is how the next
+// run finds it again, so it must not change.
+func b2bCatalogTitle(priceList int) string {
+	return fmt.Sprintf("B2B price list %d", priceList)
+}
+
+func (c *ClientB2B) Run(ctx context.Context) error {
+	if c.logger != nil {
+		c.logger.Log(fmt.Sprintf("B2B sync started currency=%s", c.cfg.Currency))
+	}
+
+	customers, err := c.apixCustomers.Customers(ctx)
+	if err != nil {
+		if c.logger != nil {
+			c.logger.LogError("Error fetch api customers", err)
+		}
+		return err
+	}
+	byList := groupCustomersByPriceList(customers, c.cfg)
+	if len(byList) == 0 {
+		if c.logger != nil {
+			c.logger.LogWarning(fmt.Sprintf("B2B sync skipped: none of %d ERP customers is on a B2B price list", len(customers)))
+		}
+		return nil
+	}
+
+	companies := make([]shopify.B2BCompanyInput, 0)
+	for _, list := range sortedPriceLists(byList) {
+		for _, customer := range byList[list] {
+			companies = append(companies, shopify.B2BCompanyInput{
+				ExternalID: customer.Key,
+				Name:       customer.Name,
+				Email:      customer.Email,
+				Phone:      customer.Phone,
+			})
+		}
+	}
+	locations, err := c.shopifyClient.EnsureCompanies(ctx, companies)
+	if err != nil {
+		if c.logger != nil {
+			c.logger.LogError("Error ensure b2b companies", err)
+		}
+		return err
+	}
+
+	prices, err := c.apixPrices.PriceList(ctx)
+	if err != nil {
+		if c.logger != nil {
+			c.logger.LogError("Error fetch api prices", err)
+		}
+		return err
+	}
+	pricesByList := c.pricesByList(prices, byList)
+
+	// A SKU-filtered run priced only a few SKUs, so it must not delete the rest.
+	prune := !debugsync.HasOnlySKUFilter()
+	pushed := 0
+	for _, list := range sortedPriceLists(byList) {
+		locationIDs := make([]string, 0, len(byList[list]))
+		for _, customer := range byList[list] {
+			if id := locations[customer.Key]; id != "" {
+				locationIDs = append(locationIDs, id)
+			}
+		}
+		inputs := pricesByList[list]
+		if len(locationIDs) == 0 || len(inputs) == 0 {
+			// An empty list is far more likely a feed problem than a real one, and
+			// pruning against it would strip every wholesale price.
+			c.warnB2B(fmt.Sprintf("B2B price list %d skipped: customers=%d prices=%d in %s", list, len(locationIDs), len(inputs), c.cfg.Currency))
+			continue
+		}
+
+		catalog, err := c.shopifyClient.EnsureB2BCatalog(ctx, shopify.B2BCatalogInput{
+			Title:         b2bCatalogTitle(list),
+			PriceListName: fmt.Sprintf("%s %s", b2bCatalogTitle(list), c.cfg.Currency),
+			Currency:      c.cfg.Currency,
+			LocationIDs:   locationIDs,
+		})
+		if err != nil {
+			if c.logger != nil {
+				c.logger.LogError(fmt.Sprintf("Error ensure b2b catalog for price list %d", list), err)
+			}
+			return err
+		}
+		if err := c.shopifyClient.UpsertB2BPrices(ctx, catalog, inputs, prune); err != nil {
+			if c.logger != nil {
+				c.logger.LogError(fmt.Sprintf("Error sync b2b prices for price list %d", list), err)
+			}
+			return err
+		}
+		pushed++
+	}
+
+	if c.recorder != nil {
+		c.recorder.Incr("b2b", "companies", int64(len(locations)))
+		c.recorder.Incr("b2b", "catalogs", int64(pushed))
+	}
+	if c.logger != nil {
+		c.logger.LogSuccess(fmt.Sprintf("B2B sync completed companies=%d catalogs=%d of=%d", len(locations), pushed, len(byList)))
+	}
+	return nil
+}
+
+// groupCustomersByPriceList keeps the customers whose price list gets a B2B catalog,
+// sorted by key so the companies are created in a stable order.
+func groupCustomersByPriceList(customers []model.Customer, cfg config.B2BConfig) map[int][]model.Customer {
+	byList := make(map[int][]model.Customer)
+	seen := make(map[string]bool, len(customers))
+	for _, customer := range customers {
+		key := strings.TrimSpace(customer.Key)
+		if key == "" || seen[key] || !cfg.Includes(customer.PriceListNumber) {
+			continue
+		}
+		seen[key] = true
+		customer.Key = key
+		byList[customer.PriceListNumber] = append(byList[customer.PriceListNumber], customer)
+	}
+	for _, group := range byList {
+		sort.Slice(group, func(i, j int) bool { return group[i].Key < group[j].Key })
+	}
+	return byList
+}
+
+// pricesByList picks each B2B list's prices in the B2B currency, one per SKU. A SKU
+// the ERP lists twice keeps its first price, as the retail price step does. Volume
+// break rows become the SKU's quantity breaks; a SKU whose breaks or quantity rule
+// Shopify would reject is pushed with its unit price alone and reported.
+func (c *ClientB2B) pricesByList(prices []model.Price, byList map[int][]model.Customer) map[int][]shopify.PriceUpsertInput {
+	seen := make(map[int]map[string]bool, len(byList))
+	inputs := make(map[int][]shopify.PriceUpsertInput, len(byList))
+	breaks := make(map[int]map[string][]shopify.QuantityBreak, len(byList))
+	otherCurrency := 0
+	for _, price := range prices {
+		list := price.PriceListNumber
+		if _, ok := byList[list]; !ok {
+			continue
+		}
+		sku := strings.TrimSpace(price.Sku)
+		if sku == "" || !debugsync.ShouldProcessSKU(sku) {
+			continue
+		}
+		if !strings.EqualFold(strings.TrimSpace(price.Currency), c.cfg.Currency) {
+			otherCurrency++
+			continue
+		}
+		if price.IsQuantityBreak() {
+			if breaks[list] == nil {
+				breaks[list] = make(map[string][]shopify.QuantityBreak)
+			}
+			breaks[list][sku] = append(breaks[list][sku], shopify.QuantityBreak{
+				MinimumQuantity: price.FromQuantity,
+				Price:           float64(price.Price),
+			})
+			continue
+		}
+		if seen[list] == nil {
+			seen[list] = make(map[string]bool)
+		}
+		if seen[list][sku] {
+			continue
+		}
+		seen[list][sku] = true
+		inputs[list] = append(inputs[list], shopify.PriceUpsertInput{
+			SKU:    sku,
+			Prices: map[string]float64{c.cfg.Currency: float64(price.Price)},
+			QuantityRule: shopify.QuantityRule{
+				Minimum:   price.OrderMinimum,
+				Maximum:   price.OrderMaximum,
+				Increment: price.OrderIncrement,
+			},
+		})
+	}
+
+	withVolume, rejected := 0, 0
+	for list, items := range inputs {
+		sort.Slice(items, func(i, j int) bool { return items[i].SKU < items[j].SKU })
+		for i := range items {
+			items[i].QuantityBreaks = sortedQuantityBreaks(breaks[list][items[i].SKU])
+			if len(items[i].QuantityBreaks) == 0 && items[i].QuantityRule.IsZero() {
+				continue
+			}
+			if err := items[i].ValidateQuantityPricing(c.cfg.Currency); err != nil {
+				rejected++
+				c.warnB2B(fmt.Sprintf("B2B price list %d sku %s volume pricing skipped: %v", list, items[i].SKU, err))
+				if c.recorder != nil {
+					c.recorder.DataIssue(items[i].SKU, "b2b_quantity_pricing", fmt.Sprintf("price list %d: %v", list, err))
+				}
+				items[i].QuantityBreaks = nil
+				items[i].QuantityRule = shopify.QuantityRule{}
+				continue
+			}
+			withVolume++
+		}
+	}
+	if c.recorder != nil {
+		c.recorder.Incr("b2b", "prices_other_currency", int64(otherCurrency))
+		c.recorder.Incr("b2b", "quantity_pricing", int64(withVolume))
+		c.recorder.Incr("b2b", "quantity_pricing_rejected", int64(rejected))
+	}
+	return inputs
+}
+
+// sortedQuantityBreaks orders a SKU's breaks by quantity, the order Shopify and the
+// validation expect; the ERP returns them in no particular order.
+func sortedQuantityBreaks(breaks []shopify.QuantityBreak) []shopify.QuantityBreak {
+	sort.SliceStable(breaks, func(i, j int) bool { return breaks[i].MinimumQuantity < breaks[j].MinimumQuantity })
+	return breaks
+}
+
+func sortedPriceLists(byList map[int][]model.Customer) []int {
+	lists := make([]int, 0, len(byList))
+	for list := range byList {
+		lists = append(lists, list)
+	}
+	sort.Ints(lists)
+	return lists
+}
+
+func (c *ClientB2B) warnB2B(message string) {
+	if c.logger != nil {
+		c.logger.LogWarning(message)
+	}
+	if c.recorder != nil {
+		c.recorder.Warn("b2b", message)
+	}
+}
@@ -0,0 +1,131 @@
+package usecases
+
+import (
+	"context"
+	"shopify-exporter/internal/adapters/shopify"
+	"shopify-exporter/internal/config"
+	"shopify-exporter/internal/domain/model"
+	"testing"
+)
+
+type fakeCustomers struct {
+	customers []model.Customer
+}
+
+func (f *fakeCustomers) Customers(context.Context) ([]model.Customer, error) {
+	return f.customers, nil
+}
+
+// fakeB2BShopify gives each company the location "loc-<key>" and records the rest.
+type fakeB2BShopify struct {
+	companies []shopify.B2BCompanyInput
+	catalogs  []shopify.B2BCatalogInput
+	prices    map[string][]shopify.PriceUpsertInput
+	prune     bool
+}
+
+func (f *fakeB2BShopify) EnsureCompanies(_ context.Context, companies []shopify.B2BCompanyInput) (map[string]string, error) {
+	f.companies = companies
+	locations := make(map[string]string, len(companies))
+	for _, company := range companies {
+		locations[company.ExternalID] = "loc-" + company.ExternalID
+	}
+	return locations, nil
+}
+
+func (f *fakeB2BShopify) EnsureB2BCatalog(_ context.Context, input shopify.B2BCatalogInput) (shopify.B2BCatalog, error) {
+	f.catalogs = append(f.catalogs, input)
+	return shopify.B2BCatalog{Title: input.Title, Currency: input.Currency, PriceListID: "pl-" + input.Title}, nil
+}
+
+func (f *fakeB2BShopify) UpsertB2BPrices(_ context.Context, catalog shopify.B2BCatalog, inputs []shopify.PriceUpsertInput, prune bool) error {
+	if f.prices == nil {
+		f.prices = make(map[string][]shopify.PriceUpsertInput)
+	}
+	f.prices[catalog.Title] = inputs
+	f.prune = prune
+	return nil
+}
+
+// Customers are grouped into one catalog per configured price list; a list with no
+// prices in the B2B currency is skipped rather than emptied.
+func TestSyncB2BBuildsOneCatalogPerPriceList(t *testing.T) {
+	customers := &fakeCustomers{customers: []model.Customer{
+		{Key: "C-2", Name: "Beta", PriceListNumber: 12},
+		{Key: "C-1", Name: "Alpha", PriceListNumber: 12},
+		{Key: "C-3", Name: "Gamma", PriceListNumber: 14},
+		{Key: "C-4", Name: "Retail", PriceListNumber: 10},
+		{Key: "C-5", Name: "No list"},
+	}}
+	prices := &fakePriceAPI{prices: []model.Price{
+		{Sku: "A-1", Currency: "ILS", Price: 80, PriceListNumber: 12},
+		{Sku: "A-1", Currency: "ILS", Price: 75, PriceListNumber: 12},
+		{Sku: "B-2", Currency: "ILS", Price: 30, PriceListNumber: 12},
+		{Sku: "A-1", Currency: "USD", Price: 22, PriceListNumber: 12},
+		{Sku: "A-1", Currency: "USD", Price: 21, PriceListNumber: 14},
+		{Sku: "A-1", Currency: "ILS", Price: 100, PriceListNumber: 10},
+	}}
+	shop := &fakeB2BShopify{}
+	cfg := config.B2BConfig{Enabled: true, Currency: "ILS", PriceLists: []int{12, 14}}
+
+	if err := NewSyncB2B(customers, prices, shop, nil, nil, cfg).Run(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(shop.companies) != 3 || shop.companies[0].ExternalID != "C-1" {
+		t.Fatalf("companies = %+v, want C-1, C-2, C-3", shop.companies)
+	}
+	if len(shop.catalogs) != 1 {
+		t.Fatalf("catalogs = %+v, want only list 12 (list 14 has no ILS prices)", shop.catalogs)
+	}
+	catalog := shop.catalogs[0]
+	if catalog.Title != "B2B price list 12" || len(catalog.LocationIDs) != 2 || catalog.LocationIDs[0] != "loc-C-1" {
+		t.Errorf("catalog = %+v", catalog)
+	}
+
+	pushed := shop.prices["B2B price list 12"]
+	if len(pushed) != 2 || pushed[0].SKU != "A-1" || pushed[0].Prices["ILS"] != 80 || pushed[1].SKU != "B-2" {
+		t.Errorf("pushed = %+v, want A-1 at its first price 80 and B-2", pushed)
+	}
+	if !shop.prune {
+		t.Error("an unfiltered run must prune prices the ERP list dropped")
+	}
+}
+
+// Break rows become the SKU's sorted quantity breaks, never its unit price; a SKU
+// with breaks Shopify would reject keeps its unit price without them.
+func TestSyncB2BCarriesVolumePricing(t *testing.T) {
+	customers := &fakeCustomers{customers: []model.Customer{{Key: "C-1", Name: "Alpha", PriceListNumber: 12}}}
+	prices := &fakePriceAPI{prices: []model.Price{
+		{Sku: "A-1", Currency: "ILS", Price: 70, PriceListNumber: 12, FromQuantity: 50},
+		{Sku: "A-1", Currency: "ILS", Price: 80, PriceListNumber: 12, OrderMinimum: 10, OrderIncrement: 10},
+		{Sku: "A-1", Currency: "ILS", Price: 75, PriceListNumber: 12, FromQuantity: 20},
+		{Sku: "B-2", Currency: "ILS", Price: 30, PriceListNumber: 12},
+		{Sku: "B-2", Currency: "ILS", Price: 35, PriceListNumber: 12, FromQuantity: 10},
+	}}
+	shop := &fakeB2BShopify{}
+	cfg := config.B2BConfig{Enabled: true, Currency: "ILS", PriceLists: []int{12}}
+
+	if err := NewSyncB2B(customers, prices, shop, nil, nil, cfg).Run(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	pushed := shop.prices["B2B price list 12"]
+	if len(pushed) != 2 {
+		t.Fatalf("pushed = %+v, want A-1 and B-2", pushed)
+	}
+	a := pushed[0]
+	if a.SKU != "A-1" || a.Prices["ILS"] != 80 {
+		t.Fatalf("A-1 = %+v, want its unit price 80", a)
+	}
+	if len(a.QuantityBreaks) != 2 || a.QuantityBreaks[0].MinimumQuantity != 20 || a.QuantityBreaks[1].Price != 70 {
+		t.Errorf("A-1 breaks = %+v, want 20@75 then 50@70", a.QuantityBreaks)
+	}
+	if a.QuantityRule != (shopify.QuantityRule{Minimum: 10, Increment: 10}) {
+		t.Errorf("A-1 rule = %+v", a.QuantityRule)
+	}
+	b := pushed[1]
+	if b.Prices["ILS"] != 30 || len(b.QuantityBreaks) != 0 {
+		t.Errorf("B-2 = %+v, want unit price 30 without its break above it", b)
+	}
+}
@@ -5,9 +5,18 @@ import (
 	"fmt"
 	"shopify-exporter/internal/adapters/apix"
 	"shopify-exporter/internal/adapters/shopify"
+	"shopify-exporter/internal/config"
 	"shopify-exporter/internal/debugsync"
+	"shopify-exporter/internal/domain/model"
+	"shopify-exporter/internal/infra/pricequarantine"
+	"shopify-exporter/internal/infra/pricerates"
+	"shopify-exporter/internal/infra/pricestate"
+	"shopify-exporter/internal/infra/salescalendar"
 	"shopify-exporter/internal/logging"
+	"shopify-exporter/internal/report"
+	"sort"
 	"strings"
+	"time"
 )
 
 type SyncPricesService interface {
@@ -15,45 +24,100 @@ type SyncPricesService interface {
 }
 
 type ClientPrice struct {
-	apixClient     apix.PriceService
-	apixProducts   apix.NewClientService
-	shopifyClient  shopify.PriceService
-	logger         logging.LoggerService
+	apixClient    apix.PriceService
+	apixProducts  apix.NewClientService
+	shopifyClient shopify.PriceService
+	logger        logging.LoggerService
+	recorder      report.Recorder
+	priceLists    priceListSelector
+	currencies    []string
+	discounts     discountTable
+	// saleCollection is where discounted products are kept; empty disables it.
+	saleCollection string
+	// calendar is the scheduled sales source; nil when none is configured.
+	calendar salescalendar.Source
+	// rates feeds the conversion fallback; nil when it is off.
+	rates      pricerates.Source
+	conversion config.PriceConversionConfig
+	vat        vatTreatment
+	markets    []config.MarketDefinition
+	guard      priceGuard
+	// deprecated are legacy settings still set, warned about on every run.
+	deprecated []string
+	// mode, statePath and dryRun follow the stock step's delta semantics.
+	mode      string
+	statePath string
+	dryRun    bool
+	now       func() time.Time
 }
 
-const (
-	discountCode50Pct       = "5"
-	discountProductPageSize = 100
+// priceItem is what the price step needs from the ERP item card.
+type priceItem struct {
+	Discount itemDiscount
+	// Cost is the ERP purchase price, 0 when unknown.
+	Cost float64
+	// VATExempt items are pushed at their net price even where VAT is added.
+	VATExempt bool
+	// CostWithVAT is set when the cost currency's price went out with VAT added, so
+	// the guard compares it with the cost grossed up the same way.
+	CostWithVAT bool
+}
 
-	// preferredUSDPriceList is the ERP price list number used for B2C USD prices.
-	preferredUSDPriceList = 7
-	// preferredILSPriceList is the ERP price list number used for ILS prices.
-	preferredILSPriceList = 10
-)
+const discountProductPageSize = 100
 
-func NewSyncPrices(apixClient apix.PriceService, apixProducts apix.NewClientService, shopifyClient shopify.PriceService, logger logging.LoggerService) SyncPricesService {
-	return &ClientPrice{
-		apixClient:    apixClient,
-		apixProducts:  apixProducts,
-		shopifyClient: shopifyClient,
-		logger:        logger,
+func NewSyncPrices(apixClient apix.PriceService, apixProducts apix.NewClientService, shopifyClient shopify.PriceService, logger logging.LoggerService, recorder report.Recorder, cfg config.PriceConfig, calendar salescalendar.Source, rates pricerates.Source) SyncPricesService {
+	if len(cfg.Markets) == 0 {
+		cfg.Markets = config.DefaultMarkets
 	}
-}
-
-func discountFraction(code string) float64 {
-	switch strings.TrimSpace(code) {
-	case discountCode50Pct:
-		return 0.5
+	if len(cfg.Currencies) == 0 {
+		cfg.Currencies = config.MarketCurrencies(cfg.Markets)
+	}
+	return &ClientPrice{
+		apixClient:     apixClient,
+		apixProducts:   apixProducts,
+		shopifyClient:  shopifyClient,
+		logger:         logger,
+		recorder:       recorder,
+		priceLists:     newPriceListSelector(cfg.ListRules),
+		currencies:     cfg.Currencies,
+		discounts:      newDiscountTable(cfg.Discounts),
+		saleCollection: cfg.Discounts.SaleCollection,
+		calendar:       calendar,
+		rates:          rates,
+		conversion:     cfg.Conversion,
+		vat:            newVATTreatment(cfg.VAT, cfg.Markets),
+		markets:        cfg.Markets,
+		guard:          priceGuard{cfg: cfg.Guard},
+		deprecated:     cfg.DeprecatedSettings,
+		mode:           cfg.Mode,
+		statePath:      cfg.StatePath,
+		dryRun:         cfg.DryRun,
+		now:            time.Now,
 	}
-	return 0
 }
 
 func (c *ClientPrice) Run(ctx context.Context) error {
+	if c.mode != config.PriceModeDelta {
+		c.mode = config.PriceModeFull
+	}
 	if c.logger != nil {
-		c.logger.Log("Price sync started")
+		c.logger.Log(fmt.Sprintf("Price sync started mode=%s dry_run=%t", c.mode, c.dryRun))
+		if c.dryRun {
+			c.logger.LogWarning("DRY RUN active (SYNC_PRICE_DRY_RUN): reads only, no price writes to Shopify, snapshot and quarantine not updated")
+		}
+	}
+	// Only the full run warns: a warning on every */15 delta tick would make each of
+	// them a report worth mailing.
+	if len(c.deprecated) > 0 {
+		message := fmt.Sprintf("%s are deprecated: move them to the international market in SHOPIFY_MARKETS_FILE and remove them (see FIXES.md)", strings.Join(c.deprecated, ", "))
+		if c.mode == config.PriceModeFull {
+			c.warnPrices(message)
+		} else if c.logger != nil {
+			c.logger.Log(message)
+		}
 	}
 
-	discountByCode, err := c.fetchDiscountCodes(ctx)
+	items, err := c.fetchItems(ctx)
 	if err != nil {
 		if c.logger != nil {
 			c.logger.LogError("Error fetch api products for discounts", err)
@@ -61,6 +125,16 @@ func (c *ClientPrice) Run(ctx context.Context) error {
 		return err
 	}
 
+	// A calendar that cannot be read fails the step: pushing full prices would end
+	// every running sale early, while the last pushed prices are still right.
+	schedule, err := c.loadSales(ctx)
+	if err != nil {
+		if c.logger != nil {
+			c.logger.LogError("Error load sales calendar", err)
+		}
+		return err
+	}
+
 	prices, err := c.apixClient.PriceList(ctx)
 	if err != nil {
 		if c.logger != nil {
@@ -69,14 +143,21 @@ func (c *ClientPrice) Run(ctx context.Context) error {
 		return err
 	}
 
+	// currencyPrice is the accepted price in one currency. Rank is the accepted list's
+	// position in the configured order; a candidate replaces it only with a lower rank.
+	type currencyPrice struct {
+		Price  float64
+		FromPL int
+		Rank   int
+	}
 	type skuPrices struct {
-		USD       float64
-		ILS       float64
-		HasUSD    bool
-		HasILS    bool
-		SkuTrim   string
-		USDFromPL int
-		ILSFromPL int
+		SkuTrim    string
+		ByCurrency map[string]currencyPrice
+	}
+
+	wanted := make(map[string]bool, len(c.currencies))
+	for _, currency := range c.currencies {
+		wanted[currency] = true
 	}
 
 	priceMap := make(map[string]*skuPrices)
@@ -90,126 +171,171 @@ func (c *ClientPrice) Run(ctx context.Context) error {
 			filteredOut++
 			continue
 		}
+		// A volume price is never a unit price, whatever list it is on.
+		if price.IsQuantityBreak() {
+			continue
+		}
 		entry := priceMap[sku]
 		if entry == nil {
-			entry = &skuPrices{SkuTrim: sku}
+			entry = &skuPrices{SkuTrim: sku, ByCurrency: make(map[string]currencyPrice)}
 			priceMap[sku] = entry
 		}
-		switch strings.ToUpper(strings.TrimSpace(price.Currency)) {
-		case "USD":
-			accept := !entry.HasUSD || (entry.USDFromPL != preferredUSDPriceList && price.PriceListNumber == preferredUSDPriceList)
-			if c.logger != nil && debugsync.MatchSKU(sku) {
-				c.logger.Log(fmt.Sprintf(
-					"trace price candidate sku=%s currency=USD price=%.2f price_list=%d accepted=%t",
-					sku,
-					float64(price.Price),
-					price.PriceListNumber,
-					accept,
-				))
-			}
-			if accept {
-				entry.USD = float64(price.Price)
-				entry.HasUSD = true
-				entry.USDFromPL = price.PriceListNumber
-			}
-		case "ILS":
-			accept := !entry.HasILS || (entry.ILSFromPL != preferredILSPriceList && price.PriceListNumber == preferredILSPriceList)
-			if c.logger != nil && debugsync.MatchSKU(sku) {
-				c.logger.Log(fmt.Sprintf(
-					"trace price candidate sku=%s currency=ILS price=%.2f price_list=%d accepted=%t",
-					sku,
-					float64(price.Price),
-					price.PriceListNumber,
-					accept,
-				))
-			}
-			if accept {
-				entry.ILS = float64(price.Price)
-				entry.HasILS = true
-				entry.ILSFromPL = price.PriceListNumber
+		currency := strings.ToUpper(strings.TrimSpace(price.Currency))
+		if !wanted[currency] {
+			continue
+		}
+		rank := c.priceLists.rank(sku, currency, price.PriceListNumber)
+		current, has := entry.ByCurrency[currency]
+		accept := rank >= 0 && (!has || rank < current.Rank)
+		if c.logger != nil && debugsync.MatchSKU(sku) {
+			c.logger.Log(fmt.Sprintf(
+				"trace price candidate sku=%s currency=%s price=%.2f price_list=%d rank=%d accepted=%t",
+				sku,
+				currency,
+				float64(price.Price),
+				price.PriceListNumber,
+				rank,
+				accept,
+			))
+		}
+		if accept {
+			entry.ByCurrency[currency] = currencyPrice{
+				Price:  float64(price.Price),
+				FromPL: price.PriceListNumber,
+				Rank:   rank,
 			}
 		}
 	}
 
 	inputs := make([]shopify.PriceUpsertInput, 0, len(priceMap))
-	missingBoth := 0
+	missingAny := 0
+	discounted := make([]string, 0)
+	converter := c.lazyConverter(ctx)
 	for _, entry := range priceMap {
-		if !entry.HasUSD || !entry.HasILS {
+		missing := make([]string, 0)
+		for _, currency := range c.currencies {
+			if _, ok := entry.ByCurrency[currency]; !ok {
+				missing = append(missing, currency)
+			}
+		}
+		var derived map[string]derivedPrice
+		if len(missing) > 0 {
+			ok := false
+			if convert, usable := converter(); usable {
+				have := make(map[string]float64, len(entry.ByCurrency))
+				for currency, accepted := range entry.ByCurrency {
+					have[currency] = accepted.Price
+				}
+				derived, ok = convert.derive(have, missing, c.currencies)
+			}
+			if ok {
+				missing = missing[:0]
+			}
+		}
+		if len(missing) > 0 {
 			if c.logger != nil && debugsync.MatchSKU(entry.SkuTrim) {
 				c.logger.Log(fmt.Sprintf(
-					"trace price skipped sku=%s has_usd=%t usd=%.2f has_ils=%t ils=%.2f",
+					"trace price skipped sku=%s missing=%s",
 					entry.SkuTrim,
-					entry.HasUSD,
-					entry.USD,
-					entry.HasILS,
-					entry.ILS,
+					strings.Join(missing, ","),
 				))
 			}
-			missingBoth++
+			missingAny++
 			continue
 		}
-		if c.logger != nil && debugsync.MatchSKU(entry.SkuTrim) {
-			c.logger.Log(fmt.Sprintf(
-				"trace price prepared sku=%s usd=%.2f usd_pl=%d ils=%.2f ils_pl=%d",
-				entry.SkuTrim,
-				entry.USD,
-				entry.USDFromPL,
-				entry.ILS,
-				entry.ILSFromPL,
-			))
-		}
 		input := shopify.PriceUpsertInput{
-			SKU:      entry.SkuTrim,
-			USDPrice: entry.USD,
-			ILSPrice: entry.ILS,
-		}
-		if frac := discountFraction(discountByCode[entry.SkuTrim]); frac > 0 {
-			input.USDCompareAt = entry.USD
-			input.ILSCompareAt = entry.ILS
-			input.USDPrice = entry.USD * (1 - frac)
-			input.ILSPrice = entry.ILS * (1 - frac)
-			if c.logger != nil && debugsync.MatchSKU(entry.SkuTrim) {
-				c.logger.Log(fmt.Sprintf(
-					"trace price discount sku=%s code=%s fraction=%.2f usd_price=%.2f usd_compare_at=%.2f ils_price=%.2f ils_compare_at=%.2f",
-					entry.SkuTrim,
-					discountByCode[entry.SkuTrim],
-					frac,
-					input.USDPrice,
-					input.USDCompareAt,
-					input.ILSPrice,
-					input.ILSCompareAt,
-				))
+			SKU:       entry.SkuTrim,
+			Prices:    make(map[string]float64, len(c.currencies)),
+			CompareAt: make(map[string]float64, len(c.currencies)),
+		}
+		parts := make([]string, 0, len(c.currencies))
+		exempt := items[entry.SkuTrim].VATExempt
+		costWithVAT := false
+		for _, currency := range c.currencies {
+			if d, ok := derived[currency]; ok {
+				// A price converted from a net list is net too; the currency's rounding
+				// is applied again to the gross price.
+				gross, vat := c.vat.resolve(d.Price, currency, entry.ByCurrency[d.From].FromPL, exempt)
+				if vat == vatAdded {
+					convert, _ := converter()
+					d.Price = convert.round(gross, currency)
+					costWithVAT = costWithVAT || currency == c.guard.cfg.CostCurrency
+				}
+				input.Prices[currency] = d.Price
+				parts = append(parts, fmt.Sprintf("%s=%.2f %s_from=%s@%.4f", strings.ToLower(currency), d.Price, strings.ToLower(currency), d.From, d.Rate))
+				c.recordDerivedPrice(entry.SkuTrim, currency, d)
+				continue
+			}
+			accepted := entry.ByCurrency[currency]
+			pushed, vat := c.vat.resolve(accepted.Price, currency, accepted.FromPL, exempt)
+			input.Prices[currency] = pushed
+			costWithVAT = costWithVAT || (vat == vatAdded && currency == c.guard.cfg.CostCurrency)
+			parts = append(parts, fmt.Sprintf("%s=%.2f %s_pl=%d", strings.ToLower(currency), pushed, strings.ToLower(currency), accepted.FromPL))
+			if vat != vatAsIs {
+				parts = append(parts, fmt.Sprintf("%s_erp=%.2f %s_vat=%q", strings.ToLower(currency), accepted.Price, strings.ToLower(currency), c.vat.describe(vat)))
 			}
+			c.recordPriceSource(entry.SkuTrim, currency, accepted.FromPL, accepted.Price, vat)
+		}
+		if costWithVAT {
+			item := items[entry.SkuTrim]
+			item.CostWithVAT = true
+			items[entry.SkuTrim] = item
+		}
+		if c.logger != nil && debugsync.MatchSKU(entry.SkuTrim) {
+			c.logger.Log(fmt.Sprintf("trace price prepared sku=%s %s", entry.SkuTrim, strings.Join(parts, " ")))
+		}
+		if c.applyDiscount(&input, items[entry.SkuTrim].Discount, schedule.best(entry.SkuTrim, c.currencies)) {
+			discounted = append(discounted, entry.SkuTrim)
 		}
 		inputs = append(inputs, input)
 	}
 
 	if len(inputs) == 0 {
 		if c.logger != nil {
-			c.logger.LogWarning("Price sync skipped: no SKUs with both USD and ILS prices")
+			c.logger.LogWarning(fmt.Sprintf("Price sync skipped: no SKUs with prices in all of %s", strings.Join(c.currencies, ", ")))
 		}
 		return nil
 	}
 
-	if _, err := c.shopifyClient.EnsureIsraelMarketAndCatalog(ctx); err != nil {
+	pending, snapshotUsable := c.selectInputs(inputs)
+	if len(pending) == 0 {
+		// A quiet delta tick: nothing moved, so no Shopify call at all, and the sale
+		// collection cannot have changed either.
 		if c.logger != nil {
-			c.logger.LogError("Error ensure Israel market", err)
+			c.logger.LogSuccess(fmt.Sprintf("Price sync completed mode=%s no_price_changes=true sku=%d", c.mode, len(inputs)))
+		}
+		return nil
+	}
+
+	if _, err := c.shopifyClient.EnsureMarketsAndCatalogs(ctx); err != nil {
+		if c.logger != nil {
+			c.logger.LogError("Error ensure markets", err)
 		}
 		return err
 	}
 
-	if err := c.shopifyClient.UpsertPricesBatch(ctx, inputs); err != nil {
+	held, err := c.pushPrices(ctx, pending, items)
+	if err != nil {
 		if c.logger != nil {
 			c.logger.LogError("Error sync prices", err)
 		}
 		return err
 	}
 
+	if !c.dryRun {
+		c.syncSaleCollection(ctx, discounted)
+	}
+	c.saveSnapshot(inputs, held, snapshotUsable)
+
 	if c.logger != nil {
 		c.logger.LogSuccess(fmt.Sprintf(
-			"Price sync completed sku=%d skipped_missing=%d filtered_out=%d",
+			"Price sync completed mode=%s candidates=%d of=%d discounted=%d held=%d skipped_missing=%d filtered_out=%d",
+			c.mode,
+			len(pending),
 			len(inputs),
-			missingBoth,
+			len(discounted),
+			len(held),
+			missingAny,
 			filteredOut,
 		))
 	}
@@ -217,10 +343,378 @@ func (c *ClientPrice) Run(ctx context.Context) error {
 	return nil
 }
 
-func (c *ClientPrice) fetchDiscountCodes(ctx context.Context) (map[string]string, error) {
-	codes := make(map[string]string)
+// selectInputs narrows the resolved prices to what this run should push: all of them
+// in full mode, in delta mode only those that moved since the last successful run.
+// The second return value reports whether the snapshot may be written back; see
+// ClientStock.selectInputs, whose rules this follows.
+func (c *ClientPrice) selectInputs(inputs []shopify.PriceUpsertInput) ([]shopify.PriceUpsertInput, bool) {
+	snapshotUsable := !debugsync.HasOnlySKUFilter()
+	sort.Slice(inputs, func(i, j int) bool { return inputs[i].SKU < inputs[j].SKU })
+
+	if c.mode != config.PriceModeDelta {
+		return inputs, snapshotUsable
+	}
+	snapshot, err := pricestate.Load(c.statePath)
+	if err != nil {
+		c.warnPrices(fmt.Sprintf("price delta snapshot unusable, falling back to a full push: %v", err))
+		return inputs, snapshotUsable
+	}
+	if len(snapshot.Prices) == 0 {
+		if c.logger != nil {
+			c.logger.Log("price delta has no previous snapshot, pushing everything once")
+		}
+		return inputs, snapshotUsable
+	}
+
+	pending := make([]shopify.PriceUpsertInput, 0)
+	for _, input := range inputs {
+		if snapshot.Changed(input.SKU, stateEntry(input)) {
+			pending = append(pending, input)
+		}
+	}
+	if c.logger != nil {
+		c.logger.Log(fmt.Sprintf(
+			"price delta changed=%d of=%d snapshot_age=%s",
+			len(pending),
+			len(inputs),
+			c.now().Sub(snapshot.UpdatedAt).Round(time.Second),
+		))
+	}
+	return pending, snapshotUsable
+}
+
+// saveSnapshot records every resolved SKU except the ones the guard held, including
+// SKUs this run had no reason to push. A held SKU stays out so the next delta offers
+// it again, which is how an approval or an ERP fix gets pushed. Written only after a
+// successful push, and never by a dry run or a SKU-filtered run.
+func (c *ClientPrice) saveSnapshot(inputs []shopify.PriceUpsertInput, held map[string]bool, usable bool) {
+	if c.dryRun {
+		if c.logger != nil {
+			c.logger.Log("price snapshot not written: dry run")
+		}
+		return
+	}
+	if !usable {
+		if c.logger != nil {
+			c.logger.LogWarning("price snapshot not written: " + debugsync.OnlySKUsEnv + " limited this run to a subset of SKUs")
+		}
+		return
+	}
+	entries := make(map[string]pricestate.Entry, len(inputs))
+	for _, input := range inputs {
+		if !held[input.SKU] {
+			entries[input.SKU] = stateEntry(input)
+		}
+	}
+	if err := pricestate.Save(c.statePath, entries, c.now()); err != nil {
+		// Not fatal: the prices were pushed. The next run diffs against an older
+		// snapshot or pushes everything, which is correct either way.
+		if c.logger != nil {
+			c.logger.LogWarning(fmt.Sprintf("price snapshot write failed at %s: %v", c.statePath, err))
+		}
+	}
+}
+
+func stateEntry(input shopify.PriceUpsertInput) pricestate.Entry {
+	entry := pricestate.Entry{Prices: input.Prices}
+	if len(input.CompareAt) > 0 {
+		entry.CompareAt = input.CompareAt
+	}
+	return entry
+}
+
+// recordPriceSource tells the report which ERP list a pushed price came from and
+// the ERP's own value, and tallies the lists per currency so a mis-set rule shows up
+// in the footer even when no price moved, and counts the VAT treatment per currency.
+func (c *ClientPrice) recordPriceSource(sku, currency string, priceList int, erp float64, vat vatOutcome) {
+	if c.recorder == nil {
+		return
+	}
+	source := fmt.Sprintf("ERP list %d", priceList)
+	switch vat {
+	case vatAdded:
+		c.recorder.Incr("prices", "vat_added_"+strings.ToLower(currency), 1)
+	case vatExempt:
+		c.recorder.Incr("prices", "vat_exempt_"+strings.ToLower(currency), 1)
+	}
+	if vat != vatAsIs {
+		source += " " + c.vat.describe(vat)
+	}
+	c.recorder.PriceSource(sku, currency, source)
+	c.recorder.PriceERPValue(sku, currency, erp)
+	c.recorder.Incr("prices", fmt.Sprintf("%s_from_list_%d", strings.ToLower(currency), priceList), 1)
+}
+
+// recordDerivedPrice marks a converted price in the report, in place of the ERP list
+// a price normally names, and counts the conversions per currency.
+func (c *ClientPrice) recordDerivedPrice(sku, currency string, derived derivedPrice) {
+	if c.recorder == nil {
+		return
+	}
+	c.recorder.PriceSource(sku, currency, fmt.Sprintf("converted from %s at %.4f", derived.From, derived.Rate))
+	c.recorder.Incr("prices", "derived_"+strings.ToLower(currency), 1)
+}
+
+// lazyConverter returns a loader for the conversion fallback that reads the rates the
+// first time a SKU needs them, so a run where the ERP has every price reads nothing.
+// usable is false when the fallback is off or the rates could not be read; a rate
+// failure is a warning and those SKUs are skipped, as they were without the fallback.
+func (c *ClientPrice) lazyConverter(ctx context.Context) func() (priceConverter, bool) {
+	var (
+		loaded    bool
+		converter priceConverter
+		usable    bool
+	)
+	return func() (priceConverter, bool) {
+		if loaded {
+			return converter, usable
+		}
+		loaded = true
+		if c.rates == nil {
+			return converter, false
+		}
+		rates, err := c.rates.Rates(ctx)
+		if err != nil {
+			c.warnPrices(fmt.Sprintf("exchange rates not loaded, SKUs missing a currency are skipped: %v", err))
+			return converter, false
+		}
+		if c.logger != nil {
+			c.logger.Log(fmt.Sprintf("Exchange rates loaded source=%s currencies=%d", c.conversion.Source, len(rates)))
+		}
+		converter = priceConverter{rates: rates, rounding: c.conversion.Rounding}
+		usable = true
+		return converter, usable
+	}
+}
+
+// applyDiscount turns the item's ERP discount and any running calendar sale into
+// sale prices: the full price moves to compare-at and the price drops by the larger
+// of the two percentages, per currency. Discounts never stack. It reports whether any
+// currency was discounted; a SKU with neither goes out with no compare-at, which is
+// what ends a sale in Shopify.
+func (c *ClientPrice) applyDiscount(input *shopify.PriceUpsertInput, item itemDiscount, sales map[string]model.Sale) bool {
+	rule, codePercent, codeOK := c.discounts.lookup(item)
+	if !codeOK && item.Code != "" && !c.discounts.knows(item.Code) && c.recorder != nil {
+		c.recorder.Incr("prices", "discount_code_unmapped_"+item.Code, 1)
+	}
+	if !codeOK && len(sales) == 0 {
+		return false
+	}
+
+	byCode, bySale := false, false
+	parts := make([]string, 0, len(c.currencies))
+	for _, currency := range c.currencies {
+		full := input.Prices[currency]
+		if full <= 0 {
+			continue
+		}
+		percent, source, fromSale := 0.0, "", false
+		if codeOK && rule.AppliesTo(currency) {
+			percent, source = codePercent, "code="+rule.Code
+		}
+		if sale, ok := sales[currency]; ok && sale.Percent > percent {
+			percent, source, fromSale = sale.Percent, fmt.Sprintf("sale=%q", sale.Name), true
+		}
+		if percent == 0 {
+			continue
+		}
+		if fromSale {
+			bySale = true
+		} else {
+			byCode = true
+		}
+		input.CompareAt[currency] = full
+		input.Prices[currency] = c.discounts.apply(full, percent)
+		parts = append(parts, fmt.Sprintf("%s_price=%.2f %s_compare_at=%.2f %s_percent=%.2f %s", strings.ToLower(currency), input.Prices[currency], strings.ToLower(currency), full, strings.ToLower(currency), percent, source))
+	}
+	if c.logger != nil && debugsync.MatchSKU(input.SKU) && len(parts) > 0 {
+		c.logger.Log(fmt.Sprintf("trace price discount sku=%s %s", input.SKU, strings.Join(parts, " ")))
+	}
+	if c.recorder != nil {
+		if byCode {
+			c.recorder.Incr("prices", "discounted_code_"+rule.Code, 1)
+		}
+		if bySale {
+			c.recorder.Incr("prices", "discounted_by_sale", 1)
+		}
+	}
+	return byCode || bySale
+}
+
+// loadSales reads the calendar and picks the sales running now. With no calendar
+// configured it returns an empty schedule.
+func (c *ClientPrice) loadSales(ctx context.Context) (saleSchedule, error) {
+	if c.calendar == nil {
+		return saleSchedule{}, nil
+	}
+	sales, err := c.calendar.Sales(ctx)
+	if err != nil {
+		return saleSchedule{}, err
+	}
+	now := c.now()
+	schedule, err := newSaleSchedule(sales, now, c.markets)
+	if err != nil {
+		return saleSchedule{}, err
+	}
+	if c.logger != nil {
+		next := "none"
+		if !schedule.next.IsZero() {
+			next = schedule.next.Format(model.SaleTimeLayout + " MST")
+		}
+		c.logger.Log(fmt.Sprintf(
+			"Sales calendar loaded sales=%d active=%d names=%s next_change=%s",
+			len(sales),
+			len(schedule.active),
+			strings.Join(schedule.names(), ","),
+			next,
+		))
+	}
+	if c.recorder != nil {
+		c.recorder.Incr("prices", "sales_active", int64(len(schedule.active)))
+	}
+	return schedule, nil
+}
+
+// syncSaleCollection puts the discounted products in the sale collection and takes
+// the rest out. It runs after the prices are pushed, and a failure is a warning: the
+// prices themselves are already right.
+func (c *ClientPrice) syncSaleCollection(ctx context.Context, discounted []string) {
+	if c.saleCollection == "" {
+		return
+	}
+	saleClient, ok := c.shopifyClient.(shopify.SaleCollectionService)
+	if !ok {
+		return
+	}
+	// A run filtered to a few SKUs priced only those, so it must not empty the
+	// collection of everything else.
+	prune := !debugsync.HasOnlySKUFilter()
+	if err := saleClient.SyncSaleCollection(ctx, c.saleCollection, discounted, prune); err != nil {
+		c.warnPrices(fmt.Sprintf("sale collection %q not synced: %v", c.saleCollection, err))
+	}
+}
+
+// pushPrices sends the prices through the guard when it is on. A held SKU keeps its
+// Shopify prices and goes to the quarantine file until the ERP is corrected or the
+// change is approved with approve-prices. The quarantine is rewritten only after a
+// successful push, so a failed run does not spend approvals.
+func (c *ClientPrice) pushPrices(ctx context.Context, inputs []shopify.PriceUpsertInput, items map[string]priceItem) (map[string]bool, error) {
+	held := make(map[string]bool)
+	guarded, ok := c.shopifyClient.(shopify.GuardedPriceService)
+	if !c.guard.cfg.Enabled || !ok {
+		return held, c.shopifyClient.UpsertPricesBatch(ctx, inputs)
+	}
+
+	path := c.guard.cfg.QuarantinePath
+	quarantine, err := pricequarantine.Load(path)
+	if err != nil {
+		c.warnPrices(fmt.Sprintf("price quarantine not loaded, approvals ignored: %v", err))
+	}
+	now := c.now()
+	next := pricequarantine.Quarantine{Entries: make(map[string]pricequarantine.Entry)}
+	// A SKU-filtered run or a delta run says nothing about the holds of SKUs it did
+	// not offer; in full mode a SKU no longer offered has nothing left to hold.
+	offered := make(map[string]bool, len(inputs))
+	for _, input := range inputs {
+		offered[input.SKU] = true
+	}
+	for sku, entry := range quarantine.Entries {
+		if !debugsync.ShouldProcessSKU(sku) || (c.mode == config.PriceModeDelta && !offered[sku]) {
+			next.Entries[sku] = entry
+		}
+	}
+
+	released, stillHeld := 0, 0
+	hold := func(check shopify.PriceCheck) bool {
+		sku := check.Input.SKU
+		if quarantine.Approved(sku, check.Input.Prices) {
+			released++
+			if c.logger != nil && debugsync.MatchSKU(sku) {
+				c.logger.Log(fmt.Sprintf("trace price guard approved sku=%s", sku))
+			}
+			return false
+		}
+		cost := items[sku].Cost
+		if items[sku].CostWithVAT {
+			cost = c.vat.add(cost)
+		}
+		reasons := c.guard.check(check, c.currencies, cost)
+		if len(reasons) == 0 {
+			return false
+		}
+
+		entry := pricequarantine.Entry{
+			SKU:       sku,
+			Prices:    check.Input.Prices,
+			CompareAt: check.Input.CompareAt,
+			Before:    check.Before,
+			HeldAt:    now,
+		}
+		// A delta tick re-offers a held SKU every few minutes. When its prices are the
+		// ones already held, it was reported when first held and the daily full run
+		// lists it again, so the tick only counts it.
+		known := false
+		if previous, ok := quarantine.Entries[sku]; ok && previous.Matches(check.Input.Prices) {
+			entry.HeldAt = previous.HeldAt
+			known = c.mode == config.PriceModeDelta
+		}
+		for _, reason := range reasons {
+			entry.Reasons = append(entry.Reasons, reason.Currency+": "+reason.Reason)
+			if c.recorder != nil && !known {
+				before, beforeKnown := check.Before[reason.Currency]
+				c.recorder.PriceHeld(sku, reason.Currency, before, beforeKnown, check.Input.Prices[reason.Currency], reason.Reason)
+			}
+		}
+		next.Entries[sku] = entry
+		held[sku] = true
+		if known {
+			stillHeld++
+			if c.logger != nil && debugsync.MatchSKU(sku) {
+				c.logger.Log(fmt.Sprintf("trace price guard still held sku=%s %s", sku, strings.Join(entry.Reasons, "; ")))
+			}
+			return true
+		}
+		if c.logger != nil {
+			c.logger.LogWarning(fmt.Sprintf("price held sku=%s %s", sku, strings.Join(entry.Reasons, "; ")))
+		}
+		return true
+	}
+
+	if err := guarded.UpsertPricesGuarded(ctx, inputs, hold); err != nil {
+		return nil, err
+	}
+	if c.recorder != nil {
+		c.recorder.Incr("prices", "guard_released", int64(released))
+		c.recorder.Incr("prices", "guard_still_held", int64(stillHeld))
+	}
+	// A dry run pushed nothing, so it must neither spend approvals nor record holds.
+	if c.dryRun {
+		return held, nil
+	}
+	next.UpdatedAt = now
+	// approve-prices may have saved approvals since the quarantine was loaded.
+	if latest, err := pricequarantine.Load(path); err == nil {
+		next.KeepApprovals(latest)
+	}
+	if err := pricequarantine.Save(path, next); err != nil {
+		c.warnPrices(fmt.Sprintf("price quarantine not saved: %v", err))
+	}
+	return held, nil
+}
+
+func (c *ClientPrice) warnPrices(message string) {
+	if c.logger != nil {
+		c.logger.LogWarning(message)
+	}
+	if c.recorder != nil {
+		c.recorder.Warn("prices", message)
+	}
+}
+
+func (c *ClientPrice) fetchItems(ctx context.Context) (map[string]priceItem, error) {
+	items := make(map[string]priceItem)
 	if c.apixProducts == nil {
-		return codes, nil
+		return items, nil
 	}
 
 	page := 1
@@ -235,13 +729,16 @@ func (c *ClientPrice) fetchDiscountCodes(ctx context.Context) (map[string]string
 		}
 		for _, p := range products {
 			sku := strings.TrimSpace(p.Sku)
-			code := strings.TrimSpace(p.DiscountCode)
-			if sku == "" || code == "" {
+			if sku == "" {
 				continue
 			}
-			codes[sku] = code
+			items[sku] = priceItem{
+				Discount:  itemDiscount{Code: strings.TrimSpace(p.DiscountCode), Percent: p.DiscountPercent},
+				Cost:      p.PurchasePrice,
+				VATExempt: p.VatExempt,
+			}
 		}
 		page++
 	}
-	return codes, nil
+	return items, nil
 }
@@ -0,0 +1,310 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"shopify-exporter/internal/adapters/shopify"
+	"shopify-exporter/internal/config"
+	"shopify-exporter/internal/domain/model"
+	"shopify-exporter/internal/infra/pricerates"
+	"shopify-exporter/internal/infra/pricestate"
+	"sort"
+	"testing"
+)
+
+type fakePriceAPI struct {
+	prices []model.Price
+}
+
+func (f *fakePriceAPI) PriceList(context.Context) ([]model.Price, error) {
+	return f.prices, nil
+}
+
+// fakePriceShopify records every call and every push.
+type fakePriceShopify struct {
+	calls   int
+	batches [][]shopify.PriceUpsertInput
+	err     error
+}
+
+func (f *fakePriceShopify) EnsureMarketsAndCatalogs(context.Context) ([]shopify.MarketResources, error) {
+	f.calls++
+	return nil, nil
+}
+
+func (f *fakePriceShopify) UpsertPrices(ctx context.Context, input shopify.PriceUpsertInput) error {
+	return f.UpsertPricesBatch(ctx, []shopify.PriceUpsertInput{input})
+}
+
+func (f *fakePriceShopify) UpsertPricesBatch(_ context.Context, inputs []shopify.PriceUpsertInput) error {
+	f.calls++
+	f.batches = append(f.batches, append([]shopify.PriceUpsertInput(nil), inputs...))
+	return f.err
+}
+
+func (f *fakePriceShopify) pushed() []string {
+	skus := make([]string, 0)
+	for _, batch := range f.batches {
+		for _, input := range batch {
+			skus = append(skus, input.SKU)
+		}
+	}
+	sort.Strings(skus)
+	return skus
+}
+
+// guardedFakePriceShopify also asks the guard, with Shopify holding the given prices.
+type guardedFakePriceShopify struct {
+	fakePriceShopify
+	before map[string]map[string]float64
+}
+
+func (f *guardedFakePriceShopify) UpsertPricesGuarded(ctx context.Context, inputs []shopify.PriceUpsertInput, guard shopify.PriceGuard) error {
+	kept := make([]shopify.PriceUpsertInput, 0, len(inputs))
+	for _, input := range inputs {
+		if guard(shopify.PriceCheck{Input: input, BaseCurrency: "ILS", Before: f.before[input.SKU]}) {
+			continue
+		}
+		kept = append(kept, input)
+	}
+	return f.UpsertPricesBatch(ctx, kept)
+}
+
+// erpPrices gives each SKU the ILS price and a USD price of a quarter of it.
+func erpPrices(ils map[string]float32) []model.Price {
+	prices := make([]model.Price, 0, len(ils)*2)
+	for sku, amount := range ils {
+		prices = append(prices,
+			model.Price{Sku: sku, Currency: "ILS", Price: amount, PriceListNumber: 10},
+			model.Price{Sku: sku, Currency: "USD", Price: amount / 4, PriceListNumber: 7},
+		)
+	}
+	return prices
+}
+
+func priceDeltaConfig(t *testing.T) config.PriceConfig {
+	t.Helper()
+	dir := t.TempDir()
+	return config.PriceConfig{
+		Mode:      config.PriceModeDelta,
+		StatePath: filepath.Join(dir, "price-state.json"),
+		ListRules: []config.PriceListRule{
+			{Currency: "ILS", Lists: []int{10}},
+			{Currency: "USD", Lists: []int{7}},
+		},
+		Currencies: []string{"ILS", "USD"},
+		Guard:      config.PriceGuardConfig{QuarantinePath: filepath.Join(dir, "price-quarantine.json")},
+	}
+}
+
+func runPrices(t *testing.T, cfg config.PriceConfig, api *fakePriceAPI, shop shopify.PriceService) error {
+	t.Helper()
+	return runPricesWithRates(t, cfg, api, shop, nil)
+}
+
+func runPricesWithRates(t *testing.T, cfg config.PriceConfig, api *fakePriceAPI, shop shopify.PriceService, rates pricerates.Source) error {
+	t.Helper()
+	return NewSyncPrices(api, nil, shop, nil, nil, cfg, nil, rates).Run(context.Background())
+}
+
+type fakeRates struct {
+	rates model.ExchangeRates
+	err   error
+	calls int
+}
+
+func (f *fakeRates) Rates(context.Context) (model.ExchangeRates, error) {
+	f.calls++
+	return f.rates, f.err
+}
+
+// A quiet delta tick must cost no Shopify call at all, not even the market setup.
+func TestSyncPricesDeltaPushesEverythingOnceThenNothing(t *testing.T) {
+	cfg := priceDeltaConfig(t)
+	api := &fakePriceAPI{prices: erpPrices(map[string]float32{"A-1": 100, "B-2": 40})}
+
+	first := &fakePriceShopify{}
+	if err := runPrices(t, cfg, api, first); err != nil {
+		t.Fatal(err)
+	}
+	equalSKUs(t, first.pushed(), []string{"A-1", "B-2"})
+
+	second := &fakePriceShopify{}
+	if err := runPrices(t, cfg, api, second); err != nil {
+		t.Fatal(err)
+	}
+	if second.calls != 0 {
+		t.Errorf("unchanged prices must cost zero Shopify calls, got %d", second.calls)
+	}
+}
+
+func TestSyncPricesDeltaPushesOnlyMovedSKUs(t *testing.T) {
+	cfg := priceDeltaConfig(t)
+	if err := runPrices(t, cfg, &fakePriceAPI{prices: erpPrices(map[string]float32{"A-1": 100, "B-2": 40})}, &fakePriceShopify{}); err != nil {
+		t.Fatal(err)
+	}
+
+	shop := &fakePriceShopify{}
+	if err := runPrices(t, cfg, &fakePriceAPI{prices: erpPrices(map[string]float32{"A-1": 100, "B-2": 44})}, shop); err != nil {
+		t.Fatal(err)
+	}
+	equalSKUs(t, shop.pushed(), []string{"B-2"})
+}
+
+// A failed push must be retried on the next tick, so it may not reach the snapshot.
+func TestSyncPricesDeltaDoesNotSaveSnapshotAfterFailure(t *testing.T) {
+	cfg := priceDeltaConfig(t)
+	api := &fakePriceAPI{prices: erpPrices(map[string]float32{"A-1": 100})}
+	pushErr := errors.New("shopify rejected the mutation")
+
+	if err := runPrices(t, cfg, api, &fakePriceShopify{err: pushErr}); !errors.Is(err, pushErr) {
+		t.Fatalf("expected the push error to propagate, got %v", err)
+	}
+	retry := &fakePriceShopify{}
+	if err := runPrices(t, cfg, api, retry); err != nil {
+		t.Fatal(err)
+	}
+	equalSKUs(t, retry.pushed(), []string{"A-1"})
+}
+
+func TestSyncPricesDryRunNeverWritesSnapshot(t *testing.T) {
+	cfg := priceDeltaConfig(t)
+	cfg.DryRun = true
+	if err := runPrices(t, cfg, &fakePriceAPI{prices: erpPrices(map[string]float32{"A-1": 100})}, &fakePriceShopify{}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(cfg.StatePath); !os.IsNotExist(err) {
+		t.Fatalf("a dry run must not create %s (err=%v)", cfg.StatePath, err)
+	}
+}
+
+// A held SKU stays out of the snapshot, so the next delta offers it to the guard
+// again; otherwise an approval would never be pushed.
+func TestSyncPricesDeltaReoffersHeldSKUs(t *testing.T) {
+	cfg := priceDeltaConfig(t)
+	cfg.Guard.Enabled = true
+	cfg.Guard.MaxChangePercent = 60
+	api := &fakePriceAPI{prices: erpPrices(map[string]float32{"A-1": 2336, "B-2": 40})}
+	before := map[string]map[string]float64{"A-1": {"ILS": 23.36}, "B-2": {"ILS": 40}}
+
+	first := &guardedFakePriceShopify{before: before}
+	if err := runPrices(t, cfg, api, first); err != nil {
+		t.Fatal(err)
+	}
+	equalSKUs(t, first.pushed(), []string{"B-2"})
+
+	snapshot, err := pricestate.Load(cfg.StatePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := snapshot.Prices["A-1"]; ok {
+		t.Error("a held SKU must not be recorded as pushed")
+	}
+
+	second := &guardedFakePriceShopify{before: before}
+	if err := runPrices(t, cfg, api, second); err != nil {
+		t.Fatal(err)
+	}
+	if len(second.batches) != 1 || len(second.pushed()) != 0 {
+		t.Errorf("the held SKU should be offered and held again, pushed %v", second.pushed())
+	}
+}
+
+// A SKU with only an ILS price gets its USD price converted; without rates it is
+// skipped, as it was before the fallback existed.
+func TestSyncPricesDerivesMissingCurrency(t *testing.T) {
+	cfg := priceDeltaConfig(t)
+	cfg.Mode = config.PriceModeFull
+	cfg.Conversion = config.PriceConversionConfig{
+		Source:   config.ConversionSourceFile,
+		Rounding: map[string]config.ConversionRounding{"USD": {Ending: 0.90}},
+	}
+	api := &fakePriceAPI{prices: append(
+		erpPrices(map[string]float32{"A-1": 100}),
+		model.Price{Sku: "B-2", Currency: "ILS", Price: 182, PriceListNumber: 10},
+	)}
+
+	rates := &fakeRates{rates: model.ExchangeRates{"ILS": 1, "USD": 3.65}}
+	shop := &fakePriceShopify{}
+	if err := runPricesWithRates(t, cfg, api, shop, rates); err != nil {
+		t.Fatal(err)
+	}
+	equalSKUs(t, shop.pushed(), []string{"A-1", "B-2"})
+	for _, input := range shop.batches[0] {
+		if input.SKU == "B-2" && input.Prices["USD"] != 49.90 {
+			t.Errorf("B-2 USD = %v, want 182/3.65 rounded to 49.90", input.Prices["USD"])
+		}
+	}
+
+	unconverted := &fakePriceShopify{}
+	if err := runPricesWithRates(t, cfg, api, unconverted, &fakeRates{err: errors.New("rates endpoint down")}); err != nil {
+		t.Fatal(err)
+	}
+	equalSKUs(t, unconverted.pushed(), []string{"A-1"})
+}
+
+// The rates are read only when a SKU actually misses a currency.
+func TestSyncPricesReadsRatesOnlyWhenNeeded(t *testing.T) {
+	cfg := priceDeltaConfig(t)
+	cfg.Mode = config.PriceModeFull
+	rates := &fakeRates{rates: model.ExchangeRates{"ILS": 1, "USD": 3.65}}
+	if err := runPricesWithRates(t, cfg, &fakePriceAPI{prices: erpPrices(map[string]float32{"A-1": 100})}, &fakePriceShopify{}, rates); err != nil {
+		t.Fatal(err)
+	}
+	if rates.calls != 0 {
+		t.Errorf("rates read %d times with every price present", rates.calls)
+	}
+}
+
+// A net ERP list is grossed up only for a currency whose market shows VAT, and never
+// for a VAT exempt item.
+func TestSyncPricesAddsVATToNetLists(t *testing.T) {
+	cfg := priceDeltaConfig(t)
+	cfg.Mode = config.PriceModeFull
+	cfg.VAT = config.PriceVATConfig{Rate: 18, NetLists: []int{10, 7}}
+	api := &fakePriceAPI{prices: erpPrices(map[string]float32{"A-1": 100, "EX-1": 100})}
+	products := &fakeProductAPI{pages: [][]model.Product{{{Sku: "A-1"}, {Sku: "EX-1", VatExempt: true}}}}
+
+	shop := &fakePriceShopify{}
+	if err := NewSyncPrices(api, products, shop, nil, nil, cfg, nil, nil).Run(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	got := make(map[string]shopify.PriceUpsertInput)
+	for _, input := range shop.batches[0] {
+		got[input.SKU] = input
+	}
+	if price := got["A-1"].Prices["ILS"]; price != 118 {
+		t.Errorf("A-1 ILS = %v, want 100 net + 18%% VAT", price)
+	}
+	if price := got["A-1"].Prices["USD"]; price != 25 {
+		t.Errorf("A-1 USD = %v, want 25: the USD market does not show VAT", price)
+	}
+	if price := got["EX-1"].Prices["ILS"]; price != 100 {
+		t.Errorf("EX-1 ILS = %v, want the exempt item's net 100", price)
+	}
+}
+
+// With VAT added to the pushed price, the cost is grossed up too: a net price under
+// cost is held although its gross price is above it.
+func TestSyncPricesGuardComparesCostWithVAT(t *testing.T) {
+	cfg := priceDeltaConfig(t)
+	cfg.Mode = config.PriceModeFull
+	cfg.VAT = config.PriceVATConfig{Rate: 18, NetLists: []int{10}}
+	cfg.Guard.Enabled = true
+	cfg.Guard.BelowCost = true
+	cfg.Guard.CostCurrency = "ILS"
+	api := &fakePriceAPI{prices: erpPrices(map[string]float32{"UNDER-1": 100, "OVER-1": 100, "EX-1": 100})}
+	products := &fakeProductAPI{pages: [][]model.Product{{
+		{Sku: "UNDER-1", PurchasePrice: 110},
+		{Sku: "OVER-1", PurchasePrice: 95},
+		{Sku: "EX-1", PurchasePrice: 95, VatExempt: true},
+	}}}
+
+	shop := &guardedFakePriceShopify{}
+	if err := NewSyncPrices(api, products, shop, nil, nil, cfg, nil, nil).Run(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	equalSKUs(t, shop.pushed(), []string{"EX-1", "OVER-1"})
+}
@@ -57,6 +57,32 @@ func (c *Client) recordWarning(message string) {
 	}
 }
 
+// lookupPage resolves a whole ERP page's SKUs in one batched search. It returns nil
+// when the client cannot batch or the search failed, and the page then falls back to
+// one CheckExistProductBySku per product, as before.
+func (c *Client) lookupPage(ctx context.Context, products []model.Product) map[string]string {
+	lookup, ok := c.shopifyClient.(shopify.ProductLookupService)
+	if !ok {
+		return nil
+	}
+	skus := make([]string, 0, len(products))
+	for _, product := range products {
+		if sku := strings.TrimSpace(product.Sku); sku != "" {
+			skus = append(skus, sku)
+		}
+	}
+	if len(skus) == 0 {
+		return nil
+	}
+	existing, err := lookup.LookupProductIDsBySKU(ctx, skus)
+	if err != nil {
+		c.logger.LogError("Product batch lookup failed, falling back to per-SKU lookups", err)
+		c.recordWarning(fmt.Sprintf("batch lookup failed, per-SKU lookups used: %v", err))
+		return nil
+	}
+	return existing
+}
+
 func (c *Client) Run(ctx context.Context) error {
 	const pageSize = 100
 	const maxConcurrent = 4
@@ -84,6 +110,8 @@ func (c *Client) Run(ctx context.Context) error {
 		}
 		c.logger.Log(fmt.Sprintf("Product sync page=%d/%d fetched=%d limit=%d", page, totalPages, len(apiProducts), pageSize))
 
+		existing := c.lookupPage(ctx, apiProducts)
+
 		sem := make(chan struct{}, maxConcurrent)
 		var wg sync.WaitGroup
 		for _, v := range apiProducts {
@@ -109,7 +137,16 @@ func (c *Client) Run(ctx context.Context) error {
 					return
 				}
 
-				productExists, productGid, err := c.shopifyClient.CheckExistProductBySku(ctx, product)
+				var (
+					productExists bool
+					productGid    string
+					err           error
+				)
+				if existing != nil {
+					productGid, productExists = existing[sku]
+				} else {
+					productExists, productGid, err = c.shopifyClient.CheckExistProductBySku(ctx, product)
+				}
 				if err != nil {
 					failedProducts.Add(1)
 					c.logger.LogError(fmt.Sprintf("Product lookup failed sku=%s", sku), err)
@@ -0,0 +1,135 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"shopify-exporter/internal/domain/model"
+	"sync"
+	"testing"
+)
+
+type nopLogger struct{}
+
+func (nopLogger) Log(string)             {}
+func (nopLogger) LogError(string, error) {}
+func (nopLogger) LogWarning(string)      {}
+func (nopLogger) LogSuccess(string)      {}
+
+type fakeProductAPI struct {
+	pages [][]model.Product
+}
+
+func (f *fakeProductAPI) ListProducts(_ context.Context, page, _ int) ([]model.Product, int, error) {
+	if page < 1 || page > len(f.pages) {
+		return nil, len(f.pages), nil
+	}
+	return f.pages[page-1], len(f.pages), nil
+}
+
+// fakeProductShopify records which calls the product sync makes. existing maps SKU to
+// product id; batchErr makes the batched lookup fail.
+type fakeProductShopify struct {
+	mu          sync.Mutex
+	existing    map[string]string
+	batchErr    error
+	batchCalls  int
+	singleCalls int
+	created     []string
+	updated     []string
+}
+
+func (f *fakeProductShopify) LookupProductIDsBySKU(_ context.Context, skus []string) (map[string]string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.batchCalls++
+	if f.batchErr != nil {
+		return nil, f.batchErr
+	}
+	found := make(map[string]string)
+	for _, sku := range skus {
+		if id, ok := f.existing[sku]; ok {
+			found[sku] = id
+		}
+	}
+	return found, nil
+}
+
+func (f *fakeProductShopify) CheckExistProductBySku(_ context.Context, product model.Product) (bool, string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.singleCalls++
+	id, ok := f.existing[product.Sku]
+	return ok, id, nil
+}
+
+func (f *fakeProductShopify) CreateProduct(_ context.Context, product model.Product) (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.created = append(f.created, product.Sku)
+	return "gid://shopify/Product/new-" + product.Sku, nil
+}
+
+func (f *fakeProductShopify) UpdateProduct(_ context.Context, product model.Product, _ string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.updated = append(f.updated, product.Sku)
+	return nil
+}
+
+func (f *fakeProductShopify) UpdateLocalization(context.Context, model.Product, string) error {
+	return nil
+}
+
+func (f *fakeProductShopify) GetCollectionProducts(context.Context) ([]model.Product, error) {
+	return nil, nil
+}
+
+func (f *fakeProductShopify) UnpublishProduct(context.Context, string) error { return nil }
+
+func (f *fakeProductShopify) AttachCategoryToProduct(context.Context, model.ProductCategories) {}
+
+func productPage(skus ...string) []model.Product {
+	out := make([]model.Product, 0, len(skus))
+	for _, sku := range skus {
+		out = append(out, model.Product{Sku: sku, EnglishTitle: "Item " + sku})
+	}
+	return out
+}
+
+// Each ERP page is resolved with one batched lookup; the per-SKU lookup is not used.
+func TestSyncProductsResolvesEachPageInOneLookup(t *testing.T) {
+	api := &fakeProductAPI{pages: [][]model.Product{productPage("A-1", "A-2", "A-3"), productPage("B-1")}}
+	shop := &fakeProductShopify{existing: map[string]string{"A-2": "gid://shopify/Product/2"}}
+
+	if err := NewSyncProducts(api, shop, nopLogger{}, nil).Run(context.Background()); err != nil {
+		t.Fatalf("Run() = %v", err)
+	}
+	if shop.batchCalls != 2 || shop.singleCalls != 0 {
+		t.Fatalf("batch calls = %d, single calls = %d, want 2 and 0", shop.batchCalls, shop.singleCalls)
+	}
+	if len(shop.updated) != 1 || shop.updated[0] != "A-2" {
+		t.Fatalf("updated = %v, want [A-2]", shop.updated)
+	}
+	if len(shop.created) != 3 {
+		t.Fatalf("created = %v, want the 3 missing SKUs", shop.created)
+	}
+}
+
+// A failed batched lookup must not lose the page: it falls back to per-SKU lookups.
+func TestSyncProductsFallsBackToPerSKULookup(t *testing.T) {
+	api := &fakeProductAPI{pages: [][]model.Product{productPage("A-1", "A-2")}}
+	shop := &fakeProductShopify{
+		existing: map[string]string{"A-1": "gid://shopify/Product/1"},
+		batchErr: errors.New("throttled"),
+	}
+
+	if err := NewSyncProducts(api, shop, nopLogger{}, nil).Run(context.Background()); err != nil {
+		t.Fatalf("Run() = %v", err)
+	}
+	if shop.singleCalls != 2 {
+		t.Fatalf("single calls = %d, want 2", shop.singleCalls)
+	}
+	if len(shop.updated) != 1 || len(shop.created) != 1 {
+		t.Fatalf("updated = %v created = %v, want one of each", shop.updated, shop.created)
+	}
+}
@@ -7,8 +7,10 @@ import (
 	"shopify-exporter/internal/adapters/shopify"
 	"shopify-exporter/internal/config"
 	"shopify-exporter/internal/debugsync"
+	"shopify-exporter/internal/domain/model"
 	"shopify-exporter/internal/infra/stockstate"
 	"shopify-exporter/internal/logging"
+	"shopify-exporter/internal/report"
 	"sort"
 	"strings"
 	"time"
@@ -19,23 +21,35 @@ type SyncStocksService interface {
 }
 
 type ClientStock struct {
-	apixClient    apix.StockService
-	shopifyClient shopify.StockService
-	logger        logging.LoggerService
-	stockConfig   config.StockConfig
+	apixClient apix.StockService
+	// apixCategories is read only when a reserve rule names a category; may be nil.
+	apixCategories apix.CategoryService
+	shopifyClient  shopify.StockService
+	logger         logging.LoggerService
+	recorder       report.Recorder
+	stockConfig    config.StockConfig
+
+	// categoryList is read on first use by categories.
+	categoryList   []model.ProductCategories
+	categoryErr    error
+	categoriesRead bool
 }
 
 func NewSyncStocks(
 	apixClient apix.StockService,
+	apixCategories apix.CategoryService,
 	shopifyClient shopify.StockService,
 	logger logging.LoggerService,
+	recorder report.Recorder,
 	stockConfig config.StockConfig,
 ) SyncStocksService {
 	return &ClientStock{
-		apixClient:    apixClient,
-		shopifyClient: shopifyClient,
-		logger:        logger,
-		stockConfig:   stockConfig,
+		apixClient:     apixClient,
+		apixCategories: apixCategories,
+		shopifyClient:  shopifyClient,
+		logger:         logger,
+		recorder:       recorder,
+		stockConfig:    stockConfig,
 	}
 }
 
@@ -45,68 +59,39 @@ func (c *ClientStock) Run(ctx context.Context) error {
 		c.logWarning("DRY RUN active (SYNC_STOCK_DRY_RUN): reads only, no writes to Shopify, snapshot not updated")
 	}
 
-	stocks, err := c.apixClient.FetchStocks(ctx)
+	feed, err := c.fetchFeed(ctx)
 	if err != nil {
 		c.logError("Error fetch api stocks", err)
 		return err
 	}
 
-	targets := make(map[string]int, len(stocks))
-	skippedEmptySKU := 0
-	skippedNegative := 0
-	duplicateSKU := 0
-	filteredOut := 0
+	targets, counts := c.buildTargets(feed.items, c.reservePolicy(ctx))
 
-	for _, item := range stocks {
-		sku := strings.TrimSpace(item.Sku)
-		if sku == "" {
-			skippedEmptySKU++
-			continue
-		}
-		if !debugsync.ShouldProcessSKU(sku) {
-			filteredOut++
-			continue
-		}
-		if item.Stock < 0 {
-			// Defensive only: apix.dtoMap clamps at 0, so an out-of-stock item arrives
-			// as 0 and gets pushed as 0 rather than being silently dropped and left
-			// showing as available. See FIXES.md 2026-06-30.
-			if debugsync.MatchSKU(sku) {
-				c.log(fmt.Sprintf("trace stock skipped sku=%s reason=negative api_quantity=%d", sku, item.Stock))
-			}
-			skippedNegative++
-			continue
-		}
-		if previous, ok := targets[sku]; ok {
-			duplicateSKU++
-			if debugsync.MatchSKU(sku) {
-				c.log(fmt.Sprintf(
-					"trace stock duplicate sku=%s previous_quantity=%d replacement_quantity=%d",
-					sku,
-					previous,
-					int(item.Stock),
-				))
-			}
-		}
-		targets[sku] = int(item.Stock)
-		if debugsync.MatchSKU(sku) {
-			c.log(fmt.Sprintf(
-				"trace stock prepared sku=%s api_quantity=%d shopify_quantity=%d",
-				sku,
-				item.Stock,
-				int(item.Stock),
-			))
+	// Without a breakdown the item's stock cannot be split across locations, and
+	// guessing would put a store's shelf count in the warehouse. Those SKUs keep
+	// whatever Shopify holds until the ERP feed carries their warehouses.
+	if counts.noBreakdown > 0 {
+		c.warnStock(fmt.Sprintf("stock sync skipped %d SKUs with no per-warehouse balance in the ERP feed", counts.noBreakdown))
+		if c.recorder != nil {
+			c.recorder.Incr("stock", "skipped_no_warehouse_breakdown", int64(counts.noBreakdown))
 		}
 	}
 
-	if len(targets) == 0 {
+	if c.stockConfig.Alerts.Enabled {
+		c.checkAlerts(ctx, targets, feed)
+	}
+
+	// An empty changes feed is the normal quiet tick, not a broken feed. The cursor
+	// still moves on, or the next tick would re-read the same window.
+	if len(targets) == 0 && !feed.incremental {
 		c.logWarning("Stock sync skipped: no valid SKUs")
 		return nil
 	}
 
-	inputs, snapshotUsable := c.selectInputs(targets)
+	inputs, snapshotUsable := c.selectInputs(targets, feed)
 
 	if len(inputs) == 0 {
+		c.saveCursor(feed, snapshotUsable)
 		c.logSuccess(fmt.Sprintf(
 			"Stock sync completed mode=%s no_erp_changes=true sku=%d",
 			c.stockConfig.Mode,
@@ -125,35 +110,215 @@ func (c *ClientStock) Run(ctx context.Context) error {
 		return err
 	}
 
-	c.saveSnapshot(targets, snapshotUsable)
+	c.saveSnapshot(targets, feed, snapshotUsable)
 
 	// candidates, not "pushed": this is how many SKUs were handed to the adapter, which
 	// then skips the ones Shopify already holds. The adapter logs what it actually
 	// wrote. Calling this "pushed" read as 1 written on a dry run that wrote nothing.
 	c.logSuccess(fmt.Sprintf(
-		"Stock sync completed mode=%s candidates=%d of=%d skipped_empty_sku=%d skipped_negative=%d duplicates=%d filtered_out=%d",
+		"Stock sync completed mode=%s candidates=%d of=%d skipped_empty_sku=%d skipped_no_breakdown=%d negative_erp=%d duplicates=%d filtered_out=%d",
 		c.stockConfig.Mode,
 		len(inputs),
 		len(targets),
-		skippedEmptySKU,
-		skippedNegative,
-		duplicateSKU,
-		filteredOut,
+		counts.emptySKU,
+		counts.noBreakdown,
+		counts.negative,
+		counts.duplicates,
+		counts.filteredOut,
 	))
 
 	return nil
 }
 
+// stockTargetCounts tallies the ERP rows buildTargets left out or adjusted.
+type stockTargetCounts struct {
+	emptySKU    int
+	noBreakdown int
+	negative    int
+	duplicates  int
+	filteredOut int
+}
+
+// buildTargets turns the ERP rows into the quantities to push, keyed by
+// stockstate.Key: the balance at each location less its share of the SKU's reserve
+// (see stockReserve.spread), clamped at 0. Kits
+// are left to addKitTargets, which needs every row read first.
+func (c *ClientStock) buildTargets(stocks []model.Stock, policy stockReservePolicy) (map[string]stockTarget, stockTargetCounts) {
+	targets := make(map[string]stockTarget, len(stocks))
+	var counts stockTargetCounts
+	kits := c.kitIndex()
+	rows := make(map[string]model.Stock, len(stocks))
+
+	for _, item := range stocks {
+		sku := strings.TrimSpace(item.Sku)
+		if sku == "" {
+			counts.emptySKU++
+			continue
+		}
+		// Components are read even when a SKU filter leaves them out: the kits made of
+		// them may still be in.
+		rows[stockSKUKey(sku)] = item
+		if _, isKit := kits[stockSKUKey(sku)]; isKit {
+			continue
+		}
+		if !debugsync.ShouldProcessSKU(sku) {
+			counts.filteredOut++
+			continue
+		}
+		item.Sku = sku
+		balances, ok := c.locationBalances(item)
+		if !ok {
+			counts.noBreakdown++
+			continue
+		}
+		reserve := policy.reserve(item)
+		quantities, held := reserve.spread(balanceValues(balances))
+		for i, balance := range balances {
+			if balance.Balance < 0 {
+				counts.negative++
+			}
+			key := stockstate.Key(sku, balance.Location)
+			quantity := quantities[i]
+			if previous, ok := targets[key]; ok {
+				counts.duplicates++
+				if debugsync.MatchSKU(sku) {
+					c.log(fmt.Sprintf(
+						"trace stock duplicate sku=%s location=%q previous_quantity=%d replacement_quantity=%d",
+						sku,
+						balance.Location,
+						previous.Quantity,
+						quantity,
+					))
+				}
+			}
+			targets[key] = stockTarget{SKU: sku, Location: balance.Location, Quantity: quantity}
+			if c.recorder != nil {
+				c.recorder.StockReserve(sku, balance.Location, int(balance.Balance), held[i], reserve.Source)
+			}
+			if debugsync.MatchSKU(sku) {
+				c.log(fmt.Sprintf(
+					"trace stock prepared sku=%s location=%q api_quantity=%d reserve=%d reserve_held=%d reserve_source=%q shopify_quantity=%d",
+					sku,
+					balance.Location,
+					balance.Balance,
+					reserve.Units,
+					held[i],
+					reserve.Source,
+					quantity,
+				))
+			}
+		}
+	}
+
+	c.addKitTargets(targets, &counts, rows, policy)
+	return targets, counts
+}
+
+// stockTarget is one quantity to push: a SKU at a Shopify location, where an empty
+// location is the primary one.
+type stockTarget struct {
+	SKU      string
+	Location string
+	Quantity int
+}
+
+// locationBalance is an item's ERP balance attributed to one Shopify location.
+type locationBalance struct {
+	Location string
+	Balance  int32
+}
+
+// locationBalances splits an item's ERP balance across the configured locations, each
+// the sum of its mapped warehouses; warehouses no location maps are not sold online.
+// Without a mapping the whole balance goes to the primary location, as it always
+// has. ok is false when a mapping exists but the feed has no breakdown for the item.
+func (c *ClientStock) locationBalances(item model.Stock) ([]locationBalance, bool) {
+	if !c.stockConfig.MultiLocation() {
+		return []locationBalance{{Balance: item.Stock}}, true
+	}
+	if item.Warehouses == nil {
+		return nil, false
+	}
+	balances := make([]locationBalance, 0, len(c.stockConfig.Locations))
+	for _, location := range c.stockConfig.Locations {
+		balance := locationBalance{Location: location.Name}
+		for _, warehouse := range location.Warehouses {
+			balance.Balance += item.Warehouses[warehouse]
+		}
+		balances = append(balances, balance)
+	}
+	return balances, true
+}
+
+// balanceValues is the balance at each location, in the same order.
+func balanceValues(balances []locationBalance) []int32 {
+	values := make([]int32, len(balances))
+	for i, balance := range balances {
+		values[i] = balance.Balance
+	}
+	return values
+}
+
+// reservePolicy reads the ERP categories when a reserve rule needs them. Without them
+// the category rules are skipped with a warning and those SKUs fall through to the
+// default: stopping the stock sync over a reserve would leave every quantity stale.
+func (c *ClientStock) reservePolicy(ctx context.Context) stockReservePolicy {
+	return c.rulePolicy(ctx, c.stockConfig.Reserve, "stock reserve")
+}
+
+// alertPolicy resolves the alert thresholds; they share the reserve rules' syntax and
+// order, and the same fallback when the categories cannot be read.
+func (c *ClientStock) alertPolicy(ctx context.Context) stockReservePolicy {
+	return c.rulePolicy(ctx, c.stockConfig.Alerts.Thresholds, "stock alert")
+}
+
+func (c *ClientStock) rulePolicy(ctx context.Context, cfg config.StockReserveConfig, name string) stockReservePolicy {
+	if !cfg.HasCategoryRules() {
+		return newStockReservePolicy(cfg, nil)
+	}
+	if c.apixCategories == nil {
+		c.warnStock(name + " category rules skipped: no ERP category source")
+		return newStockReservePolicy(cfg, nil)
+	}
+	categories, err := c.categories(ctx)
+	if err != nil {
+		c.warnStock(fmt.Sprintf("%s category rules skipped, ERP categories not loaded: %v", name, err))
+		return newStockReservePolicy(cfg, nil)
+	}
+	return newStockReservePolicy(cfg, categories)
+}
+
+// categories reads the ERP categories once per ClientStock, however many rule sets
+// need them.
+func (c *ClientStock) categories(ctx context.Context) ([]model.ProductCategories, error) {
+	if !c.categoriesRead {
+		c.categoryList, c.categoryErr = c.apixCategories.CategoryList(ctx)
+		c.categoriesRead = true
+	}
+	return c.categoryList, c.categoryErr
+}
+
 // selectInputs narrows the ERP feed to what this run should push. In full mode that is
 // everything; in delta mode only the SKUs whose ERP quantity moved since the last
 // successful run. The second return value reports whether the snapshot is trustworthy
 // enough to write back afterwards.
-func (c *ClientStock) selectInputs(targets map[string]int) ([]shopify.StockInput, bool) {
+func (c *ClientStock) selectInputs(targets map[string]stockTarget, feed stockFeed) ([]shopify.StockInput, bool) {
 	// A SKU filter means this run deliberately saw only part of the catalogue. Writing
 	// that back as the snapshot would tell the next run that every other SKU is
 	// unchanged at a quantity it never pushed, so the snapshot is left alone.
 	snapshotUsable := !debugsync.HasOnlySKUFilter()
 
+	if feed.incremental {
+		inputs := c.inputsFor(targets, &feed.base)
+		c.log(fmt.Sprintf(
+			"stock delta from changes feed changed=%d of=%d since=%s",
+			len(inputs),
+			len(targets),
+			feed.since.Format(time.RFC3339),
+		))
+		return inputs, snapshotUsable
+	}
+
 	if !c.stockConfig.IsDelta() {
 		return c.inputsFor(targets, nil), snapshotUsable
 	}
@@ -180,29 +345,32 @@ func (c *ClientStock) selectInputs(targets map[string]int) ([]shopify.StockInput
 	return inputs, snapshotUsable
 }
 
-// inputsFor builds the push list, keeping only changed SKUs when a snapshot is given.
-// The order is stable so a trace of two runs can be compared line by line.
-func (c *ClientStock) inputsFor(targets map[string]int, snapshot *stockstate.Snapshot) []shopify.StockInput {
-	skus := make([]string, 0, len(targets))
-	for sku := range targets {
-		if snapshot != nil && !snapshot.Changed(sku, targets[sku]) {
+// inputsFor builds the push list, keeping only changed targets when a snapshot is
+// given. The order is stable so a trace of two runs can be compared line by line.
+func (c *ClientStock) inputsFor(targets map[string]stockTarget, snapshot *stockstate.Snapshot) []shopify.StockInput {
+	keys := make([]string, 0, len(targets))
+	for key, target := range targets {
+		if snapshot != nil && !snapshot.Changed(key, target.Quantity) {
 			continue
 		}
-		skus = append(skus, sku)
+		keys = append(keys, key)
 	}
-	sort.Strings(skus)
+	sort.Strings(keys)
 
-	inputs := make([]shopify.StockInput, 0, len(skus))
-	for _, sku := range skus {
-		inputs = append(inputs, shopify.StockInput{SKU: sku, Quantity: targets[sku]})
+	inputs := make([]shopify.StockInput, 0, len(keys))
+	for _, key := range keys {
+		target := targets[key]
+		inputs = append(inputs, shopify.StockInput{SKU: target.SKU, Quantity: target.Quantity, Location: target.Location})
 	}
 	return inputs
 }
 
 // saveSnapshot records the full ERP state, including SKUs this run had no reason to
-// push, because that is what the next delta compares against. Written only after a
-// successful push: if the push failed, the changed SKUs must be retried next tick.
-func (c *ClientStock) saveSnapshot(targets map[string]int, usable bool) {
+// push, because that is what the next delta compares against. A changes feed only
+// carries what moved, so its targets are laid over the snapshot it was diffed
+// against. Written only after a successful push: if the push failed, the changed SKUs
+// must be retried next tick.
+func (c *ClientStock) saveSnapshot(targets map[string]stockTarget, feed stockFeed, usable bool) {
 	// A dry run wrote nothing, so recording these quantities as pushed would make the
 	// next real delta skip every one of them — the sync would go quiet while Shopify
 	// stayed wrong. This is the single most dangerous thing a dry run could do.
@@ -214,10 +382,33 @@ func (c *ClientStock) saveSnapshot(targets map[string]int, usable bool) {
 		c.logWarning("stock snapshot not written: " + debugsync.OnlySKUsEnv + " limited this run to a subset of SKUs")
 		return
 	}
-	if err := stockstate.Save(c.stockConfig.StatePath, targets, time.Now()); err != nil {
+	quantities := make(map[string]int, len(targets)+len(feed.base.Quantities))
+	if feed.incremental {
+		for key, quantity := range feed.base.Quantities {
+			quantities[key] = quantity
+		}
+	}
+	for key, target := range targets {
+		quantities[key] = target.Quantity
+	}
+	now := time.Now()
+	snapshot := stockstate.Snapshot{UpdatedAt: now, Quantities: quantities, RunID: stockstate.NextRunID(c.stockConfig.HistoryDir, now)}
+	if err := stockstate.SaveSnapshot(c.stockConfig.StatePath, snapshot); err != nil {
 		// Not fatal: the stock was pushed. The next run just diffs against an older
 		// snapshot, or pushes everything, which is correct either way.
 		c.logWarning(fmt.Sprintf("stock snapshot write failed at %s: %v", c.stockConfig.StatePath, err))
+		return
+	}
+	c.archiveSnapshot(snapshot)
+	c.saveCursor(feed, usable)
+}
+
+// archiveSnapshot keeps a copy in the history, so a bad ERP feed can be diffed and
+// rolled back with cmd/stock-history. A failed copy is only a warning: the snapshot
+// itself is written.
+func (c *ClientStock) archiveSnapshot(snapshot stockstate.Snapshot) {
+	if _, err := stockstate.Archive(c.stockConfig.HistoryDir, snapshot, c.stockConfig.HistoryKeep); err != nil {
+		c.logWarning(fmt.Sprintf("stock snapshot history write failed at %s: %v", c.stockConfig.HistoryDir, err))
 	}
 }
 
@@ -233,6 +424,14 @@ func (c *ClientStock) logWarning(message string) {
 	}
 }
 
+// warnStock logs a warning and puts it in the run report.
+func (c *ClientStock) warnStock(message string) {
+	c.logWarning(message)
+	if c.recorder != nil {
+		c.recorder.Warn("stock", message)
+	}
+}
+
 func (c *ClientStock) logSuccess(message string) {
 	if c.logger != nil {
 		c.logger.LogSuccess(message)
@@ -5,10 +5,12 @@ import (
 	"errors"
 	"os"
 	"path/filepath"
+	"shopify-exporter/internal/adapters/apix"
 	"shopify-exporter/internal/adapters/shopify"
 	"shopify-exporter/internal/config"
 	"shopify-exporter/internal/domain/model"
 	"shopify-exporter/internal/infra/stockstate"
+	"shopify-exporter/internal/report"
 	"sort"
 	"testing"
 	"time"
@@ -28,12 +30,23 @@ func writeFile(path, content string) error {
 type fakeStockAPI struct {
 	stocks []model.Stock
 	err    error
+	// changes answers FetchStockChanges; changesErr fails it.
+	changes     apix.StockChanges
+	changesErr  error
+	fullReads   int
+	changeReads []time.Time
 }
 
 func (f *fakeStockAPI) FetchStocks(context.Context) ([]model.Stock, error) {
+	f.fullReads++
 	return f.stocks, f.err
 }
 
+func (f *fakeStockAPI) FetchStockChanges(_ context.Context, since time.Time) (apix.StockChanges, error) {
+	f.changeReads = append(f.changeReads, since)
+	return f.changes, f.changesErr
+}
+
 type fakeStockShopify struct {
 	calls   int
 	batches [][]shopify.StockInput
@@ -98,7 +111,7 @@ func TestSyncStocksPushesInOneCall(t *testing.T) {
 	api := &fakeStockAPI{stocks: stocks(map[string]int32{"A-1": 5, "B-2": 0, "C-3": 12})}
 	shop := &fakeStockShopify{}
 
-	if err := NewSyncStocks(api, shop, nil, config.StockConfig{Mode: config.StockModeFull}).Run(context.Background()); err != nil {
+	if err := NewSyncStocks(api, nil, shop, nil, nil, config.StockConfig{Mode: config.StockModeFull}).Run(context.Background()); err != nil {
 		t.Fatal(err)
 	}
 
@@ -117,7 +130,7 @@ func TestSyncStocksFullModeIgnoresSnapshot(t *testing.T) {
 
 	api := &fakeStockAPI{stocks: stocks(map[string]int32{"A-1": 5})}
 	shop := &fakeStockShopify{}
-	if err := NewSyncStocks(api, shop, nil, cfg).Run(context.Background()); err != nil {
+	if err := NewSyncStocks(api, nil, shop, nil, nil, cfg).Run(context.Background()); err != nil {
 		t.Fatal(err)
 	}
 
@@ -131,13 +144,13 @@ func TestSyncStocksDeltaPushesEverythingOnceThenNothing(t *testing.T) {
 	feed := stocks(map[string]int32{"A-1": 5, "B-2": 0})
 
 	first := &fakeStockShopify{}
-	if err := NewSyncStocks(&fakeStockAPI{stocks: feed}, first, nil, cfg).Run(context.Background()); err != nil {
+	if err := NewSyncStocks(&fakeStockAPI{stocks: feed}, nil, first, nil, nil, cfg).Run(context.Background()); err != nil {
 		t.Fatal(err)
 	}
 	equalSKUs(t, first.pushed(), []string{"A-1", "B-2"})
 
 	second := &fakeStockShopify{}
-	if err := NewSyncStocks(&fakeStockAPI{stocks: feed}, second, nil, cfg).Run(context.Background()); err != nil {
+	if err := NewSyncStocks(&fakeStockAPI{stocks: feed}, nil, second, nil, nil, cfg).Run(context.Background()); err != nil {
 		t.Fatal(err)
 	}
 	if second.calls != 0 {
@@ -149,8 +162,8 @@ func TestSyncStocksDeltaPushesOnlyChangedSKUs(t *testing.T) {
 	cfg := deltaConfig(t)
 
 	if err := NewSyncStocks(
-		&fakeStockAPI{stocks: stocks(map[string]int32{"A-1": 5, "B-2": 3, "C-3": 0})},
-		&fakeStockShopify{}, nil, cfg,
+		&fakeStockAPI{stocks: stocks(map[string]int32{"A-1": 5, "B-2": 3, "C-3": 0})}, nil,
+		&fakeStockShopify{}, nil, nil, cfg,
 	).Run(context.Background()); err != nil {
 		t.Fatal(err)
 	}
@@ -158,8 +171,8 @@ func TestSyncStocksDeltaPushesOnlyChangedSKUs(t *testing.T) {
 	// B-2 drops to zero, C-3 is restocked, A-1 is untouched.
 	shop := &fakeStockShopify{}
 	if err := NewSyncStocks(
-		&fakeStockAPI{stocks: stocks(map[string]int32{"A-1": 5, "B-2": 0, "C-3": 4})},
-		shop, nil, cfg,
+		&fakeStockAPI{stocks: stocks(map[string]int32{"A-1": 5, "B-2": 0, "C-3": 4})}, nil,
+		shop, nil, nil, cfg,
 	).Run(context.Background()); err != nil {
 		t.Fatal(err)
 	}
@@ -185,12 +198,12 @@ func TestSyncStocksDeltaDoesNotSaveSnapshotAfterFailure(t *testing.T) {
 	pushErr := errors.New("shopify rejected the mutation")
 
 	failing := &fakeStockShopify{err: pushErr}
-	if err := NewSyncStocks(&fakeStockAPI{stocks: feed}, failing, nil, cfg).Run(context.Background()); !errors.Is(err, pushErr) {
+	if err := NewSyncStocks(&fakeStockAPI{stocks: feed}, nil, failing, nil, nil, cfg).Run(context.Background()); !errors.Is(err, pushErr) {
 		t.Fatalf("expected the push error to propagate, got %v", err)
 	}
 
 	retry := &fakeStockShopify{}
-	if err := NewSyncStocks(&fakeStockAPI{stocks: feed}, retry, nil, cfg).Run(context.Background()); err != nil {
+	if err := NewSyncStocks(&fakeStockAPI{stocks: feed}, nil, retry, nil, nil, cfg).Run(context.Background()); err != nil {
 		t.Fatal(err)
 	}
 	equalSKUs(t, retry.pushed(), []string{"A-1"})
@@ -204,8 +217,8 @@ func TestSyncStocksDeltaFallsBackToFullPushOnCorruptSnapshot(t *testing.T) {
 
 	shop := &fakeStockShopify{}
 	if err := NewSyncStocks(
-		&fakeStockAPI{stocks: stocks(map[string]int32{"A-1": 5, "B-2": 1})},
-		shop, nil, cfg,
+		&fakeStockAPI{stocks: stocks(map[string]int32{"A-1": 5, "B-2": 1})}, nil,
+		shop, nil, nil, cfg,
 	).Run(context.Background()); err != nil {
 		t.Fatal(err)
 	}
@@ -221,13 +234,18 @@ func TestSyncStocksSkipsInvalidRows(t *testing.T) {
 	}}
 	shop := &fakeStockShopify{}
 
-	if err := NewSyncStocks(api, shop, nil, config.StockConfig{Mode: config.StockModeFull}).Run(context.Background()); err != nil {
+	if err := NewSyncStocks(api, nil, shop, nil, nil, config.StockConfig{Mode: config.StockModeFull}).Run(context.Background()); err != nil {
 		t.Fatal(err)
 	}
 
-	// Blank SKU dropped, negative dropped (apix clamps at 0 before this point, so a
-	// negative here means the feed itself is odd), and the survivor is trimmed.
-	equalSKUs(t, shop.pushed(), []string{"A-1"})
+	// Blank SKU dropped and the survivor trimmed. A negative ERP balance is pushed as
+	// 0, never skipped: skipping left out-of-stock items showing as available.
+	equalSKUs(t, shop.pushed(), []string{"A-1", "NEG-1"})
+	for _, input := range shop.batches[0] {
+		if input.SKU == "NEG-1" && input.Quantity != 0 {
+			t.Errorf("NEG-1 quantity = %d, want 0", input.Quantity)
+		}
+	}
 }
 
 // The worst thing a dry run could do is record its proposals as pushed: the next real
@@ -237,7 +255,7 @@ func TestSyncStocksDryRunNeverWritesSnapshot(t *testing.T) {
 	cfg.DryRun = true
 	feed := stocks(map[string]int32{"A-1": 5, "B-2": 2})
 
-	if err := NewSyncStocks(&fakeStockAPI{stocks: feed}, &fakeStockShopify{}, nil, cfg).Run(context.Background()); err != nil {
+	if err := NewSyncStocks(&fakeStockAPI{stocks: feed}, nil, &fakeStockShopify{}, nil, nil, cfg).Run(context.Background()); err != nil {
 		t.Fatal(err)
 	}
 
@@ -248,7 +266,7 @@ func TestSyncStocksDryRunNeverWritesSnapshot(t *testing.T) {
 	// And the next real run must therefore still see everything as changed.
 	cfg.DryRun = false
 	shop := &fakeStockShopify{}
-	if err := NewSyncStocks(&fakeStockAPI{stocks: feed}, shop, nil, cfg).Run(context.Background()); err != nil {
+	if err := NewSyncStocks(&fakeStockAPI{stocks: feed}, nil, shop, nil, nil, cfg).Run(context.Background()); err != nil {
 		t.Fatal(err)
 	}
 	equalSKUs(t, shop.pushed(), []string{"A-1", "B-2"})
@@ -264,8 +282,8 @@ func TestSyncStocksDryRunLeavesExistingSnapshotIntact(t *testing.T) {
 
 	cfg.DryRun = true
 	if err := NewSyncStocks(
-		&fakeStockAPI{stocks: stocks(map[string]int32{"A-1": 9})},
-		&fakeStockShopify{}, nil, cfg,
+		&fakeStockAPI{stocks: stocks(map[string]int32{"A-1": 9})}, nil,
+		&fakeStockShopify{}, nil, nil, cfg,
 	).Run(context.Background()); err != nil {
 		t.Fatal(err)
 	}
@@ -286,7 +304,7 @@ func TestSyncStocksFetchErrorPropagates(t *testing.T) {
 	fetchErr := errors.New("erp unreachable")
 	shop := &fakeStockShopify{}
 
-	err := NewSyncStocks(&fakeStockAPI{err: fetchErr}, shop, nil, config.StockConfig{Mode: config.StockModeFull}).Run(context.Background())
+	err := NewSyncStocks(&fakeStockAPI{err: fetchErr}, nil, shop, nil, nil, config.StockConfig{Mode: config.StockModeFull}).Run(context.Background())
 	if !errors.Is(err, fetchErr) {
 		t.Fatalf("expected the fetch error to propagate, got %v", err)
 	}
@@ -297,10 +315,255 @@ func TestSyncStocksFetchErrorPropagates(t *testing.T) {
 
 func TestSyncStocksEmptyFeedIsNotAFailure(t *testing.T) {
 	shop := &fakeStockShopify{}
-	if err := NewSyncStocks(&fakeStockAPI{}, shop, nil, config.StockConfig{Mode: config.StockModeFull}).Run(context.Background()); err != nil {
+	if err := NewSyncStocks(&fakeStockAPI{}, nil, shop, nil, nil, config.StockConfig{Mode: config.StockModeFull}).Run(context.Background()); err != nil {
 		t.Fatal(err)
 	}
 	if shop.calls != 0 {
 		t.Error("an empty feed must not reach Shopify")
 	}
 }
+
+// The pushed quantity is the ERP balance less the reserve, and the report can say why.
+func TestSyncStocksAppliesReservePolicy(t *testing.T) {
+	cfg := config.StockConfig{Mode: config.StockModeFull, Reserve: config.StockReserveConfig{
+		Default: 3,
+		SKUs:    map[string]int{"SLOW-1": 0},
+	}}
+	shop := &fakeStockShopify{}
+	run := report.NewRun("test", "full", "", "", testTime())
+
+	api := &fakeStockAPI{stocks: stocks(map[string]int32{"FAST-1": 5, "SLOW-1": 1})}
+	if err := NewSyncStocks(api, nil, shop, nil, run, cfg).Run(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	got := make(map[string]int)
+	for _, input := range shop.batches[0] {
+		got[input.SKU] = input.Quantity
+	}
+	if got["FAST-1"] != 2 || got["SLOW-1"] != 1 {
+		t.Errorf("pushed = %v, want FAST-1=2 (5 less the default 3) and SLOW-1=1", got)
+	}
+
+	run.StockSeen("FAST-1", 4, true, 2)
+	changes := run.Snapshot().StockChanges
+	if len(changes) != 1 || changes[0].Reserve == nil || *changes[0].Reserve != (report.StockReserve{ERP: 5, Units: 3, Source: "default"}) {
+		t.Errorf("stock change = %+v, want ERP 5 less reserve 3 (default)", changes)
+	}
+}
+
+// With a warehouse mapping each location gets the sum of its warehouses less the
+// reserve; unmapped warehouses are ignored and an item without a breakdown is skipped.
+func TestSyncStocksSplitsWarehousesAcrossLocations(t *testing.T) {
+	cfg := deltaConfig(t)
+	cfg.Reserve = config.StockReserveConfig{Default: 1}
+	cfg.Locations = []config.StockLocation{
+		{Name: "Main Warehouse", Warehouses: []int{1, 3}},
+		{Name: "Tel Aviv Store", Warehouses: []int{5}},
+	}
+	api := &fakeStockAPI{stocks: []model.Stock{
+		{Sku: "CMG-28", Stock: 19, Warehouses: map[int]int32{1: 6, 3: 4, 5: 2, 9: 7}},
+		{Sku: "NO-SPLIT", Stock: 8},
+	}}
+	shop := &fakeStockShopify{}
+	if err := NewSyncStocks(api, nil, shop, nil, nil, cfg).Run(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []shopify.StockInput{
+		{SKU: "CMG-28", Quantity: 9, Location: "Main Warehouse"},
+		{SKU: "CMG-28", Quantity: 2, Location: "Tel Aviv Store"},
+	}
+	if len(shop.batches) != 1 || len(shop.batches[0]) != len(want) {
+		t.Fatalf("pushed = %+v, want %+v", shop.batches, want)
+	}
+	for i, input := range shop.batches[0] {
+		if input != want[i] {
+			t.Errorf("input %d = %+v, want %+v", i, input, want[i])
+		}
+	}
+
+	snapshot, err := stockstate.Load(cfg.StatePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if snapshot.Quantities[stockstate.Key("CMG-28", "Tel Aviv Store")] != 2 || len(snapshot.Quantities) != 2 {
+		t.Errorf("snapshot = %v, want one entry per SKU and location", snapshot.Quantities)
+	}
+
+	// Only the store moved: the next delta pushes that location alone.
+	api.stocks[0].Warehouses[5] = 4
+	shop.batches = nil
+	if err := NewSyncStocks(api, nil, shop, nil, nil, cfg).Run(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if len(shop.batches) != 1 || len(shop.batches[0]) != 1 || shop.batches[0][0].Location != "Tel Aviv Store" {
+		t.Errorf("delta pushed = %+v, want only CMG-28 at Tel Aviv Store", shop.batches)
+	}
+}
+
+func changesConfig(t *testing.T) config.StockConfig {
+	t.Helper()
+	cfg := deltaConfig(t)
+	cfg.ChangesFeed = true
+	cfg.CursorPath = filepath.Join(filepath.Dir(cfg.StatePath), "stock-cursor.json")
+	return cfg
+}
+
+// The first delta reads the full feed and seeds the cursor; the next reads only the
+// changes, pushes what moved and merges it into the snapshot.
+func TestSyncStocksChangesFeedReadsOnlyChanges(t *testing.T) {
+	cfg := changesConfig(t)
+	api := &fakeStockAPI{stocks: stocks(map[string]int32{"HVM-1": 5, "CMG-28": 7})}
+	shop := &fakeStockShopify{}
+	if err := NewSyncStocks(api, nil, shop, nil, nil, cfg).Run(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if api.fullReads != 1 || len(api.changeReads) != 0 {
+		t.Fatalf("first run reads = full %d, changes %d; want the full feed once", api.fullReads, len(api.changeReads))
+	}
+	seeded, err := stockstate.LoadCursor(cfg.CursorPath)
+	if err != nil || seeded.Since.IsZero() {
+		t.Fatalf("cursor after a full read = %+v, %v; want it seeded", seeded, err)
+	}
+
+	next := time.Now().UTC().Truncate(time.Second)
+	api.changes = apix.StockChanges{Items: stocks(map[string]int32{"HVM-1": 9}), Cursor: next}
+	shop.batches = nil
+	if err := NewSyncStocks(api, nil, shop, nil, nil, cfg).Run(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if api.fullReads != 1 || len(api.changeReads) != 1 || !api.changeReads[0].Equal(seeded.Since) {
+		t.Fatalf("second run reads = full %d, changes %v; want one changes read from the seeded cursor", api.fullReads, api.changeReads)
+	}
+	if got := shop.pushed(); len(got) != 1 || got[0] != "HVM-1" {
+		t.Errorf("pushed = %v, want only HVM-1", got)
+	}
+
+	snapshot, _ := stockstate.Load(cfg.StatePath)
+	if snapshot.Quantities["HVM-1"] != 9 || snapshot.Quantities["CMG-28"] != 7 {
+		t.Errorf("snapshot = %v, want HVM-1 updated and CMG-28 kept", snapshot.Quantities)
+	}
+	if cursor, _ := stockstate.LoadCursor(cfg.CursorPath); !cursor.Since.Equal(next) {
+		t.Errorf("cursor = %s, want the ERP's %s", cursor.Since, next)
+	}
+}
+
+// A gap behind the cursor, a failed changes read or a stale cursor all fall back to
+// the full feed rather than trusting a partial view.
+func TestSyncStocksChangesFeedFallsBackToFullFeed(t *testing.T) {
+	cases := map[string]func(*fakeStockAPI, config.StockConfig){
+		"cursor expired": func(api *fakeStockAPI, _ config.StockConfig) { api.changesErr = apix.ErrStockCursorExpired },
+		"changes failed": func(api *fakeStockAPI, _ config.StockConfig) { api.changesErr = errors.New("timeout") },
+		"stale cursor": func(_ *fakeStockAPI, cfg config.StockConfig) {
+			_ = stockstate.SaveCursor(cfg.CursorPath, time.Now().Add(-48*time.Hour), time.Now())
+		},
+	}
+	for name, setup := range cases {
+		t.Run(name, func(t *testing.T) {
+			cfg := changesConfig(t)
+			api := &fakeStockAPI{stocks: stocks(map[string]int32{"HVM-1": 5})}
+			if err := stockstate.Save(cfg.StatePath, map[string]int{"HVM-1": 0}, time.Now()); err != nil {
+				t.Fatal(err)
+			}
+			if err := stockstate.SaveCursor(cfg.CursorPath, time.Now().Add(-time.Hour), time.Now()); err != nil {
+				t.Fatal(err)
+			}
+			setup(api, cfg)
+			shop := &fakeStockShopify{}
+			if err := NewSyncStocks(api, nil, shop, nil, nil, cfg).Run(context.Background()); err != nil {
+				t.Fatal(err)
+			}
+			if api.fullReads != 1 {
+				t.Errorf("full reads = %d, want the fallback to the full feed", api.fullReads)
+			}
+			if got := shop.pushed(); len(got) != 1 || got[0] != "HVM-1" {
+				t.Errorf("pushed = %v, want HVM-1 from the full feed", got)
+			}
+		})
+	}
+}
+
+// A kit sells its own balance plus what its components' storefront quantities make,
+// reserved like any SKU, and names the component that limits it. A component the
+// feed lacks counts as 0.
+func TestSyncStocksComputesKitsFromComponents(t *testing.T) {
+	cfg := config.StockConfig{Mode: config.StockModeFull, Reserve: config.StockReserveConfig{Default: 1}}
+	cfg.Kits = []config.StockKit{
+		{SKU: "GIFT-1", Components: []config.StockKitComponent{{SKU: "CMG-28", Quantity: 2}, {SKU: "hvm-1", Quantity: 1}}},
+		{SKU: "GIFT-2", Components: []config.StockKitComponent{{SKU: "CMG-28", Quantity: 1}, {SKU: "GONE-1", Quantity: 1}}},
+		{SKU: "GIFT-3", Components: []config.StockKitComponent{{SKU: "NOT-SOLD", Quantity: 1}}},
+	}
+	api := &fakeStockAPI{stocks: stocks(map[string]int32{"GIFT-1": 1, "CMG-28": 11, "HVM-1": 4})}
+	shop := &fakeStockShopify{}
+	run := report.NewRun("test", "full", "", "", testTime())
+	if err := NewSyncStocks(api, nil, shop, nil, run, cfg).Run(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	got := make(map[string]int)
+	for _, input := range shop.batches[0] {
+		got[input.SKU] = input.Quantity
+	}
+	// GIFT-1: 1 of its own + min(10/2, 3/1) = 4 (each component less its reserve of
+	// 1), less the kit's reserve of 1.
+	if len(got) != 4 || got["GIFT-1"] != 3 || got["GIFT-2"] != 0 || got["CMG-28"] != 10 {
+		t.Errorf("pushed = %v, want GIFT-1=3, GIFT-2=0 and no GIFT-3", got)
+	}
+
+	run.StockSeen("GIFT-1", 0, true, 3)
+	changes := run.Snapshot().StockChanges
+	if len(changes) != 1 || changes[0].Kit == nil || *changes[0].Kit != (report.StockKit{Assemblable: 3, Limiting: "hvm-1"}) {
+		t.Errorf("stock change = %+v, want 3 kits limited by hvm-1", changes)
+	}
+}
+
+// A component the reserve keeps off the storefront is not sold through a kit either.
+func TestSyncStocksKitsRespectComponentReserve(t *testing.T) {
+	cfg := config.StockConfig{Mode: config.StockModeFull, Reserve: config.StockReserveConfig{SKUs: map[string]int{"CMG-28": 5}}}
+	cfg.Kits = []config.StockKit{
+		{SKU: "GIFT-1", Components: []config.StockKitComponent{{SKU: "CMG-28", Quantity: 1}, {SKU: "HVM-1", Quantity: 1}}},
+	}
+	api := &fakeStockAPI{stocks: stocks(map[string]int32{"CMG-28": 5, "HVM-1": 8})}
+	shop := &fakeStockShopify{}
+	if err := NewSyncStocks(api, nil, shop, nil, nil, cfg).Run(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	got := make(map[string]int)
+	for _, input := range shop.batches[0] {
+		got[input.SKU] = input.Quantity
+	}
+	if got["CMG-28"] != 0 || got["GIFT-1"] != 0 || got["HVM-1"] != 8 {
+		t.Errorf("pushed = %v, want CMG-28=0 and GIFT-1=0 with it, HVM-1=8", got)
+	}
+}
+
+// A changes feed touching a component cannot recompute the kit from the rows that did
+// not change, so the full feed is read instead.
+func TestSyncStocksChangesFeedReadsFullFeedForKits(t *testing.T) {
+	cfg := changesConfig(t)
+	cfg.Kits = []config.StockKit{{SKU: "GIFT-1", Components: []config.StockKitComponent{{SKU: "HVM-1", Quantity: 2}}}}
+	api := &fakeStockAPI{stocks: stocks(map[string]int32{"HVM-1": 6, "CMG-28": 7})}
+	if err := NewSyncStocks(api, nil, &fakeStockShopify{}, nil, nil, cfg).Run(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	api.changes = apix.StockChanges{Items: stocks(map[string]int32{"CMG-28": 8}), Cursor: time.Now()}
+	if err := NewSyncStocks(api, nil, &fakeStockShopify{}, nil, nil, cfg).Run(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if api.fullReads != 1 {
+		t.Fatalf("full reads = %d, want a change to a plain SKU read from the changes feed", api.fullReads)
+	}
+
+	api.stocks = stocks(map[string]int32{"HVM-1": 2, "CMG-28": 8})
+	api.changes = apix.StockChanges{Items: stocks(map[string]int32{"HVM-1": 2}), Cursor: time.Now()}
+	shop := &fakeStockShopify{}
+	if err := NewSyncStocks(api, nil, shop, nil, nil, cfg).Run(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if api.fullReads != 2 {
+		t.Fatalf("full reads = %d, want a changed component to read the full feed", api.fullReads)
+	}
+	equalSKUs(t, shop.pushed(), []string{"GIFT-1", "HVM-1"})
+}
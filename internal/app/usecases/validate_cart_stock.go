@@ -0,0 +1,439 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"shopify-exporter/internal/adapters/apix"
+	"shopify-exporter/internal/adapters/shopify"
+	"shopify-exporter/internal/config"
+	"shopify-exporter/internal/debugsync"
+	"shopify-exporter/internal/domain/model"
+	"shopify-exporter/internal/logging"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Cart line outcomes. Only CartLineOK, CartLineUntracked and CartLinePreorder are
+// always allowed; CartLineUnverified is allowed when the validator fails open.
+const (
+	CartLineOK = "ok"
+	// CartLineUntracked is a line with no stock to check: no SKU (a custom item) or a
+	// service SKU under SHOPIFY_UNTRACKED_SKU_PREFIXES.
+	CartLineUntracked = "untracked"
+	// CartLinePreorder is a SKU whose pre-order is active by the ERP row just read:
+	// the product sync has set it to CONTINUE, so it may sell beyond its stock. A
+	// pre-order SKU whose date passed, or with nothing on order when that is
+	// required, is DENY in Shopify and is checked like any other line: its
+	// storefront quantity can be as stale as any.
+	CartLinePreorder = "preorder"
+	// CartLineInsufficient asks for more than the ERP can sell after the reserve.
+	CartLineInsufficient = "insufficient_stock"
+	// CartLineUnknownSKU is a SKU the ERP says it does not have. That is an answer,
+	// not an outage, so it blocks whatever FailOpen says.
+	CartLineUnknownSKU = "unknown_sku"
+	// CartLineUnverified is a SKU the ERP did not answer for within the budget.
+	CartLineUnverified = "unverified"
+)
+
+type ValidateCartStockService interface {
+	Validate(ctx context.Context, lines []CartLine) CartStockValidation
+}
+
+// CartLine is one line of a cart or checkout.
+type CartLine struct {
+	SKU      string
+	Quantity int
+}
+
+// CartLineStock is the verdict for one cart line, in the order the lines came in.
+type CartLineStock struct {
+	SKU      string
+	Quantity int
+	// Available is what the storefront may sell: the ERP balance less the reserve,
+	// exactly the quantity the stock sync would push. Meaningful only when Verified.
+	Available int
+	Verified  bool
+	Status    string
+	Allowed   bool
+}
+
+// CartStockValidation is the answer for a whole cart.
+type CartStockValidation struct {
+	Lines []CartLineStock
+	// Allowed is true when every line is.
+	Allowed bool
+}
+
+// CartStockValidator checks a cart against the ERP live, at checkout. The stock sync
+// runs every few minutes; between two runs Shopify sells stock the ERP may already
+// have given to the shop counter, and only a check at the moment of purchase closes
+// that window. It applies the sync's reserve, location and kit rules, so a checkout is
+// never allowed more than the next sync would show.
+type CartStockValidator struct {
+	lookup apix.StockLookupService
+	// stock holds the config and logger the sync's helpers read; its feed clients are
+	// unused.
+	stock             *ClientStock
+	policy            stockReservePolicy
+	untrackedPrefixes []string
+	preorderRules     shopify.PreorderRules
+	// location is REPORT_TIMEZONE, where a pre-order return date ends.
+	location        *time.Location
+	timeout         time.Duration
+	cacheTTL        time.Duration
+	failOpen        bool
+	maxCacheEntries int
+	now             func() time.Time
+	// lookups holds one slot per ERP lookup in flight, shared by all requests, so a
+	// burst of large carts cannot open hundreds of connections to the ERP.
+	lookups chan struct{}
+
+	mu    sync.Mutex
+	cache map[string]cachedStock
+}
+
+// cachedStock is one ERP answer; found=false is cached too, so a bot hammering an
+// unknown SKU does not hammer the ERP.
+type cachedStock struct {
+	stock model.Stock
+	found bool
+	at    time.Time
+}
+
+// NewValidateCartStock reads the ERP categories once when a reserve rule needs them,
+// like the stock step does at the start of a run. The service is long-lived, so a
+// recategorised item takes the new category reserve after a restart.
+func NewValidateCartStock(
+	ctx context.Context,
+	lookup apix.StockLookupService,
+	apixCategories apix.CategoryService,
+	untrackedPrefixes []string,
+	logger logging.LoggerService,
+	stockConfig config.StockConfig,
+	validatorConfig config.StockValidatorConfig,
+) ValidateCartStockService {
+	stock := &ClientStock{
+		apixCategories: apixCategories,
+		logger:         logger,
+		stockConfig:    stockConfig,
+	}
+	return &CartStockValidator{
+		lookup:            lookup,
+		stock:             stock,
+		policy:            stock.reservePolicy(ctx),
+		untrackedPrefixes: untrackedPrefixes,
+		preorderRules: shopify.PreorderRules{
+			SKUs:          validatorConfig.PreorderSkus,
+			Prefixes:      validatorConfig.PreorderSkuPrefixes,
+			NeedsIncoming: validatorConfig.PreorderNeedsIncoming,
+		},
+		location:        stock.resolveLocation(validatorConfig.Timezone),
+		timeout:         validatorConfig.Timeout,
+		cacheTTL:        validatorConfig.CacheTTL,
+		failOpen:        validatorConfig.FailOpen,
+		maxCacheEntries: validatorConfig.MaxCacheEntries,
+		now:             time.Now,
+		lookups:         make(chan struct{}, max(validatorConfig.MaxLookups, 1)),
+		cache:           make(map[string]cachedStock),
+	}
+}
+
+func (v *CartStockValidator) Validate(ctx context.Context, lines []CartLine) CartStockValidation {
+	// Lines are checked per SKU with their quantities summed: two lines of 2 against
+	// 3 available must fail, each alone would pass.
+	requested := make(map[string]int)
+	// skus maps each key to the SKU as the cart spelled it first, which is what the
+	// ERP is asked for. A kit asks for its components too.
+	skus := make(map[string]string)
+	kits := v.stock.kitIndex()
+	for _, line := range lines {
+		key := stockSKUKey(line.SKU)
+		if key == "" || !v.tracked(key) || line.Quantity <= 0 {
+			continue
+		}
+		if _, seen := skus[key]; !seen {
+			skus[key] = strings.TrimSpace(line.SKU)
+		}
+		requested[key] += line.Quantity
+		if kit, isKit := kits[key]; isKit {
+			for _, component := range kit.Components {
+				if _, seen := skus[stockSKUKey(component.SKU)]; !seen {
+					skus[stockSKUKey(component.SKU)] = component.SKU
+				}
+			}
+		}
+	}
+
+	answers := v.fetch(ctx, skus)
+
+	result := CartStockValidation{Lines: make([]CartLineStock, 0, len(lines)), Allowed: true}
+	for _, line := range lines {
+		verdict := CartLineStock{SKU: line.SKU, Quantity: line.Quantity}
+		key := stockSKUKey(line.SKU)
+		answer, checked := answers[key]
+		kit, isKit := kits[key]
+		switch {
+		case key == "" || !v.tracked(key):
+			verdict.Status, verdict.Allowed = CartLineUntracked, true
+		case checked && answer.err == nil && answer.found && v.preorder(answer.stock):
+			verdict.Verified = true
+			verdict.Status, verdict.Allowed = CartLinePreorder, true
+		case line.Quantity <= 0:
+			verdict.Status, verdict.Allowed = CartLineOK, true
+		case !isKit && (!checked || answer.err != nil):
+			verdict.Status, verdict.Allowed = CartLineUnverified, v.failOpen
+		case !isKit && !answer.found:
+			verdict.Verified = true
+			verdict.Status = CartLineUnknownSKU
+		default:
+			var available int
+			var ok bool
+			if isKit {
+				available, ok = v.kitSellable(kit, answers)
+			} else {
+				available, ok = v.sellable(answer.stock)
+			}
+			if !ok {
+				verdict.Status, verdict.Allowed = CartLineUnverified, v.failOpen
+				break
+			}
+			verdict.Verified = true
+			verdict.Available = available
+			if requested[key] > available {
+				verdict.Status = CartLineInsufficient
+			} else {
+				verdict.Status, verdict.Allowed = CartLineOK, true
+			}
+		}
+		if !verdict.Allowed {
+			result.Allowed = false
+		}
+		if !verdict.Allowed || verdict.Status == CartLineUnverified {
+			v.stock.log(fmt.Sprintf(
+				"stock validation sku=%s quantity=%d requested_total=%d available=%d status=%s allowed=%t",
+				line.SKU,
+				line.Quantity,
+				requested[key],
+				verdict.Available,
+				verdict.Status,
+				verdict.Allowed,
+			))
+		}
+		result.Lines = append(result.Lines, verdict)
+	}
+	return result
+}
+
+// sellable is what the stock sync would push for the item, summed over the mapped
+// locations: a checkout can be fulfilled from any of them. The reserve comes off
+// once, as the sync takes it. ok is false when locations
+// are mapped but the ERP sent no breakdown, which the sync skips too.
+func (v *CartStockValidator) sellable(stock model.Stock) (int, bool) {
+	balances, ok := v.stock.locationBalances(stock)
+	if !ok {
+		return 0, false
+	}
+	reserve := v.policy.reserve(stock)
+	quantities, _ := reserve.spread(balanceValues(balances))
+	total := sumQuantities(quantities)
+	if debugsync.MatchSKU(stock.Sku) {
+		v.stock.log(fmt.Sprintf(
+			"trace stock validation sku=%s api_quantity=%d reserve=%d reserve_source=%q sellable=%d",
+			stock.Sku,
+			stock.Stock,
+			reserve.Units,
+			reserve.Source,
+			total,
+		))
+	}
+	return total, true
+}
+
+// kitSellable is sellable for a kit, made from its components as the stock sync
+// makes it. A kit is never an unknown SKU: the ERP seldom has a row for one. ok is
+// false when the kit or a component went unanswered.
+func (v *CartStockValidator) kitSellable(kit config.StockKit, answers map[string]stockAnswer) (int, bool) {
+	rows := make(map[string]model.Stock, len(kit.Components)+1)
+	keys := []string{stockSKUKey(kit.SKU)}
+	for _, component := range kit.Components {
+		keys = append(keys, stockSKUKey(component.SKU))
+	}
+	for _, key := range keys {
+		answer, checked := answers[key]
+		if !checked || answer.err != nil {
+			return 0, false
+		}
+		if answer.found {
+			rows[key] = answer.stock
+		}
+	}
+	balances, ok := v.stock.kitBalances(kit, rows, v.policy)
+	if !ok {
+		return 0, false
+	}
+	own, found := rows[stockSKUKey(kit.SKU)]
+	if !found {
+		own = model.Stock{Sku: kit.SKU}
+	}
+	reserve := v.policy.reserve(own)
+	values := make([]int32, len(balances))
+	for i, balance := range balances {
+		values[i] = balance.Balance
+	}
+	quantities, _ := reserve.spread(values)
+	total := sumQuantities(quantities)
+	if debugsync.MatchSKU(kit.SKU) {
+		v.stock.log(fmt.Sprintf(
+			"trace stock validation kit=%s reserve=%d reserve_source=%q sellable=%d",
+			kit.SKU,
+			reserve.Units,
+			reserve.Source,
+			total,
+		))
+	}
+	return total, true
+}
+
+type stockAnswer struct {
+	stock model.Stock
+	found bool
+	err   error
+}
+
+// fetch answers every SKU from the cache or the ERP, all lookups sharing one timeout
+// budget and the process-wide lookup slots. A lookup still running when the budget is spent is cancelled and its SKU
+// left unanswered: a slow checkout costs more sales than the check saves.
+func (v *CartStockValidator) fetch(ctx context.Context, skus map[string]string) map[string]stockAnswer {
+	answers := make(map[string]stockAnswer, len(skus))
+	missing := make([]string, 0, len(skus))
+	for key := range skus {
+		if cached, ok := v.cached(key); ok {
+			answers[key] = stockAnswer{stock: cached.stock, found: cached.found}
+			continue
+		}
+		missing = append(missing, key)
+	}
+	if len(missing) == 0 {
+		return answers
+	}
+
+	budget, cancel := context.WithTimeout(ctx, v.timeout)
+	defer cancel()
+
+	type lookupResult struct {
+		sku    string
+		answer stockAnswer
+	}
+	// Buffered, so a lookup finishing after the budget does not block forever.
+	results := make(chan lookupResult, len(missing))
+	for _, key := range missing {
+		go func(key string) {
+			select {
+			case v.lookups <- struct{}{}:
+				defer func() { <-v.lookups }()
+			case <-budget.Done():
+				results <- lookupResult{sku: key, answer: stockAnswer{err: budget.Err()}}
+				return
+			}
+			stock, found, err := v.lookup.FetchStock(budget, skus[key])
+			if err == nil {
+				v.store(key, stock, found)
+			}
+			results <- lookupResult{sku: key, answer: stockAnswer{stock: stock, found: found, err: err}}
+		}(key)
+	}
+
+	for answered := 0; answered < len(missing); answered++ {
+		select {
+		case result := <-results:
+			if result.answer.err != nil {
+				v.stock.log(fmt.Sprintf("stock validation ERP lookup failed sku=%s: %v", skus[result.sku], result.answer.err))
+			}
+			answers[result.sku] = result.answer
+		case <-budget.Done():
+			if errors.Is(budget.Err(), context.DeadlineExceeded) {
+				v.stock.log(fmt.Sprintf("stock validation ERP budget of %s spent, unanswered=%d", v.timeout, len(missing)-answered))
+			}
+			return answers
+		}
+	}
+	return answers
+}
+
+func (v *CartStockValidator) cached(sku string) (cachedStock, bool) {
+	if v.cacheTTL <= 0 {
+		return cachedStock{}, false
+	}
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	entry, ok := v.cache[sku]
+	if !ok || v.now().Sub(entry.at) >= v.cacheTTL {
+		return cachedStock{}, false
+	}
+	return entry, true
+}
+
+func (v *CartStockValidator) store(sku string, stock model.Stock, found bool) {
+	if v.cacheTTL <= 0 {
+		return
+	}
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	now := v.now()
+	// Expired entries are dropped once the cache is full, so a long-lived process
+	// does not keep every SKU it was ever asked about. Still full means a burst of
+	// distinct SKUs within the TTL; the answer is then not cached.
+	if _, ok := v.cache[sku]; !ok && len(v.cache) >= v.maxCacheEntries {
+		for key, entry := range v.cache {
+			if now.Sub(entry.at) >= v.cacheTTL {
+				delete(v.cache, key)
+			}
+		}
+		if len(v.cache) >= v.maxCacheEntries {
+			return
+		}
+	}
+	v.cache[sku] = cachedStock{stock: stock, found: found, at: now}
+}
+
+func (v *CartStockValidator) tracked(sku string) bool {
+	for _, prefix := range v.untrackedPrefixes {
+		prefix = strings.ToUpper(strings.TrimSpace(prefix))
+		if prefix != "" && strings.HasPrefix(sku, prefix) {
+			return false
+		}
+	}
+	return true
+}
+
+// resolveLocation loads REPORT_TIMEZONE. An unknown name falls back to UTC with a
+// warning, as the report does.
+func (c *ClientStock) resolveLocation(name string) *time.Location {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return time.UTC
+	}
+	location, err := time.LoadLocation(name)
+	if err != nil {
+		c.logWarning(fmt.Sprintf("unknown REPORT_TIMEZONE=%q, judging pre-order dates in UTC", name))
+		return time.UTC
+	}
+	return location
+}
+
+// preorder applies the product sync's pre-order decision to the ERP row, on today's
+// date in REPORT_TIMEZONE.
+func (v *CartStockValidator) preorder(stock model.Stock) bool {
+	product := model.Product{Sku: stock.Sku, ExpectedReturnDate: stock.ExpectedReturnDate, OnOrder: stock.OnOrder}
+	return shopify.PreorderActive(product, v.now().In(v.location), v.preorderRules)
+}
+
+func sumQuantities(quantities []int) int {
+	total := 0
+	for _, quantity := range quantities {
+		total += quantity
+	}
+	return total
+}
@@ -0,0 +1,236 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"shopify-exporter/internal/adapters/shopify"
+	"shopify-exporter/internal/config"
+	"shopify-exporter/internal/domain/model"
+	"sync"
+	"testing"
+	"time"
+)
+
+type fakeStockLookup struct {
+	mu     sync.Mutex
+	stocks map[string]model.Stock
+	// slow SKUs answer only once the request's budget is spent.
+	slow  map[string]bool
+	err   error
+	reads map[string]int
+}
+
+func (f *fakeStockLookup) FetchStock(ctx context.Context, sku string) (model.Stock, bool, error) {
+	f.mu.Lock()
+	if f.reads == nil {
+		f.reads = make(map[string]int)
+	}
+	f.reads[sku]++
+	f.mu.Unlock()
+	if f.slow[sku] {
+		<-ctx.Done()
+		return model.Stock{}, false, ctx.Err()
+	}
+	if f.err != nil {
+		return model.Stock{}, false, f.err
+	}
+	stock, ok := f.stocks[sku]
+	return stock, ok, nil
+}
+
+func newTestCartValidator(lookup *fakeStockLookup, failOpen bool) *CartStockValidator {
+	stockConfig := config.StockConfig{Reserve: config.StockReserveConfig{Default: 2}}
+	validatorConfig := config.StockValidatorConfig{Timeout: 50 * time.Millisecond, CacheTTL: time.Minute, FailOpen: failOpen, MaxLookups: 4, MaxCacheEntries: 100}
+	return NewValidateCartStock(context.Background(), lookup, nil, []string{"ZZ-"}, nil, stockConfig, validatorConfig).(*CartStockValidator)
+}
+
+// With mapped locations the reserve comes off once, as the sync takes it, not once
+// per location.
+func TestValidateCartStockTakesTheReserveOncePerSKU(t *testing.T) {
+	lookup := &fakeStockLookup{stocks: map[string]model.Stock{
+		"HVM-1": {Sku: "HVM-1", Stock: 7, Warehouses: map[int]int32{1: 3, 5: 4}},
+	}}
+	validator := newTestCartValidator(lookup, true)
+	validator.stock.stockConfig.Locations = []config.StockLocation{
+		{Name: "Main Warehouse", Warehouses: []int{1}},
+		{Name: "Tel Aviv Store", Warehouses: []int{5}},
+	}
+
+	got := validator.Validate(context.Background(), []CartLine{{SKU: "HVM-1", Quantity: 5}})
+	if !got.Allowed || got.Lines[0].Available != 5 {
+		t.Fatalf("lines = %+v, want 7 less a reserve of 2 = 5 available", got.Lines)
+	}
+}
+
+func TestValidateCartStockAppliesTheReserveToSummedLines(t *testing.T) {
+	lookup := &fakeStockLookup{stocks: map[string]model.Stock{
+		"HVM-1": {Sku: "HVM-1", Stock: 5},
+		"CMG-2": {Sku: "CMG-2", Stock: 10},
+	}}
+	validator := newTestCartValidator(lookup, true)
+
+	got := validator.Validate(context.Background(), []CartLine{
+		{SKU: "HVM-1", Quantity: 2},
+		{SKU: "CMG-2", Quantity: 8},
+		{SKU: "hvm-1", Quantity: 2},
+		{SKU: "ZZ-SPECIAL", Quantity: 1},
+		{SKU: "", Quantity: 1},
+	})
+	if got.Allowed {
+		t.Fatal("4 units of HVM-1 against 5 less a reserve of 2 must be refused")
+	}
+	want := []struct {
+		status    string
+		allowed   bool
+		available int
+	}{
+		{CartLineInsufficient, false, 3},
+		{CartLineOK, true, 8},
+		{CartLineInsufficient, false, 3},
+		{CartLineUntracked, true, 0},
+		{CartLineUntracked, true, 0},
+	}
+	for i, line := range got.Lines {
+		if line.Status != want[i].status || line.Allowed != want[i].allowed || line.Available != want[i].available {
+			t.Errorf("line %d (%s) = %+v, want %+v", i, line.SKU, line, want[i])
+		}
+	}
+	if lookup.reads["HVM-1"] != 1 || lookup.reads["hvm-1"] != 0 || len(lookup.reads) != 2 {
+		t.Errorf("ERP reads = %v, want HVM-1 and CMG-2 once each", lookup.reads)
+	}
+}
+
+func TestValidateCartStockBlocksSKUsTheERPDoesNotHave(t *testing.T) {
+	validator := newTestCartValidator(&fakeStockLookup{}, true)
+	got := validator.Validate(context.Background(), []CartLine{{SKU: "GONE-1", Quantity: 1}})
+	if got.Allowed || got.Lines[0].Status != CartLineUnknownSKU {
+		t.Errorf("unknown SKU -> %+v, want it refused even when failing open", got.Lines[0])
+	}
+}
+
+// A pre-order SKU is let through only while the product sync has it on CONTINUE:
+// return date ahead and, when required, units on order. Otherwise Shopify has it on
+// DENY and its stale quantity is checked like any other.
+func TestValidateCartStockAllowsOnlyActivePreorders(t *testing.T) {
+	now := time.Date(2026, 3, 10, 15, 0, 0, 0, time.UTC)
+	day := func(d int) time.Time { return time.Date(2026, 3, d, 0, 0, 0, 0, time.UTC) }
+	cases := []struct {
+		name          string
+		stock         model.Stock
+		needsIncoming bool
+		want          string
+	}{
+		{"date ahead", model.Stock{Sku: "PRE-1", ExpectedReturnDate: day(20)}, false, CartLinePreorder},
+		{"date today", model.Stock{Sku: "PRE-1", ExpectedReturnDate: day(10)}, false, CartLinePreorder},
+		{"date passed", model.Stock{Sku: "PRE-1", ExpectedReturnDate: day(9)}, false, CartLineInsufficient},
+		{"no date", model.Stock{Sku: "PRE-1"}, false, CartLineInsufficient},
+		{"nothing on order", model.Stock{Sku: "PRE-1", ExpectedReturnDate: day(20)}, true, CartLineInsufficient},
+		{"units on order", model.Stock{Sku: "PRE-1", ExpectedReturnDate: day(20), OnOrder: 4}, true, CartLinePreorder},
+	}
+	for _, tc := range cases {
+		lookup := &fakeStockLookup{stocks: map[string]model.Stock{"PRE-1": tc.stock}}
+		validator := newTestCartValidator(lookup, false)
+		validator.preorderRules = shopify.PreorderRules{Prefixes: []string{"pre-"}, NeedsIncoming: tc.needsIncoming}
+		validator.now = func() time.Time { return now }
+		got := validator.Validate(context.Background(), []CartLine{{SKU: "PRE-1", Quantity: 5}})
+		if got.Lines[0].Status != tc.want || got.Allowed != (tc.want == CartLinePreorder) {
+			t.Errorf("%s: line = %+v, want %s", tc.name, got.Lines[0], tc.want)
+		}
+	}
+}
+
+// An ERP that does not answer in time follows the fail-open setting; an ERP answer is
+// never overruled by it.
+func TestValidateCartStockFailOpenOnlyCoversUnansweredLines(t *testing.T) {
+	for _, failOpen := range []bool{true, false} {
+		lookup := &fakeStockLookup{
+			stocks: map[string]model.Stock{"HVM-1": {Sku: "HVM-1", Stock: 1}},
+			slow:   map[string]bool{"SLOW-1": true},
+		}
+		got := newTestCartValidator(lookup, failOpen).Validate(context.Background(), []CartLine{
+			{SKU: "SLOW-1", Quantity: 1},
+			{SKU: "HVM-1", Quantity: 1},
+		})
+		slow, known := got.Lines[0], got.Lines[1]
+		if slow.Status != CartLineUnverified || slow.Allowed != failOpen || slow.Verified {
+			t.Errorf("fail_open=%t: slow line = %+v, want unverified and allowed=%t", failOpen, slow, failOpen)
+		}
+		if known.Status != CartLineInsufficient || known.Allowed {
+			t.Errorf("fail_open=%t: answered line = %+v, want insufficient", failOpen, known)
+		}
+	}
+
+	lookup := &fakeStockLookup{err: errors.New("apix down")}
+	got := newTestCartValidator(lookup, false).Validate(context.Background(), []CartLine{{SKU: "HVM-1", Quantity: 1}})
+	if got.Allowed || got.Lines[0].Status != CartLineUnverified {
+		t.Errorf("ERP error failing closed -> %+v, want refused as unverified", got.Lines[0])
+	}
+}
+
+func TestValidateCartStockCachesERPAnswers(t *testing.T) {
+	lookup := &fakeStockLookup{stocks: map[string]model.Stock{"HVM-1": {Sku: "HVM-1", Stock: 9}}}
+	validator := newTestCartValidator(lookup, true)
+	now := time.Date(2026, 8, 4, 12, 0, 0, 0, time.UTC)
+	validator.now = func() time.Time { return now }
+
+	cart := []CartLine{{SKU: "HVM-1", Quantity: 1}, {SKU: "GONE-1", Quantity: 1}}
+	validator.Validate(context.Background(), cart)
+	now = now.Add(30 * time.Second)
+	validator.Validate(context.Background(), cart)
+	if lookup.reads["HVM-1"] != 1 || lookup.reads["GONE-1"] != 1 {
+		t.Errorf("reads within the TTL = %v, want one each", lookup.reads)
+	}
+
+	now = now.Add(time.Minute)
+	validator.Validate(context.Background(), cart)
+	if lookup.reads["HVM-1"] != 2 {
+		t.Errorf("reads after the TTL = %v, want a fresh read", lookup.reads)
+	}
+}
+
+// A full cache makes room from expired answers only; a fresh answer that does not fit
+// is simply read again next time.
+func TestValidateCartStockBoundsTheCache(t *testing.T) {
+	lookup := &fakeStockLookup{stocks: map[string]model.Stock{"HVM-1": {Sku: "HVM-1", Stock: 9}}}
+	validator := newTestCartValidator(lookup, true)
+	validator.maxCacheEntries = 1
+	now := time.Date(2026, 8, 4, 12, 0, 0, 0, time.UTC)
+	validator.now = func() time.Time { return now }
+
+	validator.Validate(context.Background(), []CartLine{{SKU: "HVM-1", Quantity: 1}})
+	validator.Validate(context.Background(), []CartLine{{SKU: "GONE-1", Quantity: 1}})
+	validator.Validate(context.Background(), []CartLine{{SKU: "GONE-1", Quantity: 1}})
+	if len(validator.cache) != 1 || lookup.reads["GONE-1"] != 2 {
+		t.Errorf("cache = %d entries, GONE-1 reads = %d; want 1 entry and an uncached SKU read each time", len(validator.cache), lookup.reads["GONE-1"])
+	}
+
+	now = now.Add(2 * time.Minute)
+	validator.Validate(context.Background(), []CartLine{{SKU: "GONE-1", Quantity: 1}})
+	if _, ok := validator.cache["GONE-1"]; !ok || len(validator.cache) != 1 {
+		t.Errorf("cache after the TTL = %v, want the expired entry replaced", validator.cache)
+	}
+}
+
+// A kit is checked against what its components make, like the sync pushes it; the
+// ERP having no row for the kit itself is not an unknown SKU.
+func TestValidateCartStockMakesKitsFromComponents(t *testing.T) {
+	lookup := &fakeStockLookup{stocks: map[string]model.Stock{
+		"CMG-28": {Sku: "CMG-28", Stock: 11},
+		"HVM-1":  {Sku: "HVM-1", Stock: 20},
+	}}
+	validator := newTestCartValidator(lookup, false)
+	validator.stock.stockConfig.Kits = []config.StockKit{
+		{SKU: "GIFT-1", Components: []config.StockKitComponent{{SKU: "CMG-28", Quantity: 3}, {SKU: "HVM-1", Quantity: 1}}},
+	}
+
+	// CMG-28 sells 11 less its reserve of 2, so 9/3 = 3 kits, less the kit's reserve
+	// of 2.
+	got := validator.Validate(context.Background(), []CartLine{{SKU: "GIFT-1", Quantity: 1}})
+	if !got.Allowed || got.Lines[0].Available != 1 {
+		t.Errorf("one kit -> %+v, want allowed with 1 available", got.Lines[0])
+	}
+	got = validator.Validate(context.Background(), []CartLine{{SKU: "GIFT-1", Quantity: 2}})
+	if got.Allowed || got.Lines[0].Status != CartLineInsufficient {
+		t.Errorf("two kits -> %+v, want insufficient", got.Lines[0])
+	}
+}
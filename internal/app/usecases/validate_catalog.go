@@ -0,0 +1,320 @@
+package usecases
+
+import (
+	"context"
+	"fmt"
+	"shopify-exporter/internal/adapters/apix"
+	"shopify-exporter/internal/config"
+	"shopify-exporter/internal/debugsync"
+	"shopify-exporter/internal/domain/model"
+	"shopify-exporter/internal/logging"
+	"shopify-exporter/internal/report"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+type ValidateCatalogService interface {
+	Run(ctx context.Context) error
+}
+
+// CatalogValidator checks the ERP catalogue before the product step and records what
+// the ERP team should fix at source. It is read-only: nothing is skipped or changed
+// in Shopify because of what it finds.
+type CatalogValidator struct {
+	apixProducts   apix.NewClientService
+	apixPrices     apix.PriceService
+	apixCategories apix.CategoryService
+	logger         logging.LoggerService
+	recorder       report.Recorder
+	pricing        catalogPricing
+}
+
+// catalogPricing is what the price step needs of a SKU: a price in every market
+// currency, where Convert lets one priced currency stand in for the rest.
+type catalogPricing struct {
+	Currencies []string
+	Convert    bool
+}
+
+const catalogProductPageSize = 100
+
+func NewValidateCatalog(apixProducts apix.NewClientService, apixPrices apix.PriceService, apixCategories apix.CategoryService, logger logging.LoggerService, recorder report.Recorder, priceConfig config.PriceConfig) ValidateCatalogService {
+	return &CatalogValidator{
+		apixProducts:   apixProducts,
+		apixPrices:     apixPrices,
+		apixCategories: apixCategories,
+		logger:         logger,
+		recorder:       recorder,
+		pricing: catalogPricing{
+			Currencies: priceConfig.Currencies,
+			Convert:    priceConfig.Conversion.Enabled(),
+		},
+	}
+}
+
+// catalogIssue is one finding of validateCatalog; Kind is one of the report.Issue*
+// constants.
+type catalogIssue struct {
+	SKU    string
+	Kind   string
+	Detail string
+}
+
+func (c *CatalogValidator) Run(ctx context.Context) error {
+	if c.logger != nil {
+		c.logger.Log("Catalog validation started")
+	}
+
+	products, err := c.fetchProducts(ctx)
+	if err != nil {
+		if c.logger != nil {
+			c.logger.LogError("Error fetch api products for validation", err)
+		}
+		return err
+	}
+
+	var prices []model.Price
+	if c.apixPrices != nil {
+		prices, err = c.apixPrices.PriceList(ctx)
+		if err != nil {
+			if c.logger != nil {
+				c.logger.LogError("Error fetch api prices for validation", err)
+			}
+			return err
+		}
+	}
+
+	var categories []model.ProductCategories
+	if c.apixCategories != nil {
+		categories, err = c.apixCategories.CategoryList(ctx)
+		if err != nil {
+			if c.logger != nil {
+				c.logger.LogError("Error fetch api categories for validation", err)
+			}
+			return err
+		}
+	}
+
+	issues := validateCatalog(products, prices, categories, c.pricing)
+	byKind := make(map[string]int)
+	for _, issue := range issues {
+		byKind[issue.Kind]++
+		if c.recorder != nil {
+			c.recorder.DataIssue(issue.SKU, issue.Kind, issue.Detail)
+		}
+		if c.logger != nil && debugsync.MatchSKU(issue.SKU) {
+			c.logger.Log(fmt.Sprintf("trace catalog issue sku=%s kind=%s detail=%s", issue.SKU, issue.Kind, issue.Detail))
+		}
+	}
+
+	kinds := make([]string, 0, len(byKind))
+	for kind := range byKind {
+		kinds = append(kinds, kind)
+	}
+	sort.Strings(kinds)
+	parts := make([]string, 0, len(kinds))
+	for _, kind := range kinds {
+		if c.recorder != nil {
+			c.recorder.Incr("quality", kind, int64(byKind[kind]))
+		}
+		parts = append(parts, fmt.Sprintf("%s=%d", kind, byKind[kind]))
+	}
+
+	if c.logger != nil {
+		if len(issues) == 0 {
+			c.logger.LogSuccess(fmt.Sprintf("Catalog validation completed products=%d issues=0", len(products)))
+		} else {
+			c.logger.LogWarning(fmt.Sprintf("Catalog validation completed products=%d issues=%d %s", len(products), len(issues), strings.Join(parts, " ")))
+		}
+	}
+	return nil
+}
+
+func (c *CatalogValidator) fetchProducts(ctx context.Context) ([]model.Product, error) {
+	products := make([]model.Product, 0)
+	page := 1
+	totalPages := 1
+	for page <= totalPages {
+		pageProducts, pageTotal, err := c.apixProducts.ListProducts(ctx, page, catalogProductPageSize)
+		if err != nil {
+			return nil, err
+		}
+		if pageTotal > 0 {
+			totalPages = pageTotal
+		}
+		for _, product := range pageProducts {
+			if debugsync.ShouldProcessSKU(strings.TrimSpace(product.Sku)) {
+				products = append(products, product)
+			}
+		}
+		page++
+	}
+	return products, nil
+}
+
+// validateCatalog runs every check over the ERP catalogue. Identity checks (barcodes,
+// duplicate SKUs) cover every item, because the product step pushes inactive items
+// too, as drafts. Content checks (English title, price, category) only cover active
+// items: an inactive item missing a price is not something anyone needs to fix.
+// Issues come back sorted by kind then SKU.
+func validateCatalog(products []model.Product, prices []model.Price, categories []model.ProductCategories, pricing catalogPricing) []catalogIssue {
+	issues := make([]catalogIssue, 0)
+
+	priced := make(map[string]map[string]bool)
+	for _, price := range prices {
+		if price.Price <= 0 || price.IsQuantityBreak() {
+			continue
+		}
+		sku := strings.TrimSpace(price.Sku)
+		if priced[sku] == nil {
+			priced[sku] = make(map[string]bool)
+		}
+		priced[sku][strings.ToUpper(strings.TrimSpace(price.Currency))] = true
+	}
+
+	categorized := make(map[string]bool)
+	for _, entry := range categories {
+		if len(entry.Categproes) > 0 {
+			categorized[strings.TrimSpace(entry.SKU)] = true
+		}
+	}
+
+	skusByKey := make(map[string][]string)
+	skusByBarcode := make(map[string][]string)
+	for _, product := range products {
+		sku := strings.TrimSpace(product.Sku)
+		if key := normalizeSKUKey(product.Sku); key != "" {
+			skusByKey[key] = append(skusByKey[key], product.Sku)
+		}
+		if sku == "" {
+			continue
+		}
+
+		if barcode := strings.TrimSpace(product.Barcode); barcode != "" {
+			skusByBarcode[barcode] = append(skusByBarcode[barcode], sku)
+			if problem := barcodeProblem(barcode); problem != "" {
+				issues = append(issues, catalogIssue{SKU: sku, Kind: report.IssueInvalidBarcode, Detail: fmt.Sprintf("%s: %s", barcode, problem)})
+			}
+		}
+
+		if !product.IsPublished {
+			continue
+		}
+		if strings.TrimSpace(product.EnglishTitle) == "" {
+			issues = append(issues, catalogIssue{SKU: sku, Kind: report.IssueMissingEnglishTitle, Detail: strings.TrimSpace(product.HebrewTitle)})
+		}
+		if missing := pricing.missing(priced[sku]); len(missing) > 0 {
+			issues = append(issues, catalogIssue{SKU: sku, Kind: report.IssueMissingPrice, Detail: strings.Join(missing, ", ")})
+		}
+		if !categorized[sku] {
+			issues = append(issues, catalogIssue{SKU: sku, Kind: report.IssueMissingCategory})
+		}
+	}
+
+	for _, variants := range skusByKey {
+		if len(variants) < 2 {
+			continue
+		}
+		for i, sku := range variants {
+			issues = append(issues, catalogIssue{
+				SKU:    strings.TrimSpace(sku),
+				Kind:   report.IssueDuplicateSKU,
+				Detail: "also as " + quotedExcept(variants, i),
+			})
+		}
+	}
+
+	for barcode, skus := range skusByBarcode {
+		if len(skus) < 2 {
+			continue
+		}
+		for i, sku := range skus {
+			issues = append(issues, catalogIssue{
+				SKU:    sku,
+				Kind:   report.IssueDuplicateBarcode,
+				Detail: barcode + " also on " + quotedExcept(skus, i),
+			})
+		}
+	}
+
+	sort.SliceStable(issues, func(i, j int) bool {
+		if issues[i].Kind != issues[j].Kind {
+			return issues[i].Kind < issues[j].Kind
+		}
+		if issues[i].SKU != issues[j].SKU {
+			return issues[i].SKU < issues[j].SKU
+		}
+		return issues[i].Detail < issues[j].Detail
+	})
+	return issues
+}
+
+// missing returns the market currencies the price step would find no price for. With
+// conversion on, any one priced market currency derives the others; the rates
+// themselves are only known to the price step, which reports a pair it cannot convert.
+func (p catalogPricing) missing(have map[string]bool) []string {
+	missing := make([]string, 0, len(p.Currencies))
+	for _, currency := range p.Currencies {
+		if !have[currency] {
+			missing = append(missing, currency)
+		}
+	}
+	if p.Convert && len(missing) < len(p.Currencies) {
+		return nil
+	}
+	return missing
+}
+
+// normalizeSKUKey folds the case and whitespace variants Shopify's SKU search treats
+// as the same item ("ab-1", "AB-1 ", "AB -1") into one key.
+func normalizeSKUKey(sku string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsSpace(r) {
+			return -1
+		}
+		return unicode.ToUpper(r)
+	}, sku)
+}
+
+func quotedExcept(values []string, skip int) string {
+	parts := make([]string, 0, len(values)-1)
+	for i, value := range values {
+		if i != skip {
+			parts = append(parts, strconv.Quote(value))
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+// barcodeProblem returns why a barcode is not a valid EAN-13, UPC-A or EAN-8, or ""
+// when it is one. All three share the GS1 mod-10 check digit.
+func barcodeProblem(barcode string) string {
+	for _, r := range barcode {
+		if r < '0' || r > '9' {
+			return "not numeric"
+		}
+	}
+	switch len(barcode) {
+	case 8, 12, 13:
+	default:
+		return fmt.Sprintf("length %d is not EAN-13, UPC-A or EAN-8", len(barcode))
+	}
+	if want := gs1CheckDigit(barcode[:len(barcode)-1]); want != barcode[len(barcode)-1] {
+		return fmt.Sprintf("check digit should be %c", want)
+	}
+	return ""
+}
+
+// gs1CheckDigit computes the check digit for the payload digits: weights 3 and 1
+// alternate from the rightmost payload digit.
+func gs1CheckDigit(payload string) byte {
+	sum := 0
+	weight := 3
+	for i := len(payload) - 1; i >= 0; i-- {
+		sum += int(payload[i]-'0') * weight
+		weight = 4 - weight
+	}
+	return byte('0' + (10-sum%10)%10)
+}
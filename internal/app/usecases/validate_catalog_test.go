@@ -0,0 +1,105 @@
+package usecases
+
+import (
+	"shopify-exporter/internal/domain/model"
+	"shopify-exporter/internal/report"
+	"testing"
+)
+
+// TestBarcodeProblem checks the GS1 check digit for every supported length and that
+// anything else is reported rather than pushed as if it were a retail barcode.
+func TestBarcodeProblem(t *testing.T) {
+	tests := []struct {
+		barcode string
+		valid   bool
+	}{
+		{"4006381333931", true},  // EAN-13
+		{"4006381333932", false}, // EAN-13, wrong check digit
+		{"036000291452", true},   // UPC-A
+		{"036000291453", false},  // UPC-A, wrong check digit
+		{"96385074", true},       // EAN-8
+		{"96385075", false},      // EAN-8, wrong check digit
+		{"12345", false},         // unsupported length
+		{"40063813339A1", false}, // not numeric
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.barcode, func(t *testing.T) {
+			problem := barcodeProblem(tt.barcode)
+			if (problem == "") != tt.valid {
+				t.Fatalf("barcodeProblem(%q) = %q, want valid=%t", tt.barcode, problem, tt.valid)
+			}
+		})
+	}
+}
+
+// TestValidateCatalogFindsEachIssueKind runs a small catalogue with one of every
+// problem and checks each SKU is flagged for exactly what is wrong with it.
+func TestValidateCatalogFindsEachIssueKind(t *testing.T) {
+	products := []model.Product{
+		{Sku: "OK-1", EnglishTitle: "Candle", Barcode: "4006381333931", IsPublished: true},
+		{Sku: "ab-2", EnglishTitle: "Cup", IsPublished: true},
+		{Sku: "AB-2 ", EnglishTitle: "Cup", IsPublished: true},
+		{Sku: "BAD-3", EnglishTitle: "Plate", Barcode: "4006381333932", IsPublished: true},
+		{Sku: "DUP-4", EnglishTitle: "Bowl", Barcode: "96385074", IsPublished: true},
+		{Sku: "DUP-5", EnglishTitle: "Bowl", Barcode: "96385074", IsPublished: true},
+		{Sku: "HE-6", HebrewTitle: "נר", IsPublished: true},
+		{Sku: "OFF-7", IsPublished: false},
+	}
+	var prices []model.Price
+	var categories []model.ProductCategories
+	for _, sku := range []string{"OK-1", "ab-2", "AB-2", "BAD-3", "DUP-4", "DUP-5", "HE-6"} {
+		prices = append(prices, model.Price{Sku: sku, Currency: "ILS", Price: 10})
+		if sku != "HE-6" {
+			prices = append(prices, model.Price{Sku: sku, Currency: "USD", Price: 3})
+			categories = append(categories, model.ProductCategories{SKU: sku, Categproes: []model.Category{{TitleHebrew: "x"}}})
+		}
+	}
+	prices = append(prices, model.Price{Sku: "HE-6", Currency: "USD", Price: 0})
+
+	got := make(map[string][]string)
+	pricing := catalogPricing{Currencies: []string{"ILS", "USD"}}
+	for _, issue := range validateCatalog(products, prices, categories, pricing) {
+		got[issue.SKU] = append(got[issue.SKU], issue.Kind)
+	}
+
+	want := map[string][]string{
+		"ab-2":  {report.IssueDuplicateSKU},
+		"AB-2":  {report.IssueDuplicateSKU},
+		"BAD-3": {report.IssueInvalidBarcode},
+		"DUP-4": {report.IssueDuplicateBarcode},
+		"DUP-5": {report.IssueDuplicateBarcode},
+		// HE-6 has an ILS price but only a zero USD one, which counts as missing.
+		"HE-6": {report.IssueMissingCategory, report.IssueMissingEnglishTitle, report.IssueMissingPrice},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("issues for %d SKUs, want %d: %v", len(got), len(want), got)
+	}
+	for sku, kinds := range want {
+		if len(got[sku]) != len(kinds) {
+			t.Fatalf("sku %s kinds = %v, want %v", sku, got[sku], kinds)
+		}
+		for i := range kinds {
+			if got[sku][i] != kinds[i] {
+				t.Fatalf("sku %s kinds = %v, want %v", sku, got[sku], kinds)
+			}
+		}
+	}
+}
+
+// The currencies checked are the market ones, and with conversion on one priced market
+// currency covers the rest.
+func TestCatalogPricingMissing(t *testing.T) {
+	have := map[string]bool{"ILS": true, "GBP": true}
+	pricing := catalogPricing{Currencies: []string{"ILS", "USD", "EUR"}}
+	if got := pricing.missing(have); len(got) != 2 || got[0] != "USD" || got[1] != "EUR" {
+		t.Errorf("missing = %v, want [USD EUR]", got)
+	}
+	pricing.Convert = true
+	if got := pricing.missing(have); len(got) != 0 {
+		t.Errorf("missing with conversion = %v, want none", got)
+	}
+	if got := pricing.missing(map[string]bool{"GBP": true}); len(got) != 3 {
+		t.Errorf("missing with conversion and no market price = %v, want all three", got)
+	}
+}
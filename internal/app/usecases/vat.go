@@ -0,0 +1,64 @@
+package usecases
+
+import (
+	"fmt"
+	"math"
+	"shopify-exporter/internal/config"
+)
+
+// vatOutcome is what the VAT treatment did to one ERP price.
+type vatOutcome int
+
+const (
+	// vatAsIs: the price went out as the ERP has it.
+	vatAsIs vatOutcome = iota
+	// vatAdded: a net price was grossed up.
+	vatAdded
+	// vatExempt: a net price stayed net because the item is VAT exempt.
+	vatExempt
+)
+
+// vatTreatment grosses up the prices the ERP keeps net of VAT for the currencies
+// whose markets show prices with VAT.
+type vatTreatment struct {
+	cfg   config.PriceVATConfig
+	gross map[string]bool
+}
+
+func newVATTreatment(cfg config.PriceVATConfig, markets []config.MarketDefinition) vatTreatment {
+	return vatTreatment{cfg: cfg, gross: config.VATIncludedCurrencies(markets)}
+}
+
+// applies reports whether a price in currency taken from the ERP price list needs
+// VAT added. An exempt item still applies: it is counted, and keeps its net price.
+func (v vatTreatment) applies(currency string, priceList int) bool {
+	return v.cfg.Rate > 0 && v.gross[currency] && v.cfg.IsNet(priceList)
+}
+
+// add returns the gross price, to the cent.
+func (v vatTreatment) add(net float64) float64 {
+	return math.Round(net*(1+v.cfg.Rate/100)*100) / 100
+}
+
+// resolve returns the price to push for an ERP price in currency from priceList.
+func (v vatTreatment) resolve(erp float64, currency string, priceList int, exempt bool) (float64, vatOutcome) {
+	switch {
+	case !v.applies(currency, priceList):
+		return erp, vatAsIs
+	case exempt:
+		return erp, vatExempt
+	default:
+		return v.add(erp), vatAdded
+	}
+}
+
+// describe is the outcome as the report's price source shows it; empty for vatAsIs.
+func (v vatTreatment) describe(outcome vatOutcome) string {
+	switch outcome {
+	case vatAdded:
+		return fmt.Sprintf("net +%g%% VAT", v.cfg.Rate)
+	case vatExempt:
+		return "net, VAT exempt"
+	}
+	return ""
+}
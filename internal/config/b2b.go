@@ -0,0 +1,81 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// B2BConfig controls the B2B step, which gives wholesale customers their ERP price
+// lists in Shopify: each ERP customer with a price list becomes a Shopify company
+// with one location, and each price list becomes a B2B catalog assigned to the
+// locations of the customers on it.
+type B2BConfig struct {
+	// Enabled turns the step on. B2B needs a Shopify Plus store, so it is opt-in.
+	Enabled bool
+	// Currency is the currency every B2B price list is kept in, and the currency of
+	// the ERP prices it is filled from. Defaults to the shop's base currency.
+	Currency string
+	// PriceLists limits the step to these ERP price lists; empty means every list
+	// some customer is on.
+	PriceLists []int
+}
+
+// Includes reports whether the ERP price list gets a B2B catalog.
+func (c B2BConfig) Includes(priceList int) bool {
+	if priceList <= 0 {
+		return false
+	}
+	if len(c.PriceLists) == 0 {
+		return true
+	}
+	for _, list := range c.PriceLists {
+		if list == priceList {
+			return true
+		}
+	}
+	return false
+}
+
+func loadB2BConfig(baseCurrency string) (B2BConfig, error) {
+	currency := strings.ToUpper(strings.TrimSpace(stringWithDefault("SYNC_B2B_CURRENCY", baseCurrency)))
+	if currency == "" {
+		// The same fallback the Shopify client uses for an unset base currency.
+		currency = "USD"
+	}
+	if !isCurrencyCode(currency) {
+		return B2BConfig{}, fmt.Errorf("Invalid SYNC_B2B_CURRENCY %q: want a currency code", currency)
+	}
+	lists, err := parseB2BPriceLists(stringWithDefault("SYNC_B2B_PRICE_LISTS", ""))
+	if err != nil {
+		return B2BConfig{}, fmt.Errorf("Invalid SYNC_B2B_PRICE_LISTS: %w", err)
+	}
+	return B2BConfig{
+		Enabled:    boolWithDefault("SYNC_B2B", false),
+		Currency:   currency,
+		PriceLists: lists,
+	}, nil
+}
+
+// parseB2BPriceLists reads a comma separated list of ERP price list numbers.
+func parseB2BPriceLists(raw string) ([]int, error) {
+	lists := make([]int, 0)
+	seen := make(map[int]bool)
+	for _, part := range strings.Split(raw, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		list, err := strconv.Atoi(part)
+		if err != nil || list <= 0 {
+			return nil, fmt.Errorf("%q is not a price list number", part)
+		}
+		if !seen[list] {
+			seen[list] = true
+			lists = append(lists, list)
+		}
+	}
+	sort.Ints(lists)
+	return lists, nil
+}
@@ -0,0 +1,23 @@
+package config
+
+import "testing"
+
+func TestParseB2BPriceLists(t *testing.T) {
+	lists, err := parseB2BPriceLists(" 14, 12,14 ,")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(lists) != 2 || lists[0] != 12 || lists[1] != 14 {
+		t.Errorf("lists = %v, want [12 14]", lists)
+	}
+	cfg := B2BConfig{PriceLists: lists}
+	if !cfg.Includes(12) || cfg.Includes(10) || (B2BConfig{}).Includes(0) {
+		t.Error("Includes must honour the list and never include list 0")
+	}
+
+	for _, raw := range []string{"12,x", "0", "-3"} {
+		if _, err := parseB2BPriceLists(raw); err == nil {
+			t.Errorf("parseB2BPriceLists(%q) = nil error", raw)
+		}
+	}
+}
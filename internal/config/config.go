@@ -6,14 +6,25 @@ import "time"
 // ZZ-* are the Hashavshevet placeholder/service items for Emanuel.
 var DefaultUntrackedSkuPrefixes = []string{"ZZ-"}
 
+// ChannelPublicationsAll is the wildcard in SHOPIFY_CHANNEL_PUBLICATIONS. As a key it
+// matches any web item kind not listed on its own; as a value it means every
+// publication the store has.
+const ChannelPublicationsAll = "*"
+
 type DailyConfig struct {
 	Shopify     ShopifyConfig
 	ApiHasav    ApiHasvConfig
 	TelegramBot TelegramBotConfig
 	Report      ReportConfig
 	Stock       StockConfig
+	Prices      PriceConfig
+	B2B         B2BConfig
 }
 
+// DefaultStockHistoryKeep is the fallback for SYNC_STOCK_HISTORY_KEEP: a day of
+// snapshots at the five-minute delta cadence.
+const DefaultStockHistoryKeep = 288
+
 // Stock sync modes for SYNC_STOCK_MODE.
 const (
 	// StockModeFull pushes the whole ERP feed, skipping only SKUs Shopify already
@@ -26,6 +37,18 @@ const (
 	StockModeDelta = "delta"
 )
 
+// Incoming quantity delivery for SHOPIFY_INCOMING_QUANTITY.
+const (
+	// IncomingInventory adjusts the variant's Shopify "incoming" inventory quantity
+	// at the primary location, where the admin and the theme read it.
+	IncomingInventory = "inventory"
+	// IncomingMetafield writes the custom.incoming_quantity product metafield
+	// instead, for a store where incoming cannot be adjusted.
+	IncomingMetafield = "metafield"
+	// IncomingOff leaves incoming quantities out of Shopify.
+	IncomingOff = "off"
+)
+
 // StockConfig controls how much of the ERP feed a stock run pushes.
 type StockConfig struct {
 	// Mode is StockModeFull (default) or StockModeDelta. An unrecognised value falls
@@ -40,6 +63,38 @@ type StockConfig struct {
 	// before -> after list. The snapshot is deliberately not written either, so a dry
 	// run cannot make the next real delta believe those quantities were pushed.
 	DryRun bool
+	// Reserve is how many units per SKU are kept off the storefront. See
+	// SYNC_STOCK_RESERVE.
+	Reserve StockReserveConfig
+	// Locations maps ERP warehouses to Shopify locations. Empty pushes the ERP total
+	// to the primary location, as the sync always did. See SYNC_STOCK_LOCATIONS.
+	Locations []StockLocation
+	// Kits are SKUs sold as many as their components can make. See
+	// SYNC_STOCK_KITS_FILE.
+	Kits []StockKit
+	// ChangesFeed makes a delta run read only the ERP rows changed since CursorPath
+	// instead of the whole feed. See SYNC_STOCK_CHANGES_FEED.
+	ChangesFeed bool
+	// CursorPath is where the changes-feed cursor lives, next to StatePath.
+	CursorPath string
+	// DriftAudit adds the read-only auditStockDrift step, which compares Shopify's
+	// quantities with the ERP targets. See SYNC_STOCK_DRIFT_AUDIT.
+	DriftAudit bool
+	// DriftPath is where the audit totals are kept between runs, next to StatePath.
+	DriftPath string
+	// HistoryDir keeps a copy of every snapshot written, next to StatePath, for
+	// cmd/stock-history to diff and roll back. HistoryKeep is how many are kept; 0
+	// turns the history off. See SYNC_STOCK_HISTORY_KEEP.
+	HistoryDir  string
+	HistoryKeep int
+	// Alerts are the low-stock, out-of-stock and back-in-stock alerts raised by the
+	// stock step. See SYNC_STOCK_ALERTS.
+	Alerts StockAlertConfig
+}
+
+// MultiLocation reports whether stock is pushed per Shopify location.
+func (c StockConfig) MultiLocation() bool {
+	return len(c.Locations) > 0
 }
 
 // IsDelta reports whether this run should push only ERP changes.
@@ -90,6 +145,16 @@ func (c ReportConfig) Configured() bool {
 	return c.SMTP.Host != "" && c.SMTP.From != "" && len(c.Recipients) > 0
 }
 
+// ValidatorConfig is what cmd/stock-validator needs: the ERP, the stock rules it
+// shares with the sync, and its own settings. Nothing Shopify-side is read, so the
+// service does not need the sync's Shopify token.
+type ValidatorConfig struct {
+	ApiHasav       ApiHasvConfig
+	TelegramBot    TelegramBotConfig
+	Stock          StockConfig
+	StockValidator StockValidatorConfig
+}
+
 type OrdersConfig struct {
 	Mysql       MysqlConfig
 	TelegramBot TelegramBotConfig
@@ -114,12 +179,40 @@ type ShopifyConfig struct {
 	// SetOnHandQuantities able to write during a dry run. Both fields are filled from
 	// one read of SYNC_STOCK_DRY_RUN.
 	StockDryRun bool
-	// Optional pricing settings used by price sync.
-	BaseCurrency               string
-	InternationalMarketHandle  string
-	InternationalMarketName    string
-	InternationalCatalogTitle  string
-	InternationalPriceListName string
+	// PriceDryRun mirrors PriceConfig.DryRun, for the same reason: the adapter is what
+	// must refuse to send the price mutations.
+	PriceDryRun bool
+	// PreorderSkus and PreorderSkuPrefixes opt SKUs into pre-order mode: while the ERP
+	// carries an expected return date that has not passed yet, the product sync sets
+	// inventoryPolicy=CONTINUE so the item stays buyable at 0, and puts it back to
+	// DENY once the date passes or disappears. Matched case-insensitively. Both empty
+	// (the default) means no SKU is ever sold beyond its stock.
+	PreorderSkus        []string
+	PreorderSkuPrefixes []string
+	// PreorderNeedsIncoming also requires something on order from suppliers (the
+	// ERP's orden) before a pre-order SKU is sold at 0, so a return date nobody
+	// ordered stock for does not take orders. See SHOPIFY_PREORDER_REQUIRE_INCOMING.
+	PreorderNeedsIncoming bool
+	// Timezone is REPORT_TIMEZONE. The ERP's return dates are calendar days there, so
+	// "today" for a pre-order date is judged in it rather than the server's zone.
+	Timezone string
+	// IncomingMode is how the product sync delivers the quantities on order from
+	// suppliers: IncomingInventory, IncomingMetafield or IncomingOff (the default).
+	// See SHOPIFY_INCOMING_QUANTITY.
+	IncomingMode string
+	// ChannelPublications maps an ERP WebItem kind ("1", "2", ... or "*" for any other
+	// non-zero kind) to the names of the Shopify publications (sales channels and B2B
+	// catalogs) the item is published to. Items that are inactive in the ERP, or whose
+	// kind maps to nothing, are unpublished everywhere. Empty (the default) publishes
+	// an active web item (WebItem other than 0) to every publication and never
+	// unpublishes anything. "Every publication", here and as the name "*", leaves out
+	// B2B catalogs: they are published to only when named.
+	ChannelPublications map[string][]string
+	// Optional pricing settings used by price sync. BaseCurrency is the shop's
+	// currency: the market priced in it also sets the variant price. Markets are the
+	// markets the price step prices, DefaultMarkets when SHOPIFY_MARKETS_FILE is unset.
+	BaseCurrency string
+	Markets      []MarketDefinition
 }
 
 type MysqlConfig struct {
@@ -0,0 +1,107 @@
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Exchange rate sources for SYNC_PRICE_CONVERSION.
+const (
+	ConversionSourceNone = "none"
+	ConversionSourceFile = "file"
+	ConversionSourceERP  = "erp"
+)
+
+// ConversionRounding is how a converted price in one currency is rounded: to the
+// cent, to a whole unit, or to the nearest price with a fixed ending such as .90.
+type ConversionRounding struct {
+	// Whole rounds to the nearest whole unit.
+	Whole bool
+	// Ending, when above 0, rounds to the nearest whole unit plus Ending (0.90 gives
+	// 49.90). Whole and Ending both unset keeps cents.
+	Ending float64
+}
+
+// PriceConversionConfig controls the fallback that derives a SKU's missing currency
+// prices from the ones the ERP has.
+type PriceConversionConfig struct {
+	// Source is one of the ConversionSource* values; none turns the fallback off and
+	// a SKU missing a currency is skipped, as it always was.
+	Source string
+	// RatesFile is the JSON rate table for the file source.
+	RatesFile string
+	// Rounding is keyed by target currency; a currency without an entry keeps cents.
+	Rounding map[string]ConversionRounding
+}
+
+// Enabled reports whether missing prices should be derived.
+func (c PriceConversionConfig) Enabled() bool {
+	return c.Source != "" && c.Source != ConversionSourceNone
+}
+
+// loadPriceConversionConfig reads SYNC_PRICE_CONVERSION and what its source needs.
+func loadPriceConversionConfig() (PriceConversionConfig, error) {
+	cfg := PriceConversionConfig{
+		Source: strings.ToLower(strings.TrimSpace(stringWithDefault("SYNC_PRICE_CONVERSION", ConversionSourceNone))),
+	}
+	switch cfg.Source {
+	case ConversionSourceNone, ConversionSourceERP:
+	case ConversionSourceFile:
+		path, err := requriedString("SYNC_PRICE_RATES_FILE")
+		if err != nil {
+			return PriceConversionConfig{}, err
+		}
+		cfg.RatesFile = strings.TrimSpace(path)
+	default:
+		return PriceConversionConfig{}, fmt.Errorf("Invalid SYNC_PRICE_CONVERSION %q: want %s, %s or %s", cfg.Source, ConversionSourceNone, ConversionSourceFile, ConversionSourceERP)
+	}
+	rounding, err := parseConversionRounding(stringWithDefault("SYNC_PRICE_CONVERSION_ROUNDING", ""))
+	if err != nil {
+		return PriceConversionConfig{}, fmt.Errorf("Invalid SYNC_PRICE_CONVERSION_ROUNDING: %w", err)
+	}
+	cfg.Rounding = rounding
+	return cfg, nil
+}
+
+// parseConversionRounding reads "CUR=rounding" entries separated by ";", where the
+// rounding is cents, whole, charm (.90) or an ending written as ".NN".
+func parseConversionRounding(raw string) (map[string]ConversionRounding, error) {
+	rounding := make(map[string]ConversionRounding)
+	for _, entry := range strings.FieldsFunc(raw, func(r rune) bool { return r == ';' || r == '\n' || r == '\r' }) {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		currency, mode, found := strings.Cut(entry, "=")
+		if !found {
+			return nil, fmt.Errorf("entry %q: want CUR=rounding", entry)
+		}
+		currency = strings.ToUpper(strings.TrimSpace(currency))
+		if !isCurrencyCode(currency) {
+			return nil, fmt.Errorf("entry %q: %q is not a currency code", entry, currency)
+		}
+		if _, dup := rounding[currency]; dup {
+			return nil, fmt.Errorf("entry %q: %s is set twice", entry, currency)
+		}
+
+		mode = strings.ToLower(strings.TrimSpace(mode))
+		switch {
+		case mode == DiscountRoundCents:
+			rounding[currency] = ConversionRounding{}
+		case mode == DiscountRoundWhole:
+			rounding[currency] = ConversionRounding{Whole: true}
+		case mode == DiscountRoundCharm:
+			rounding[currency] = ConversionRounding{Ending: 0.90}
+		case strings.HasPrefix(mode, ".") && len(mode) == 3:
+			cents, err := strconv.Atoi(mode[1:])
+			if err != nil || cents <= 0 {
+				return nil, fmt.Errorf("entry %q: ending %q must be .01 to .99", entry, mode)
+			}
+			rounding[currency] = ConversionRounding{Ending: float64(cents) / 100}
+		default:
+			return nil, fmt.Errorf("entry %q: want %s, %s, %s or an ending like .90", entry, DiscountRoundCents, DiscountRoundWhole, DiscountRoundCharm)
+		}
+	}
+	return rounding, nil
+}
@@ -0,0 +1,39 @@
+package config
+
+import "testing"
+
+// TestParseConversionRounding checks each rounding form and that anything that could
+// round to an unintended price is refused at startup.
+func TestParseConversionRounding(t *testing.T) {
+	rounding, err := parseConversionRounding("usd=.90; EUR=whole; GBP=.99; ILS=cents; CHF=charm")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]ConversionRounding{
+		"USD": {Ending: 0.90},
+		"EUR": {Whole: true},
+		"GBP": {Ending: 0.99},
+		"ILS": {},
+		"CHF": {Ending: 0.90},
+	}
+	for currency, expected := range want {
+		if got, ok := rounding[currency]; !ok || got != expected {
+			t.Errorf("%s = %+v (set %t), want %+v", currency, got, ok, expected)
+		}
+	}
+
+	invalid := map[string]string{
+		"no rounding":   "USD",
+		"bad currency":  "DOLLAR=.90",
+		"twice":         "USD=.90;USD=whole",
+		"zero ending":   "USD=.00",
+		"long ending":   "USD=.900",
+		"unknown mode":  "USD=up",
+		"number ending": "USD=.9x",
+	}
+	for name, raw := range invalid {
+		if _, err := parseConversionRounding(raw); err == nil {
+			t.Errorf("%s: parseConversionRounding(%q) = nil error", name, raw)
+		}
+	}
+}
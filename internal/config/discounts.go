@@ -0,0 +1,166 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// DiscountERPPercent as a discount table percentage means "take the item's ERP
+// DiscountPrc". Written as "erp" in SYNC_DISCOUNTS.
+const DiscountERPPercent = -1
+
+// DefaultDiscounts is the fallback for SYNC_DISCOUNTS and reproduces the original
+// hard-coded table: ERP discount code 5 is 50% off.
+const DefaultDiscounts = "5=50"
+
+// DefaultSaleCollection is the fallback for SYNC_SALE_COLLECTION: the static "sale"
+// collection the category step creates.
+const DefaultSaleCollection = "sale"
+
+// Discount rounding modes for SYNC_DISCOUNT_ROUNDING.
+const (
+	// DiscountRoundCents keeps the discounted price to the agora/cent (the default).
+	DiscountRoundCents = "cents"
+	// DiscountRoundWhole rounds the discounted price to the nearest whole unit.
+	DiscountRoundWhole = "whole"
+	// DiscountRoundCharm rounds to the nearest whole unit minus 0.10 (49.90).
+	DiscountRoundCharm = "charm"
+)
+
+// DiscountConfig controls how ERP discount codes become sale prices.
+type DiscountConfig struct {
+	// Rules are keyed by ERP discount code. An item whose code has no rule sells at
+	// full price.
+	Rules map[string]DiscountRule
+	// Rounding is one of the DiscountRound* modes.
+	Rounding string
+	// SaleCollection is the collection discounted products are kept in; empty turns
+	// the membership sync off.
+	SaleCollection string
+}
+
+// DiscountRule is one line of the discount table.
+type DiscountRule struct {
+	Code string
+	// Percent is the discount off the full price, or DiscountERPPercent.
+	Percent float64
+	// Markets are the market handles the discount applies to; empty means all.
+	// Currencies are their currencies, resolved at load: prices are per currency, so
+	// a rule can only name markets that do not share a currency with one it leaves out.
+	Markets    []string
+	Currencies []string
+}
+
+// AppliesTo reports whether the rule discounts prices in the currency.
+func (r DiscountRule) AppliesTo(currency string) bool {
+	if len(r.Currencies) == 0 {
+		return true
+	}
+	for _, candidate := range r.Currencies {
+		if strings.EqualFold(candidate, currency) {
+			return true
+		}
+	}
+	return false
+}
+
+func loadDiscountConfig(markets []MarketDefinition) (DiscountConfig, error) {
+	rules, err := parseDiscountRules(stringWithDefault("SYNC_DISCOUNTS", DefaultDiscounts), markets)
+	if err != nil {
+		return DiscountConfig{}, fmt.Errorf("Invalid SYNC_DISCOUNTS: %w", err)
+	}
+	rounding := strings.ToLower(strings.TrimSpace(stringWithDefault("SYNC_DISCOUNT_ROUNDING", DiscountRoundCents)))
+	switch rounding {
+	case DiscountRoundCents, DiscountRoundWhole, DiscountRoundCharm:
+	default:
+		return DiscountConfig{}, fmt.Errorf("Invalid SYNC_DISCOUNT_ROUNDING %q: want %s, %s or %s", rounding, DiscountRoundCents, DiscountRoundWhole, DiscountRoundCharm)
+	}
+	// Set-but-empty turns the collection sync off, so this cannot use stringWithDefault.
+	saleCollection, set := os.LookupEnv("SYNC_SALE_COLLECTION")
+	if !set {
+		saleCollection = DefaultSaleCollection
+	}
+	return DiscountConfig{
+		Rules:          rules,
+		Rounding:       rounding,
+		SaleCollection: strings.TrimSpace(saleCollection),
+	}, nil
+}
+
+// parseDiscountRules reads "code=percent[@market,market]" entries separated by ";" or
+// newlines; "erp" in place of the percentage uses the item's ERP DiscountPrc.
+func parseDiscountRules(raw string, markets []MarketDefinition) (map[string]DiscountRule, error) {
+	currencyByHandle := make(map[string]string, len(markets))
+	for _, market := range markets {
+		currencyByHandle[strings.ToLower(market.Handle)] = market.Currency
+	}
+
+	rules := make(map[string]DiscountRule)
+	for _, entry := range strings.FieldsFunc(raw, func(r rune) bool { return r == ';' || r == '\n' || r == '\r' }) {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		code, value, found := strings.Cut(entry, "=")
+		code = strings.TrimSpace(code)
+		if !found || code == "" {
+			return nil, fmt.Errorf("entry %q: want code=percent", entry)
+		}
+		if _, dup := rules[code]; dup {
+			return nil, fmt.Errorf("entry %q: code %s listed twice", entry, code)
+		}
+
+		percentText, marketList, scoped := strings.Cut(value, "@")
+		rule := DiscountRule{Code: code}
+		percentText = strings.TrimSpace(percentText)
+		if strings.EqualFold(percentText, "erp") {
+			rule.Percent = DiscountERPPercent
+		} else {
+			percent, err := strconv.ParseFloat(percentText, 64)
+			if err != nil || percent <= 0 || percent >= 100 {
+				return nil, fmt.Errorf("entry %q: %q is not a percentage between 0 and 100 or erp", entry, percentText)
+			}
+			rule.Percent = percent
+		}
+
+		if scoped {
+			listed := make(map[string]bool)
+			for _, handle := range strings.Split(marketList, ",") {
+				handle = strings.TrimSpace(handle)
+				if handle == "" {
+					continue
+				}
+				currency, ok := currencyByHandle[strings.ToLower(handle)]
+				if !ok {
+					return nil, fmt.Errorf("entry %q: unknown market %q", entry, handle)
+				}
+				listed[strings.ToLower(handle)] = true
+				rule.Markets = append(rule.Markets, handle)
+				if !containsFold(rule.Currencies, currency) {
+					rule.Currencies = append(rule.Currencies, currency)
+				}
+			}
+			if len(rule.Markets) == 0 {
+				return nil, fmt.Errorf("entry %q: empty market list", entry)
+			}
+			for _, market := range markets {
+				if !listed[strings.ToLower(market.Handle)] && containsFold(rule.Currencies, market.Currency) {
+					return nil, fmt.Errorf("entry %q: market %s shares %s with a listed market", entry, market.Handle, market.Currency)
+				}
+			}
+		}
+		rules[code] = rule
+	}
+	return rules, nil
+}
+
+func containsFold(values []string, value string) bool {
+	for _, candidate := range values {
+		if strings.EqualFold(candidate, value) {
+			return true
+		}
+	}
+	return false
+}
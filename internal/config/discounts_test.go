@@ -0,0 +1,41 @@
+package config
+
+import "testing"
+
+// TestParseDiscountRules checks the default keeps code 5 at 50%, that a rule can be
+// scoped to markets, and that a scope splitting one currency is refused.
+func TestParseDiscountRules(t *testing.T) {
+	rules, err := parseDiscountRules(DefaultDiscounts, DefaultMarkets)
+	if err != nil {
+		t.Fatalf("default: %v", err)
+	}
+	if rule := rules["5"]; rule.Percent != 50 || len(rule.Currencies) != 0 {
+		t.Fatalf("default rule = %+v", rule)
+	}
+
+	rules, err = parseDiscountRules("5=50; 7=erp@il", DefaultMarkets)
+	if err != nil {
+		t.Fatalf("scoped: %v", err)
+	}
+	if rule := rules["7"]; rule.Percent != DiscountERPPercent || !rule.AppliesTo("ILS") || rule.AppliesTo("USD") {
+		t.Fatalf("scoped rule = %+v", rule)
+	}
+
+	shared := append([]MarketDefinition{}, DefaultMarkets...)
+	shared = append(shared, MarketDefinition{Handle: "us", Currency: "USD", CatalogTitle: "US", PriceList: "US USD", ERPPriceList: 7})
+	invalid := map[string]string{
+		"not a number":    "5=half",
+		"zero percent":    "5=0",
+		"full percent":    "5=100",
+		"code twice":      "5=50;5=40",
+		"unknown market":  "5=50@eu",
+		"empty scope":     "5=50@",
+		"missing percent": "5",
+		"split currency":  "5=50@us",
+	}
+	for name, raw := range invalid {
+		if _, err := parseDiscountRules(raw, shared); err == nil {
+			t.Errorf("%s: parseDiscountRules(%q) = nil error", name, raw)
+		}
+	}
+}
@@ -89,6 +89,65 @@ func stringSliceWithDefault(key string, def []string) []string {
 	return values
 }
 
+// channelPublicationsWithDefault reads "kind=Name,Name;kind=Name" pairs. Kinds are
+// ERP WebItem values or "*"; names are Shopify publication names or "*". Unlike the
+// other helpers a malformed value is an error: a typo here would silently pull the
+// catalogue off a sales channel.
+// incomingModeWithDefault reads one of the IncomingMode values, case-insensitively.
+func incomingModeWithDefault(key, def string) (string, error) {
+	mode := strings.ToLower(strings.TrimSpace(stringWithDefault(key, def)))
+	switch mode {
+	case IncomingInventory, IncomingMetafield, IncomingOff:
+		return mode, nil
+	}
+	return "", fmt.Errorf("Invalid %s %q: want %s, %s or %s", key, mode, IncomingInventory, IncomingMetafield, IncomingOff)
+}
+
+func channelPublicationsWithDefault(key string, def map[string][]string) (map[string][]string, error) {
+	raw, isOk := os.LookupEnv(key)
+	if !isOk || strings.TrimSpace(raw) == "" {
+		return def, nil
+	}
+	rules := make(map[string][]string)
+	for _, entry := range strings.FieldsFunc(raw, func(r rune) bool { return r == ';' || r == '\n' || r == '\r' }) {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		kind, names, found := strings.Cut(entry, "=")
+		kind = strings.TrimSpace(kind)
+		if !found || kind == "" {
+			return nil, fmt.Errorf("Invalid %s entry %q: want kind=Publication,Publication", key, entry)
+		}
+		if kind != ChannelPublicationsAll {
+			if _, err := strconv.Atoi(kind); err != nil {
+				return nil, fmt.Errorf("Invalid %s kind %q: want a WebItem number or *", key, kind)
+			}
+		}
+		if _, dup := rules[kind]; dup {
+			return nil, fmt.Errorf("Invalid %s: kind %q listed twice", key, kind)
+		}
+		list := make([]string, 0)
+		for _, name := range strings.Split(names, ",") {
+			if trimmed := strings.TrimSpace(name); trimmed != "" {
+				list = append(list, trimmed)
+			}
+		}
+		rules[kind] = list
+	}
+	return rules, nil
+}
+
+// isAPIVersion reports whether value is a Shopify API version: a release named
+// YYYY-MM, or unstable.
+func isAPIVersion(value string) bool {
+	if value == "unstable" {
+		return true
+	}
+	parsed, err := time.Parse("2006-01", value)
+	return err == nil && parsed.Format("2006-01") == value
+}
+
 // boolWithDefault reads a permissive boolean (1/true/yes/on and their negatives).
 func boolWithDefault(key string, def bool) bool {
 	variable, isOk := os.LookupEnv(key)
@@ -117,6 +176,18 @@ func intWithDefault(key string, def int) (int, error) {
 	return number, nil
 }
 
+func floatWithDefault(key string, def float64) (float64, error) {
+	variable, isOk := os.LookupEnv(key)
+	if !isOk || strings.TrimSpace(variable) == "" {
+		return def, nil
+	}
+	number, err := strconv.ParseFloat(strings.TrimSpace(variable), 64)
+	if err != nil {
+		return 0, fmt.Errorf("Invalid float for %s: %w", key, err)
+	}
+	return number, nil
+}
+
 func durationWithDefualt(key string, def time.Duration) (time.Duration, error) {
 	variable, isOk := os.LookupEnv(key)
 	if !isOk || variable == "" {
@@ -153,59 +224,58 @@ func LoadForDailySync() (*DailyConfig, error) {
 	if err != nil {
 		return nil, err
 	}
-
-	shopifyBaseCurrency := stringWithDefault("SHOPIFY_BASE_CURRENCY", "")
-	shopifyIntlMarketHandle := stringWithDefault("SHOPIFY_INTERNATIONAL_MARKET_HANDLE", "")
-	shopifyIntlMarketName := stringWithDefault("SHOPIFY_INTERNATIONAL_MARKET_NAME", "")
-	shopifyIntlCatalogTitle := stringWithDefault("SHOPIFY_INTERNATIONAL_CATALOG_TITLE", "")
-	shopifyIntlPriceListName := stringWithDefault("SHOPIFY_INTERNATIONAL_PRICE_LIST_NAME", "")
-	shopifyUntrackedPrefixes := stringSliceWithDefault("SHOPIFY_UNTRACKED_SKU_PREFIXES", DefaultUntrackedSkuPrefixes)
-
-	cfgShopify := ShopifyConfig{
-		ShopDomain:                 shopifyBaseUrl,
-		Token:                      shopifyToken,
-		Timeout:                    shopifyDuration,
-		APIVer:                     shopifyVersion,
-		BaseCurrency:               shopifyBaseCurrency,
-		InternationalMarketHandle:  shopifyIntlMarketHandle,
-		InternationalMarketName:    shopifyIntlMarketName,
-		InternationalCatalogTitle:  shopifyIntlCatalogTitle,
-		InternationalPriceListName: shopifyIntlPriceListName,
-		UntrackedSkuPrefixes:       shopifyUntrackedPrefixes,
+	// The adapter picks field names by version (compareQuantity became
+	// changeFromQuantity in 2026-04), so it must be one it can compare.
+	shopifyVersion = strings.TrimSpace(shopifyVersion)
+	if !isAPIVersion(shopifyVersion) {
+		return nil, fmt.Errorf("Invalid SHOPIFY_API_VERSION %q: want YYYY-MM or unstable", shopifyVersion)
 	}
 
-	hasavBaseUrl, err := requriedString("API_BASE_URL")
-
+	shopifyBaseCurrency := stringWithDefault("SHOPIFY_BASE_CURRENCY", "")
+	shopifyMarkets, deprecatedMarketEnv, err := loadMarkets()
 	if err != nil {
 		return nil, err
 	}
-
-	hasavToken, err := requriedString("API_TOKEN")
-
+	shopifyUntrackedPrefixes := stringSliceWithDefault("SHOPIFY_UNTRACKED_SKU_PREFIXES", DefaultUntrackedSkuPrefixes)
+	shopifyPreorderSkus := stringSliceWithDefault("SHOPIFY_PREORDER_SKUS", nil)
+	shopifyPreorderPrefixes := stringSliceWithDefault("SHOPIFY_PREORDER_SKU_PREFIXES", nil)
+	shopifyPreorderNeedsIncoming := boolWithDefault("SHOPIFY_PREORDER_REQUIRE_INCOMING", false)
+	shopifyTimezone := stringWithDefault("REPORT_TIMEZONE", "Asia/Jerusalem")
+	shopifyIncomingMode, err := incomingModeWithDefault("SHOPIFY_INCOMING_QUANTITY", IncomingOff)
 	if err != nil {
 		return nil, err
 	}
-
-	hasavDuration, err := durationWithDefualt("API_DURATION_MS", 10000)
-
+	shopifyChannelPublications, err := channelPublicationsWithDefault("SHOPIFY_CHANNEL_PUBLICATIONS", nil)
 	if err != nil {
 		return nil, err
 	}
 
-	cpfHasav := ApiHasvConfig{
-		BaseUrl: hasavBaseUrl,
-		Token:   hasavToken,
-		Timeout: hasavDuration,
+	cfgShopify := ShopifyConfig{
+		ShopDomain:            shopifyBaseUrl,
+		Token:                 shopifyToken,
+		Timeout:               shopifyDuration,
+		APIVer:                shopifyVersion,
+		BaseCurrency:          shopifyBaseCurrency,
+		Markets:               shopifyMarkets,
+		UntrackedSkuPrefixes:  shopifyUntrackedPrefixes,
+		PreorderSkus:          shopifyPreorderSkus,
+		PreorderSkuPrefixes:   shopifyPreorderPrefixes,
+		PreorderNeedsIncoming: shopifyPreorderNeedsIncoming,
+		Timezone:              shopifyTimezone,
+		IncomingMode:          shopifyIncomingMode,
+		ChannelPublications:   shopifyChannelPublications,
+	}
+
+	cpfHasav, err := loadApiHasavConfig()
+	if err != nil {
+		return nil, err
 	}
 
 	cfgDaily := &DailyConfig{
 		Shopify:  cfgShopify,
 		ApiHasav: cpfHasav,
 	}
-	cfgDaily.TelegramBot.ChatId = stringWithDefault("TELEGRAM_CHAT_ID", "")
-	cfgDaily.TelegramBot.Token = stringWithDefault("TELEGRAM_TOKEN", "")
-	cfgDaily.TelegramBot.LogOutput = stringWithDefault("LOG_OUTPUT", "")
-	cfgDaily.TelegramBot.LogFileDir = stringWithDefault("LOG_FILE_DIR", "")
+	cfgDaily.TelegramBot = loadTelegramBotConfig()
 
 	reportCfg, err := loadReportConfig()
 	if err != nil {
@@ -213,14 +283,120 @@ func LoadForDailySync() (*DailyConfig, error) {
 	}
 	cfgDaily.Report = reportCfg
 	cfgDaily.Stock = loadStockConfig(cfgDaily.TelegramBot.LogFileDir)
+	if err := loadStockRules(&cfgDaily.Stock); err != nil {
+		return nil, err
+	}
+	historyKeep, err := intWithDefault("SYNC_STOCK_HISTORY_KEEP", DefaultStockHistoryKeep)
+	if err != nil {
+		return nil, err
+	}
+	if historyKeep < 0 {
+		return nil, fmt.Errorf("Invalid SYNC_STOCK_HISTORY_KEEP %d: want 0 or more", historyKeep)
+	}
+	cfgDaily.Stock.HistoryKeep = historyKeep
+	alertCfg, err := loadStockAlertConfig(cfgDaily.Stock.StatePath)
+	if err != nil {
+		return nil, err
+	}
+	cfgDaily.Stock.Alerts = alertCfg
+	priceCfg, err := loadPriceConfig(shopifyMarkets, cfgDaily.TelegramBot.LogFileDir)
+	if err != nil {
+		return nil, err
+	}
+	priceCfg.DeprecatedSettings = deprecatedMarketEnv
+	cfgDaily.Prices = priceCfg
+	b2bCfg, err := loadB2BConfig(shopifyBaseCurrency)
+	if err != nil {
+		return nil, err
+	}
+	cfgDaily.B2B = b2bCfg
 	// One read, two consumers: the use case decides whether to persist the snapshot,
 	// the adapter decides whether to send mutations at all.
 	cfgDaily.Shopify.StockDryRun = cfgDaily.Stock.DryRun
+	cfgDaily.Shopify.PriceDryRun = cfgDaily.Prices.DryRun
 
 	return cfgDaily, nil
 }
 
-// loadStockConfig reads the stock sync mode and snapshot location. An unrecognised
+// LoadForStockValidator reads only what cmd/stock-validator uses. It does not
+// require the Shopify settings or validate the price, B2B and report ones, so a typo
+// there cannot keep checkout validation down.
+func LoadForStockValidator() (*ValidatorConfig, error) {
+	if err := loadDotEnv(); err != nil {
+		return nil, err
+	}
+
+	cpfHasav, err := loadApiHasavConfig()
+	if err != nil {
+		return nil, err
+	}
+	cfgValidator := &ValidatorConfig{
+		ApiHasav:    cpfHasav,
+		TelegramBot: loadTelegramBotConfig(),
+	}
+	cfgValidator.Stock = loadStockConfig(cfgValidator.TelegramBot.LogFileDir)
+	if err := loadStockRules(&cfgValidator.Stock); err != nil {
+		return nil, err
+	}
+	validatorCfg, err := loadStockValidatorConfig()
+	if err != nil {
+		return nil, err
+	}
+	cfgValidator.StockValidator = validatorCfg
+	return cfgValidator, nil
+}
+
+func loadApiHasavConfig() (ApiHasvConfig, error) {
+	hasavBaseUrl, err := requriedString("API_BASE_URL")
+	if err != nil {
+		return ApiHasvConfig{}, err
+	}
+	hasavToken, err := requriedString("API_TOKEN")
+	if err != nil {
+		return ApiHasvConfig{}, err
+	}
+	hasavDuration, err := durationWithDefualt("API_DURATION_MS", 10000)
+	if err != nil {
+		return ApiHasvConfig{}, err
+	}
+	return ApiHasvConfig{
+		BaseUrl: hasavBaseUrl,
+		Token:   hasavToken,
+		Timeout: hasavDuration,
+	}, nil
+}
+
+func loadTelegramBotConfig() TelegramBotConfig {
+	return TelegramBotConfig{
+		ChatId:     stringWithDefault("TELEGRAM_CHAT_ID", ""),
+		Token:      stringWithDefault("TELEGRAM_TOKEN", ""),
+		LogOutput:  stringWithDefault("LOG_OUTPUT", ""),
+		LogFileDir: stringWithDefault("LOG_FILE_DIR", ""),
+	}
+}
+
+// loadStockRules reads the rules that decide what a SKU may sell, which the sync and
+// the validator must apply alike: the reserve, the location mapping and the kits.
+func loadStockRules(stock *StockConfig) error {
+	reserveCfg, err := loadStockReserveConfig()
+	if err != nil {
+		return err
+	}
+	stock.Reserve = reserveCfg
+	stockLocations, err := loadStockLocations()
+	if err != nil {
+		return err
+	}
+	stock.Locations = stockLocations
+	stockKits, err := loadStockKits()
+	if err != nil {
+		return err
+	}
+	stock.Kits = stockKits
+	return nil
+}
+
+// loadStockConfig reads the stock sync mode and the snapshot and cursor locations. An unrecognised
 // SYNC_STOCK_MODE is not an error: it degrades to a full push, which is correct but
 // slow, rather than refusing to sync stock at all. The caller logs the mode it ended
 // up with, so a typo is visible in the run report.
@@ -240,9 +416,16 @@ func loadStockConfig(logFileDir string) StockConfig {
 	}
 
 	return StockConfig{
-		Mode:      mode,
-		StatePath: statePath,
-		DryRun:    boolWithDefault("SYNC_STOCK_DRY_RUN", false),
+		Mode:        mode,
+		StatePath:   statePath,
+		DryRun:      boolWithDefault("SYNC_STOCK_DRY_RUN", false),
+		ChangesFeed: boolWithDefault("SYNC_STOCK_CHANGES_FEED", false),
+		// The cursor only means anything alongside the snapshot it was saved with, so
+		// it always sits in the same directory.
+		CursorPath: filepath.Join(filepath.Dir(statePath), "stock-cursor.json"),
+		DriftAudit: boolWithDefault("SYNC_STOCK_DRIFT_AUDIT", false),
+		DriftPath:  filepath.Join(filepath.Dir(statePath), "stock-drift.json"),
+		HistoryDir: filepath.Join(filepath.Dir(statePath), "stock-history"),
 	}
 }
 
@@ -306,87 +489,65 @@ func LoadForSyncOrder() (*OrdersConfig, error) {
 	}
 
 	shopifyBaseCurrency := stringWithDefault("SHOPIFY_BASE_CURRENCY", "")
-	shopifyIntlMarketHandle := stringWithDefault("SHOPIFY_INTERNATIONAL_MARKET_HANDLE", "")
-	shopifyIntlMarketName := stringWithDefault("SHOPIFY_INTERNATIONAL_MARKET_NAME", "")
-	shopifyIntlCatalogTitle := stringWithDefault("SHOPIFY_INTERNATIONAL_CATALOG_TITLE", "")
-	shopifyIntlPriceListName := stringWithDefault("SHOPIFY_INTERNATIONAL_PRICE_LIST_NAME", "")
 	shopifyUntrackedPrefixes := stringSliceWithDefault("SHOPIFY_UNTRACKED_SKU_PREFIXES", DefaultUntrackedSkuPrefixes)
 
 	cfgShopify := ShopifyConfig{
-		ShopDomain:                 shopifyBaseUrl,
-		Token:                      shopifyToken,
-		Timeout:                    shopifyDuration,
-		BaseCurrency:               shopifyBaseCurrency,
-		InternationalMarketHandle:  shopifyIntlMarketHandle,
-		InternationalMarketName:    shopifyIntlMarketName,
-		InternationalCatalogTitle:  shopifyIntlCatalogTitle,
-		InternationalPriceListName: shopifyIntlPriceListName,
-		UntrackedSkuPrefixes:       shopifyUntrackedPrefixes,
+		ShopDomain:           shopifyBaseUrl,
+		Token:                shopifyToken,
+		Timeout:              shopifyDuration,
+		BaseCurrency:         shopifyBaseCurrency,
+		UntrackedSkuPrefixes: shopifyUntrackedPrefixes,
 	}
 
-	hasavBaseUrl, err := requriedString("API_BASE_URL")
-
+	cpfHasav, err := loadApiHasavConfig()
 	if err != nil {
 		return nil, err
 	}
 
-	hasavToken, err := requriedString("API_TOKEN")
-
+	cfgMysql, err := loadMysqlConfig()
 	if err != nil {
 		return nil, err
 	}
 
-	hasavDuration, err := durationWithDefualt("API_DURATION_MS", 10000)
-
-	if err != nil {
-		return nil, err
+	cfgOrd := &OrdersConfig{
+		Shopify:  cfgShopify,
+		ApiHasav: cpfHasav,
+		Mysql:    cfgMysql,
 	}
 
-	cpfHasav := ApiHasvConfig{
-		BaseUrl: hasavBaseUrl,
-		Token:   hasavToken,
-		Timeout: hasavDuration,
-	}
+	cfgOrd.TelegramBot = loadTelegramBotConfig()
 
+	return cfgOrd, nil
+}
+
+// loadMysqlConfig reads the MYSQL_* connection settings.
+func loadMysqlConfig() (MysqlConfig, error) {
 	mySqlHost, err := requriedString("MYSQL_HOST")
 	if err != nil {
-		return nil, err
+		return MysqlConfig{}, err
 	}
 	mySqlPort, err := intWithDefault("MYSQL_PORT", 3306)
 	if err != nil {
-		return nil, err
+		return MysqlConfig{}, err
 	}
 	mySqlUser, err := requriedString("MYSQL_USER")
 	if err != nil {
-		return nil, err
+		return MysqlConfig{}, err
 	}
 	mySqlPassword, err := requriedString("MYSQL_PASSWORD")
 	if err != nil {
-		return nil, err
+		return MysqlConfig{}, err
 	}
 	mySqlDatabase, err := requriedString("MYSQL_DATABASE")
 	if err != nil {
-		return nil, err
+		return MysqlConfig{}, err
 	}
 
-	cfgMysql := MysqlConfig{
+	return MysqlConfig{
 		Host:     mySqlHost,
 		Port:     mySqlPort,
 		Username: mySqlUser,
 		Password: mySqlPassword,
 		Database: mySqlDatabase,
-	}
-
-	cfgOrd := &OrdersConfig{
-		Shopify:  cfgShopify,
-		ApiHasav: cpfHasav,
-		Mysql:    cfgMysql,
-	}
-
-	cfgOrd.TelegramBot.ChatId = stringWithDefault("TELEGRAM_CHAT_ID", "")
-	cfgOrd.TelegramBot.Token = stringWithDefault("TELEGRAM_TOKEN", "")
-	cfgOrd.TelegramBot.LogOutput = stringWithDefault("LOG_OUTPUT", "")
-	cfgOrd.TelegramBot.LogFileDir = stringWithDefault("LOG_FILE_DIR", "")
-
-	return cfgOrd, nil
+	}, nil
 }
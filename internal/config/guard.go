@@ -0,0 +1,68 @@
+package config
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// DefaultPriceGuardMaxChangePercent is the fallback for SYNC_PRICE_GUARD_MAX_CHANGE_PCT.
+// Loose enough for a normal supplier price rise, tight enough for a misplaced decimal
+// point (23.36 -> 2336 or -> 0.23).
+const DefaultPriceGuardMaxChangePercent = 60
+
+// PriceGuardConfig sets the limits a price move must stay within to go live without
+// a human approving it. The guard is off unless SYNC_PRICE_GUARD turns it on. A zero
+// limit turns that check off.
+type PriceGuardConfig struct {
+	Enabled bool
+	// MaxChangePercent is the largest move from the price Shopify holds, either way.
+	MaxChangePercent float64
+	// MinPrice is an absolute floor, in every currency.
+	MinPrice float64
+	// AllowZero lets a zero price through. Off by default: nothing here is free.
+	AllowZero bool
+	// BelowCost holds a list price below the ERP purchase price, compared in
+	// CostCurrency. Off by default: the ERP cost is not always current.
+	BelowCost    bool
+	CostCurrency string
+	// QuarantinePath is where held changes wait for approve-prices.
+	QuarantinePath string
+}
+
+func loadPriceGuardConfig(logFileDir string) (PriceGuardConfig, error) {
+	maxChange, err := floatWithDefault("SYNC_PRICE_GUARD_MAX_CHANGE_PCT", DefaultPriceGuardMaxChangePercent)
+	if err != nil {
+		return PriceGuardConfig{}, err
+	}
+	minPrice, err := floatWithDefault("SYNC_PRICE_GUARD_MIN_PRICE", 0)
+	if err != nil {
+		return PriceGuardConfig{}, err
+	}
+	if maxChange < 0 || minPrice < 0 {
+		return PriceGuardConfig{}, fmt.Errorf("Invalid ENV: SYNC_PRICE_GUARD_MAX_CHANGE_PCT and SYNC_PRICE_GUARD_MIN_PRICE must not be negative")
+	}
+	costCurrency := strings.ToUpper(strings.TrimSpace(stringWithDefault("SYNC_PRICE_GUARD_COST_CURRENCY", "ILS")))
+	if !isCurrencyCode(costCurrency) {
+		return PriceGuardConfig{}, fmt.Errorf("Invalid SYNC_PRICE_GUARD_COST_CURRENCY %q", costCurrency)
+	}
+
+	path := strings.TrimSpace(stringWithDefault("SYNC_PRICE_QUARANTINE_FILE", ""))
+	if path == "" {
+		dir := strings.TrimSpace(logFileDir)
+		if dir == "" {
+			dir = "logs"
+		}
+		path = filepath.Join(dir, "price-quarantine.json")
+	}
+
+	return PriceGuardConfig{
+		Enabled:          boolWithDefault("SYNC_PRICE_GUARD", false),
+		MaxChangePercent: maxChange,
+		MinPrice:         minPrice,
+		AllowZero:        boolWithDefault("SYNC_PRICE_GUARD_ALLOW_ZERO", false),
+		BelowCost:        boolWithDefault("SYNC_PRICE_GUARD_BELOW_COST", false),
+		CostCurrency:     costCurrency,
+		QuarantinePath:   path,
+	}, nil
+}
@@ -0,0 +1,314 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// MarketDefinition is one Shopify market the price step prices. Every market gets its
+// own currency's price from ERP price list ERPPriceList, delivered one of two ways:
+//   - PriceList set: a Shopify market (found by Handle or Name, else by a country in
+//     Countries, else created) with catalog CatalogTitle and a fixed-price list in
+//     Currency. The market whose currency is the shop's base currency also gets the
+//     variant price itself.
+//   - Metafield set ("namespace.key"): a number_decimal product metafield the theme
+//     reads. This is how USD is sold today, because the Israeli single-currency payment
+//     gateway blocks a USD market — see .claude/PRICE_ISSUE_KNOWN_ROOT_CAUSE.md. The
+//     Shopify market itself is not touched.
+//
+// VATIncluded marks a market whose storefront shows prices with VAT, so a price from
+// a net ERP list (SYNC_PRICE_NET_LISTS) is grossed up for it.
+type MarketDefinition struct {
+	Handle       string   `json:"handle"`
+	Name         string   `json:"name"`
+	Countries    []string `json:"countries,omitempty"`
+	Currency     string   `json:"currency"`
+	CatalogTitle string   `json:"catalog,omitempty"`
+	PriceList    string   `json:"price_list,omitempty"`
+	Metafield    string   `json:"metafield,omitempty"`
+	ERPPriceList int      `json:"erp_price_list"`
+	VATIncluded  bool     `json:"vat_included,omitempty"`
+}
+
+// UsesMetafield reports whether the market's price is delivered as a product metafield
+// rather than through a Shopify price list.
+func (m MarketDefinition) UsesMetafield() bool {
+	return strings.TrimSpace(m.Metafield) != ""
+}
+
+// MetafieldKey splits Metafield into its namespace and key.
+func (m MarketDefinition) MetafieldKey() (string, string) {
+	namespace, key, _ := strings.Cut(strings.TrimSpace(m.Metafield), ".")
+	return strings.TrimSpace(namespace), strings.TrimSpace(key)
+}
+
+// DefaultMarkets is the fallback for SHOPIFY_MARKETS_FILE and reproduces the original
+// two-market setup: Israel in ILS through its own catalog and price list, and USD for
+// everyone else through the custom.usd_price metafield.
+var DefaultMarkets = []MarketDefinition{
+	{
+		Handle:       "il",
+		Name:         "Israel",
+		Countries:    []string{"IL"},
+		Currency:     "ILS",
+		CatalogTitle: "Israel Catalog",
+		PriceList:    "Israel ILS",
+		ERPPriceList: 10,
+		VATIncluded:  true,
+	},
+	{
+		Handle:       "international",
+		Name:         "International",
+		Currency:     "USD",
+		Metafield:    "custom.usd_price",
+		ERPPriceList: 7,
+	},
+}
+
+// VATIncludedCurrencies returns the currencies whose markets show prices with VAT.
+func VATIncludedCurrencies(markets []MarketDefinition) map[string]bool {
+	currencies := make(map[string]bool, len(markets))
+	for _, market := range markets {
+		if market.VATIncluded {
+			currencies[market.Currency] = true
+		}
+	}
+	return currencies
+}
+
+// MarketCurrencies returns the distinct market currencies in definition order.
+func MarketCurrencies(markets []MarketDefinition) []string {
+	currencies := make([]string, 0, len(markets))
+	seen := make(map[string]bool, len(markets))
+	for _, market := range markets {
+		if !seen[market.Currency] {
+			seen[market.Currency] = true
+			currencies = append(currencies, market.Currency)
+		}
+	}
+	return currencies
+}
+
+// legacyMarketEnv are the single-international-market settings SHOPIFY_MARKETS_FILE
+// replaced, in the order of the market fields they map to: handle, name, catalog and
+// price_list of the international market.
+var legacyMarketEnv = []string{
+	"SHOPIFY_INTERNATIONAL_MARKET_HANDLE",
+	"SHOPIFY_INTERNATIONAL_MARKET_NAME",
+	"SHOPIFY_INTERNATIONAL_CATALOG_TITLE",
+	"SHOPIFY_INTERNATIONAL_PRICE_LIST_NAME",
+}
+
+// legacyInternationalHandle is the DefaultMarkets entry the legacy settings describe.
+const legacyInternationalHandle = "international"
+
+// loadMarkets reads the market list from the JSON file named by SHOPIFY_MARKETS_FILE,
+// or returns DefaultMarkets when it is unset. deprecated names the legacy
+// SHOPIFY_INTERNATIONAL_* settings still set, for the price step to warn about.
+// Without a file they are applied to the default international market, as they
+// always were; with one they must agree with the file's international market, since
+// which of the two was meant cannot be told.
+func loadMarkets() (markets []MarketDefinition, deprecated []string, err error) {
+	legacy := legacyMarketSettings()
+	for _, key := range legacyMarketEnv {
+		if legacy[key] != "" {
+			deprecated = append(deprecated, key)
+		}
+	}
+	path := stringWithDefault("SHOPIFY_MARKETS_FILE", "")
+	if path == "" {
+		return applyLegacyMarketSettings(DefaultMarkets, legacy), deprecated, nil
+	}
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, nil, fmt.Errorf("Invalid SHOPIFY_MARKETS_FILE: %w", err)
+	}
+	markets, err = parseMarkets(raw)
+	if err != nil {
+		return nil, nil, fmt.Errorf("Invalid SHOPIFY_MARKETS_FILE %s: %w", path, err)
+	}
+	if conflicts := legacyMarketConflicts(markets, legacy); len(conflicts) > 0 {
+		return nil, nil, fmt.Errorf("Invalid ENV: %s disagree with SHOPIFY_MARKETS_FILE %s; they are no longer needed with a markets file, remove them (see FIXES.md)", strings.Join(conflicts, ", "), path)
+	}
+	return markets, deprecated, nil
+}
+
+// legacyMarketSettings returns the legacy market variables set to a value.
+func legacyMarketSettings() map[string]string {
+	set := make(map[string]string)
+	for _, key := range legacyMarketEnv {
+		if value := strings.TrimSpace(stringWithDefault(key, "")); value != "" {
+			set[key] = value
+		}
+	}
+	return set
+}
+
+// legacyMarketField points at the field of market a legacy variable sets.
+func legacyMarketField(market *MarketDefinition, key string) *string {
+	switch key {
+	case "SHOPIFY_INTERNATIONAL_MARKET_HANDLE":
+		return &market.Handle
+	case "SHOPIFY_INTERNATIONAL_MARKET_NAME":
+		return &market.Name
+	case "SHOPIFY_INTERNATIONAL_CATALOG_TITLE":
+		return &market.CatalogTitle
+	default:
+		return &market.PriceList
+	}
+}
+
+// applyLegacyMarketSettings returns a copy of markets with the legacy values set on
+// the international market. It keeps its metafield delivery: the catalog and price
+// list were only ever used for a price-list market, so they are carried but, as
+// before, change nothing while USD is sold through custom.usd_price.
+func applyLegacyMarketSettings(markets []MarketDefinition, legacy map[string]string) []MarketDefinition {
+	if len(legacy) == 0 {
+		return markets
+	}
+	result := make([]MarketDefinition, len(markets))
+	copy(result, markets)
+	for i := range result {
+		if result[i].Handle != legacyInternationalHandle {
+			continue
+		}
+		for key, value := range legacy {
+			*legacyMarketField(&result[i], key) = value
+		}
+	}
+	return result
+}
+
+// legacyMarketConflicts lists the legacy variables that disagree with the file's
+// international market: the one with the legacy handle, else the one named
+// "international". With no such market every legacy variable conflicts.
+func legacyMarketConflicts(markets []MarketDefinition, legacy map[string]string) []string {
+	if len(legacy) == 0 {
+		return nil
+	}
+	handle := legacyInternationalHandle
+	if value := legacy["SHOPIFY_INTERNATIONAL_MARKET_HANDLE"]; value != "" {
+		handle = value
+	}
+	var international *MarketDefinition
+	for i := range markets {
+		if strings.EqualFold(markets[i].Handle, handle) {
+			international = &markets[i]
+		}
+	}
+	conflicts := make([]string, 0)
+	for _, key := range legacyMarketEnv {
+		value := legacy[key]
+		if value == "" {
+			continue
+		}
+		if international == nil || !strings.EqualFold(*legacyMarketField(international, key), value) {
+			conflicts = append(conflicts, key)
+		}
+	}
+	return conflicts
+}
+
+// parseMarkets decodes and validates a market list. Like SYNC_PRICE_LISTS it refuses
+// anything ambiguous, so a typo stops the sync at startup instead of pricing a market
+// in the wrong currency.
+func parseMarkets(raw []byte) ([]MarketDefinition, error) {
+	var markets []MarketDefinition
+	decoder := json.NewDecoder(strings.NewReader(string(raw)))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&markets); err != nil {
+		return nil, err
+	}
+	if len(markets) == 0 {
+		return nil, fmt.Errorf("no markets defined")
+	}
+
+	handles := make(map[string]bool, len(markets))
+	metafields := make(map[string]bool, len(markets))
+	listByCurrency := make(map[string]int, len(markets))
+	vatByCurrency := make(map[string]bool, len(markets))
+	for i := range markets {
+		market := &markets[i]
+		market.Handle = strings.TrimSpace(market.Handle)
+		market.Name = strings.TrimSpace(market.Name)
+		market.Currency = strings.ToUpper(strings.TrimSpace(market.Currency))
+		market.CatalogTitle = strings.TrimSpace(market.CatalogTitle)
+		market.PriceList = strings.TrimSpace(market.PriceList)
+		market.Metafield = strings.TrimSpace(market.Metafield)
+		for j, country := range market.Countries {
+			market.Countries[j] = strings.ToUpper(strings.TrimSpace(country))
+		}
+
+		label := market.Handle
+		if label == "" {
+			label = fmt.Sprintf("#%d", i+1)
+		}
+		if market.Handle == "" {
+			return nil, fmt.Errorf("market %s: handle is required", label)
+		}
+		key := strings.ToLower(market.Handle)
+		if handles[key] {
+			return nil, fmt.Errorf("market %s: handle listed twice", label)
+		}
+		handles[key] = true
+		if !isCurrencyCode(market.Currency) {
+			return nil, fmt.Errorf("market %s: %q is not a currency code", label, market.Currency)
+		}
+		if market.ERPPriceList <= 0 {
+			return nil, fmt.Errorf("market %s: erp_price_list must be a price list number", label)
+		}
+		if previous, ok := listByCurrency[market.Currency]; ok && previous != market.ERPPriceList {
+			return nil, fmt.Errorf("market %s: %s is already priced from ERP list %d", label, market.Currency, previous)
+		}
+		listByCurrency[market.Currency] = market.ERPPriceList
+		// One price per currency is pushed, so it cannot be net for one market and
+		// gross for another.
+		if included, ok := vatByCurrency[market.Currency]; ok && included != market.VATIncluded {
+			return nil, fmt.Errorf("market %s: vat_included differs from another %s market", label, market.Currency)
+		}
+		vatByCurrency[market.Currency] = market.VATIncluded
+
+		switch {
+		case market.UsesMetafield() && market.PriceList != "":
+			return nil, fmt.Errorf("market %s: set price_list or metafield, not both", label)
+		case market.UsesMetafield():
+			namespace, mfKey := market.MetafieldKey()
+			if namespace == "" || mfKey == "" {
+				return nil, fmt.Errorf("market %s: metafield %q is not namespace.key", label, market.Metafield)
+			}
+			if metafields[strings.ToLower(market.Metafield)] {
+				return nil, fmt.Errorf("market %s: metafield %s used twice", label, market.Metafield)
+			}
+			metafields[strings.ToLower(market.Metafield)] = true
+		case market.PriceList != "":
+			if market.CatalogTitle == "" {
+				return nil, fmt.Errorf("market %s: catalog is required with price_list", label)
+			}
+			for _, country := range market.Countries {
+				if len(country) != 2 {
+					return nil, fmt.Errorf("market %s: %q is not a country code", label, country)
+				}
+			}
+		default:
+			return nil, fmt.Errorf("market %s: price_list or metafield is required", label)
+		}
+	}
+	return markets, nil
+}
+
+// defaultPriceListRules derives the SYNC_PRICE_LISTS fallback from the markets: each
+// currency from its market's ERP list first, else any list the ERP has.
+func defaultPriceListRules(markets []MarketDefinition) string {
+	entries := make([]string, 0, len(markets))
+	seen := make(map[string]bool, len(markets))
+	for _, market := range markets {
+		if seen[market.Currency] {
+			continue
+		}
+		seen[market.Currency] = true
+		entries = append(entries, fmt.Sprintf("%s=%d,*", market.Currency, market.ERPPriceList))
+	}
+	return strings.Join(entries, ";")
+}
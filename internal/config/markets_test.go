@@ -0,0 +1,91 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestParseMarkets checks a EUR/GBP setup is accepted next to the defaults and that
+// definitions which could price a market wrongly are refused at startup.
+func TestParseMarkets(t *testing.T) {
+	markets, err := parseMarkets([]byte(`[
+		{"handle": "il", "name": "Israel", "countries": ["il"], "currency": "ils", "catalog": "Israel Catalog", "price_list": "Israel ILS", "erp_price_list": 10, "vat_included": true},
+		{"handle": "international", "currency": "USD", "metafield": "custom.usd_price", "erp_price_list": 7},
+		{"handle": "eu", "name": "Europe", "countries": ["DE", "FR"], "currency": "EUR", "catalog": "Europe Catalog", "price_list": "Europe EUR", "erp_price_list": 12},
+		{"handle": "uk", "countries": ["GB"], "currency": "GBP", "catalog": "UK Catalog", "price_list": "UK GBP", "erp_price_list": 13}
+	]`))
+	if err != nil {
+		t.Fatalf("parseMarkets: %v", err)
+	}
+	if markets[0].Currency != "ILS" || markets[0].Countries[0] != "IL" {
+		t.Fatalf("market not normalised: %+v", markets[0])
+	}
+	if got := MarketCurrencies(markets); len(got) != 4 || got[2] != "EUR" || got[3] != "GBP" {
+		t.Fatalf("MarketCurrencies = %v", got)
+	}
+	if got := VATIncludedCurrencies(markets); len(got) != 1 || !got["ILS"] {
+		t.Fatalf("VATIncludedCurrencies = %v, want only ILS", got)
+	}
+	if got := defaultPriceListRules(markets); got != "ILS=10,*;USD=7,*;EUR=12,*;GBP=13,*" {
+		t.Fatalf("defaultPriceListRules = %q", got)
+	}
+
+	invalid := map[string]string{
+		"empty":                 `[]`,
+		"unknown field":         `[{"handle": "il", "currency": "ILS", "catalog": "C", "price_list": "P", "erp_price_list": 10, "pricelist": "x"}]`,
+		"no handle":             `[{"currency": "ILS", "catalog": "C", "price_list": "P", "erp_price_list": 10}]`,
+		"handle twice":          `[{"handle": "il", "currency": "ILS", "catalog": "C", "price_list": "P", "erp_price_list": 10}, {"handle": "IL", "currency": "USD", "metafield": "custom.usd", "erp_price_list": 7}]`,
+		"bad currency":          `[{"handle": "il", "currency": "SHEKEL", "catalog": "C", "price_list": "P", "erp_price_list": 10}]`,
+		"no erp list":           `[{"handle": "il", "currency": "ILS", "catalog": "C", "price_list": "P"}]`,
+		"currency two lists":    `[{"handle": "il", "currency": "ILS", "catalog": "C", "price_list": "P", "erp_price_list": 10}, {"handle": "ps", "currency": "ILS", "catalog": "D", "price_list": "Q", "erp_price_list": 11}]`,
+		"both deliveries":       `[{"handle": "il", "currency": "ILS", "catalog": "C", "price_list": "P", "metafield": "custom.ils", "erp_price_list": 10}]`,
+		"no delivery":           `[{"handle": "il", "currency": "ILS", "erp_price_list": 10}]`,
+		"metafield no key":      `[{"handle": "us", "currency": "USD", "metafield": "usd_price", "erp_price_list": 7}]`,
+		"price list no catalog": `[{"handle": "il", "currency": "ILS", "price_list": "P", "erp_price_list": 10}]`,
+		"vat mixed in currency": `[{"handle": "il", "currency": "ILS", "catalog": "C", "price_list": "P", "erp_price_list": 10, "vat_included": true}, {"handle": "ps", "currency": "ILS", "catalog": "D", "price_list": "Q", "erp_price_list": 10}]`,
+		"bad country":           `[{"handle": "il", "countries": ["ISR"], "currency": "ILS", "catalog": "C", "price_list": "P", "erp_price_list": 10}]`,
+	}
+	for name, raw := range invalid {
+		if _, err := parseMarkets([]byte(raw)); err == nil {
+			t.Errorf("%s: parseMarkets(%s) = nil error", name, raw)
+		}
+	}
+}
+
+// Without a markets file the legacy SHOPIFY_INTERNATIONAL_* values still name the
+// international market and are reported as deprecated; with one they must agree
+// with it.
+func TestLoadMarketsHonoursLegacySettings(t *testing.T) {
+	t.Setenv("SHOPIFY_MARKETS_FILE", "")
+	t.Setenv("SHOPIFY_INTERNATIONAL_MARKET_HANDLE", "")
+	t.Setenv("SHOPIFY_INTERNATIONAL_MARKET_NAME", "")
+	markets, deprecated, err := loadMarkets()
+	if err != nil || len(deprecated) != 0 || markets[1].Name != "International" {
+		t.Fatalf("empty legacy values -> %+v, %v, %v; want the default markets", markets, deprecated, err)
+	}
+
+	t.Setenv("SHOPIFY_INTERNATIONAL_MARKET_NAME", "Worldwide")
+	markets, deprecated, err = loadMarkets()
+	if err != nil || len(deprecated) != 1 || deprecated[0] != "SHOPIFY_INTERNATIONAL_MARKET_NAME" {
+		t.Fatalf("legacy name -> %v, %v; want it reported as deprecated", deprecated, err)
+	}
+	if markets[1].Name != "Worldwide" || !markets[1].UsesMetafield() || DefaultMarkets[1].Name != "International" {
+		t.Errorf("markets = %+v, want the international market renamed, still on its metafield, and the defaults untouched", markets)
+	}
+
+	path := filepath.Join(t.TempDir(), "markets.json")
+	file := `[{"handle": "international", "name": "Worldwide", "currency": "USD", "metafield": "custom.usd_price", "erp_price_list": 7}]`
+	if err := os.WriteFile(path, []byte(file), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("SHOPIFY_MARKETS_FILE", path)
+	if _, _, err := loadMarkets(); err != nil {
+		t.Fatalf("legacy name matching the file -> %v, want it accepted", err)
+	}
+	t.Setenv("SHOPIFY_INTERNATIONAL_MARKET_NAME", "Elsewhere")
+	if _, _, err := loadMarkets(); err == nil || !strings.Contains(err.Error(), "SHOPIFY_INTERNATIONAL_MARKET_NAME") {
+		t.Fatalf("legacy name disagreeing with the file -> %v, want an error naming it", err)
+	}
+}
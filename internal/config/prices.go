@@ -0,0 +1,215 @@
+package config
+
+import (
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// PriceListAny in a price list order accepts any ERP price list not named before it.
+// Written as "*" in SYNC_PRICE_LISTS.
+const PriceListAny = -1
+
+// Price sync modes for SYNC_PRICE_MODE. They mean what the stock modes mean: full
+// pushes every SKU, delta only the SKUs whose resolved prices moved since the last
+// successful run.
+const (
+	PriceModeFull  = "full"
+	PriceModeDelta = "delta"
+)
+
+// PriceConfig controls where the price step takes each price from.
+type PriceConfig struct {
+	// Mode is PriceModeFull (default) or PriceModeDelta; an unrecognised value falls
+	// back to full.
+	Mode string
+	// StatePath is where the delta snapshot of last-pushed prices lives. Defaults to
+	// price-state.json under LOG_FILE_DIR, next to the stock snapshot.
+	StatePath string
+	// DryRun resolves and reports every price move without writing to Shopify, and
+	// leaves the snapshot and the quarantine alone. See StockConfig.DryRun.
+	DryRun bool
+	// ListRules are the ordered ERP price list preferences per currency, optionally
+	// narrowed to a SKU prefix. See SYNC_PRICE_LISTS in .env.example.
+	ListRules []PriceListRule
+	// Currencies are the market currencies (see MarketDefinition). Each has a default
+	// rule, and the price step pushes a SKU only when it has a price in all of them,
+	// or one that Conversion can derive.
+	Currencies []string
+	// Conversion derives a currency's missing price from another currency's. See
+	// SYNC_PRICE_CONVERSION.
+	Conversion PriceConversionConfig
+	// VAT grosses up prices from net ERP lists for markets that show VAT. See
+	// SYNC_PRICE_NET_LISTS.
+	VAT PriceVATConfig
+	// Discounts turn ERP discount codes into sale prices. See SYNC_DISCOUNTS.
+	Discounts DiscountConfig
+	// Sales is the scheduled sales calendar. See SYNC_SALES_SOURCE.
+	Sales SalesCalendarConfig
+	// Markets are the market definitions, for rules and sales scoped to a market.
+	Markets []MarketDefinition
+	// Guard holds back suspicious price moves. See SYNC_PRICE_GUARD.
+	Guard PriceGuardConfig
+	// DeprecatedSettings are the legacy SHOPIFY_INTERNATIONAL_* variables still set.
+	// They are honoured (see loadMarkets), and the price step warns on every run
+	// until they are moved to SHOPIFY_MARKETS_FILE.
+	DeprecatedSettings []string
+}
+
+// IsDelta reports whether this run should push only moved prices.
+func (c PriceConfig) IsDelta() bool {
+	return c.Mode == PriceModeDelta
+}
+
+// PriceListRule is the ordered list of ERP price lists one currency's price is taken
+// from. The first list in Lists that has a price for the SKU wins. SkuPrefix is empty
+// for the currency's default rule; a non-empty prefix overrides the default for
+// matching SKUs (longest prefix wins).
+type PriceListRule struct {
+	Currency  string
+	SkuPrefix string
+	Lists     []int
+}
+
+// loadPriceConfig reads SYNC_PRICE_LISTS. When it is unset each market currency comes
+// from its market's erp_price_list, which with the default markets is the original
+// hard-coded choice: USD from list 7 and ILS from list 10, else whatever list the ERP
+// has.
+func loadPriceConfig(markets []MarketDefinition, logFileDir string) (PriceConfig, error) {
+	currencies := MarketCurrencies(markets)
+	raw := stringWithDefault("SYNC_PRICE_LISTS", defaultPriceListRules(markets))
+	rules, err := parsePriceListRules(raw, currencies)
+	if err != nil {
+		return PriceConfig{}, fmt.Errorf("Invalid SYNC_PRICE_LISTS: %w", err)
+	}
+	discounts, err := loadDiscountConfig(markets)
+	if err != nil {
+		return PriceConfig{}, err
+	}
+	sales, err := loadSalesCalendarConfig()
+	if err != nil {
+		return PriceConfig{}, err
+	}
+	guard, err := loadPriceGuardConfig(logFileDir)
+	if err != nil {
+		return PriceConfig{}, err
+	}
+	conversion, err := loadPriceConversionConfig()
+	if err != nil {
+		return PriceConfig{}, err
+	}
+	vat, err := loadPriceVATConfig()
+	if err != nil {
+		return PriceConfig{}, err
+	}
+
+	mode := strings.ToLower(strings.TrimSpace(stringWithDefault("SYNC_PRICE_MODE", PriceModeFull)))
+	if mode != PriceModeDelta {
+		mode = PriceModeFull
+	}
+	statePath := strings.TrimSpace(stringWithDefault("SYNC_PRICE_STATE_FILE", ""))
+	if statePath == "" {
+		dir := strings.TrimSpace(logFileDir)
+		if dir == "" {
+			dir = "logs"
+		}
+		statePath = filepath.Join(dir, "price-state.json")
+	}
+
+	return PriceConfig{
+		Mode:       mode,
+		StatePath:  statePath,
+		DryRun:     boolWithDefault("SYNC_PRICE_DRY_RUN", false),
+		ListRules:  rules,
+		Currencies: currencies,
+		Conversion: conversion,
+		VAT:        vat,
+		Discounts:  discounts,
+		Sales:      sales,
+		Markets:    markets,
+		Guard:      guard,
+	}, nil
+}
+
+// parsePriceListRules reads "CUR[@PREFIX]=list,list,*" entries separated by ";" or
+// newlines, and refuses anything ambiguous: pricing from the wrong list is exactly
+// the mistake this setting exists to prevent, so it fails at startup instead. Every
+// required currency must have a default rule.
+func parsePriceListRules(raw string, required []string) ([]PriceListRule, error) {
+	rules := make([]PriceListRule, 0)
+	seen := make(map[string]bool)
+	for _, entry := range strings.FieldsFunc(raw, func(r rune) bool { return r == ';' || r == '\n' || r == '\r' }) {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		target, lists, found := strings.Cut(entry, "=")
+		if !found {
+			return nil, fmt.Errorf("entry %q: want CUR=list,list", entry)
+		}
+		currency, prefix, _ := strings.Cut(strings.TrimSpace(target), "@")
+		currency = strings.ToUpper(strings.TrimSpace(currency))
+		prefix = strings.TrimSpace(prefix)
+		if !isCurrencyCode(currency) {
+			return nil, fmt.Errorf("entry %q: %q is not a currency code", entry, currency)
+		}
+		if strings.Contains(target, "@") && prefix == "" {
+			return nil, fmt.Errorf("entry %q: empty SKU prefix", entry)
+		}
+
+		key := currency + "@" + strings.ToUpper(prefix)
+		if seen[key] {
+			return nil, fmt.Errorf("entry %q: %s listed twice", entry, strings.TrimSuffix(key, "@"))
+		}
+		seen[key] = true
+
+		rule := PriceListRule{Currency: currency, SkuPrefix: prefix}
+		used := make(map[int]bool)
+		parts := strings.Split(lists, ",")
+		for i, part := range parts {
+			part = strings.TrimSpace(part)
+			if part == "*" {
+				if i != len(parts)-1 {
+					return nil, fmt.Errorf("entry %q: * must come last", entry)
+				}
+				rule.Lists = append(rule.Lists, PriceListAny)
+				continue
+			}
+			number, err := strconv.Atoi(part)
+			if err != nil || number <= 0 {
+				return nil, fmt.Errorf("entry %q: %q is not a price list number", entry, part)
+			}
+			if used[number] {
+				return nil, fmt.Errorf("entry %q: price list %d listed twice", entry, number)
+			}
+			used[number] = true
+			rule.Lists = append(rule.Lists, number)
+		}
+		rules = append(rules, rule)
+	}
+
+	for _, rule := range rules {
+		if rule.SkuPrefix != "" && !seen[rule.Currency+"@"] {
+			return nil, fmt.Errorf("%s@%s has no default %s rule to override", rule.Currency, rule.SkuPrefix, rule.Currency)
+		}
+	}
+	for _, currency := range required {
+		if !seen[currency+"@"] {
+			return nil, fmt.Errorf("no rule for %s", currency)
+		}
+	}
+	return rules, nil
+}
+
+func isCurrencyCode(value string) bool {
+	if len(value) != 3 {
+		return false
+	}
+	for _, r := range value {
+		if r < 'A' || r > 'Z' {
+			return false
+		}
+	}
+	return true
+}
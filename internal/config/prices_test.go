@@ -0,0 +1,37 @@
+package config
+
+import "testing"
+
+// TestParsePriceListRules checks the default derived from the default markets
+// reproduces the original 7/10 choice and that ambiguous or incomplete settings are
+// refused at startup.
+func TestParsePriceListRules(t *testing.T) {
+	required := MarketCurrencies(DefaultMarkets)
+	rules, err := parsePriceListRules(defaultPriceListRules(DefaultMarkets), required)
+	if err != nil {
+		t.Fatalf("default rules: %v", err)
+	}
+	if len(rules) != 2 || rules[0].Currency != "ILS" || rules[0].Lists[0] != 10 || rules[1].Currency != "USD" || rules[1].Lists[0] != 7 {
+		t.Fatalf("default rules = %+v", rules)
+	}
+
+	if _, err := parsePriceListRules("USD=7;ILS=10;ILS@GC-=12,10,*", required); err != nil {
+		t.Fatalf("prefix override: %v", err)
+	}
+
+	invalid := map[string]string{
+		"missing ILS":           "USD=7",
+		"bad currency":          "USD=7;ILS=10;SHEKEL=10",
+		"not a number":          "USD=seven;ILS=10",
+		"wildcard not last":     "USD=*,7;ILS=10",
+		"list repeated":         "USD=7,7;ILS=10",
+		"currency repeated":     "USD=7;USD=8;ILS=10",
+		"override without base": "USD=7;ILS=10;EUR@GC-=3",
+		"empty prefix":          "USD=7;ILS@=10;ILS=10",
+	}
+	for name, raw := range invalid {
+		if _, err := parsePriceListRules(raw, required); err == nil {
+			t.Errorf("%s: parsePriceListRules(%q) = nil error", name, raw)
+		}
+	}
+}
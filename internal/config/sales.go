@@ -0,0 +1,77 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Sales calendar sources for SYNC_SALES_SOURCE.
+const (
+	SalesSourceNone  = "none"
+	SalesSourceFile  = "file"
+	SalesSourceMySQL = "mysql"
+	SalesSourceERP   = "erp"
+)
+
+// DefaultSalesTable is the fallback for SYNC_SALES_TABLE.
+const DefaultSalesTable = "shopify_sales"
+
+// SalesCalendarConfig says where scheduled sales come from. Windows are wall-clock
+// time in Timezone (REPORT_TIMEZONE), the zone the people writing them live in.
+type SalesCalendarConfig struct {
+	Source   string
+	File     string
+	Table    string
+	Mysql    MysqlConfig
+	Timezone string
+}
+
+// Enabled reports whether a calendar is configured.
+func (c SalesCalendarConfig) Enabled() bool {
+	return c.Source != "" && c.Source != SalesSourceNone
+}
+
+// loadSalesCalendarConfig reads SYNC_SALES_SOURCE and what that source needs. A
+// source that is named but not usable is an error: a calendar that silently does
+// not load would end a sale early.
+func loadSalesCalendarConfig() (SalesCalendarConfig, error) {
+	cfg := SalesCalendarConfig{
+		Source:   strings.ToLower(strings.TrimSpace(stringWithDefault("SYNC_SALES_SOURCE", SalesSourceNone))),
+		Timezone: stringWithDefault("REPORT_TIMEZONE", "Asia/Jerusalem"),
+	}
+	switch cfg.Source {
+	case SalesSourceNone, SalesSourceERP:
+	case SalesSourceFile:
+		path, err := requriedString("SYNC_SALES_FILE")
+		if err != nil {
+			return SalesCalendarConfig{}, err
+		}
+		cfg.File = path
+	case SalesSourceMySQL:
+		cfg.Table = stringWithDefault("SYNC_SALES_TABLE", DefaultSalesTable)
+		if !isTableName(cfg.Table) {
+			return SalesCalendarConfig{}, fmt.Errorf("Invalid SYNC_SALES_TABLE %q: letters, digits and _ only", cfg.Table)
+		}
+		mysqlCfg, err := loadMysqlConfig()
+		if err != nil {
+			return SalesCalendarConfig{}, err
+		}
+		cfg.Mysql = mysqlCfg
+	default:
+		return SalesCalendarConfig{}, fmt.Errorf("Invalid SYNC_SALES_SOURCE %q: want %s, %s, %s or %s", cfg.Source, SalesSourceNone, SalesSourceFile, SalesSourceMySQL, SalesSourceERP)
+	}
+	return cfg, nil
+}
+
+// isTableName guards the table name, which is spliced into the query text.
+func isTableName(value string) bool {
+	if value == "" {
+		return false
+	}
+	for _, r := range value {
+		if !(r == '_' || r >= '0' && r <= '9' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z') {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,67 @@
+package config
+
+import (
+	"fmt"
+	"path/filepath"
+	"time"
+)
+
+// StockAlertConfig controls the stock alerts the stock step raises: a SKU dropping to
+// or below its threshold, going out of stock, or coming back. Each alert fires once,
+// when the SKU's level changes, and at most once per Cooldown; see SYNC_STOCK_ALERTS.
+type StockAlertConfig struct {
+	Enabled bool
+	// Thresholds resolves each SKU's low-stock threshold with the reserve rules'
+	// syntax and order: sku, longest prefix, largest category, then Default. A
+	// threshold of 0 means no low-stock alert for the SKU. UseERPField is unused.
+	Thresholds StockReserveConfig
+	// OutOfStock alerts when a SKU's storefront quantity reaches 0.
+	OutOfStock bool
+	// BackInStock alerts when a SKU that was out of stock has stock again.
+	BackInStock bool
+	// Cooldown is the least time between two alerts of the same kind for a SKU. A
+	// SKU flipping between 0 and 1 would otherwise alert out and back in every run;
+	// a change inside the cooldown is held, not dropped, and alerts once the
+	// cooldown ends if the SKU is still there. 0 alerts on every change.
+	Cooldown time.Duration
+	// StatePath keeps each SKU's last alerted level, next to the stock snapshot.
+	StatePath string
+}
+
+// HasCategoryRules reports whether the alerts need the ERP categories.
+func (c StockAlertConfig) HasCategoryRules() bool {
+	return c.Enabled && c.Thresholds.HasCategoryRules()
+}
+
+// loadStockAlertConfig reads SYNC_STOCK_ALERTS and its thresholds. A bad threshold
+// stops the sync like a bad reserve does: an alert that silently never fires is
+// worse than none.
+func loadStockAlertConfig(statePath string) (StockAlertConfig, error) {
+	threshold, err := intWithDefault("SYNC_STOCK_ALERT_THRESHOLD", 0)
+	if err != nil {
+		return StockAlertConfig{}, err
+	}
+	if threshold < 0 {
+		return StockAlertConfig{}, fmt.Errorf("Invalid SYNC_STOCK_ALERT_THRESHOLD %d: want 0 or more", threshold)
+	}
+	cooldown, err := intWithDefault("SYNC_STOCK_ALERT_COOLDOWN_MINUTES", 360)
+	if err != nil {
+		return StockAlertConfig{}, err
+	}
+	if cooldown < 0 {
+		return StockAlertConfig{}, fmt.Errorf("Invalid SYNC_STOCK_ALERT_COOLDOWN_MINUTES %d: want 0 or more", cooldown)
+	}
+	thresholds, err := parseStockReserveRules(stringWithDefault("SYNC_STOCK_ALERT_RULES", ""))
+	if err != nil {
+		return StockAlertConfig{}, fmt.Errorf("Invalid SYNC_STOCK_ALERT_RULES: %w", err)
+	}
+	thresholds.Default = threshold
+	return StockAlertConfig{
+		Enabled:     boolWithDefault("SYNC_STOCK_ALERTS", false),
+		Thresholds:  thresholds,
+		OutOfStock:  boolWithDefault("SYNC_STOCK_ALERT_OUT_OF_STOCK", true),
+		BackInStock: boolWithDefault("SYNC_STOCK_ALERT_BACK_IN_STOCK", true),
+		Cooldown:    time.Duration(cooldown) * time.Minute,
+		StatePath:   filepath.Join(filepath.Dir(statePath), "stock-alerts.json"),
+	}, nil
+}
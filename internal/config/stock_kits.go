@@ -0,0 +1,100 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// StockKit is a SKU assembled from other SKUs, such as a gift set. The ERP seldom
+// holds a balance for the kit itself, so the stock step sells as many as its
+// components can make.
+type StockKit struct {
+	SKU        string              `json:"sku"`
+	Components []StockKitComponent `json:"components"`
+}
+
+// StockKitComponent is one SKU that goes into a kit, Quantity units per kit.
+type StockKitComponent struct {
+	SKU      string `json:"sku"`
+	Quantity int    `json:"quantity"`
+}
+
+// loadStockKits reads the kit list from the JSON file named by SYNC_STOCK_KITS_FILE.
+// Unset means no kits. Like SYNC_STOCK_LOCATIONS a bad file stops the sync: a kit
+// computed from the wrong components is a kit sold without them.
+func loadStockKits() ([]StockKit, error) {
+	path := stringWithDefault("SYNC_STOCK_KITS_FILE", "")
+	if path == "" {
+		return nil, nil
+	}
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid SYNC_STOCK_KITS_FILE: %w", err)
+	}
+	kits, err := parseStockKits(raw)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid SYNC_STOCK_KITS_FILE %s: %w", path, err)
+	}
+	return kits, nil
+}
+
+// parseStockKits decodes and validates a kit list. SKUs are compared the way the
+// reserve rules compare them, trimmed and case-insensitively. A kit cannot be a
+// component of another kit: the ERP would have to hold the inner kit's stock, and
+// then it is not a kit.
+func parseStockKits(raw []byte) ([]StockKit, error) {
+	var file struct {
+		Kits []StockKit `json:"kits"`
+	}
+	decoder := json.NewDecoder(strings.NewReader(string(raw)))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&file); err != nil {
+		return nil, err
+	}
+
+	kits := make(map[string]bool, len(file.Kits))
+	for i := range file.Kits {
+		kit := &file.Kits[i]
+		kit.SKU = strings.TrimSpace(kit.SKU)
+		if kit.SKU == "" {
+			return nil, fmt.Errorf("kit %d: no sku", i+1)
+		}
+		key := strings.ToUpper(kit.SKU)
+		if kits[key] {
+			return nil, fmt.Errorf("kit %s listed twice", kit.SKU)
+		}
+		kits[key] = true
+		if len(kit.Components) == 0 {
+			return nil, fmt.Errorf("kit %s: no components", kit.SKU)
+		}
+
+		components := make(map[string]bool, len(kit.Components))
+		for j := range kit.Components {
+			component := &kit.Components[j]
+			component.SKU = strings.TrimSpace(component.SKU)
+			componentKey := strings.ToUpper(component.SKU)
+			switch {
+			case component.SKU == "":
+				return nil, fmt.Errorf("kit %s: component %d has no sku", kit.SKU, j+1)
+			case componentKey == key:
+				return nil, fmt.Errorf("kit %s: contains itself", kit.SKU)
+			case components[componentKey]:
+				return nil, fmt.Errorf("kit %s: component %s listed twice", kit.SKU, component.SKU)
+			case component.Quantity <= 0:
+				return nil, fmt.Errorf("kit %s: component %s quantity %d, want 1 or more", kit.SKU, component.SKU, component.Quantity)
+			}
+			components[componentKey] = true
+		}
+	}
+
+	for _, kit := range file.Kits {
+		for _, component := range kit.Components {
+			if kits[strings.ToUpper(component.SKU)] {
+				return nil, fmt.Errorf("kit %s: component %s is itself a kit", kit.SKU, component.SKU)
+			}
+		}
+	}
+	return file.Kits, nil
+}
@@ -0,0 +1,37 @@
+package config
+
+import "testing"
+
+func TestParseStockKits(t *testing.T) {
+	kits, err := parseStockKits([]byte(`{"kits": [
+		{"sku": " GIFT-1 ", "components": [{"sku": "CMG-28", "quantity": 2}, {"sku": "hvm-1", "quantity": 1}]},
+		{"sku": "GIFT-2", "components": [{"sku": "CMG-28", "quantity": 1}]}
+	]}`))
+	if err != nil {
+		t.Fatalf("parseStockKits: %v", err)
+	}
+	if len(kits) != 2 || kits[0].SKU != "GIFT-1" || len(kits[0].Components) != 2 || kits[0].Components[0].Quantity != 2 {
+		t.Fatalf("kits = %+v", kits)
+	}
+	if empty, err := parseStockKits([]byte(`{"kits": []}`)); err != nil || len(empty) != 0 {
+		t.Fatalf("empty = %+v, %v; want no kits", empty, err)
+	}
+
+	invalid := map[string]string{
+		"unknown field":    `{"kits": [{"sku": "G", "parts": []}]}`,
+		"no sku":           `{"kits": [{"components": [{"sku": "A", "quantity": 1}]}]}`,
+		"kit twice":        `{"kits": [{"sku": "G", "components": [{"sku": "A", "quantity": 1}]}, {"sku": "g", "components": [{"sku": "B", "quantity": 1}]}]}`,
+		"no components":    `{"kits": [{"sku": "G", "components": []}]}`,
+		"component no sku": `{"kits": [{"sku": "G", "components": [{"quantity": 1}]}]}`,
+		"contains itself":  `{"kits": [{"sku": "G", "components": [{"sku": "g", "quantity": 1}]}]}`,
+		"component twice":  `{"kits": [{"sku": "G", "components": [{"sku": "A", "quantity": 1}, {"sku": "a", "quantity": 2}]}]}`,
+		"zero quantity":    `{"kits": [{"sku": "G", "components": [{"sku": "A"}]}]}`,
+		"kit inside a kit": `{"kits": [{"sku": "G", "components": [{"sku": "H", "quantity": 1}]}, {"sku": "H", "components": [{"sku": "A", "quantity": 1}]}]}`,
+		"not a kit list":   `[{"sku": "G"}]`,
+	}
+	for name, raw := range invalid {
+		if _, err := parseStockKits([]byte(raw)); err == nil {
+			t.Errorf("%s: parseStockKits(%s) = nil error", name, raw)
+		}
+	}
+}
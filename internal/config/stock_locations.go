@@ -0,0 +1,72 @@
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// StockLocation is one Shopify location and the ERP warehouses whose balances make up
+// its on-hand quantity.
+type StockLocation struct {
+	// Name is the Shopify location's name, as shown under Settings > Locations.
+	Name       string
+	Warehouses []int
+}
+
+// loadStockLocations reads SYNC_STOCK_LOCATIONS. Empty keeps the single-location
+// setup: the ERP's total balance goes to the store's primary location. Like
+// SYNC_STOCK_RESERVE a bad value stops the sync, because stock pushed to the wrong
+// location is stock sold twice.
+func loadStockLocations() ([]StockLocation, error) {
+	locations, err := parseStockLocations(stringWithDefault("SYNC_STOCK_LOCATIONS", ""))
+	if err != nil {
+		return nil, fmt.Errorf("Invalid SYNC_STOCK_LOCATIONS: %w", err)
+	}
+	return locations, nil
+}
+
+// parseStockLocations reads "Location name=warehouse,warehouse" entries separated by
+// ";" or newlines. A warehouse belongs to one location at most.
+func parseStockLocations(raw string) ([]StockLocation, error) {
+	locations := make([]StockLocation, 0)
+	names := make(map[string]bool)
+	owner := make(map[int]string)
+	for _, entry := range strings.FieldsFunc(raw, func(r rune) bool { return r == ';' || r == '\n' || r == '\r' }) {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		name, warehouses, found := strings.Cut(entry, "=")
+		name = strings.TrimSpace(name)
+		if !found || name == "" {
+			return nil, fmt.Errorf("entry %q: want Location name=warehouse,warehouse", entry)
+		}
+		if names[strings.ToLower(name)] {
+			return nil, fmt.Errorf("entry %q: location %s listed twice", entry, name)
+		}
+		names[strings.ToLower(name)] = true
+
+		location := StockLocation{Name: name}
+		for _, part := range strings.Split(warehouses, ",") {
+			part = strings.TrimSpace(part)
+			if part == "" {
+				continue
+			}
+			warehouse, err := strconv.Atoi(part)
+			if err != nil || warehouse <= 0 {
+				return nil, fmt.Errorf("entry %q: %q is not a warehouse number", entry, part)
+			}
+			if previous, taken := owner[warehouse]; taken {
+				return nil, fmt.Errorf("entry %q: warehouse %d already goes to %s", entry, warehouse, previous)
+			}
+			owner[warehouse] = name
+			location.Warehouses = append(location.Warehouses, warehouse)
+		}
+		if len(location.Warehouses) == 0 {
+			return nil, fmt.Errorf("entry %q: no warehouses", entry)
+		}
+		locations = append(locations, location)
+	}
+	return locations, nil
+}
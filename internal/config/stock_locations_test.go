@@ -0,0 +1,30 @@
+package config
+
+import "testing"
+
+func TestParseStockLocations(t *testing.T) {
+	locations, err := parseStockLocations("Main Warehouse=1, 3;\nTel Aviv Store=5")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(locations) != 2 || locations[0].Name != "Main Warehouse" || len(locations[0].Warehouses) != 2 || locations[1].Warehouses[0] != 5 {
+		t.Fatalf("locations = %+v", locations)
+	}
+	if empty, err := parseStockLocations(""); err != nil || len(empty) != 0 {
+		t.Fatalf("empty = %+v, %v; want no locations", empty, err)
+	}
+
+	for _, raw := range []string{
+		"Main",
+		"=1",
+		"Main=",
+		"Main=x",
+		"Main=0",
+		"Main=1;main=2",
+		"Main=1;Store=1",
+	} {
+		if _, err := parseStockLocations(raw); err == nil {
+			t.Errorf("parseStockLocations(%q) = nil error", raw)
+		}
+	}
+}
@@ -0,0 +1,126 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// DefaultStockReserve is the reserve every SKU had before SYNC_STOCK_RESERVE existed:
+// the client's wish to keep 3 units off the storefront.
+const DefaultStockReserve = 3
+
+// Reserve rule kinds in SYNC_STOCK_RESERVE_RULES.
+const (
+	StockReserveBySKU      = "sku"
+	StockReserveByPrefix   = "prefix"
+	StockReserveByCategory = "category"
+)
+
+// StockReserveConfig is how many units of each SKU are held back from the storefront:
+// the ERP balance minus the reserve, clamped at 0, is what Shopify gets. The reserve
+// is per SKU, not per location: with SHOPIFY_STOCK_LOCATIONS it comes off the first
+// location and whatever that one lacks off the next, in the configured order. The reserve
+// is the first that applies of a per-SKU override, the ERP's own reserve field (with
+// UseERPField), the longest matching prefix rule, the largest matching category rule
+// and Default.
+type StockReserveConfig struct {
+	Default int
+	// SKUs are per-SKU overrides, keyed by the upper-cased SKU.
+	SKUs map[string]int
+	// Prefixes are sorted longest first, so the first match is the most specific.
+	Prefixes []StockReservePrefix
+	// Categories are keyed by the lower-cased category title, Hebrew or English.
+	Categories map[string]int
+	// UseERPField takes the ERP's per-item reserve when the feed has one.
+	UseERPField bool
+}
+
+// StockReservePrefix is one prefix rule.
+type StockReservePrefix struct {
+	Prefix  string
+	Reserve int
+}
+
+// HasCategoryRules reports whether the stock step needs the ERP categories.
+func (c StockReserveConfig) HasCategoryRules() bool {
+	return len(c.Categories) > 0
+}
+
+// loadStockReserveConfig reads SYNC_STOCK_RESERVE, SYNC_STOCK_RESERVE_RULES and
+// SYNC_STOCK_RESERVE_ERP. Unlike SYNC_STOCK_MODE a bad value stops the sync: a
+// mistyped reserve is an oversold storefront, not a slow run.
+func loadStockReserveConfig() (StockReserveConfig, error) {
+	reserve, err := intWithDefault("SYNC_STOCK_RESERVE", DefaultStockReserve)
+	if err != nil {
+		return StockReserveConfig{}, err
+	}
+	if reserve < 0 {
+		return StockReserveConfig{}, fmt.Errorf("Invalid SYNC_STOCK_RESERVE %d: want 0 or more", reserve)
+	}
+	cfg, err := parseStockReserveRules(stringWithDefault("SYNC_STOCK_RESERVE_RULES", ""))
+	if err != nil {
+		return StockReserveConfig{}, fmt.Errorf("Invalid SYNC_STOCK_RESERVE_RULES: %w", err)
+	}
+	cfg.Default = reserve
+	cfg.UseERPField = boolWithDefault("SYNC_STOCK_RESERVE_ERP", false)
+	return cfg, nil
+}
+
+// parseStockReserveRules reads "kind:value=units" entries separated by ";" or
+// newlines, where kind is sku, prefix or category.
+func parseStockReserveRules(raw string) (StockReserveConfig, error) {
+	cfg := StockReserveConfig{
+		SKUs:       make(map[string]int),
+		Prefixes:   make([]StockReservePrefix, 0),
+		Categories: make(map[string]int),
+	}
+	prefixes := make(map[string]bool)
+	for _, entry := range strings.FieldsFunc(raw, func(r rune) bool { return r == ';' || r == '\n' || r == '\r' }) {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		target, units, found := strings.Cut(entry, "=")
+		if !found {
+			return StockReserveConfig{}, fmt.Errorf("entry %q: want kind:value=units", entry)
+		}
+		kind, value, found := strings.Cut(strings.TrimSpace(target), ":")
+		kind = strings.ToLower(strings.TrimSpace(kind))
+		value = strings.TrimSpace(value)
+		if !found || value == "" {
+			return StockReserveConfig{}, fmt.Errorf("entry %q: want kind:value=units", entry)
+		}
+		reserve, err := strconv.Atoi(strings.TrimSpace(units))
+		if err != nil || reserve < 0 {
+			return StockReserveConfig{}, fmt.Errorf("entry %q: %q is not a number of units", entry, strings.TrimSpace(units))
+		}
+
+		switch kind {
+		case StockReserveBySKU:
+			key := strings.ToUpper(value)
+			if _, dup := cfg.SKUs[key]; dup {
+				return StockReserveConfig{}, fmt.Errorf("entry %q: sku %s listed twice", entry, value)
+			}
+			cfg.SKUs[key] = reserve
+		case StockReserveByPrefix:
+			key := strings.ToUpper(value)
+			if prefixes[key] {
+				return StockReserveConfig{}, fmt.Errorf("entry %q: prefix %s listed twice", entry, value)
+			}
+			prefixes[key] = true
+			cfg.Prefixes = append(cfg.Prefixes, StockReservePrefix{Prefix: key, Reserve: reserve})
+		case StockReserveByCategory:
+			key := strings.ToLower(value)
+			if _, dup := cfg.Categories[key]; dup {
+				return StockReserveConfig{}, fmt.Errorf("entry %q: category %s listed twice", entry, value)
+			}
+			cfg.Categories[key] = reserve
+		default:
+			return StockReserveConfig{}, fmt.Errorf("entry %q: kind %q is not %s, %s or %s", entry, kind, StockReserveBySKU, StockReserveByPrefix, StockReserveByCategory)
+		}
+	}
+	sort.SliceStable(cfg.Prefixes, func(i, j int) bool { return len(cfg.Prefixes[i].Prefix) > len(cfg.Prefixes[j].Prefix) })
+	return cfg, nil
+}
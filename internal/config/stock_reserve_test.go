@@ -0,0 +1,33 @@
+package config
+
+import "testing"
+
+func TestParseStockReserveRules(t *testing.T) {
+	cfg, err := parseStockReserveRules("sku:hvm-1=0; prefix:CMG-=5;prefix:CMG-GOLD-=1\ncategory:Silver=2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if units, ok := cfg.SKUs["HVM-1"]; !ok || units != 0 {
+		t.Errorf("SKUs = %v, want HVM-1=0", cfg.SKUs)
+	}
+	if len(cfg.Prefixes) != 2 || cfg.Prefixes[0].Prefix != "CMG-GOLD-" {
+		t.Errorf("Prefixes = %+v, want the longest first", cfg.Prefixes)
+	}
+	if cfg.Categories["silver"] != 2 || !cfg.HasCategoryRules() {
+		t.Errorf("Categories = %v, want silver=2", cfg.Categories)
+	}
+
+	for _, raw := range []string{
+		"HVM-1=0",
+		"sku:=2",
+		"sku:A=x",
+		"sku:A=-1",
+		"brand:A=1",
+		"sku:A=1;sku:a=2",
+		"prefix:CMG-=1;prefix:cmg-=2",
+	} {
+		if _, err := parseStockReserveRules(raw); err == nil {
+			t.Errorf("parseStockReserveRules(%q) = nil error", raw)
+		}
+	}
+}
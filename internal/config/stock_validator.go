@@ -0,0 +1,95 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// StockValidatorConfig controls cmd/stock-validator, the service a Shopify checkout
+// asks whether the cart's quantities are still in the ERP. It answers while the
+// customer waits, so every setting here trades accuracy against checkout latency.
+type StockValidatorConfig struct {
+	// Addr is the listen address, ":8085" by default.
+	Addr string
+	// Timeout is the budget for one validation, all ERP lookups included. A SKU not
+	// answered within it is unverified and follows FailOpen.
+	Timeout time.Duration
+	// CacheTTL is how long an ERP answer is reused. A few seconds absorb a busy
+	// product page; longer reopens the window validation is meant to close.
+	CacheTTL time.Duration
+	// FailOpen lets an unverified line through (default); false blocks it. Blocking
+	// turns an ERP outage into a checkout outage, so it is opt-in.
+	FailOpen bool
+	// Token must arrive as the X-Validator-Token header. Loading fails without one:
+	// /validate reads the ERP for whatever SKUs it is sent.
+	Token string
+	// UntrackedSkuPrefixes is SHOPIFY_UNTRACKED_SKU_PREFIXES: lines with no stock to
+	// check.
+	UntrackedSkuPrefixes []string
+	// MaxLookups is how many ERP lookups run at once across all requests. A lookup
+	// waiting for a slot past the budget is unverified like a slow one.
+	MaxLookups int
+	// MaxCacheEntries bounds the answer cache; when full, an answer is not cached.
+	MaxCacheEntries int
+	// PreorderSkus, PreorderSkuPrefixes and PreorderNeedsIncoming are the product
+	// sync's SHOPIFY_PREORDER_* settings. A line whose pre-order is active (see
+	// shopify.PreorderActive) is let through; any other is checked like the rest.
+	PreorderSkus          []string
+	PreorderSkuPrefixes   []string
+	PreorderNeedsIncoming bool
+	// Timezone is REPORT_TIMEZONE, the zone a pre-order return date ends in.
+	Timezone string
+}
+
+func loadStockValidatorConfig() (StockValidatorConfig, error) {
+	timeout, err := durationWithDefualt("STOCK_VALIDATOR_TIMEOUT_MS", 1500)
+	if err != nil {
+		return StockValidatorConfig{}, err
+	}
+	if timeout <= 0 {
+		return StockValidatorConfig{}, fmt.Errorf("Invalid STOCK_VALIDATOR_TIMEOUT_MS %d: want more than 0", timeout.Milliseconds())
+	}
+	cacheSeconds, err := intWithDefault("STOCK_VALIDATOR_CACHE_SECONDS", 20)
+	if err != nil {
+		return StockValidatorConfig{}, err
+	}
+	if cacheSeconds < 0 {
+		return StockValidatorConfig{}, fmt.Errorf("Invalid STOCK_VALIDATOR_CACHE_SECONDS %d: want 0 or more", cacheSeconds)
+	}
+	token := strings.TrimSpace(stringWithDefault("STOCK_VALIDATOR_TOKEN", ""))
+	if token == "" {
+		return StockValidatorConfig{}, fmt.Errorf("missing requried env var: STOCK_VALIDATOR_TOKEN")
+	}
+	maxLookups, err := intWithDefault("STOCK_VALIDATOR_MAX_LOOKUPS", 8)
+	if err != nil {
+		return StockValidatorConfig{}, err
+	}
+	if maxLookups <= 0 {
+		return StockValidatorConfig{}, fmt.Errorf("Invalid STOCK_VALIDATOR_MAX_LOOKUPS %d: want more than 0", maxLookups)
+	}
+	maxCacheEntries, err := intWithDefault("STOCK_VALIDATOR_CACHE_ENTRIES", 5000)
+	if err != nil {
+		return StockValidatorConfig{}, err
+	}
+	if maxCacheEntries < 0 {
+		return StockValidatorConfig{}, fmt.Errorf("Invalid STOCK_VALIDATOR_CACHE_ENTRIES %d: want 0 or more", maxCacheEntries)
+	}
+	return StockValidatorConfig{
+		Addr:     strings.TrimSpace(stringWithDefault("STOCK_VALIDATOR_ADDR", ":8085")),
+		Timeout:  timeout,
+		CacheTTL: time.Duration(cacheSeconds) * time.Second,
+		FailOpen: boolWithDefault("STOCK_VALIDATOR_FAIL_OPEN", true),
+		Token:    token,
+
+		UntrackedSkuPrefixes: stringSliceWithDefault("SHOPIFY_UNTRACKED_SKU_PREFIXES", DefaultUntrackedSkuPrefixes),
+
+		MaxLookups:      maxLookups,
+		MaxCacheEntries: maxCacheEntries,
+
+		PreorderSkus:          stringSliceWithDefault("SHOPIFY_PREORDER_SKUS", nil),
+		PreorderSkuPrefixes:   stringSliceWithDefault("SHOPIFY_PREORDER_SKU_PREFIXES", nil),
+		PreorderNeedsIncoming: boolWithDefault("SHOPIFY_PREORDER_REQUIRE_INCOMING", false),
+		Timezone:              stringWithDefault("REPORT_TIMEZONE", "Asia/Jerusalem"),
+	}, nil
+}
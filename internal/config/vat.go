@@ -0,0 +1,44 @@
+package config
+
+import "fmt"
+
+// DefaultVATRate is Israel's VAT rate, in percent.
+const DefaultVATRate = 18.0
+
+// PriceVATConfig says which ERP price lists hold prices net of VAT. A net price bound
+// for a market whose prices include VAT (MarketDefinition.VATIncluded) is grossed up
+// at Rate before it is pushed, unless the item is VAT exempt. Everything else is
+// pushed as the ERP has it, as it always was.
+type PriceVATConfig struct {
+	// Rate is the VAT rate in percent.
+	Rate float64
+	// NetLists are the ERP price lists stored net of VAT.
+	NetLists []int
+}
+
+// IsNet reports whether the ERP price list holds net prices.
+func (c PriceVATConfig) IsNet(priceList int) bool {
+	for _, list := range c.NetLists {
+		if list == priceList {
+			return true
+		}
+	}
+	return false
+}
+
+// loadPriceVATConfig reads SYNC_PRICE_VAT_RATE and SYNC_PRICE_NET_LISTS.
+func loadPriceVATConfig() (PriceVATConfig, error) {
+	rate, err := floatWithDefault("SYNC_PRICE_VAT_RATE", DefaultVATRate)
+	if err != nil {
+		return PriceVATConfig{}, err
+	}
+	if rate < 0 || rate >= 100 {
+		return PriceVATConfig{}, fmt.Errorf("Invalid SYNC_PRICE_VAT_RATE %.2f: want a percentage from 0 to 100", rate)
+	}
+	// Same shape as SYNC_B2B_PRICE_LISTS: comma separated list numbers.
+	lists, err := parseB2BPriceLists(stringWithDefault("SYNC_PRICE_NET_LISTS", ""))
+	if err != nil {
+		return PriceVATConfig{}, fmt.Errorf("Invalid SYNC_PRICE_NET_LISTS: %w", err)
+	}
+	return PriceVATConfig{Rate: rate, NetLists: lists}, nil
+}
@@ -0,0 +1,11 @@
+package model
+
+// Customer is an ERP customer card, as much of it as B2B pricing needs.
+// PriceListNumber is the ERP price list the customer buys at, 0 when none.
+type Customer struct {
+	Key             string
+	Name            string
+	Email           string
+	Phone           string
+	PriceListNumber int
+}
@@ -5,4 +5,18 @@ type Price struct {
 	Currency        string
 	Price           float32
 	PriceListNumber int
+	// FromQuantity is the quantity from which Price applies: 0 or 1 for the unit
+	// price, above 1 for a volume price break on a wholesale list.
+	FromQuantity int
+	// OrderMinimum, OrderMaximum and OrderIncrement are the list's quantity rules for
+	// the SKU, carried on the unit price row; 0 means no rule.
+	OrderMinimum   int
+	OrderMaximum   int
+	OrderIncrement int
+}
+
+// IsQuantityBreak reports whether the row is a volume price rather than the unit
+// price. Only the B2B step reads these; everything priced per unit skips them.
+func (p Price) IsQuantityBreak() bool {
+	return p.FromQuantity > 1
 }
@@ -1,11 +1,43 @@
 package model
 
+import (
+	"math"
+	"time"
+)
+
 type Product struct {
 	Sku          string
 	HebrewTitle  string
 	EnglishTitle string
 	Description  string
 	IsPublished  bool
+	// WebItem is the ERP web item kind; 0 means not a web item. It picks the
+	// Shopify publications the product goes to.
+	WebItem      int
 	Barcode      string
 	DiscountCode string
+	// DiscountPercent is the ERP DiscountPrc, used by discount codes mapped to "erp".
+	DiscountPercent float64
+	// VatExempt is the ERP VatExampt flag. Exempt items must reach Shopify with
+	// taxable=false, or checkout charges VAT on them.
+	VatExempt bool
+	// PurchasePrice is the ERP purchase price, pushed as the inventory item cost so
+	// Shopify's margin reports have something to work with. Zero means unknown.
+	PurchasePrice float64
+	// ExpectedReturnDate is when the ERP expects an out-of-stock item back. Zero
+	// when the ERP has no date.
+	ExpectedReturnDate time.Time
+	// OnOrder is the ERP's orden, the quantity on open purchase orders that has not
+	// arrived yet. The feed's purchased is not counted: only open orders are
+	// still on their way.
+	OnOrder float64
+}
+
+// Incoming is the whole units on their way from suppliers, never negative.
+func (p Product) Incoming() int {
+	incoming := int(math.Floor(p.OnOrder))
+	if incoming < 0 {
+		return 0
+	}
+	return incoming
 }
@@ -0,0 +1,24 @@
+package model
+
+import "strings"
+
+// ExchangeRates is what one unit of each currency is worth in a common reference
+// currency, keyed by currency code. Only the ratios matter, so the reference can be
+// any currency, usually the one the ERP keeps its books in.
+type ExchangeRates map[string]float64
+
+// Convert converts amount between two currencies. ok is false when either rate is
+// missing or not positive.
+func (r ExchangeRates) Convert(amount float64, from, to string) (float64, bool) {
+	fromRate := r[strings.ToUpper(from)]
+	toRate := r[strings.ToUpper(to)]
+	if fromRate <= 0 || toRate <= 0 {
+		return 0, false
+	}
+	return amount * fromRate / toRate, true
+}
+
+// Rate is the price of one unit of from in to, for logs and the report.
+func (r ExchangeRates) Rate(from, to string) (float64, bool) {
+	return r.Convert(1, from, to)
+}
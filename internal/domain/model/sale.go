@@ -0,0 +1,63 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// SaleTimeLayout is how sales calendar windows are written, as wall-clock time in
+// REPORT_TIMEZONE.
+const SaleTimeLayout = "2006-01-02 15:04"
+
+// Sale is one sales calendar entry: Percent off the SKUs it covers, in the listed
+// markets (all when empty), from Start until End.
+type Sale struct {
+	Name        string
+	Skus        []string
+	SkuPrefixes []string
+	Percent     float64
+	Markets     []string
+	Start       time.Time
+	End         time.Time
+}
+
+// ActiveAt reports whether t falls in the window. Start is inclusive and End
+// exclusive, so back-to-back sales never overlap.
+func (s Sale) ActiveAt(t time.Time) bool {
+	return !t.Before(s.Start) && t.Before(s.End)
+}
+
+// Covers reports whether the sale includes the SKU, matched case-insensitively.
+func (s Sale) Covers(sku string) bool {
+	sku = strings.ToUpper(strings.TrimSpace(sku))
+	for _, candidate := range s.Skus {
+		if strings.ToUpper(strings.TrimSpace(candidate)) == sku {
+			return true
+		}
+	}
+	for _, prefix := range s.SkuPrefixes {
+		prefix = strings.ToUpper(strings.TrimSpace(prefix))
+		if prefix != "" && strings.HasPrefix(sku, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// Validate rejects an entry that could not be applied as written.
+func (s Sale) Validate() error {
+	if strings.TrimSpace(s.Name) == "" {
+		return fmt.Errorf("sale without a name")
+	}
+	if len(s.Skus) == 0 && len(s.SkuPrefixes) == 0 {
+		return fmt.Errorf("sale %q covers no SKUs", s.Name)
+	}
+	if s.Percent <= 0 || s.Percent >= 100 {
+		return fmt.Errorf("sale %q: %v is not a percentage between 0 and 100", s.Name, s.Percent)
+	}
+	if !s.End.After(s.Start) {
+		return fmt.Errorf("sale %q ends before it starts", s.Name)
+	}
+	return nil
+}
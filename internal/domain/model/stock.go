@@ -1,6 +1,21 @@
 package model
 
+import "time"
+
 type Stock struct {
-	Sku   string
+	Sku string
+	// Stock is the ERP warehouse balance, rounded, and negative when the ERP is. What
+	// Shopify gets is this minus the SKU's reserve, clamped at 0.
 	Stock int32
+	// Reserve is the ERP's own reserve for the item; HasReserve is false when the ERP
+	// left it empty.
+	Reserve    int32
+	HasReserve bool
+	// Warehouses is the balance per ERP warehouse number, rounded like Stock; nil
+	// when the feed has no breakdown for the item.
+	Warehouses map[int]int32
+	// ExpectedReturnDate and OnOrder are as on Product, when the stock endpoint
+	// carries them: zero otherwise, which never makes a pre-order active.
+	ExpectedReturnDate time.Time
+	OnOrder            float64
 }
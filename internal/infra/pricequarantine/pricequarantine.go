@@ -0,0 +1,176 @@
+// Package pricequarantine persists the price changes the guard held back, so a human
+// can look at them and approve the ones that are real.
+//
+// An approval is for exact prices, not for a SKU: if the ERP moves the price again
+// before the next run pushes it, the new price is judged afresh. That way approving
+// "2336 is right" can never wave through a later "23360".
+package pricequarantine
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Entry is one held SKU: the prices the price step wanted to push and why it did not.
+type Entry struct {
+	SKU       string             `json:"sku"`
+	Prices    map[string]float64 `json:"prices"`
+	CompareAt map[string]float64 `json:"compareAt,omitempty"`
+	// Before is what Shopify held when the change was held, per currency.
+	Before  map[string]float64 `json:"before,omitempty"`
+	Reasons []string           `json:"reasons"`
+	HeldAt  time.Time          `json:"heldAt"`
+	// ApprovedAt is set by approve-prices; nil while the change waits.
+	ApprovedAt *time.Time `json:"approvedAt,omitempty"`
+}
+
+// Approved reports whether the entry has been approved.
+func (e Entry) Approved() bool {
+	return e.ApprovedAt != nil
+}
+
+// Matches reports whether prices are the ones held, to the cent.
+func (e Entry) Matches(prices map[string]float64) bool {
+	if len(prices) != len(e.Prices) {
+		return false
+	}
+	for currency, amount := range prices {
+		held, ok := e.Prices[currency]
+		if !ok || math.Abs(held-amount) >= 0.005 {
+			return false
+		}
+	}
+	return true
+}
+
+// Quarantine is the set of held changes, keyed by SKU.
+type Quarantine struct {
+	UpdatedAt time.Time        `json:"updatedAt"`
+	Entries   map[string]Entry `json:"entries"`
+}
+
+// Approved reports whether sku's prices were approved exactly as they stand.
+func (q Quarantine) Approved(sku string, prices map[string]float64) bool {
+	entry, ok := q.Entries[sku]
+	return ok && entry.Approved() && entry.Matches(prices)
+}
+
+// Approve marks the named SKUs approved and returns the ones not in quarantine.
+// No SKUs approves every entry.
+func (q *Quarantine) Approve(skus []string, at time.Time) []string {
+	missing := make([]string, 0)
+	if len(skus) == 0 {
+		for sku, entry := range q.Entries {
+			entry.ApprovedAt = &at
+			q.Entries[sku] = entry
+		}
+		return missing
+	}
+	for _, sku := range skus {
+		sku = strings.TrimSpace(sku)
+		entry, ok := q.Entries[sku]
+		if !ok {
+			missing = append(missing, sku)
+			continue
+		}
+		entry.ApprovedAt = &at
+		q.Entries[sku] = entry
+	}
+	return missing
+}
+
+// KeepApprovals copies onto q the approvals latest holds for the same prices. The
+// price step saves the quarantine it built from the file it loaded at the start of
+// the run; an approve-prices run in between would otherwise be overwritten.
+func (q *Quarantine) KeepApprovals(latest Quarantine) {
+	for sku, entry := range q.Entries {
+		approved, ok := latest.Entries[sku]
+		if entry.Approved() || !ok || !approved.Approved() || !approved.Matches(entry.Prices) {
+			continue
+		}
+		entry.ApprovedAt = approved.ApprovedAt
+		q.Entries[sku] = entry
+	}
+}
+
+// Sorted returns the entries ordered by SKU.
+func (q Quarantine) Sorted() []Entry {
+	entries := make([]Entry, 0, len(q.Entries))
+	for _, entry := range q.Entries {
+		entries = append(entries, entry)
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].SKU < entries[j].SKU })
+	return entries
+}
+
+// Load reads the quarantine at path. A missing file is an empty quarantine. A
+// corrupt one is reported and also yields an empty quarantine: the guard re-holds
+// anything still suspicious on the next run, so the only loss is the approvals.
+func Load(path string) (Quarantine, error) {
+	empty := Quarantine{Entries: map[string]Entry{}}
+	if path == "" {
+		return empty, nil
+	}
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return empty, nil
+		}
+		return empty, err
+	}
+
+	var quarantine Quarantine
+	if err := json.Unmarshal(raw, &quarantine); err != nil {
+		return empty, fmt.Errorf("price quarantine %s is unreadable: %w", path, err)
+	}
+	if quarantine.Entries == nil {
+		quarantine.Entries = map[string]Entry{}
+	}
+	return quarantine, nil
+}
+
+// Save writes the quarantine atomically, indented: it is read by people as well as
+// by the sync.
+func Save(path string, quarantine Quarantine) error {
+	if path == "" {
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	if quarantine.Entries == nil {
+		quarantine.Entries = map[string]Entry{}
+	}
+
+	payload, err := json.MarshalIndent(quarantine, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	temp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp*")
+	if err != nil {
+		return err
+	}
+	tempName := temp.Name()
+
+	if _, err := temp.Write(payload); err != nil {
+		temp.Close()
+		os.Remove(tempName)
+		return err
+	}
+	if err := temp.Close(); err != nil {
+		os.Remove(tempName)
+		return err
+	}
+	if err := os.Rename(tempName, path); err != nil {
+		os.Remove(tempName)
+		return err
+	}
+	return nil
+}
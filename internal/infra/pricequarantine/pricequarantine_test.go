@@ -0,0 +1,87 @@
+package pricequarantine
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// An approval covers the exact prices held; a later ERP change must be judged again.
+func TestApprovedMatchesExactPrices(t *testing.T) {
+	quarantine := Quarantine{Entries: map[string]Entry{
+		"HVM-1": {SKU: "HVM-1", Prices: map[string]float64{"ILS": 2336, "USD": 630}},
+	}}
+	prices := map[string]float64{"ILS": 2336, "USD": 630}
+
+	if quarantine.Approved("HVM-1", prices) {
+		t.Error("an entry nobody approved must not count as approved")
+	}
+	if missing := quarantine.Approve([]string{"HVM-1", "NOPE"}, time.Now()); len(missing) != 1 || missing[0] != "NOPE" {
+		t.Errorf("missing = %v, want [NOPE]", missing)
+	}
+	if !quarantine.Approved("HVM-1", prices) {
+		t.Error("approved prices must be released")
+	}
+	if quarantine.Approved("HVM-1", map[string]float64{"ILS": 23360, "USD": 630}) {
+		t.Error("a different price must not ride on an earlier approval")
+	}
+}
+
+// An approval saved while a price run was in flight survives the run's save, but only
+// for the prices it approved.
+func TestKeepApprovals(t *testing.T) {
+	approvedAt := time.Date(2026, 8, 4, 12, 0, 0, 0, time.UTC)
+	latest := Quarantine{Entries: map[string]Entry{
+		"HVM-1": {SKU: "HVM-1", Prices: map[string]float64{"ILS": 2336}, ApprovedAt: &approvedAt},
+		"HVM-2": {SKU: "HVM-2", Prices: map[string]float64{"ILS": 50}, ApprovedAt: &approvedAt},
+	}}
+	next := Quarantine{Entries: map[string]Entry{
+		"HVM-1": {SKU: "HVM-1", Prices: map[string]float64{"ILS": 2336}},
+		"HVM-2": {SKU: "HVM-2", Prices: map[string]float64{"ILS": 5000}},
+		"HVM-3": {SKU: "HVM-3", Prices: map[string]float64{"ILS": 1}},
+	}}
+	next.KeepApprovals(latest)
+
+	if !next.Approved("HVM-1", map[string]float64{"ILS": 2336}) {
+		t.Error("an approval of the same prices must be kept")
+	}
+	if next.Entries["HVM-2"].Approved() || next.Entries["HVM-3"].Approved() {
+		t.Errorf("approvals leaked to other prices or SKUs: %+v", next.Entries)
+	}
+}
+
+func TestSaveLoadRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "price-quarantine.json")
+	held := time.Date(2026, 8, 4, 12, 0, 0, 0, time.UTC)
+	quarantine := Quarantine{UpdatedAt: held, Entries: map[string]Entry{
+		"HVM-1": {SKU: "HVM-1", Prices: map[string]float64{"ILS": 2336}, Reasons: []string{"ILS: zero price"}, HeldAt: held},
+	}}
+	if err := Save(path, quarantine); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	entry := loaded.Entries["HVM-1"]
+	if !entry.HeldAt.Equal(held) || entry.Approved() || entry.Prices["ILS"] != 2336 {
+		t.Errorf("round trip lost data: %+v", entry)
+	}
+}
+
+// A corrupt file is reported but still yields a usable, empty quarantine: the guard
+// re-holds anything still suspicious, so only the approvals are lost.
+func TestLoadCorruptFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "price-quarantine.json")
+	if err := os.WriteFile(path, []byte("{not json"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	quarantine, err := Load(path)
+	if err == nil {
+		t.Error("expected an error for a corrupt file")
+	}
+	if quarantine.Entries == nil {
+		t.Error("a corrupt file must still yield a usable quarantine")
+	}
+}
@@ -0,0 +1,77 @@
+// Package pricerates loads the exchange rates the price step derives a missing
+// currency's price with: from a JSON file kept by hand, or from the ERP.
+package pricerates
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"shopify-exporter/internal/config"
+	"shopify-exporter/internal/domain/model"
+	"strings"
+)
+
+// Source returns the current exchange rates.
+type Source interface {
+	Rates(ctx context.Context) (model.ExchangeRates, error)
+}
+
+// Open returns the configured source, or nil when the conversion fallback is off.
+// erp builds the ERP source; it is only called for the erp source.
+func Open(cfg config.PriceConversionConfig, erp func() Source) (Source, error) {
+	if !cfg.Enabled() {
+		return nil, nil
+	}
+	switch cfg.Source {
+	case config.ConversionSourceFile:
+		return &fileSource{path: cfg.RatesFile}, nil
+	case config.ConversionSourceERP:
+		if erp == nil {
+			return nil, fmt.Errorf("exchange rate source erp is not available here")
+		}
+		return erp(), nil
+	default:
+		return nil, fmt.Errorf("unknown exchange rate source %q", cfg.Source)
+	}
+}
+
+// fileSource reads a JSON object of what one unit of each currency is worth in a
+// common reference, which is itself listed at 1:
+//
+//	{"ILS": 1, "USD": 3.65, "EUR": 3.98}
+type fileSource struct {
+	path string
+}
+
+// Rates re-reads the file on every call, so an edit takes effect on the next run.
+func (s *fileSource) Rates(ctx context.Context) (model.ExchangeRates, error) {
+	raw, err := os.ReadFile(s.path)
+	if err != nil {
+		return nil, fmt.Errorf("exchange rates %s: %w", s.path, err)
+	}
+	rates, err := parseFile(raw)
+	if err != nil {
+		return nil, fmt.Errorf("exchange rates %s: %w", s.path, err)
+	}
+	return rates, nil
+}
+
+func parseFile(raw []byte) (model.ExchangeRates, error) {
+	var entries map[string]float64
+	if err := json.Unmarshal(raw, &entries); err != nil {
+		return nil, err
+	}
+	rates := make(model.ExchangeRates, len(entries))
+	for currency, rate := range entries {
+		code := strings.ToUpper(strings.TrimSpace(currency))
+		if rate <= 0 {
+			return nil, fmt.Errorf("rate for %s must be above 0, got %v", code, rate)
+		}
+		if _, dup := rates[code]; dup {
+			return nil, fmt.Errorf("%s is listed twice", code)
+		}
+		rates[code] = rate
+	}
+	return rates, nil
+}
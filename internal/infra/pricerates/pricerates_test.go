@@ -0,0 +1,27 @@
+package pricerates
+
+import "testing"
+
+func TestParseFileNormalisesCodes(t *testing.T) {
+	rates, err := parseFile([]byte(`{"ils": 1, " USD ": 3.65}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, ok := rates.Convert(10, "USD", "ILS"); !ok || got != 36.5 {
+		t.Errorf("10 USD = %v ILS (ok=%t), want 36.5", got, ok)
+	}
+}
+
+// A zero rate would derive a zero price, which is worse than skipping the SKU.
+func TestParseFileRejectsBadRates(t *testing.T) {
+	for name, raw := range map[string]string{
+		"zero":      `{"ILS": 1, "USD": 0}`,
+		"negative":  `{"ILS": 1, "USD": -3.6}`,
+		"duplicate": `{"USD": 3.6, "usd": 3.7}`,
+		"not json":  `ILS=1`,
+	} {
+		if _, err := parseFile([]byte(raw)); err == nil {
+			t.Errorf("%s: parseFile(%s) = nil error", name, raw)
+		}
+	}
+}
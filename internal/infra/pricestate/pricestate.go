@@ -0,0 +1,117 @@
+// Package pricestate persists the prices the last successful price sync pushed, so a
+// frequent run can push only the SKUs whose prices actually moved.
+//
+// Like stockstate it records our side, not Shopify's: the resolved target per SKU,
+// after price list selection, discounts and the sales calendar. A sale starting or
+// ending therefore shows up as a move on the first run inside the new window, with no
+// scheduling of its own. Anything changed in Shopify by hand is for the periodic full
+// run to put back.
+package pricestate
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Entry is one SKU's pushed prices and compare-at prices, keyed by currency.
+type Entry struct {
+	Prices    map[string]float64 `json:"prices"`
+	CompareAt map[string]float64 `json:"compareAt,omitempty"`
+}
+
+// Snapshot is the last pushed state.
+type Snapshot struct {
+	UpdatedAt time.Time        `json:"updatedAt"`
+	Prices    map[string]Entry `json:"prices"`
+}
+
+// Changed reports whether sku's target differs from the snapshot, to the cent. A SKU
+// the snapshot has never seen counts as changed, so a first run pushes everything.
+func (s Snapshot) Changed(sku string, target Entry) bool {
+	previous, ok := s.Prices[sku]
+	if !ok {
+		return true
+	}
+	return !sameAmounts(previous.Prices, target.Prices) || !sameAmounts(previous.CompareAt, target.CompareAt)
+}
+
+func sameAmounts(a, b map[string]float64) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for currency, amount := range a {
+		other, ok := b[currency]
+		if !ok || math.Abs(other-amount) >= 0.005 {
+			return false
+		}
+	}
+	return true
+}
+
+// Load reads the snapshot at path. A missing file yields an empty snapshot, which
+// makes the next run a full push. A corrupt file is reported and also yields an empty
+// snapshot: pushing everything is always safe.
+func Load(path string) (Snapshot, error) {
+	if path == "" {
+		return Snapshot{}, nil
+	}
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return Snapshot{}, nil
+		}
+		return Snapshot{}, err
+	}
+
+	var snapshot Snapshot
+	if err := json.Unmarshal(raw, &snapshot); err != nil {
+		return Snapshot{}, fmt.Errorf("price state %s is unreadable: %w", path, err)
+	}
+	if snapshot.Prices == nil {
+		snapshot.Prices = map[string]Entry{}
+	}
+	return snapshot, nil
+}
+
+// Save writes the snapshot atomically, for the same reason stockstate.Save does.
+func Save(path string, prices map[string]Entry, updatedAt time.Time) error {
+	if path == "" {
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+
+	payload, err := json.Marshal(Snapshot{
+		UpdatedAt: updatedAt,
+		Prices:    prices,
+	})
+	if err != nil {
+		return err
+	}
+
+	temp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp*")
+	if err != nil {
+		return err
+	}
+	tempName := temp.Name()
+
+	if _, err := temp.Write(payload); err != nil {
+		temp.Close()
+		os.Remove(tempName)
+		return err
+	}
+	if err := temp.Close(); err != nil {
+		os.Remove(tempName)
+		return err
+	}
+	if err := os.Rename(tempName, path); err != nil {
+		os.Remove(tempName)
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,67 @@
+package pricestate
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestChangedComparesToTheCent(t *testing.T) {
+	snapshot := Snapshot{Prices: map[string]Entry{
+		"HVM-1": {Prices: map[string]float64{"ILS": 99.9, "USD": 27}, CompareAt: map[string]float64{"ILS": 199.8}},
+	}}
+
+	same := Entry{Prices: map[string]float64{"ILS": 99.900001, "USD": 27}, CompareAt: map[string]float64{"ILS": 199.8}}
+	if snapshot.Changed("HVM-1", same) {
+		t.Error("float noise below a cent must not count as a change")
+	}
+	moved := Entry{Prices: map[string]float64{"ILS": 99.9, "USD": 27.5}, CompareAt: map[string]float64{"ILS": 199.8}}
+	if !snapshot.Changed("HVM-1", moved) {
+		t.Error("a moved USD price must count as a change")
+	}
+	// A sale ending clears the compare-at price without touching the price itself.
+	saleEnded := Entry{Prices: map[string]float64{"ILS": 99.9, "USD": 27}}
+	if !snapshot.Changed("HVM-1", saleEnded) {
+		t.Error("a cleared compare-at price must count as a change")
+	}
+	if !snapshot.Changed("CMG-28", same) {
+		t.Error("a sku the snapshot has never seen must count as changed")
+	}
+}
+
+func TestLoadCorruptFileReportsAndYieldsEmpty(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "corrupt.json")
+	if err := os.WriteFile(path, []byte(`{"prices": {"HVM-1": `), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	snapshot, err := Load(path)
+	if err == nil {
+		t.Fatal("a truncated snapshot must be reported so the caller can warn")
+	}
+	if len(snapshot.Prices) != 0 {
+		t.Errorf("a corrupt snapshot must yield no prices, got %d", len(snapshot.Prices))
+	}
+}
+
+func TestSaveThenLoadRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "nested", "price-state.json")
+	prices := map[string]Entry{"HVM-1": {Prices: map[string]float64{"ILS": 49.9}}}
+	updatedAt := time.Date(2026, 8, 4, 9, 30, 0, 0, time.UTC)
+
+	if err := Save(path, prices, updatedAt); err != nil {
+		t.Fatalf("Save must create missing directories: %v", err)
+	}
+
+	snapshot, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if snapshot.Changed("HVM-1", prices["HVM-1"]) {
+		t.Error("a saved entry must read back unchanged")
+	}
+	if !snapshot.UpdatedAt.Equal(updatedAt) {
+		t.Errorf("UpdatedAt = %s, want %s", snapshot.UpdatedAt, updatedAt)
+	}
+}
@@ -0,0 +1,200 @@
+// Package salescalendar loads scheduled sales from wherever the merchandising team
+// keeps them: a JSON file, a MySQL table, or the ERP.
+//
+// Windows are wall-clock times in REPORT_TIMEZONE. Nothing here decides whether a
+// sale is running; the price step asks each sale on every run, so sales start and end
+// at the first price run after their window moves: within a quarter of an hour with
+// the price-delta cron tick (deploy/run-shopify-exporter.sh), at the daily full run
+// without it. There is no scheduler of its own.
+package salescalendar
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"shopify-exporter/internal/config"
+	"shopify-exporter/internal/domain/model"
+	"shopify-exporter/internal/infra/mysql"
+	"strings"
+	"time"
+)
+
+// Source returns every sale in the calendar, past and future alike.
+type Source interface {
+	Sales(ctx context.Context) ([]model.Sale, error)
+}
+
+// Open returns the configured source, or nil when no calendar is configured. erp
+// builds the ERP source for the calendar's location; it is only called for the erp
+// source.
+func Open(cfg config.SalesCalendarConfig, erp func(*time.Location) Source) (Source, error) {
+	if !cfg.Enabled() {
+		return nil, nil
+	}
+	location, err := time.LoadLocation(strings.TrimSpace(cfg.Timezone))
+	if err != nil {
+		return nil, fmt.Errorf("sales calendar timezone %q: %w", cfg.Timezone, err)
+	}
+	switch cfg.Source {
+	case config.SalesSourceFile:
+		return &fileSource{path: cfg.File, location: location}, nil
+	case config.SalesSourceMySQL:
+		return &mysqlSource{cfg: cfg.Mysql, table: cfg.Table, location: location}, nil
+	case config.SalesSourceERP:
+		if erp == nil {
+			return nil, fmt.Errorf("sales calendar source erp is not available here")
+		}
+		return erp(location), nil
+	default:
+		return nil, fmt.Errorf("unknown sales calendar source %q", cfg.Source)
+	}
+}
+
+// fileEntry is one sale in the JSON file:
+//
+//	[{"name": "Summer", "sku_prefixes": ["SUM-"], "percent": 20, "markets": ["il"],
+//	  "start": "2026-07-01 00:00", "end": "2026-07-15 00:00"}]
+type fileEntry struct {
+	Name        string   `json:"name"`
+	Skus        []string `json:"skus,omitempty"`
+	SkuPrefixes []string `json:"sku_prefixes,omitempty"`
+	Percent     float64  `json:"percent"`
+	Markets     []string `json:"markets,omitempty"`
+	Start       string   `json:"start"`
+	End         string   `json:"end"`
+}
+
+type fileSource struct {
+	path     string
+	location *time.Location
+}
+
+// Sales re-reads the file on every call, so an edit takes effect on the next run.
+func (s *fileSource) Sales(ctx context.Context) ([]model.Sale, error) {
+	raw, err := os.ReadFile(s.path)
+	if err != nil {
+		return nil, fmt.Errorf("sales calendar %s: %w", s.path, err)
+	}
+	sales, err := parseFile(raw, s.location)
+	if err != nil {
+		return nil, fmt.Errorf("sales calendar %s: %w", s.path, err)
+	}
+	return sales, nil
+}
+
+func parseFile(raw []byte, location *time.Location) ([]model.Sale, error) {
+	var entries []fileEntry
+	decoder := json.NewDecoder(strings.NewReader(string(raw)))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&entries); err != nil {
+		return nil, err
+	}
+	sales := make([]model.Sale, 0, len(entries))
+	for i, entry := range entries {
+		start, err := time.ParseInLocation(model.SaleTimeLayout, strings.TrimSpace(entry.Start), location)
+		if err != nil {
+			return nil, fmt.Errorf("sale #%d %q start: %w", i+1, entry.Name, err)
+		}
+		end, err := time.ParseInLocation(model.SaleTimeLayout, strings.TrimSpace(entry.End), location)
+		if err != nil {
+			return nil, fmt.Errorf("sale #%d %q end: %w", i+1, entry.Name, err)
+		}
+		sale := model.Sale{
+			Name:        strings.TrimSpace(entry.Name),
+			Skus:        trimAll(entry.Skus),
+			SkuPrefixes: trimAll(entry.SkuPrefixes),
+			Percent:     entry.Percent,
+			Markets:     trimAll(entry.Markets),
+			Start:       start,
+			End:         end,
+		}
+		if err := sale.Validate(); err != nil {
+			return nil, err
+		}
+		sales = append(sales, sale)
+	}
+	return sales, nil
+}
+
+// mysqlSource reads a table of the shape
+//
+//	name VARCHAR, skus TEXT, sku_prefixes TEXT, percent DECIMAL, markets VARCHAR,
+//	starts_at DATETIME, ends_at DATETIME
+//
+// where skus, sku_prefixes and markets are comma separated and may be NULL.
+type mysqlSource struct {
+	cfg      config.MysqlConfig
+	table    string
+	location *time.Location
+}
+
+// Sales opens a connection for the one query a run makes and closes it again; the
+// price step has no other use for the database.
+func (s *mysqlSource) Sales(ctx context.Context) ([]model.Sale, error) {
+	db, err := mysql.New(s.cfg)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	query := fmt.Sprintf("SELECT name, skus, sku_prefixes, percent, markets, starts_at, ends_at FROM %s", s.table)
+	rows, err := db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("sales calendar %s: %w", s.table, err)
+	}
+	defer rows.Close()
+
+	sales := make([]model.Sale, 0)
+	for rows.Next() {
+		var (
+			name                  string
+			skus, prefixes, marks *string
+			percent               float64
+			start, end            time.Time
+		)
+		if err := rows.Scan(&name, &skus, &prefixes, &percent, &marks, &start, &end); err != nil {
+			return nil, fmt.Errorf("sales calendar %s: %w", s.table, err)
+		}
+		sale := model.Sale{
+			Name:        strings.TrimSpace(name),
+			Skus:        splitList(skus),
+			SkuPrefixes: splitList(prefixes),
+			Percent:     percent,
+			Markets:     splitList(marks),
+			Start:       wallClock(start, s.location),
+			End:         wallClock(end, s.location),
+		}
+		if err := sale.Validate(); err != nil {
+			return nil, fmt.Errorf("sales calendar %s: %w", s.table, err)
+		}
+		sales = append(sales, sale)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("sales calendar %s: %w", s.table, err)
+	}
+	return sales, nil
+}
+
+// wallClock reinterprets a DATETIME, which the driver hands back labelled UTC, as
+// the same wall-clock reading in location.
+func wallClock(t time.Time, location *time.Location) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), location)
+}
+
+func splitList(raw *string) []string {
+	if raw == nil {
+		return nil
+	}
+	return trimAll(strings.Split(*raw, ","))
+}
+
+func trimAll(values []string) []string {
+	trimmed := make([]string, 0, len(values))
+	for _, value := range values {
+		if value = strings.TrimSpace(value); value != "" {
+			trimmed = append(trimmed, value)
+		}
+	}
+	return trimmed
+}
@@ -0,0 +1,61 @@
+package salescalendar
+
+import (
+	"testing"
+	"time"
+)
+
+// TestParseFileReadsWallClock checks that windows are read in the calendar's zone,
+// not UTC: a sale starting at midnight in Jerusalem starts at 21:00 UTC in summer.
+func TestParseFileReadsWallClock(t *testing.T) {
+	location, err := time.LoadLocation("Asia/Jerusalem")
+	if err != nil {
+		t.Skipf("no tzdata: %v", err)
+	}
+	raw := []byte(`[{"name": " Summer ", "sku_prefixes": ["SUM-", " "], "percent": 20, "markets": ["il"],
+		"start": "2026-07-01 00:00", "end": "2026-07-15 00:00"}]`)
+
+	sales, err := parseFile(raw, location)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sales) != 1 {
+		t.Fatalf("got %d sales, want 1", len(sales))
+	}
+	sale := sales[0]
+	if sale.Name != "Summer" || len(sale.SkuPrefixes) != 1 || sale.SkuPrefixes[0] != "SUM-" {
+		t.Errorf("sale not trimmed: %+v", sale)
+	}
+	if want := time.Date(2026, 6, 30, 21, 0, 0, 0, time.UTC); !sale.Start.Equal(want) {
+		t.Errorf("start = %v, want %v", sale.Start.UTC(), want)
+	}
+}
+
+// TestParseFileRejectsBadEntries refuses a calendar with an entry that could not be
+// applied as written, rather than dropping it and ending that sale early.
+func TestParseFileRejectsBadEntries(t *testing.T) {
+	tests := []struct {
+		name string
+		raw  string
+	}{
+		{"unknown field", `[{"name": "x", "skus": ["A"], "percent": 10, "start": "2026-07-01 00:00", "end": "2026-07-02 00:00", "pct": 1}]`},
+		{"bad date", `[{"name": "x", "skus": ["A"], "percent": 10, "start": "01/07/2026", "end": "2026-07-02 00:00"}]`},
+		{"ends before start", `[{"name": "x", "skus": ["A"], "percent": 10, "start": "2026-07-02 00:00", "end": "2026-07-01 00:00"}]`},
+		{"no skus", `[{"name": "x", "percent": 10, "start": "2026-07-01 00:00", "end": "2026-07-02 00:00"}]`},
+		{"percent out of range", `[{"name": "x", "skus": ["A"], "percent": 100, "start": "2026-07-01 00:00", "end": "2026-07-02 00:00"}]`},
+	}
+	for _, tt := range tests {
+		if _, err := parseFile([]byte(tt.raw), time.UTC); err == nil {
+			t.Errorf("%s: expected an error", tt.name)
+		}
+	}
+}
+
+// TestWallClock keeps the DATETIME reading and only changes the zone.
+func TestWallClock(t *testing.T) {
+	location := time.FixedZone("IDT", 3*60*60)
+	got := wallClock(time.Date(2026, 7, 1, 0, 0, 0, 0, time.UTC), location)
+	if want := time.Date(2026, 6, 30, 21, 0, 0, 0, time.UTC); !got.Equal(want) {
+		t.Errorf("wallClock = %v, want %v", got.UTC(), want)
+	}
+}
@@ -0,0 +1,69 @@
+package stockstate
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// AlertState is the stock level each SKU was last alerted at. An alert fires when a
+// SKU's level changes, not on every run it stays there: at a five-minute cadence a
+// SKU out of stock all weekend would otherwise alert six hundred times.
+type AlertState struct {
+	UpdatedAt time.Time `json:"updatedAt"`
+	// Levels maps a SKU to its alert level ("low" or "out"). A SKU above its
+	// threshold is absent.
+	Levels map[string]string `json:"levels"`
+	// Alerted maps a SKU to the time each alert kind last fired for it, for the
+	// alert cooldown.
+	Alerted map[string]map[string]time.Time `json:"alerted,omitempty"`
+}
+
+// LastAlerted is when sku last raised an alert of kind, or the zero time.
+func (s AlertState) LastAlerted(sku, kind string) time.Time {
+	return s.Alerted[sku][kind]
+}
+
+// Seeded reports whether the state was ever saved. Without it every SKU already low
+// would alert at once, so the first run only records the levels.
+func (s AlertState) Seeded() bool {
+	return !s.UpdatedAt.IsZero()
+}
+
+// LoadAlerts reads the alert state at path. A missing file is an unseeded state; a
+// corrupt one is reported and also yields an unseeded state.
+func LoadAlerts(path string) (AlertState, error) {
+	if path == "" {
+		return AlertState{}, nil
+	}
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return AlertState{}, nil
+		}
+		return AlertState{}, err
+	}
+
+	var state AlertState
+	if err := json.Unmarshal(raw, &state); err != nil {
+		return AlertState{}, fmt.Errorf("stock alert state %s is unreadable: %w", path, err)
+	}
+	return state, nil
+}
+
+// SaveAlerts writes the alert state atomically.
+func SaveAlerts(path string, state AlertState) error {
+	if path == "" {
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	payload, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+	return writeAtomic(path, payload)
+}
@@ -0,0 +1,38 @@
+package stockstate
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestAlertStateRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "stock-alerts.json")
+	state, err := LoadAlerts(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if state.Seeded() {
+		t.Fatal("a missing state must be unseeded")
+	}
+
+	at := time.Date(2026, 8, 4, 9, 0, 0, 0, time.UTC)
+	if err := SaveAlerts(path, AlertState{UpdatedAt: at, Levels: map[string]string{"HVM-1": "out"}}); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := LoadAlerts(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !loaded.Seeded() || loaded.Levels["HVM-1"] != "out" {
+		t.Errorf("loaded = %+v, want seeded with HVM-1 out", loaded)
+	}
+
+	if err := os.WriteFile(path, []byte("{"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if corrupt, err := LoadAlerts(path); err == nil || corrupt.Seeded() {
+		t.Errorf("corrupt state -> %+v, %v; want an error and an unseeded state", corrupt, err)
+	}
+}
@@ -0,0 +1,67 @@
+package stockstate
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Cursor is where the next incremental ERP stock read starts. It is saved together
+// with the snapshot and only after a successful push: a cursor that ran ahead of the
+// snapshot would skip changes the snapshot never recorded.
+type Cursor struct {
+	// Since is the ERP's high-water mark, passed back as "changed since".
+	Since     time.Time `json:"since"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// LoadCursor reads the cursor at path. A missing file yields the zero cursor, which
+// makes the next delta read the full feed; so does a corrupt one, with an error the
+// caller can warn about.
+func LoadCursor(path string) (Cursor, error) {
+	if path == "" {
+		return Cursor{}, nil
+	}
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return Cursor{}, nil
+		}
+		return Cursor{}, err
+	}
+
+	var cursor Cursor
+	if err := json.Unmarshal(raw, &cursor); err != nil {
+		return Cursor{}, fmt.Errorf("stock cursor %s is unreadable: %w", path, err)
+	}
+	return cursor, nil
+}
+
+// SaveCursor writes the cursor atomically.
+func SaveCursor(path string, since, updatedAt time.Time) error {
+	if path == "" {
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	payload, err := json.Marshal(Cursor{Since: since.UTC(), UpdatedAt: updatedAt})
+	if err != nil {
+		return err
+	}
+	return writeAtomic(path, payload)
+}
+
+// RemoveCursor drops the cursor so the next delta reads the full feed. A missing file
+// is not an error.
+func RemoveCursor(path string) error {
+	if path == "" {
+		return nil
+	}
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,48 @@
+package stockstate
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestCursorRoundTripAndRemove(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "nested", "stock-cursor.json")
+	since := time.Date(2026, 8, 4, 9, 25, 0, 0, time.FixedZone("IDT", 3*3600))
+
+	if cursor, err := LoadCursor(path); err != nil || !cursor.Since.IsZero() {
+		t.Fatalf("missing cursor = %+v, %v; want zero and no error", cursor, err)
+	}
+	if err := SaveCursor(path, since, since); err != nil {
+		t.Fatal(err)
+	}
+	cursor, err := LoadCursor(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !cursor.Since.Equal(since) {
+		t.Errorf("Since = %s, want %s", cursor.Since, since)
+	}
+
+	if err := RemoveCursor(path); err != nil {
+		t.Fatal(err)
+	}
+	if err := RemoveCursor(path); err != nil {
+		t.Errorf("removing a missing cursor must not fail: %v", err)
+	}
+}
+
+func TestLoadCorruptCursorReportsAndYieldsZero(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "stock-cursor.json")
+	if err := os.WriteFile(path, []byte(`{"since": "yesterday"`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	cursor, err := LoadCursor(path)
+	if err == nil {
+		t.Fatal("a corrupt cursor must be reported")
+	}
+	if !cursor.Since.IsZero() {
+		t.Errorf("a corrupt cursor must yield the zero cursor, got %s", cursor.Since)
+	}
+}
@@ -0,0 +1,78 @@
+package stockstate
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// maxDriftAudits bounds the history: a daily audit keeps about three months.
+const maxDriftAudits = 90
+
+// DriftAudit is one drift audit's totals.
+type DriftAudit struct {
+	At     time.Time      `json:"at"`
+	Total  int            `json:"total"`
+	ByKind map[string]int `json:"byKind,omitempty"`
+}
+
+// DriftHistory is the audits so far, oldest first. It is what tells a one-off spike
+// from drift that keeps growing.
+type DriftHistory struct {
+	Audits []DriftAudit `json:"audits"`
+}
+
+// Last returns the most recent audit, if any.
+func (h DriftHistory) Last() (DriftAudit, bool) {
+	if len(h.Audits) == 0 {
+		return DriftAudit{}, false
+	}
+	return h.Audits[len(h.Audits)-1], true
+}
+
+// Append adds an audit, dropping the oldest beyond maxDriftAudits.
+func (h DriftHistory) Append(audit DriftAudit) DriftHistory {
+	audits := append(append([]DriftAudit(nil), h.Audits...), audit)
+	if len(audits) > maxDriftAudits {
+		audits = audits[len(audits)-maxDriftAudits:]
+	}
+	return DriftHistory{Audits: audits}
+}
+
+// LoadDriftHistory reads the history at path. A missing file is an empty history; a
+// corrupt one is reported and also yields an empty history.
+func LoadDriftHistory(path string) (DriftHistory, error) {
+	if path == "" {
+		return DriftHistory{}, nil
+	}
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return DriftHistory{}, nil
+		}
+		return DriftHistory{}, err
+	}
+
+	var history DriftHistory
+	if err := json.Unmarshal(raw, &history); err != nil {
+		return DriftHistory{}, fmt.Errorf("stock drift history %s is unreadable: %w", path, err)
+	}
+	return history, nil
+}
+
+// SaveDriftHistory writes the history atomically.
+func SaveDriftHistory(path string, history DriftHistory) error {
+	if path == "" {
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	payload, err := json.Marshal(history)
+	if err != nil {
+		return err
+	}
+	return writeAtomic(path, payload)
+}
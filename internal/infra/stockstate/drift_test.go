@@ -0,0 +1,37 @@
+package stockstate
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestDriftHistoryKeepsTheLatestAudits(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "stock-drift.json")
+	history, err := LoadDriftHistory(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := history.Last(); ok {
+		t.Fatal("a missing history must have no last audit")
+	}
+
+	start := time.Date(2026, 8, 1, 6, 0, 0, 0, time.UTC)
+	for i := 0; i < maxDriftAudits+5; i++ {
+		history = history.Append(DriftAudit{At: start.Add(time.Duration(i) * time.Hour), Total: i})
+	}
+	if err := SaveDriftHistory(path, history); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded, err := LoadDriftHistory(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(loaded.Audits) != maxDriftAudits {
+		t.Errorf("audits = %d, want %d", len(loaded.Audits), maxDriftAudits)
+	}
+	if last, _ := loaded.Last(); last.Total != maxDriftAudits+4 {
+		t.Errorf("last total = %d, want %d", last.Total, maxDriftAudits+4)
+	}
+}
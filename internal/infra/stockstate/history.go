@@ -0,0 +1,182 @@
+package stockstate
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// runIDLayout matches the timestamp in the job log file names, so a snapshot and the
+// log of the run that wrote it are easy to pair.
+const runIDLayout = "20060102-150405"
+
+// ErrSnapshotNotFound means the history has no snapshot with the requested run id.
+var ErrSnapshotNotFound = errors.New("stock snapshot not found in history")
+
+// NewRunID names a snapshot after the moment it was written, in UTC.
+func NewRunID(at time.Time) string {
+	return at.UTC().Format(runIDLayout)
+}
+
+// NextRunID is NewRunID made unique in the history directory: a run id already taken,
+// by two runs in the same second, gets a suffix. The live snapshot must carry the id
+// it is archived under, or stock-history would not know which entry is current.
+func NextRunID(dir string, at time.Time) string {
+	return uniqueRunID(dir, NewRunID(at))
+}
+
+func uniqueRunID(dir, runID string) string {
+	if dir == "" {
+		return runID
+	}
+	id := runID
+	for n := 2; ; n++ {
+		if _, err := os.Stat(historyPath(dir, id)); os.IsNotExist(err) {
+			return id
+		}
+		id = fmt.Sprintf("%s-%d", runID, n)
+	}
+}
+
+// Archive copies the snapshot into the history directory as <RunID>.json and drops
+// the oldest beyond keep. A run id already in the history gets a suffix rather than
+// overwriting it; name the live snapshot with NextRunID so that never happens.
+// keep <= 0 disables the history.
+func Archive(dir string, snapshot Snapshot, keep int) (string, error) {
+	if dir == "" || keep <= 0 {
+		return "", nil
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", err
+	}
+	if snapshot.RunID == "" {
+		snapshot.RunID = NewRunID(snapshot.UpdatedAt)
+	}
+	snapshot.RunID = uniqueRunID(dir, snapshot.RunID)
+	if err := SaveSnapshot(historyPath(dir, snapshot.RunID), snapshot); err != nil {
+		return "", err
+	}
+	return snapshot.RunID, prune(dir, keep)
+}
+
+// History lists the archived snapshots, oldest first.
+func History(dir string) ([]Snapshot, error) {
+	ids, err := historyIDs(dir)
+	if err != nil {
+		return nil, err
+	}
+	snapshots := make([]Snapshot, 0, len(ids))
+	for _, id := range ids {
+		snapshot, err := Load(historyPath(dir, id))
+		if err != nil {
+			return nil, err
+		}
+		snapshot.RunID = id
+		snapshots = append(snapshots, snapshot)
+	}
+	return snapshots, nil
+}
+
+// LoadArchived reads one snapshot from the history.
+func LoadArchived(dir, runID string) (Snapshot, error) {
+	runID = strings.TrimSpace(runID)
+	if runID == "" || strings.ContainsAny(runID, `/\`) {
+		return Snapshot{}, fmt.Errorf("%w: %q", ErrSnapshotNotFound, runID)
+	}
+	path := historyPath(dir, runID)
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return Snapshot{}, fmt.Errorf("%w: %s", ErrSnapshotNotFound, runID)
+	}
+	snapshot, err := Load(path)
+	if err != nil {
+		return Snapshot{}, err
+	}
+	snapshot.RunID = runID
+	return snapshot, nil
+}
+
+// MarkRestored records on a history snapshot that a rollback re-pushed it.
+func MarkRestored(dir, runID string, at time.Time) error {
+	snapshot, err := LoadArchived(dir, runID)
+	if err != nil {
+		return err
+	}
+	snapshot.RestoredAt = &at
+	return SaveSnapshot(historyPath(dir, runID), snapshot)
+}
+
+// KeyChange is one Key whose quantity differs between two snapshots. A Key missing
+// from one side has Known false on that side.
+type KeyChange struct {
+	Key         string
+	Before      int
+	BeforeKnown bool
+	After       int
+	AfterKnown  bool
+}
+
+// Diff lists every Key whose quantity differs from before to after, sorted by Key.
+func Diff(before, after Snapshot) []KeyChange {
+	changes := make([]KeyChange, 0)
+	for key, quantity := range before.Quantities {
+		next, ok := after.Quantities[key]
+		if ok && next == quantity {
+			continue
+		}
+		changes = append(changes, KeyChange{Key: key, Before: quantity, BeforeKnown: true, After: next, AfterKnown: ok})
+	}
+	for key, quantity := range after.Quantities {
+		if _, ok := before.Quantities[key]; !ok {
+			changes = append(changes, KeyChange{Key: key, After: quantity, AfterKnown: true})
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Key < changes[j].Key })
+	return changes
+}
+
+func historyPath(dir, runID string) string {
+	return filepath.Join(dir, runID+".json")
+}
+
+// historyIDs lists the run ids in the directory, oldest first. The ids are
+// timestamps, so name order is time order.
+func historyIDs(dir string) ([]string, error) {
+	if dir == "" {
+		return nil, nil
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	ids := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(name, ".json") {
+			continue
+		}
+		ids = append(ids, strings.TrimSuffix(name, ".json"))
+	}
+	sort.Strings(ids)
+	return ids, nil
+}
+
+func prune(dir string, keep int) error {
+	ids, err := historyIDs(dir)
+	if err != nil {
+		return err
+	}
+	for len(ids) > keep {
+		if err := os.Remove(historyPath(dir, ids[0])); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		ids = ids[1:]
+	}
+	return nil
+}
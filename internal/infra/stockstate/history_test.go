@@ -0,0 +1,81 @@
+package stockstate
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestArchiveKeepsTheLatestSnapshots(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "stock-history")
+	start := time.Date(2026, 8, 4, 6, 0, 0, 0, time.UTC)
+	for i := 0; i < 5; i++ {
+		snapshot := Snapshot{UpdatedAt: start.Add(time.Duration(i) * time.Minute), Quantities: map[string]int{"HVM-1": i}}
+		if _, err := Archive(dir, snapshot, 3); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Same second as the last one: kept apart, not overwritten.
+	id, err := Archive(dir, Snapshot{UpdatedAt: start.Add(4 * time.Minute), Quantities: map[string]int{"HVM-1": 9}}, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id != "20260804-060400-2" {
+		t.Errorf("colliding run id = %q", id)
+	}
+	if next := NextRunID(dir, start.Add(4*time.Minute)); next != "20260804-060400-3" {
+		t.Errorf("next run id = %q, want the first free suffix", next)
+	}
+
+	history, err := History(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ids []string
+	for _, snapshot := range history {
+		ids = append(ids, snapshot.RunID)
+	}
+	if len(ids) != 3 || ids[0] != "20260804-060300" || ids[2] != "20260804-060400-2" {
+		t.Errorf("history = %v, want the three newest, oldest first", ids)
+	}
+
+	if err := MarkRestored(dir, "20260804-060300", start); err != nil {
+		t.Fatal(err)
+	}
+	restored, err := LoadArchived(dir, "20260804-060300")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if restored.RestoredAt == nil || !restored.RestoredAt.Equal(start) || restored.Quantities["HVM-1"] != 3 {
+		t.Errorf("restored = %+v", restored)
+	}
+	if _, err := LoadArchived(dir, "20260804-060000"); !errors.Is(err, ErrSnapshotNotFound) {
+		t.Errorf("pruned snapshot -> %v, want ErrSnapshotNotFound", err)
+	}
+	if _, err := LoadArchived(dir, "../stock-state"); !errors.Is(err, ErrSnapshotNotFound) {
+		t.Errorf("path in run id -> %v, want ErrSnapshotNotFound", err)
+	}
+}
+
+func TestDiff(t *testing.T) {
+	before := Snapshot{Quantities: map[string]int{"A-1": 5, "B-2": 3, "GONE": 1}}
+	after := Snapshot{Quantities: map[string]int{"A-1": 5, "B-2": 0, "NEW@Store": 4}}
+	got := Diff(before, after)
+	want := []KeyChange{
+		{Key: "B-2", Before: 3, BeforeKnown: true, After: 0, AfterKnown: true},
+		{Key: "GONE", Before: 1, BeforeKnown: true},
+		{Key: "NEW@Store", After: 4, AfterKnown: true},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("Diff = %+v, want %+v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("Diff[%d] = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+	if sku, location := SplitKey("NEW@Store"); sku != "NEW" || location != "Store" {
+		t.Errorf("SplitKey = %q, %q", sku, location)
+	}
+}
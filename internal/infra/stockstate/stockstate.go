@@ -14,23 +14,46 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 	"time"
 )
 
 // Snapshot is the last pushed ERP state.
 type Snapshot struct {
 	UpdatedAt time.Time `json:"updatedAt"`
-	// Quantities maps SKU to the quantity that was last pushed successfully.
+	// Quantities maps a Key to the quantity that was last pushed successfully.
 	Quantities map[string]int `json:"quantities"`
+	// RunID names the snapshot in the history; see NewRunID.
+	RunID string `json:"runId,omitempty"`
+	// RollbackOf is set on a snapshot written by a rollback: the run it restored.
+	RollbackOf string `json:"rollbackOf,omitempty"`
+	// RestoredAt is set on a history snapshot when a rollback last re-pushed it.
+	RestoredAt *time.Time `json:"restoredAt,omitempty"`
 }
 
-// Changed reports whether sku's target differs from the snapshot. A SKU the snapshot
+// Key identifies one pushed quantity: the bare SKU for the primary location, which
+// keeps snapshots written before multi-location support valid, and "SKU@Location"
+// for a mapped location.
+func Key(sku, location string) string {
+	if location == "" {
+		return sku
+	}
+	return sku + "@" + location
+}
+
+// SplitKey is the inverse of Key. SKUs never contain "@"; location names may.
+func SplitKey(key string) (sku, location string) {
+	sku, location, _ = strings.Cut(key, "@")
+	return sku, location
+}
+
+// Changed reports whether key's target differs from the snapshot. A key the snapshot
 // has never seen counts as changed, so a first run pushes everything.
-func (s Snapshot) Changed(sku string, quantity int) bool {
+func (s Snapshot) Changed(key string, quantity int) bool {
 	if s.Quantities == nil {
 		return true
 	}
-	previous, ok := s.Quantities[sku]
+	previous, ok := s.Quantities[key]
 	return !ok || previous != quantity
 }
 
@@ -63,21 +86,27 @@ func Load(path string) (Snapshot, error) {
 // Save writes the snapshot atomically: a crash mid-write must not leave a truncated
 // file behind, because the next run would then diff against nonsense.
 func Save(path string, quantities map[string]int, updatedAt time.Time) error {
+	return SaveSnapshot(path, Snapshot{UpdatedAt: updatedAt, Quantities: quantities})
+}
+
+// SaveSnapshot is Save with the history fields.
+func SaveSnapshot(path string, snapshot Snapshot) error {
 	if path == "" {
 		return nil
 	}
 	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
 		return err
 	}
-
-	payload, err := json.Marshal(Snapshot{
-		UpdatedAt:  updatedAt,
-		Quantities: quantities,
-	})
+	payload, err := json.Marshal(snapshot)
 	if err != nil {
 		return err
 	}
+	return writeAtomic(path, payload)
+}
 
+// writeAtomic writes payload through a temp file and a rename, so a reader sees the
+// old file or the new one, never half of either.
+func writeAtomic(path string, payload []byte) error {
 	temp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp*")
 	if err != nil {
 		return err
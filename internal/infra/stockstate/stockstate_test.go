@@ -80,6 +80,15 @@ func TestChanged(t *testing.T) {
 	}
 }
 
+func TestKey(t *testing.T) {
+	if got := Key("CMG-28", ""); got != "CMG-28" {
+		t.Errorf("primary location key = %q, want the bare SKU", got)
+	}
+	if got := Key("CMG-28", "Tel Aviv Store"); got != "CMG-28@Tel Aviv Store" {
+		t.Errorf("location key = %q", got)
+	}
+}
+
 func TestSaveLeavesNoTempFilesBehind(t *testing.T) {
 	dir := t.TempDir()
 	path := filepath.Join(dir, "stock-state.json")
@@ -67,7 +67,8 @@ func (c SMTPConfig) timeout() time.Duration {
 }
 
 // SendEmail renders the summary and delivers it as a multipart message: an HTML
-// body plus the full change list as a CSV attachment.
+// body plus the full change list as a CSV attachment (and the data-quality list as
+// a second one when the validation found anything).
 func SendEmail(summary Summary, cfg SMTPConfig, opts RenderOptions) error {
 	if err := cfg.Validate(); err != nil {
 		return err
@@ -103,22 +104,26 @@ func buildMessage(summary Summary, cfg SMTPConfig, opts RenderOptions) []byte {
 	b.WriteString(wrapBase64([]byte(summary.HTML(opts))))
 	b.WriteString("\r\n")
 
-	// CSV attachment.
-	csvBody := summary.CSV()
-	if len(csvBody) > 0 {
-		filename := summary.CSVFilename()
-		b.WriteString("--" + boundary + "\r\n")
-		b.WriteString("Content-Type: text/csv; charset=\"UTF-8\"; name=\"" + filename + "\"\r\n")
-		b.WriteString("Content-Disposition: attachment; filename=\"" + filename + "\"\r\n")
-		b.WriteString("Content-Transfer-Encoding: base64\r\n\r\n")
-		b.WriteString(wrapBase64(csvBody))
-		b.WriteString("\r\n")
-	}
+	// CSV attachments: the change list, and the data-quality list when there is one.
+	writeCSVPart(&b, boundary, summary.CSVFilename(), summary.CSV())
+	writeCSVPart(&b, boundary, summary.DataQualityCSVFilename(), summary.DataQualityCSV())
 
 	b.WriteString("--" + boundary + "--\r\n")
 	return []byte(b.String())
 }
 
+func writeCSVPart(b *strings.Builder, boundary, filename string, body []byte) {
+	if len(body) == 0 {
+		return
+	}
+	b.WriteString("--" + boundary + "\r\n")
+	b.WriteString("Content-Type: text/csv; charset=\"UTF-8\"; name=\"" + filename + "\"\r\n")
+	b.WriteString("Content-Disposition: attachment; filename=\"" + filename + "\"\r\n")
+	b.WriteString("Content-Transfer-Encoding: base64\r\n\r\n")
+	b.WriteString(wrapBase64(body))
+	b.WriteString("\r\n")
+}
+
 func deliver(cfg SMTPConfig, message []byte) error {
 	addr := net.JoinHostPort(cfg.Host, fmt.Sprintf("%d", cfg.Port))
 	dialer := &net.Dialer{Timeout: cfg.timeout()}
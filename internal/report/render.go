@@ -49,12 +49,17 @@ func (s Summary) Subject(opts RenderOptions) string {
 		statusHe = "אזהרות"
 	}
 	when := s.StartedAt.In(opts.location()).Format("02/01 15:04")
+	alerts := ""
+	if len(s.StockAlerts) > 0 {
+		alerts = fmt.Sprintf(" — 🔔 %d התראות מלאי", len(s.StockAlerts))
+	}
 	return fmt.Sprintf(
-		"%s Shopify sync (%s) — %s — %d שינויים — %s",
+		"%s Shopify sync (%s) — %s — %d שינויים%s — %s",
 		marker,
 		s.Mode,
 		statusHe,
 		s.TotalChanges,
+		alerts,
 		when,
 	)
 }
@@ -111,6 +116,49 @@ func (s Summary) HTML(opts RenderOptions) string {
 			`הסנכרון רץ כשורה ולא נדרש שום עדכון — כל הנתונים ב-Shopify זהים לחשבשבת.</p>`)
 	}
 
+	// Held prices come first: each one is a live price waiting for a decision.
+	if len(s.PricesHeld) > 0 {
+		sectionTitle(&b, fmt.Sprintf("מחירים שעוכבו לאישור (%d)", len(s.PricesHeld)))
+		b.WriteString(`<div style="background:#fce8e6;padding:8px 12px;border-radius:4px;font-size:12px;margin:0 0 6px">` +
+			`שינויי מחיר חריגים לא נשלחו ל-Shopify. יש לבדוק אותם בחשבשבת; שינוי נכון מאשרים עם approve-prices.</div>`)
+		b.WriteString(tableOpen())
+		b.WriteString(headerRow("מק\"ט", "מטבע", "לפני", "אחרי", "סיבה"))
+		for i, h := range s.PricesHeld {
+			if i >= max {
+				break
+			}
+			b.WriteString(`<tr>`)
+			cell(&b, ltr(h.SKU), "font-weight:bold")
+			cell(&b, ltr(h.Currency), "")
+			cell(&b, ltr(holdBefore(h)), "")
+			cell(&b, ltr(formatMoney(h.After)), "font-weight:bold;color:#c5221f")
+			cell(&b, ltr(truncate(h.Reason, 200)), "color:#5f6368")
+			b.WriteString(`</tr>`)
+		}
+		b.WriteString(`</table>`)
+		writeTruncationNote(&b, len(s.PricesHeld), max)
+	}
+
+	// Stock alerts: a bestseller out of stock is a call to the buyer, not a footnote.
+	if len(s.StockAlerts) > 0 {
+		sectionTitle(&b, fmt.Sprintf("התראות מלאי (%d)", len(s.StockAlerts)))
+		b.WriteString(tableOpen())
+		b.WriteString(headerRow("מק\"ט", "התראה", "כמות באתר", "סף"))
+		for i, a := range s.StockAlerts {
+			if i >= max {
+				break
+			}
+			b.WriteString(`<tr>`)
+			cell(&b, ltr(a.SKU), "font-weight:bold")
+			cell(&b, html.EscapeString(stockAlertLabel(a.Kind)), stockAlertStyle(a.Kind))
+			cell(&b, ltr(strconv.Itoa(a.Quantity)), "font-weight:bold")
+			cell(&b, ltr(stockAlertThreshold(a)), "color:#5f6368")
+			b.WriteString(`</tr>`)
+		}
+		b.WriteString(`</table>`)
+		writeTruncationNote(&b, len(s.StockAlerts), max)
+	}
+
 	// Steps.
 	if len(s.Steps) > 0 {
 		sectionTitle(&b, "שלבי הריצה")
@@ -139,7 +187,7 @@ func (s Summary) HTML(opts RenderOptions) string {
 	if len(s.StockChanges) > 0 {
 		sectionTitle(&b, fmt.Sprintf("שינויי מלאי (%d)", len(s.StockChanges)))
 		b.WriteString(tableOpen())
-		b.WriteString(headerRow("מק\"ט", "לפני", "אחרי", "שינוי"))
+		b.WriteString(headerRow("מק\"ט", "לפני", "אחרי", "שינוי", "רזרבה"))
 		for i, ch := range s.StockChanges {
 			if i >= max {
 				break
@@ -150,10 +198,11 @@ func (s Summary) HTML(opts RenderOptions) string {
 				color = "#c5221f"
 			}
 			b.WriteString(`<tr>`)
-			cell(&b, ltr(ch.SKU), "font-weight:bold")
+			cell(&b, ltr(stockLabel(ch)), "font-weight:bold")
 			cell(&b, ltr(stockBefore(ch)), "")
 			cell(&b, ltr(strconv.Itoa(ch.After)), "")
 			cell(&b, ltr(withSign(delta)), "color:"+color+";font-weight:bold")
+			cell(&b, ltr(stockReserveNote(ch)), "color:#5f6368")
 			b.WriteString(`</tr>`)
 		}
 		b.WriteString(`</table>`)
@@ -164,7 +213,7 @@ func (s Summary) HTML(opts RenderOptions) string {
 	if len(s.PriceChanges) > 0 {
 		sectionTitle(&b, fmt.Sprintf("שינויי מחיר (%d)", len(s.PriceChanges)))
 		b.WriteString(tableOpen())
-		b.WriteString(headerRow("מק\"ט", "מטבע", "לפני", "אחרי"))
+		b.WriteString(headerRow("מק\"ט", "מטבע", "לפני", "אחרי", "מקור"))
 		for i, ch := range s.PriceChanges {
 			if i >= max {
 				break
@@ -174,6 +223,7 @@ func (s Summary) HTML(opts RenderOptions) string {
 			cell(&b, ltr(ch.Currency), "")
 			cell(&b, ltr(priceBefore(ch)), "")
 			cell(&b, ltr(formatMoney(ch.After)), "font-weight:bold")
+			cell(&b, ltr(ch.Source), "color:#5f6368")
 			b.WriteString(`</tr>`)
 		}
 		b.WriteString(`</table>`)
@@ -217,6 +267,53 @@ func (s Summary) HTML(opts RenderOptions) string {
 		writeTruncationNote(&b, len(s.ProductsFailed), max)
 	}
 
+	// Data quality.
+	if len(s.DataIssues) > 0 {
+		sectionTitle(&b, fmt.Sprintf("איכות נתונים בחשבשבת (%d)", len(s.DataIssues)))
+		b.WriteString(`<div style="font-size:12px;color:#5f6368;margin:0 0 6px">` +
+			`פריטים שיש לתקן במקור, בחשבשבת. הרשימה המלאה בקובץ ה-CSV הייעודי המצורף.</div>`)
+		b.WriteString(tableOpen())
+		b.WriteString(headerRow("מק\"ט", "בעיה", "פרטים"))
+		for i, issue := range s.DataIssues {
+			if i >= max {
+				break
+			}
+			b.WriteString(`<tr>`)
+			cell(&b, ltr(issue.SKU), "font-weight:bold")
+			cell(&b, html.EscapeString(dataIssueLabel(issue.Kind)), "")
+			cell(&b, ltr(truncate(issue.Detail, 200)), "color:#5f6368")
+			b.WriteString(`</tr>`)
+		}
+		b.WriteString(`</table>`)
+		writeTruncationNote(&b, len(s.DataIssues), max)
+	}
+
+	// Stock drift.
+	if s.StockDriftAudited {
+		sectionTitle(&b, fmt.Sprintf("סטיות מלאי בין Shopify לחשבשבת (%d)", len(s.StockDrift)))
+		b.WriteString(`<div style="font-size:12px;color:#5f6368;margin:0 0 6px">` +
+			html.EscapeString(stockDriftTrend(s)) + `</div>`)
+		if len(s.StockDrift) > 0 {
+			b.WriteString(tableOpen())
+			b.WriteString(headerRow("מק\"ט", "סוג", "חשבשבת", "Shopify", "זמין", "בהזמנות"))
+			for i, d := range s.StockDrift {
+				if i >= max {
+					break
+				}
+				b.WriteString(`<tr>`)
+				cell(&b, ltr(stockLabel(StockChange{SKU: d.SKU, Location: d.Location})), "font-weight:bold")
+				cell(&b, html.EscapeString(stockDriftLabel(d.Kind)), "")
+				cell(&b, ltr(strconv.Itoa(d.Target)), "font-weight:bold")
+				cell(&b, ltr(strconv.Itoa(d.OnHand)), "")
+				cell(&b, ltr(strconv.Itoa(d.Available)), "color:#5f6368")
+				cell(&b, ltr(strconv.Itoa(d.Committed)), "color:#5f6368")
+				b.WriteString(`</tr>`)
+			}
+			b.WriteString(`</table>`)
+			writeTruncationNote(&b, len(s.StockDrift), max)
+		}
+	}
+
 	// Warnings.
 	if len(s.Warnings) > 0 {
 		sectionTitle(&b, fmt.Sprintf("אזהרות (%d)", len(s.Warnings)))
@@ -276,7 +373,8 @@ func (s Summary) CSV() []byte {
 	// BOM so Excel opens the UTF-8 Hebrew titles correctly.
 	buf.WriteString("\ufeff")
 	w := csv.NewWriter(&buf)
-	_ = w.Write([]string{"type", "sku", "currency", "before", "after", "delta", "note"})
+	// erp and location come last so sheets built on the older columns keep working.
+	_ = w.Write([]string{"type", "sku", "currency", "before", "after", "delta", "note", "erp", "location"})
 
 	for _, ch := range s.StockChanges {
 		_ = w.Write([]string{
@@ -286,7 +384,9 @@ func (s Summary) CSV() []byte {
 			stockBefore(ch),
 			strconv.Itoa(ch.After),
 			withSign(ch.Delta()),
-			"",
+			stockReserveNote(ch),
+			stockERP(ch),
+			ch.Location,
 		})
 	}
 	for _, ch := range s.PriceChanges {
@@ -297,17 +397,58 @@ func (s Summary) CSV() []byte {
 			priceBefore(ch),
 			formatMoney(ch.After),
 			"",
+			ch.Source,
+			priceERP(ch),
+			"",
+		})
+	}
+	for _, h := range s.PricesHeld {
+		_ = w.Write([]string{
+			"price_held",
+			h.SKU,
+			h.Currency,
+			holdBefore(h),
+			formatMoney(h.After),
+			"",
+			h.Reason,
+			"",
+			"",
+		})
+	}
+	for _, d := range s.StockDrift {
+		_ = w.Write([]string{
+			"stock_drift",
+			d.SKU,
+			"",
+			strconv.Itoa(d.OnHand),
+			strconv.Itoa(d.Target),
+			withSign(d.Delta()),
+			fmt.Sprintf("%s available=%d committed=%d", d.Kind, d.Available, d.Committed),
+			"",
+			d.Location,
+		})
+	}
+	for _, a := range s.StockAlerts {
+		_ = w.Write([]string{
+			"stock_alert",
+			a.SKU,
+			"",
+			"",
+			strconv.Itoa(a.Quantity),
+			"",
+			strings.TrimSpace(fmt.Sprintf("%s threshold=%d %s", a.Kind, a.Threshold, a.Source)),
+			"",
 			"",
 		})
 	}
 	for _, p := range s.ProductsNew {
-		_ = w.Write([]string{"product_created", p.SKU, "", "", "", "", p.Title})
+		_ = w.Write([]string{"product_created", p.SKU, "", "", "", "", p.Title, "", ""})
 	}
 	for _, p := range s.ProductsFailed {
-		_ = w.Write([]string{"product_failed", p.SKU, "", "", "", "", strings.TrimSpace(p.Title + " | " + p.Err)})
+		_ = w.Write([]string{"product_failed", p.SKU, "", "", "", "", strings.TrimSpace(p.Title + " | " + p.Err), "", ""})
 	}
 	for _, warning := range s.Warnings {
-		_ = w.Write([]string{"warning", "", "", "", "", "", strings.TrimSpace(warning.Scope + ": " + warning.Message)})
+		_ = w.Write([]string{"warning", "", "", "", "", "", strings.TrimSpace(warning.Scope + ": " + warning.Message), "", ""})
 	}
 	for _, step := range s.Steps {
 		note := ""
@@ -324,6 +465,8 @@ func (s Summary) CSV() []byte {
 			string(step.Status),
 			FormatDuration(step.Duration()),
 			note,
+			"",
+			"",
 		})
 	}
 	w.Flush()
@@ -340,6 +483,149 @@ func (s Summary) CSVFilename() string {
 	return fmt.Sprintf("%s-%s-changes.csv", job, stamp)
 }
 
+// DataQualityCSV renders the data-quality issues as their own CSV, meant to be
+// handed to the ERP team as a to-do list. Empty when there are no issues.
+func (s Summary) DataQualityCSV() []byte {
+	if len(s.DataIssues) == 0 {
+		return nil
+	}
+	var buf strings.Builder
+	buf.WriteString("\ufeff")
+	w := csv.NewWriter(&buf)
+	_ = w.Write([]string{"sku", "issue", "issue_he", "detail"})
+	for _, issue := range s.DataIssues {
+		_ = w.Write([]string{issue.SKU, issue.Kind, dataIssueLabel(issue.Kind), issue.Detail})
+	}
+	w.Flush()
+	return []byte(buf.String())
+}
+
+// DataQualityCSVFilename is the data-quality attachment name, stamped with the run start.
+func (s Summary) DataQualityCSVFilename() string {
+	return strings.TrimSuffix(s.CSVFilename(), "-changes.csv") + "-data-quality.csv"
+}
+
+func dataIssueLabel(kind string) string {
+	switch kind {
+	case IssueInvalidBarcode:
+		return "ברקוד לא תקין"
+	case IssueDuplicateBarcode:
+		return "ברקוד כפול"
+	case IssueDuplicateSKU:
+		return "מק\"ט כפול"
+	case IssueMissingEnglishTitle:
+		return "חסר שם באנגלית"
+	case IssueMissingPrice:
+		return "חסר מחיר"
+	case IssueMissingCategory:
+		return "חסרה קטגוריה"
+	default:
+		return kind
+	}
+}
+
+func stockDriftLabel(kind string) string {
+	switch kind {
+	case DriftUntracked:
+		return "ללא מעקב מלאי"
+	case DriftMissingLevel:
+		return "לא פעיל במיקום"
+	case DriftPendingOrder:
+		return "הזמנה שטרם סופקה"
+	case DriftManualEdit:
+		return "שינוי ידני ב-Shopify"
+	default:
+		return kind
+	}
+}
+
+func stockAlertLabel(kind string) string {
+	switch kind {
+	case AlertOutOfStock:
+		return "אזל מהמלאי"
+	case AlertLowStock:
+		return "מלאי נמוך"
+	case AlertBackInStock:
+		return "חזר למלאי"
+	default:
+		return kind
+	}
+}
+
+func stockAlertStyle(kind string) string {
+	switch kind {
+	case AlertOutOfStock:
+		return "color:#c5221f;font-weight:bold"
+	case AlertLowStock:
+		return "color:#b06000"
+	default:
+		return "color:#137333"
+	}
+}
+
+// stockAlertThreshold shows the threshold and the rule behind it, e.g. "5 (prefix CMG-)".
+func stockAlertThreshold(a StockAlert) string {
+	if a.Threshold <= 0 {
+		return "—"
+	}
+	if a.Source == "" {
+		return strconv.Itoa(a.Threshold)
+	}
+	return fmt.Sprintf("%d (%s)", a.Threshold, a.Source)
+}
+
+// stockDriftTrend compares this audit's total with the previous one.
+func stockDriftTrend(s Summary) string {
+	if s.StockDriftPrevious == nil {
+		return "ביקורת ראשונה, אין נתון קודם להשוואה."
+	}
+	previous := *s.StockDriftPrevious
+	switch current := len(s.StockDrift); {
+	case current > previous:
+		return fmt.Sprintf("הסטייה גדלה: %d בביקורת הקודמת (%s).", previous, withSign(current-previous))
+	case current < previous:
+		return fmt.Sprintf("הסטייה קטנה: %d בביקורת הקודמת (%s).", previous, withSign(current-previous))
+	default:
+		return fmt.Sprintf("ללא שינוי מהביקורת הקודמת (%d).", previous)
+	}
+}
+
+func holdBefore(h PriceHold) string {
+	if !h.BeforeKnown {
+		return "—"
+	}
+	return formatMoney(h.Before)
+}
+
+// stockLabel names a stock row, with its location when the stock step pushes to
+// more than one, e.g. "CMG-28 (Tel Aviv Store)".
+func stockLabel(ch StockChange) string {
+	if ch.Location == "" {
+		return ch.SKU
+	}
+	return fmt.Sprintf("%s (%s)", ch.SKU, ch.Location)
+}
+
+// stockReserveNote explains the pushed quantity, e.g. "ERP 5 - reserve 3 (default)",
+// and for a kit "ERP 4 - reserve 0 (default); kit 4 limited by HVM-1".
+func stockReserveNote(ch StockChange) string {
+	notes := make([]string, 0, 2)
+	if ch.Reserve != nil {
+		notes = append(notes, fmt.Sprintf("ERP %d - reserve %d (%s)", ch.Reserve.ERP, ch.Reserve.Units, ch.Reserve.Source))
+	}
+	if ch.Kit != nil {
+		notes = append(notes, fmt.Sprintf("kit %d limited by %s", ch.Kit.Assemblable, ch.Kit.Limiting))
+	}
+	return strings.Join(notes, "; ")
+}
+
+func stockERP(ch StockChange) string {
+	if ch.Reserve == nil {
+		return ""
+	}
+	return strconv.Itoa(ch.Reserve.ERP)
+}
+
 func stockBefore(ch StockChange) string {
 	if !ch.BeforeKnown {
 		return "—"
@@ -354,6 +640,14 @@ func priceBefore(ch PriceChange) string {
 	return formatMoney(ch.Before)
 }
 
+// priceERP is the ERP's own value, shown when it is known.
+func priceERP(ch PriceChange) string {
+	if !ch.ERPKnown {
+		return ""
+	}
+	return formatMoney(ch.ERP)
+}
+
 func formatMoney(v float64) string {
 	return strconv.FormatFloat(v, 'f', 2, 64)
 }
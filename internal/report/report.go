@@ -60,11 +60,34 @@ func (s *Step) Duration() time.Duration {
 // StockChange is one SKU whose Shopify on-hand quantity moved.
 type StockChange struct {
 	SKU string
+	// Location is the Shopify location the quantity was pushed to; empty for the
+	// primary location when no warehouse mapping is configured.
+	Location string
 	// Before is the on-hand quantity Shopify held before the push. BeforeKnown is
 	// false when Shopify had no inventory level for the item yet (first activation).
 	Before      int
 	BeforeKnown bool
 	After       int
+	// Reserve explains After when the stock step recorded it: the ERP balance, the
+	// units held back and the rule that set them. Nil otherwise.
+	Reserve *StockReserve
+	// Kit is set when the SKU is a kit whose quantity was assembled from its
+	// components. Nil otherwise.
+	Kit *StockKit
+}
+
+// StockReserve is one SKU's ERP balance at a location and the reserve taken off it.
+type StockReserve struct {
+	ERP    int
+	Units  int
+	Source string
+}
+
+// StockKit explains a kit's ERP balance: how many kits the components make at the
+// location, and the component that runs out first.
+type StockKit struct {
+	Assemblable int
+	Limiting    string
 }
 
 // Delta is After-Before, or After when there was no prior level.
@@ -82,6 +105,23 @@ type PriceChange struct {
 	Before      float64
 	BeforeKnown bool
 	After       float64
+	// Source names where the price came from (e.g. "ERP list 7"), when the price
+	// step recorded it. Empty otherwise.
+	Source string
+	// ERP is the price as the ERP list has it, when the price step recorded it; it
+	// differs from After when VAT was added. ERPKnown is false for derived prices.
+	ERP      float64
+	ERPKnown bool
+}
+
+// PriceHold is one SKU/currency price change the guard held back for approval.
+type PriceHold struct {
+	SKU         string
+	Currency    string
+	Before      float64
+	BeforeKnown bool
+	After       float64
+	Reason      string
 }
 
 // ProductChange is a product created on, or failed against, Shopify.
@@ -92,6 +132,69 @@ type ProductChange struct {
 	Err    string
 }
 
+// Data-quality issue kinds, recorded by the catalogue validation before the product
+// step. They describe ERP data to be fixed at source, not anything the sync changed.
+const (
+	IssueInvalidBarcode      = "invalid_barcode"
+	IssueDuplicateBarcode    = "duplicate_barcode"
+	IssueDuplicateSKU        = "duplicate_sku"
+	IssueMissingEnglishTitle = "missing_english_title"
+	IssueMissingPrice        = "missing_price"
+	IssueMissingCategory     = "missing_category"
+)
+
+// DataIssue is one ERP catalogue problem found by the validation stage.
+type DataIssue struct {
+	SKU    string
+	Kind   string
+	Detail string
+}
+
+// Stock drift kinds, as the audit classifies a Shopify on-hand that disagrees with
+// the ERP target.
+const (
+	DriftUntracked    = "untracked"
+	DriftMissingLevel = "missing_level"
+	DriftPendingOrder = "pending_order"
+	DriftManualEdit   = "manual_edit"
+)
+
+// StockDrift is one SKU at one location whose Shopify quantities disagree with the
+// quantity the stock sync would push.
+type StockDrift struct {
+	SKU      string
+	Location string
+	Kind     string
+	// Target is the ERP balance less the reserve: what a full run would push.
+	Target    int
+	OnHand    int
+	Available int
+	Committed int
+}
+
+// Delta is Target-OnHand: how far a full run would move the SKU.
+func (d StockDrift) Delta() int {
+	return d.Target - d.OnHand
+}
+
+// Stock alert kinds, in the order the report lists them.
+const (
+	AlertOutOfStock  = "out_of_stock"
+	AlertLowStock    = "low_stock"
+	AlertBackInStock = "back_in_stock"
+)
+
+// StockAlert is one SKU whose storefront stock crossed an alert line this run.
+type StockAlert struct {
+	SKU  string
+	Kind string
+	// Quantity is the storefront quantity, summed over locations.
+	Quantity int
+	// Threshold is the SKU's low-stock threshold and Source the rule that set it.
+	Threshold int
+	Source    string
+}
+
 // Note is a warning or error attached to a scope (step or adapter).
 type Note struct {
 	Scope   string
@@ -106,8 +209,27 @@ type Recorder interface {
 	// StockSeen records the outcome of pushing one SKU's quantity. It classifies
 	// the SKU as changed or unchanged; only changed SKUs reach the report body.
 	StockSeen(sku string, before int, beforeKnown bool, after int)
+	// StockSeenAt is StockSeen for one Shopify location; an empty location is the
+	// primary one.
+	StockSeenAt(sku, location string, before int, beforeKnown bool, after int)
+	// StockReserve records a SKU's ERP balance at a location and the reserve held back
+	// from it, and which rule set the reserve. It is attached to the matching stock
+	// change.
+	StockReserve(sku, location string, erp, reserve int, source string)
+	// StockKit records how many of a kit its components make at a location and which
+	// component limits it. It is attached to the matching stock change.
+	StockKit(sku, location string, assemblable int, limiting string)
 	// PriceSeen records the outcome of pushing one SKU's price in one currency.
 	PriceSeen(sku, currency string, before float64, beforeKnown bool, after float64)
+	// PriceSource records which source a SKU's price in one currency was taken from,
+	// so merchandisers can audit it. It is attached to the matching price change.
+	PriceSource(sku, currency, source string)
+	// PriceERPValue records the ERP's own value behind a SKU's price in one currency,
+	// before any VAT was added. It is attached to the matching price change.
+	PriceERPValue(sku, currency string, amount float64)
+	// PriceHeld records a price change the guard kept off the storefront. Held
+	// prices make the run a warning: each one waits for a human.
+	PriceHeld(sku, currency string, before float64, beforeKnown bool, after float64, reason string)
 	// ProductCreated records a product that did not exist in Shopify before.
 	ProductCreated(sku, title string)
 	// ProductUpdated records a product that already existed (counted, not listed —
@@ -119,6 +241,20 @@ type Recorder interface {
 	Warn(scope, message string)
 	// Incr bumps a named counter shown in the report footer.
 	Incr(scope, key string, n int64)
+	// DataIssue records an ERP catalogue problem for the data-quality section and
+	// its CSV. Issues do not change the run status: they persist until the ERP is
+	// fixed, and would otherwise turn every run into a warning.
+	DataIssue(sku, kind, detail string)
+	// StockDrift records one mismatch found by the read-only drift audit. Like data
+	// issues, drift does not change the run status.
+	StockDrift(drift StockDrift)
+	// StockDriftAudited records that the drift audit ran, with the previous audit's
+	// total when there was one, so the report can show whether drift is growing.
+	StockDriftAudited(previous int, previousKnown bool)
+	// StockAlert records a low-stock, out-of-stock or back-in-stock alert. Alerts are
+	// their own stream: they do not change the run status, but a run with alerts is
+	// always mailed.
+	StockAlert(alert StockAlert)
 }
 
 // Run is the accumulated state of a single execution of a sync binary.
@@ -135,8 +271,13 @@ type Run struct {
 	steps          []*Step
 	stock          []StockChange
 	stockUnchanged int64
+	stockReserves  map[string]StockReserve
+	stockKits      map[string]StockKit
 	prices         []PriceChange
 	priceUnchanged int64
+	priceSources   map[string]string
+	priceERP       map[string]float64
+	priceHolds     []PriceHold
 	products       []ProductChange
 	productsUpdate int64
 	warnings       []Note
@@ -146,6 +287,11 @@ type Run struct {
 	suppressedWarnings int
 	counters           map[string]int64
 	counterOrder       []string
+	dataIssues         []DataIssue
+	stockDrift         []StockDrift
+	stockDriftAudited  bool
+	stockDriftPrevious *int
+	stockAlerts        []StockAlert
 }
 
 // NewRun starts a report for the given job.
@@ -222,6 +368,10 @@ func (r *Run) SetLogFile(path string) {
 }
 
 func (r *Run) StockSeen(sku string, before int, beforeKnown bool, after int) {
+	r.StockSeenAt(sku, "", before, beforeKnown, after)
+}
+
+func (r *Run) StockSeenAt(sku, location string, before int, beforeKnown bool, after int) {
 	if r == nil {
 		return
 	}
@@ -235,7 +385,49 @@ func (r *Run) StockSeen(sku string, before int, beforeKnown bool, after int) {
 		r.stockUnchanged++
 		return
 	}
-	r.stock = append(r.stock, StockChange{SKU: sku, Before: before, BeforeKnown: beforeKnown, After: after})
+	r.stock = append(r.stock, StockChange{
+		SKU:         sku,
+		Location:    strings.TrimSpace(location),
+		Before:      before,
+		BeforeKnown: beforeKnown,
+		After:       after,
+	})
+}
+
+func (r *Run) StockReserve(sku, location string, erp, reserve int, source string) {
+	if r == nil {
+		return
+	}
+	sku = strings.TrimSpace(sku)
+	if sku == "" {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.stockReserves == nil {
+		r.stockReserves = make(map[string]StockReserve)
+	}
+	r.stockReserves[stockKey(sku, location)] = StockReserve{ERP: erp, Units: reserve, Source: strings.TrimSpace(source)}
+}
+
+func (r *Run) StockKit(sku, location string, assemblable int, limiting string) {
+	if r == nil {
+		return
+	}
+	sku = strings.TrimSpace(sku)
+	if sku == "" {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.stockKits == nil {
+		r.stockKits = make(map[string]StockKit)
+	}
+	r.stockKits[stockKey(sku, location)] = StockKit{Assemblable: assemblable, Limiting: strings.TrimSpace(limiting)}
+}
+
+func stockKey(sku, location string) string {
+	return sku + "|" + strings.TrimSpace(location)
 }
 
 func (r *Run) PriceSeen(sku, currency string, before float64, beforeKnown bool, after float64) {
@@ -261,6 +453,59 @@ func (r *Run) PriceSeen(sku, currency string, before float64, beforeKnown bool,
 	})
 }
 
+func (r *Run) PriceSource(sku, currency, source string) {
+	if r == nil {
+		return
+	}
+	sku = strings.TrimSpace(sku)
+	source = strings.TrimSpace(source)
+	if sku == "" || source == "" {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.priceSources == nil {
+		r.priceSources = make(map[string]string)
+	}
+	r.priceSources[priceSourceKey(sku, currency)] = source
+}
+
+func (r *Run) PriceERPValue(sku, currency string, amount float64) {
+	if r == nil {
+		return
+	}
+	sku = strings.TrimSpace(sku)
+	if sku == "" {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.priceERP == nil {
+		r.priceERP = make(map[string]float64)
+	}
+	r.priceERP[priceSourceKey(sku, currency)] = amount
+}
+
+func priceSourceKey(sku, currency string) string {
+	return sku + "|" + strings.ToUpper(strings.TrimSpace(currency))
+}
+
+func (r *Run) PriceHeld(sku, currency string, before float64, beforeKnown bool, after float64, reason string) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.priceHolds = append(r.priceHolds, PriceHold{
+		SKU:         strings.TrimSpace(sku),
+		Currency:    strings.ToUpper(strings.TrimSpace(currency)),
+		Before:      before,
+		BeforeKnown: beforeKnown,
+		After:       after,
+		Reason:      strings.TrimSpace(reason),
+	})
+}
+
 func (r *Run) ProductCreated(sku, title string) {
 	if r == nil {
 		return
@@ -358,6 +603,64 @@ func (r *Run) Incr(scope, key string, n int64) {
 	r.counters[name] += n
 }
 
+func (r *Run) DataIssue(sku, kind, detail string) {
+	if r == nil {
+		return
+	}
+	kind = strings.TrimSpace(kind)
+	if kind == "" {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.dataIssues = append(r.dataIssues, DataIssue{
+		SKU:    strings.TrimSpace(sku),
+		Kind:   kind,
+		Detail: strings.TrimSpace(detail),
+	})
+}
+
+func (r *Run) StockDrift(drift StockDrift) {
+	if r == nil {
+		return
+	}
+	drift.SKU = strings.TrimSpace(drift.SKU)
+	drift.Kind = strings.TrimSpace(drift.Kind)
+	if drift.SKU == "" || drift.Kind == "" {
+		return
+	}
+	drift.Location = strings.TrimSpace(drift.Location)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.stockDrift = append(r.stockDrift, drift)
+}
+
+func (r *Run) StockDriftAudited(previous int, previousKnown bool) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.stockDriftAudited = true
+	r.stockDriftPrevious = nil
+	if previousKnown {
+		r.stockDriftPrevious = &previous
+	}
+}
+
+func (r *Run) StockAlert(alert StockAlert) {
+	if r == nil {
+		return
+	}
+	alert.SKU = strings.TrimSpace(alert.SKU)
+	if alert.SKU == "" || alert.Kind == "" {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.stockAlerts = append(r.stockAlerts, alert)
+}
+
 // Summary is the immutable view of a finished run, used for rendering.
 type Summary struct {
 	Job        string
@@ -375,6 +678,8 @@ type Summary struct {
 	StockUnchanged int64
 	PriceChanges   []PriceChange
 	PriceUnchanged int64
+	// PricesHeld are the guard's holds, sorted by SKU then currency.
+	PricesHeld     []PriceHold
 	ProductsNew    []ProductChange
 	ProductsFailed []ProductChange
 	ProductsUpdate int64
@@ -382,6 +687,17 @@ type Summary struct {
 	// SuppressedWarnings is how many warnings the per-scope cap dropped from Warnings.
 	SuppressedWarnings int
 	Counters           []Counter
+	// DataIssues is the catalogue validation output, sorted by kind then SKU.
+	DataIssues []DataIssue
+	// StockDrift is the drift audit output, sorted by kind, SKU, then location.
+	// StockDriftAudited is whether an audit ran at all, so a clean audit can say so;
+	// StockDriftPrevious is the last audit's total, nil on the first.
+	StockDrift         []StockDrift
+	StockDriftAudited  bool
+	StockDriftPrevious *int
+	// StockAlerts are sorted out of stock first, then low, then back in stock, each
+	// by SKU.
+	StockAlerts []StockAlert
 
 	FailedSteps  int
 	TotalChanges int
@@ -405,7 +721,7 @@ func (s Summary) Status() string {
 	switch {
 	case s.FailedSteps > 0 || len(s.ProductsFailed) > 0:
 		return StatusFailed
-	case len(s.Warnings) > 0:
+	case len(s.Warnings) > 0 || len(s.PricesHeld) > 0:
 		return StatusWarning
 	default:
 		return StatusOK
@@ -446,15 +762,31 @@ func (r *Run) Snapshot() Summary {
 	}
 
 	s.StockChanges = append(s.StockChanges, r.stock...)
+	for i := range s.StockChanges {
+		if reserve, ok := r.stockReserves[stockKey(s.StockChanges[i].SKU, s.StockChanges[i].Location)]; ok {
+			s.StockChanges[i].Reserve = &reserve
+		}
+		if kit, ok := r.stockKits[stockKey(s.StockChanges[i].SKU, s.StockChanges[i].Location)]; ok {
+			s.StockChanges[i].Kit = &kit
+		}
+	}
 	sort.SliceStable(s.StockChanges, func(i, j int) bool {
 		di, dj := abs(s.StockChanges[i].Delta()), abs(s.StockChanges[j].Delta())
 		if di != dj {
 			return di > dj
 		}
-		return s.StockChanges[i].SKU < s.StockChanges[j].SKU
+		if s.StockChanges[i].SKU != s.StockChanges[j].SKU {
+			return s.StockChanges[i].SKU < s.StockChanges[j].SKU
+		}
+		return s.StockChanges[i].Location < s.StockChanges[j].Location
 	})
 
 	s.PriceChanges = append(s.PriceChanges, r.prices...)
+	for i := range s.PriceChanges {
+		key := priceSourceKey(s.PriceChanges[i].SKU, s.PriceChanges[i].Currency)
+		s.PriceChanges[i].Source = r.priceSources[key]
+		s.PriceChanges[i].ERP, s.PriceChanges[i].ERPKnown = r.priceERP[key]
+	}
 	sort.SliceStable(s.PriceChanges, func(i, j int) bool {
 		if s.PriceChanges[i].SKU != s.PriceChanges[j].SKU {
 			return s.PriceChanges[i].SKU < s.PriceChanges[j].SKU
@@ -462,6 +794,14 @@ func (r *Run) Snapshot() Summary {
 		return s.PriceChanges[i].Currency < s.PriceChanges[j].Currency
 	})
 
+	s.PricesHeld = append(s.PricesHeld, r.priceHolds...)
+	sort.SliceStable(s.PricesHeld, func(i, j int) bool {
+		if s.PricesHeld[i].SKU != s.PricesHeld[j].SKU {
+			return s.PricesHeld[i].SKU < s.PricesHeld[j].SKU
+		}
+		return s.PricesHeld[i].Currency < s.PricesHeld[j].Currency
+	})
+
 	for _, p := range r.products {
 		if p.Action == "failed" {
 			s.ProductsFailed = append(s.ProductsFailed, p)
@@ -475,6 +815,40 @@ func (r *Run) Snapshot() Summary {
 	s.Warnings = append(s.Warnings, r.warnings...)
 	s.SuppressedWarnings = r.suppressedWarnings
 
+	s.DataIssues = append(s.DataIssues, r.dataIssues...)
+	sort.SliceStable(s.DataIssues, func(i, j int) bool {
+		if s.DataIssues[i].Kind != s.DataIssues[j].Kind {
+			return s.DataIssues[i].Kind < s.DataIssues[j].Kind
+		}
+		return s.DataIssues[i].SKU < s.DataIssues[j].SKU
+	})
+
+	s.StockDrift = append(s.StockDrift, r.stockDrift...)
+	sort.SliceStable(s.StockDrift, func(i, j int) bool {
+		a, b := s.StockDrift[i], s.StockDrift[j]
+		if a.Kind != b.Kind {
+			return a.Kind < b.Kind
+		}
+		if a.SKU != b.SKU {
+			return a.SKU < b.SKU
+		}
+		return a.Location < b.Location
+	})
+	s.StockDriftAudited = r.stockDriftAudited
+	if r.stockDriftPrevious != nil {
+		previous := *r.stockDriftPrevious
+		s.StockDriftPrevious = &previous
+	}
+
+	s.StockAlerts = append(s.StockAlerts, r.stockAlerts...)
+	sort.SliceStable(s.StockAlerts, func(i, j int) bool {
+		a, b := s.StockAlerts[i], s.StockAlerts[j]
+		if a.Kind != b.Kind {
+			return stockAlertOrder(a.Kind) < stockAlertOrder(b.Kind)
+		}
+		return a.SKU < b.SKU
+	})
+
 	for _, name := range r.counterOrder {
 		s.Counters = append(s.Counters, Counter{Name: name, Value: r.counters[name]})
 	}
@@ -483,6 +857,19 @@ func (r *Run) Snapshot() Summary {
 	return s
 }
 
+func stockAlertOrder(kind string) int {
+	switch kind {
+	case AlertOutOfStock:
+		return 0
+	case AlertLowStock:
+		return 1
+	case AlertBackInStock:
+		return 2
+	default:
+		return 3
+	}
+}
+
 // OneLine is the compact technical summary, also used as the log line.
 func (s Summary) OneLine() string {
 	return fmt.Sprintf(
@@ -205,7 +205,11 @@ func TestWarningsAreCappedPerScopeButStillCounted(t *testing.T) {
 func TestCSVCarriesEveryRowAndOpensInExcel(t *testing.T) {
 	run := testRun()
 	run.StockSeen("CMG-28", 102, true, 247)
+	run.StockReserve("CMG-28", "", 250, 3, "default")
 	run.PriceSeen("DRA-1", "ILS", 19.80, true, 23.36)
+	run.PriceSource("DRA-1", "ils", "ERP list 10")
+	run.PriceERPValue("DRA-1", "ILS", 19.80)
+	run.PriceSeen("DRA-2", "USD", 5, true, 6)
 	run.ProductCreated("NEW-1", "כוס קידוש")
 	run.ProductFailed("BAD-1", "Broken", errors.New("boom"))
 	run.Warn("stock", "variant missing")
@@ -218,9 +222,10 @@ func TestCSVCarriesEveryRowAndOpensInExcel(t *testing.T) {
 		t.Error("CSV must start with a UTF-8 BOM so Excel renders Hebrew titles")
 	}
 	for _, want := range []string{
-		"type,sku,currency,before,after,delta,note",
-		"stock,CMG-28,,102,247,+145,",
-		"price,DRA-1,ILS,19.80,23.36",
+		"type,sku,currency,before,after,delta,note,erp,location",
+		"stock,CMG-28,,102,247,+145,ERP 250 - reserve 3 (default),250",
+		"price,DRA-1,ILS,19.80,23.36,,ERP list 10,19.80",
+		"price,DRA-2,USD,5.00,6.00,,,,\n",
 		"product_created,NEW-1",
 		"product_failed,BAD-1",
 		"warning,",
@@ -232,6 +237,136 @@ func TestCSVCarriesEveryRowAndOpensInExcel(t *testing.T) {
 	}
 }
 
+func TestStockChangesAreKeptPerLocation(t *testing.T) {
+	// The same SKU moves independently at each location; each row carries its own
+	// reserve and names the location in both the HTML and the CSV.
+	run := testRun()
+	run.StockSeenAt("CMG-28", "Main Warehouse", 10, true, 7)
+	run.StockSeenAt("CMG-28", "Tel Aviv Store", 2, true, 4)
+	run.StockReserve("CMG-28", "Main Warehouse", 10, 3, "default")
+	run.StockReserve("CMG-28", "Tel Aviv Store", 5, 1, "sku")
+	summary := run.Snapshot()
+
+	if got := len(summary.StockChanges); got != 2 {
+		t.Fatalf("stock changes = %d, want 2", got)
+	}
+	for _, ch := range summary.StockChanges {
+		if ch.Reserve == nil {
+			t.Fatalf("%s at %s has no reserve", ch.SKU, ch.Location)
+		}
+		if ch.Location == "Tel Aviv Store" && ch.Reserve.Source != "sku" {
+			t.Errorf("Tel Aviv reserve source = %q, want sku", ch.Reserve.Source)
+		}
+	}
+	if body := summary.HTML(RenderOptions{}); !strings.Contains(body, "CMG-28 (Tel Aviv Store)") {
+		t.Error("HTML must name the location of a stock change")
+	}
+	if csv := string(summary.CSV()); !strings.Contains(csv, "stock,CMG-28,,2,4,+2,ERP 5 - reserve 1 (sku),5,Tel Aviv Store") {
+		t.Errorf("CSV missing the per-location row\n%s", csv)
+	}
+}
+
+func TestKitStockNamesTheLimitingComponent(t *testing.T) {
+	run := testRun()
+	run.StockSeen("GIFT-1", 0, true, 4)
+	run.StockReserve("GIFT-1", "", 4, 0, "default")
+	run.StockKit("GIFT-1", "", 4, "HVM-1")
+	summary := run.Snapshot()
+
+	if kit := summary.StockChanges[0].Kit; kit == nil || kit.Limiting != "HVM-1" {
+		t.Fatalf("kit = %+v, want limited by HVM-1", kit)
+	}
+	if csv := string(summary.CSV()); !strings.Contains(csv, "stock,GIFT-1,,0,4,+4,ERP 4 - reserve 0 (default); kit 4 limited by HVM-1,4,") {
+		t.Errorf("CSV missing the kit note\n%s", csv)
+	}
+}
+
+func TestStockDriftShowsTheTrendAndLeavesStatusAlone(t *testing.T) {
+	run := testRun()
+	run.StockDrift(StockDrift{SKU: "EDIT-1", Kind: DriftManualEdit, Target: 8, OnHand: 3, Available: 3})
+	run.StockDrift(StockDrift{SKU: "ORD-1", Kind: DriftPendingOrder, Target: 3, OnHand: 5, Available: 3, Committed: 2})
+	run.StockDriftAudited(1, true)
+	summary := run.Snapshot()
+
+	if got := summary.Status(); got != StatusOK {
+		t.Errorf("Status() = %q, want drift to leave it %q", got, StatusOK)
+	}
+	if summary.StockDrift[0].Kind != DriftManualEdit {
+		t.Errorf("drift must be sorted by kind, got %+v", summary.StockDrift)
+	}
+	body := summary.HTML(RenderOptions{})
+	for _, want := range []string{"סטיות מלאי בין Shopify לחשבשבת (2)", "הסטייה גדלה: 1 בביקורת הקודמת (+1)", "הזמנה שטרם סופקה"} {
+		if !strings.Contains(body, want) {
+			t.Errorf("HTML missing %q", want)
+		}
+	}
+	if csv := string(summary.CSV()); !strings.Contains(csv, "stock_drift,EDIT-1,,3,8,+5,manual_edit available=3 committed=0,,") {
+		t.Errorf("CSV missing the drift row\n%s", csv)
+	}
+}
+
+func TestStockAlertsLeadTheReportAndLeaveStatusAlone(t *testing.T) {
+	run := testRun()
+	run.StockAlert(StockAlert{SKU: "LOW-1", Kind: AlertLowStock, Quantity: 2, Threshold: 5, Source: "prefix LOW-"})
+	run.StockAlert(StockAlert{SKU: "OUT-1", Kind: AlertOutOfStock})
+	run.StockAlert(StockAlert{SKU: "BACK-1", Kind: AlertBackInStock, Quantity: 7})
+	summary := run.Snapshot()
+
+	if got := summary.Status(); got != StatusOK {
+		t.Errorf("Status() = %q, want alerts to leave it %q", got, StatusOK)
+	}
+	var kinds []string
+	for _, alert := range summary.StockAlerts {
+		kinds = append(kinds, alert.Kind)
+	}
+	if strings.Join(kinds, ",") != "out_of_stock,low_stock,back_in_stock" {
+		t.Errorf("alerts order = %v, want out, low, back", kinds)
+	}
+	if subject := summary.Subject(RenderOptions{}); !strings.Contains(subject, "3 התראות מלאי") {
+		t.Errorf("subject %q must count the alerts", subject)
+	}
+	body := summary.HTML(RenderOptions{})
+	for _, want := range []string{"התראות מלאי (3)", "אזל מהמלאי", "5 (prefix LOW-)"} {
+		if !strings.Contains(body, want) {
+			t.Errorf("HTML missing %q", want)
+		}
+	}
+	if csv := string(summary.CSV()); !strings.Contains(csv, "stock_alert,LOW-1,,,2,,low_stock threshold=5 prefix LOW-,,") {
+		t.Errorf("CSV missing the alert row\n%s", csv)
+	}
+}
+
+func TestDataIssuesGetTheirOwnCSVAndLeaveStatusAlone(t *testing.T) {
+	// Catalogue problems persist until the ERP is fixed; they must reach the ERP team
+	// as a separate list without turning every run into a warning.
+	run := testRun()
+	run.DataIssue("BAD-3", IssueInvalidBarcode, "4006381333932: check digit should be 1")
+	run.DataIssue("AB-2", IssueDuplicateSKU, `also as "ab-2"`)
+	summary := run.Snapshot()
+
+	if got := summary.Status(); got != StatusOK {
+		t.Errorf("Status() = %q, want %q", got, StatusOK)
+	}
+	if summary.DataIssues[0].SKU != "AB-2" {
+		t.Errorf("issues should sort by kind then SKU, got %+v", summary.DataIssues)
+	}
+	csv := string(summary.DataQualityCSV())
+	for _, want := range []string{"sku,issue,issue_he,detail", "BAD-3,invalid_barcode,ברקוד לא תקין"} {
+		if !strings.Contains(csv, want) {
+			t.Errorf("data-quality CSV missing %q\n---\n%s", want, csv)
+		}
+	}
+	if got, want := summary.DataQualityCSVFilename(), "sync-stock-and-price-20260804-120000-data-quality.csv"; got != want {
+		t.Errorf("DataQualityCSVFilename() = %q, want %q", got, want)
+	}
+	if !strings.Contains(string(buildMessage(summary, SMTPConfig{From: "a@b.co", To: []string{"c@d.co"}}, RenderOptions{})), `filename="`+summary.DataQualityCSVFilename()+`"`) {
+		t.Error("data-quality CSV should be attached to the email")
+	}
+	if testRun().Snapshot().DataQualityCSV() != nil {
+		t.Error("a run without issues should attach no data-quality CSV")
+	}
+}
+
 func TestHTMLShowsChangesAndEscapes(t *testing.T) {
 	run := testRun()
 	run.StockSeen("CMG-28", 102, true, 247)
@@ -416,12 +551,21 @@ func TestNilRunToleratesEveryCall(t *testing.T) {
 	// A run with reporting switched off passes a nil *Run around; nothing may panic.
 	var run *Run
 	run.StockSeen("A-1", 1, true, 2)
+	run.StockSeenAt("A-1", "Store", 1, true, 2)
+	run.StockReserve("A-1", "", 5, 3, "default")
+	run.StockKit("A-1", "", 2, "B-1")
+	run.StockDrift(StockDrift{SKU: "A-1", Kind: DriftManualEdit})
+	run.StockDriftAudited(1, true)
+	run.StockAlert(StockAlert{SKU: "A-1", Kind: AlertOutOfStock})
 	run.PriceSeen("A-1", "ILS", 1, true, 2)
+	run.PriceSource("A-1", "ILS", "ERP list 10")
+	run.PriceERPValue("A-1", "ILS", 1)
 	run.ProductCreated("A-1", "t")
 	run.ProductUpdated("A-1")
 	run.ProductFailed("A-1", "t", errors.New("x"))
 	run.Warn("s", "m")
 	run.Incr("s", "k", 1)
+	run.DataIssue("A-1", IssueMissingPrice, "ILS")
 	run.SkipStep("syncStocks", "filtered")
 	run.FinishStep(run.StartStep("x", time.Now()), time.Now(), nil)
 	run.SetLogFile("/tmp/x.log")
@@ -445,3 +589,28 @@ func TestFormatDuration(t *testing.T) {
 		}
 	}
 }
+
+func TestPriceHoldsWarnAndLeadTheReport(t *testing.T) {
+	// A held price is a live price waiting for a person, so it must show above the
+	// routine sections and make the run a warning.
+	run := testRun()
+	run.PriceHeld("HVM-2", "USD", 6.3, true, 630, "moves 9900% from 6.30 (limit 60%)")
+	run.PriceHeld("HVM-1", "ILS", 23.36, true, 2336, "moves 9900% from 23.36 (limit 60%)")
+	run.StockSeen("HVM-9", 1, true, 2)
+	summary := run.Snapshot()
+
+	if got := summary.Status(); got != StatusWarning {
+		t.Errorf("Status() = %q, want %q", got, StatusWarning)
+	}
+	if summary.PricesHeld[0].SKU != "HVM-1" {
+		t.Errorf("holds should sort by SKU, got %+v", summary.PricesHeld)
+	}
+	body := summary.HTML(RenderOptions{})
+	held, stock := strings.Index(body, "מחירים שעוכבו לאישור"), strings.Index(body, "שינויי מלאי (")
+	if held < 0 || stock < 0 || held > stock {
+		t.Errorf("held prices section should come before stock changes (held=%d stock=%d)", held, stock)
+	}
+	if csv := string(summary.CSV()); !strings.Contains(csv, "price_held,HVM-1,ILS,23.36,2336.00") {
+		t.Errorf("CSV missing the hold\n---\n%s", csv)
+	}
+}